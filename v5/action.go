@@ -12,9 +12,12 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/json"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
 )
 
@@ -33,11 +36,11 @@ var defaultReturnValues = map[string]interface{}{
 func newAction(c Component, s *state) *Action {
 	api := newApi(c, s)
 
-	// Copy the command transport without keeping references to avoid changing
-	// the transport data in the command when the action changes the transport.
-	// This leaves a "vanilla" transport inside the command, to be used as base
-	// transport for the runtime calls.
-	transport := api.command.Command.Arguments.Transport.Clone()
+	// Copy the command transport to avoid changing the transport data in the command
+	// when the action changes the transport. The data, relations and files are copied
+	// when they are changed. This leaves a "vanilla" transport inside the command, to
+	// be used as base transport for the runtime calls.
+	transport := api.command.Command.Arguments.Transport.CloneShared()
 	transport.SetReply(api.reply)
 
 	// Index the files for the current action by name
@@ -53,10 +56,7 @@ func newAction(c Component, s *state) *Action {
 	}
 
 	// Index parameters by name
-	params := make(map[string]payload.Param)
-	for _, p := range api.command.Command.Arguments.Params {
-		params[p.Name] = p
-	}
+	params := indexParams(api.command.Command.Arguments.Params)
 
 	// Set a default return value for the action when there are schemas
 	if api.schemas != nil {
@@ -69,16 +69,46 @@ func newAction(c Component, s *state) *Action {
 		}
 	}
 
-	return &Action{api, transport, params, files}
+	// Record the changes made to the transport when debug is enabled
+	var auditTrail *transportAudit
+	if s.input.IsDebugEnabled() || s.sampled {
+		logged, _ := strconv.ParseBool(s.input.GetVariable(TransportAuditLogVariable))
+		auditTrail = newTransportAudit(api.logger, logged || s.sampled)
+	}
+
+	action := &Action{Api: api, transport: transport, params: params, files: files, auditTrail: auditTrail}
+	api.parent = action
+	return action
 }
 
 // Action API type for the service component.
 type Action struct {
 	*Api
 
-	transport *payload.Transport
-	params    map[string]payload.Param
-	files     map[string]payload.File
+	transport  *payload.Transport
+	params     map[string][]payload.Param
+	files      map[string]payload.File
+	auditTrail *transportAudit
+	calls      atomic.Uint32
+	callID     atomic.Value
+}
+
+// Record a transport change in the audit trail when the audit is enabled.
+func (a *Action) audit(operation, format string, args ...interface{}) {
+	if a.auditTrail != nil {
+		a.auditTrail.record(operation, format, args...)
+	}
+}
+
+// GetTransportAudit returns the changes made by the action to the transport.
+//
+// The changes are only recorded when the component runs in debug mode or
+// when the request is sampled, otherwise the result is nil.
+func (a *Action) GetTransportAudit() []TransportAuditEntry {
+	if a.auditTrail == nil {
+		return nil
+	}
+	return a.auditTrail.getEntries()
 }
 
 func (a *Action) warnWhenSchemaIsMissing(service, version, action string) {
@@ -108,9 +138,7 @@ func (a *Action) checkFiles(schema *ServiceSchema, files []File) error {
 
 // IsOrigin checks if the current service is the origin of the request.
 func (a *Action) IsOrigin() bool {
-	o := a.reply.Command.Result.Transport.Meta.Origin
-
-	return o[0] == a.GetName() && o[1] == a.GetVersion() && o[2] == a.GetActionName()
+	return a.GetOrigin().Equals(Origin{a.GetName(), a.GetVersion(), a.GetActionName()})
 }
 
 // GetActionName returns the name of the action.
@@ -123,6 +151,58 @@ func (a *Action) GetActionName() string {
 // name: The property name.
 // value: The property value.
 func (a *Action) SetProperty(name, value string) *Action {
+	a.setProperty(name, value)
+	a.audit("SetProperty", `"%s" = "%s"`, name, value)
+
+	return a
+}
+
+// SetPropertyInt sets a userland property with an integer value in the transport.
+//
+// The value is saved as a string, and it can be read with Transport.GetPropertyInt().
+//
+// name: The property name.
+// value: The property value.
+func (a *Action) SetPropertyInt(name string, value int) *Action {
+	a.setProperty(name, strconv.Itoa(value))
+	a.audit("SetPropertyInt", `"%s" = %d`, name, value)
+
+	return a
+}
+
+// SetPropertyBool sets a userland property with a boolean value in the transport.
+//
+// The value is saved as a string, and it can be read with Transport.GetPropertyBool().
+//
+// name: The property name.
+// value: The property value.
+func (a *Action) SetPropertyBool(name string, value bool) *Action {
+	a.setProperty(name, strconv.FormatBool(value))
+	a.audit("SetPropertyBool", `"%s" = %t`, name, value)
+
+	return a
+}
+
+// SetPropertyJSON sets a userland property with a value serialized as JSON in the transport.
+//
+// The value can be read with Transport.GetPropertyJSON().
+//
+// name: The property name.
+// value: The property value.
+func (a *Action) SetPropertyJSON(name string, value interface{}) (*Action, error) {
+	data, err := json.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to serialize the value of the property "%s": %v`, name, err)
+	}
+
+	a.setProperty(name, string(data))
+	a.audit("SetPropertyJSON", `"%s" = %s`, name, data)
+
+	return a, nil
+}
+
+// Set a userland property in the transport.
+func (a *Action) setProperty(name, value string) {
 	t := a.reply.Command.Result.Transport
 
 	if t.Meta.Properties == nil {
@@ -130,8 +210,13 @@ func (a *Action) SetProperty(name, value string) *Action {
 	}
 
 	t.Meta.Properties[name] = value
+}
 
-	return a
+// GetHTTPRequestInfo returns read-only information about the HTTP request received by the gateway.
+//
+// The information is read from the transport meta and the mapping.
+func (a *Action) GetHTTPRequestInfo() *HTTPRequestInfo {
+	return newHTTPRequestInfo(a)
 }
 
 // HasParam checks if a parameter exists.
@@ -147,22 +232,120 @@ func (a *Action) HasParam(name string) bool {
 //
 // name: The name of the parameter.
 func (a *Action) GetParam(name string) *Param {
-	if p, exists := a.params[name]; exists {
-		return payloadToParam(p)
+	if values, exists := a.params[name]; exists {
+		param := payloadToParam(values[len(values)-1])
+		param.format = a.getParamFormat(name)
+		return param
 	}
 
 	return newEmptyParam(name)
 }
 
+// GetParamArray returns all the action parameters with the same name.
+//
+// Parameters are repeated when the action receives more than one value for a parameter.
+// The result is empty when the parameter doesn't exist.
+//
+// name: The name of the parameter.
+func (a *Action) GetParamArray(name string) (params []*Param) {
+	format := a.getParamFormat(name)
+	for _, p := range a.params[name] {
+		param := payloadToParam(p)
+		param.format = format
+		params = append(params, param)
+	}
+
+	return params
+}
+
 // GetParams returns all the action's parameters.
+//
+// When a parameter is repeated only its last value is returned.
 func (a *Action) GetParams() (params []*Param) {
-	for _, p := range a.params {
-		params = append(params, payloadToParam(p))
+	for name, values := range a.params {
+		param := payloadToParam(values[len(values)-1])
+		param.format = a.getParamFormat(name)
+		params = append(params, param)
 	}
 
 	return params
 }
 
+// Validate the parameters that have a format with a registered validator,
+// and the sizes of the binary parameters.
+//
+// The parameters and files are validated in name order, and the error
+// of the first one that is not valid is returned.
+func (a *Action) validateParams() error {
+	schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion())
+	if err != nil {
+		return nil
+	}
+
+	actionSchema, err := schema.GetActionSchema(a.GetActionName())
+	if err != nil {
+		return nil
+	}
+
+	// The names are sorted so the same error is returned when more than one value is not valid
+	for _, name := range sortedKeys(a.params) {
+		paramSchema, err := actionSchema.GetParamSchema(name)
+		if err != nil {
+			continue
+		}
+
+		for _, p := range a.params[name] {
+			value := payloadToParam(p).GetValue()
+			if err := paramSchema.ValidateFormat(value); err != nil {
+				return fmt.Errorf(`Param "%s" validation failed: %v`, name, err)
+			}
+
+			// Binary values are validated using the minimum and maximum sizes
+			if b, ok := value.([]byte); ok && paramSchema.GetType() == datatypes.Binary {
+				if err := paramSchema.ValidateSize(b); err != nil {
+					return fmt.Errorf(`Param "%s" validation failed: %v`, name, err)
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(a.files) {
+		fileSchema, err := actionSchema.GetFileSchema(name)
+		if err != nil {
+			continue
+		}
+
+		f := a.files[name]
+		if err := fileSchema.ValidateChecksum(payloadToFile(&f)); err != nil {
+			return fmt.Errorf(`File "%s" validation failed: %v`, name, err)
+		}
+	}
+
+	return nil
+}
+
+// Get the format of a parameter from the action schema.
+//
+// An empty string is returned when the schema is not available.
+func (a *Action) getParamFormat(name string) string {
+	schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion())
+	if err != nil {
+		return ""
+	}
+
+	actionSchema, err := schema.GetActionSchema(a.GetActionName())
+	if err != nil {
+		return ""
+	}
+
+	paramSchema, err := actionSchema.GetParamSchema(name)
+	if err != nil {
+		return ""
+	}
+
+	return paramSchema.GetFormat()
+}
+
 // NewParam creates a new parameter.
 //
 // Creates an instance of Param with the given name, and optionally the value and data type.
@@ -233,29 +416,28 @@ func (a *Action) SetDownload(f File) (*Action, error) {
 
 	p := fileToPayload(f)
 	a.transport.SetDownload(&p)
+	a.audit("SetDownload", `file "%s"`, f.GetName())
 
 	return a, nil
 }
 
 // SetReturn sets the value to be returned by the action.
 //
+// When the return value schema defines the fields of an object or the items
+// of an array, the value is validated against them.
+//
 // value: The action's return value.
 func (a *Action) SetReturn(value interface{}) (*Action, error) {
-	if a.schemas != nil {
-		name := a.GetName()
-		version := a.GetVersion()
-
-		// Check that the schema for the current action is available
-		schema, err := a.GetServiceSchema(name, version)
-		if err != nil {
+	// When running the action from the CLI there is no schema available, so depending on the
+	// schema policy the setting of return values is allowed without restrictions in this case.
+	if _, actionSchema, err := a.getCurrentSchemas(); err != nil {
+		if !a.skipSchemaValidation(err) {
 			return nil, err
 		}
-
+	} else {
+		name := a.GetName()
+		version := a.GetVersion()
 		action := a.GetActionName()
-		actionSchema, err := schema.GetActionSchema(action)
-		if err != nil {
-			return nil, err
-		}
 
 		if !actionSchema.HasReturn() {
 			return nil, fmt.Errorf(`Cannot set a return value in "%s" (%s) for action: "%s"`, name, version, action)
@@ -268,13 +450,15 @@ func (a *Action) SetReturn(value interface{}) (*Action, error) {
 		} else if datatypes.ResolveType(value) != rtype {
 			return nil, fmt.Errorf(`Invalid return type given in "%s" (%s) for action: "%s"`, name, version, action)
 		}
-	} else {
-		// When running the action from the CLI there is no schema available, but the
-		// setting of return values must be allowed without restrictions in this case.
-		a.logger.Warning("Return value set without discovery mapping available")
+
+		// Validate the contents of the objects and arrays
+		if err := actionSchema.ValidateReturn(value); err != nil {
+			return nil, fmt.Errorf(`Invalid return value given in "%s" (%s) for action "%s": %w`, name, version, action, err)
+		}
 	}
 
 	a.transport.SetReturn(value)
+	a.audit("SetReturn", "%v", value)
 
 	return a, nil
 }
@@ -297,10 +481,49 @@ func (a *Action) SetEntity(entity interface{}) (*Action, error) {
 
 	// Add the entity to the transport
 	a.transport.SetData(a.GetName(), a.GetVersion(), a.GetActionName(), entity)
+	a.audit("SetEntity", "%T", entity)
 
 	return a, nil
 }
 
+// GetEntityFromData returns the entity contained in the data returned by an action.
+//
+// The entity is extracted from the data using the "entity-path" and "path-delimiter"
+// properties of the action schema, and only the fields that are part of the entity
+// definition are kept. The data can be the result of a run-time call or an item
+// from the transport data.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+// data: The data returned by the action.
+func (a *Action) GetEntityFromData(service, version, action string, data interface{}) (map[string]interface{}, error) {
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Entity data must be a map, got %T", data)
+	}
+
+	serviceSchema, err := a.GetServiceSchema(service, version)
+	if err != nil {
+		return nil, err
+	}
+
+	actionSchema, err := serviceSchema.GetActionSchema(action)
+	if err != nil {
+		return nil, err
+	}
+
+	entity, err := actionSchema.ResolveEntity(values)
+	if err != nil {
+		return nil, err
+	}
+
+	if actionSchema.HasEntity() {
+		entity = actionSchema.GetEntity().filter(entity)
+	}
+	return entity, nil
+}
+
 // SetCollection sets the collection data.
 //
 // The collection can only be a slice that contains either struct or a map types.
@@ -309,20 +532,62 @@ func (a *Action) SetEntity(entity interface{}) (*Action, error) {
 //
 // collection: The collection.
 func (a *Action) SetCollection(collection interface{}) (*Action, error) {
-	// Check that the collection and item types are valid
-	t := reflect.TypeOf(collection)
-	if k := t.Kind(); k != reflect.Slice {
-		return nil, fmt.Errorf("Collections must be of type slice, got %s", k)
-	} else if k := t.Elem().Kind(); k != reflect.Struct && k != reflect.Map {
-		return nil, fmt.Errorf("Collections must contain struct or map types, got %s", k)
+	if err := checkCollection(collection); err != nil {
+		return nil, err
 	}
 
 	// Add the collection to the transport
 	a.transport.SetData(a.GetName(), a.GetVersion(), a.GetActionName(), collection)
+	a.audit("SetCollection", "%T with %d items", collection, reflect.ValueOf(collection).Len())
+
+	return a, nil
+}
+
+// SetCollectionPage sets a page of a collection as the collection data.
+//
+// The pagination metadata is saved in the transport properties so it
+// can be read later using the GetPagination method of the transport.
+//
+// collection: The collection items in the page.
+// total: The total number of items available.
+// offset: The position of the first item in the page.
+// limit: The maximum number of items in the page.
+func (a *Action) SetCollectionPage(collection interface{}, total, offset, limit uint) (*Action, error) {
+	if err := checkCollection(collection); err != nil {
+		return nil, err
+	} else if limit == 0 {
+		return nil, fmt.Errorf("The page limit must be greater than zero")
+	}
+
+	name := a.GetName()
+	version := a.GetVersion()
+	action := a.GetActionName()
+	pagination := Pagination{total, offset, limit}
+
+	// Add the collection to the transport and the pagination as a property
+	a.transport.SetData(name, version, action, collection)
+
+	a.setProperty(getPaginationProperty(name, version, action), pagination.encode())
+
+	a.audit("SetCollectionPage", "%T with %d items (total: %d, offset: %d, limit: %d)",
+		collection, reflect.ValueOf(collection).Len(), total, offset, limit)
 
 	return a, nil
 }
 
+// Check that the collection and item types are valid.
+func checkCollection(collection interface{}) error {
+	t := reflect.TypeOf(collection)
+	if t == nil {
+		return errors.New("Collections must be of type slice, got nil")
+	} else if k := t.Kind(); k != reflect.Slice {
+		return fmt.Errorf("Collections must be of type slice, got %s", k)
+	} else if k := t.Elem().Kind(); k != reflect.Struct && k != reflect.Map {
+		return fmt.Errorf("Collections must contain struct or map types, got %s", k)
+	}
+	return nil
+}
+
 // RelateOne creates a "one-to-one" relation between entities.
 //
 // Creates a "one-to-one" relation between the entity's primary key and service with the foreign key.
@@ -340,6 +605,7 @@ func (a *Action) RelateOne(pk, service, fk string) (*Action, error) {
 	}
 
 	a.transport.SetRelateOne(a.GetName(), pk, service, fk)
+	a.audit("RelateOne", `"%s" to "%s" "%s"`, pk, service, fk)
 
 	return a, nil
 }
@@ -361,6 +627,56 @@ func (a *Action) RelateMany(pk, service string, fks []string) (*Action, error) {
 	}
 
 	a.transport.SetRelateMany(a.GetName(), pk, service, fks)
+	a.audit("RelateMany", `"%s" to "%s" %v`, pk, service, fks)
+
+	return a, nil
+}
+
+// UnrelateOne removes a relation between entities.
+//
+// Removes the relation between the entity's primary key and the foreign service. Relations
+// can be replaced by calling RelateOne() or RelateMany() again, so removing them is only
+// required when the entity is no longer related to the foreign service.
+//
+// pk: The primary key.
+// service: The foreign service.
+func (a *Action) UnrelateOne(pk, service string) (*Action, error) {
+	if pk == "" {
+		return nil, fmt.Errorf("The primary key is empty")
+	} else if service == "" {
+		return nil, fmt.Errorf("The foreign service name is empty")
+	}
+
+	if !a.transport.RemoveRelation(a.GetName(), pk, "", service, nil) {
+		a.logger.Debugf(`Relation not found for "%s" to "%s"`, pk, service)
+	} else {
+		a.audit("UnrelateOne", `"%s" to "%s"`, pk, service)
+	}
+
+	return a, nil
+}
+
+// UnrelateMany removes foreign keys from a "one-to-many" relation between entities.
+//
+// The relation is removed when all its foreign keys are removed.
+//
+// pk: The primary key.
+// service: The foreign service.
+// fks: The foreign keys to remove.
+func (a *Action) UnrelateMany(pk, service string, fks []string) (*Action, error) {
+	if pk == "" {
+		return nil, fmt.Errorf("The primary key is empty")
+	} else if service == "" {
+		return nil, fmt.Errorf("The foreign service name is empty")
+	} else if len(fks) == 0 {
+		return nil, fmt.Errorf("The foreign keys are empty")
+	}
+
+	if !a.transport.RemoveRelation(a.GetName(), pk, "", service, fks) {
+		a.logger.Debugf(`Relation not found for "%s" to "%s" with foreign keys %v`, pk, service, fks)
+	} else {
+		a.audit("UnrelateMany", `"%s" to "%s" %v`, pk, service, fks)
+	}
 
 	return a, nil
 }
@@ -387,6 +703,7 @@ func (a *Action) RelateOneRemote(pk, address, service, fk string) (*Action, erro
 	}
 
 	a.transport.SetRelateOneRemote(a.GetName(), pk, address, service, fk)
+	a.audit("RelateOneRemote", `"%s" to [%s] "%s" "%s"`, pk, address, service, fk)
 
 	return a, nil
 }
@@ -413,6 +730,7 @@ func (a *Action) RelateManyRemote(pk, address, service string, fks []string) (*A
 	}
 
 	a.transport.SetRelateManyRemote(a.GetName(), pk, address, service, fks)
+	a.audit("RelateManyRemote", `"%s" to [%s] "%s" %v`, pk, address, service, fks)
 
 	return a, nil
 }
@@ -429,12 +747,17 @@ func (a *Action) SetLink(link, uri string) (*Action, error) {
 	}
 
 	a.transport.SetLink(a.GetName(), link, uri)
+	a.audit("SetLink", `"%s" = "%s"`, link, uri)
 
 	return a, nil
 }
 
 // Commit registers a transaction to be called when request succeeds.
 //
+// Parameter values can reference the fields of the action entity using EntityField().
+// The framework doesn't resolve the references, so the transaction action must read
+// the parameters with GetResolvedParams, unless it runs using a TransactionRunner.
+//
 // action: The action name.
 // params: Optional list of parameters.
 func (a *Action) Commit(action string, params []*Param) (*Action, error) {
@@ -450,12 +773,17 @@ func (a *Action) Commit(action string, params []*Param) (*Action, error) {
 		action,
 		paramsToPayload(params),
 	)
+	a.audit("Commit", `action "%s"`, action)
 
 	return a, nil
 }
 
 // Rollback registers a transaction to be called when request fails.
 //
+// Parameter values can reference the fields of the action entity using EntityField().
+// The framework doesn't resolve the references, so the transaction action must read
+// the parameters with GetResolvedParams, unless it runs using a TransactionRunner.
+//
 // action: The action name.
 // params: Optional list of parameters.
 func (a *Action) Rollback(action string, params []*Param) (*Action, error) {
@@ -471,12 +799,17 @@ func (a *Action) Rollback(action string, params []*Param) (*Action, error) {
 		action,
 		paramsToPayload(params),
 	)
+	a.audit("Rollback", `action "%s"`, action)
 
 	return a, nil
 }
 
 // Complete registers a transaction to be called when request finishes.
 //
+// Parameter values can reference the fields of the action entity using EntityField().
+// The framework doesn't resolve the references, so the transaction action must read
+// the parameters with GetResolvedParams, unless it runs using a TransactionRunner.
+//
 // action: The action name.
 // params: Optional list of parameters.
 func (a *Action) Complete(action string, params []*Param) (*Action, error) {
@@ -492,6 +825,7 @@ func (a *Action) Complete(action string, params []*Param) (*Action, error) {
 		action,
 		paramsToPayload(params),
 	)
+	a.audit("Complete", `action "%s"`, action)
 
 	return a, nil
 }
@@ -514,55 +848,153 @@ func (a *Action) Call(
 	files []File,
 	timeout uint,
 ) (returnValue interface{}, err error) {
-	// Check that the call exists in the config
-	title := fmt.Sprintf(`"%s" (%s)`, service, version)
-	schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion())
+	result, err := a.call(service, version, action, params, files, timeout, true, nil)
 	if err != nil {
 		return nil, err
 	}
+	return result.GetReturnValue(), nil
+}
 
-	actionSchema, err := schema.GetActionSchema(a.GetActionName())
+// CallWithResult performs a run-time call to a service and returns the call result.
+//
+// The result contains the return value from the remote action, the call duration
+// and a snapshot of the transport returned by the remote action.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+// params: Optional list of Param objects.
+// files: Optional list of File objects.
+// timeout: Optional timeout in milliseconds.
+func (a *Action) CallWithResult(
+	service string,
+	version string,
+	action string,
+	params []*Param,
+	files []File,
+	timeout uint,
+) (*CallResult, error) {
+	return a.call(service, version, action, params, files, timeout, true, nil)
+}
+
+// CallWithoutCache performs a run-time call to a service without using the call cache.
+//
+// The result of the call is not saved in the cache.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+// params: Optional list of Param objects.
+// files: Optional list of File objects.
+// timeout: Optional timeout in milliseconds.
+func (a *Action) CallWithoutCache(
+	service string,
+	version string,
+	action string,
+	params []*Param,
+	files []File,
+	timeout uint,
+) (returnValue interface{}, err error) {
+	result, err := a.call(service, version, action, params, files, timeout, false, nil)
 	if err != nil {
 		return nil, err
-	} else if !actionSchema.HasCall(service, version, action) {
-		return nil, fmt.Errorf(`Call not configured, connection to action on %s aborted: "%s"`, title, action)
 	}
+	return result.GetReturnValue(), nil
+}
 
-	// Check that the remote action exists and can return a value, and if it doesn't issue a warning
-	remoteSchema, err := a.GetServiceSchema(service, version)
-	if err != nil {
-		a.logger.Warning(err)
-	}
+// GetCallID returns the ID of the last run-time call made by the action.
+//
+// Call IDs are derived from the request ID, or the parent call ID when the action
+// was called by another service, and the index of the call within the action.
+// The result is empty when the action didn't make any run-time calls.
+func (a *Action) GetCallID() string {
+	id, _ := a.callID.Load().(string)
+	return id
+}
 
-	remoteActionSchema, err := remoteSchema.GetActionSchema(action)
-	if err != nil {
-		a.logger.Warning(err)
-	} else if remoteActionSchema.HasReturn() {
-		return nil, fmt.Errorf(`Cannot return value from %s for action: "%s"`, title, action)
+// Create the ID for a new run-time call.
+func (a *Action) newCallID() string {
+	parent := a.GetParentCallID()
+	if parent == "" {
+		parent = a.command.GetRequestID()
 	}
 
-	// Check that the file server is enabled when one of the files is local
-	for _, file := range files {
-		if file.IsLocal() {
-			// Stop checking when one local file is found and the file server is enables
-			if schema.HasFileServer() {
-				break
-			}
-			return nil, fmt.Errorf("File server not configured: %s", title)
-		}
-	}
+	id := fmt.Sprintf("%s.%d", parent, a.calls.Add(1))
+	a.callID.Store(id)
+	return id
+}
 
-	if timeout == 0 {
-		timeout = ExecutionTimeout
+// InvalidateCallCache removes the cached results of the run-time calls to an action.
+//
+// The call cache is enabled with the "call-cache-size" component variable.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+func (a *Action) InvalidateCallCache(service, version, action string) *Action {
+	if a.state.cache != nil {
+		a.state.cache.invalidate(service, version, action)
+	}
+	return a
+}
+
+// Perform a run-time call to a service.
+//
+// When the call cache is enabled and the call has no files the result of
+// the call is taken from the cache, or saved in the cache after the call.
+// Only the return value is cached, so the results of the calls that change
+// the transport are not saved in the cache.
+func (a *Action) call(
+	service string,
+	version string,
+	action string,
+	params []*Param,
+	files []File,
+	timeout uint,
+	cached bool,
+	retry *CallRetryPolicy,
+) (result *CallResult, err error) {
+	// Check that the call exists in the config
+	title := fmt.Sprintf(`"%s" (%s)`, service, version)
+	schema, actionSchema, err := a.getCurrentSchemas()
+	if err != nil {
+		if !a.skipSchemaValidation(err) {
+			return nil, err
+		}
+	} else if !actionSchema.HasCall(service, version, action) {
+		return nil, fmt.Errorf(`Call not configured, connection to action on %s aborted: "%s"`, title, action)
+	}
+
+	// Check that the remote action exists and can return a value, and if it doesn't issue a warning
+	if remoteSchema, err := a.GetServiceSchema(service, version); err != nil {
+		a.logger.Warning(err)
+	} else if remoteActionSchema, err := remoteSchema.GetActionSchema(action); err != nil {
+		a.logger.Warning(err)
+	} else if remoteActionSchema.HasReturn() {
+		return nil, fmt.Errorf(`Cannot return value from %s for action: "%s"`, title, action)
+	}
+
+	// Check that the file server is enabled when one of the files is local
+	if schema != nil {
+		if err := a.checkFiles(schema, files); err != nil {
+			return nil, fmt.Errorf("%v: %s", err, title)
+		}
+	}
+
+	if timeout == 0 {
+		timeout = ExecutionTimeout
 	}
 
 	var (
 		transport *payload.Transport
 		duration  time.Duration
+		attempts  []uint
 	)
 
+	a.audit("Call", `"%s" (%s) action "%s"`, service, version, action)
+	start := time.Now()
+
 	// Make sure the action's transport always contains the call info
-	// TODO: Check that duration and transport are set correctly after the runtime call
 	defer func() {
 		a.transport.SetCall(
 			a.GetName(),
@@ -571,66 +1003,111 @@ func (a *Action) Call(
 			service,
 			version,
 			action,
-			uint(duration*time.Millisecond),
+			durationToMilliseconds(duration),
 			paramsToPayload(params),
 			filesToPayload(files),
 			timeout,
 			transport,
+			attempts...,
 		)
 	}()
 
+	// Use the cached result when available
+	var cacheKey string
+	if cached && a.state.cache != nil && len(files) == 0 {
+		if cacheKey, err = getCallCacheKey(service, version, action, params); err != nil {
+			a.logger.Warningf("Failed to create the run-time call cache key: %v", err)
+		} else if entry, ok := a.state.cache.get(cacheKey); ok {
+			// The call is registered without a transport because cached calls don't change it
+			duration = entry.duration
+			return newCallResult(entry.returnValue, nil, time.Since(start), true), nil
+		}
+	}
+
 	// Make the runtime call
 	callee := []string{service, version, action}
-	c, err := call(
-		a.Done(),
-		a.state.input.GetComponentAddress(),
-		a.GetActionName(),
-		callee,
-		a.command.GetTransport().Clone(),
-		params,
-		files,
-		a.input.IsTCPEnabled(),
-		timeout,
+	callID := a.newCallID()
+	a.logger.Debugf(`Run-time call "%s" to "%s" (%s) action "%s"`, callID, service, version, action)
+	var sent *payload.Transport
+	send := func() (<-chan callResult, error) {
+		// The transport copy is only used to create the call payload
+		transport := a.command.GetTransport().CloneFromPool()
+		defer transport.Release()
+
+		// Keep a snapshot of the transport to check the changes made by the callee
+		if cacheKey != "" && sent == nil {
+			sent = transport.Clone()
+		}
+
+		return call(
+			a.state.pool,
+			a.Done(),
+			a.state.input.GetComponentAddress(),
+			a.GetActionName(),
+			callee,
+			"",
+			callID,
+			transport,
+			params,
+			files,
+			a.input.IsTCPEnabled(),
+			timeout,
+			a.state.binary.codec,
+		)
+	}
+
+	var (
+		reply callResult
+		tries []uint
 	)
+	if retry == nil {
+		c, err := send()
+		if err != nil {
+			return nil, fmt.Errorf("Run-time call failed: %v", err)
+		}
 
-	if err != nil {
+		// Wait for the runtime response
+		reply = <-c
+	} else if reply, tries, err = retry.run(a, send); err != nil {
 		return nil, fmt.Errorf("Run-time call failed: %v", err)
 	}
 
-	// Wait for the runtime response
-	result := <-c
-	if err := result.Error; err != nil {
+	if err := reply.Error; err != nil {
 		return nil, fmt.Errorf("Run-time call failed: %v", err)
 	}
 
-	// When the call succeeds update the transport and duration
-	duration = result.Duration
-	transport = result.Transport
+	// When the call succeeds update the transport and duration.
+	// The duration of retried calls includes all the attempts.
+	duration = reply.Duration
+	if retry != nil {
+		duration = time.Since(start)
+		attempts = tries
+	}
+	transport = reply.Transport
 
-	return result.ReturnValue, nil
+	// Calls that change the transport are not cached because the changes would be lost
+	if cacheKey != "" && !hasCallSideEffects(sent, reply.Transport) {
+		a.state.cache.set(cacheKey, reply.ReturnValue, reply.Duration)
+	}
+
+	result = newCallResult(reply.ReturnValue, transport, time.Since(start), false)
+	result.callID = callID
+	return result, nil
 }
 
-// DeferCall registera a deferred call to a service.
-//
-// service: The service name.
-// version: The service version.
-// action: The action name.
-// params: Optional list of parameters.
-// files: Optional list of files.
-func (a *Action) DeferCall(service, version, action string, params []*Param, files []File) (*Action, error) {
+// Check that a deferred call can be registered for the current action.
+func (a *Action) checkDeferCall(service, version, action string, files []File) error {
 	// Check that the deferred call exists in the config
-	schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion())
-	if err != nil {
-		return nil, err
-	}
-
-	actionSchema, err := schema.GetActionSchema(a.GetActionName())
+	schema, actionSchema, err := a.getCurrentSchemas()
 	if err != nil {
-		return nil, err
+		if a.skipSchemaValidation(err) {
+			return nil
+		}
+		return err
 	}
 
 	if !actionSchema.HasDeferCall(service, version, action) {
-		return nil, fmt.Errorf(
+		return fmt.Errorf(
 			`Deferred call not configured, connection to action on "%s" (%s) aborted: "%s"`,
 			service,
 			version,
@@ -643,7 +1120,22 @@ func (a *Action) DeferCall(service, version, action string, params []*Param, fil
 
 	// Check that the file server is enabled when one of the files is local
 	if err := a.checkFiles(schema, files); err != nil {
-		return nil, fmt.Errorf(`%v: "%s" (%s)`, err, service, version)
+		return fmt.Errorf(`%v: "%s" (%s)`, err, service, version)
+	}
+
+	return nil
+}
+
+// DeferCall registera a deferred call to a service.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+// params: Optional list of parameters.
+// files: Optional list of files.
+func (a *Action) DeferCall(service, version, action string, params []*Param, files []File) (*Action, error) {
+	if err := a.checkDeferCall(service, version, action, files); err != nil {
+		return nil, err
 	}
 
 	a.transport.SetDeferCall(
@@ -656,51 +1148,142 @@ func (a *Action) DeferCall(service, version, action string, params []*Param, fil
 		paramsToPayload(params),
 		filesToPayload(files),
 	)
+	a.audit("DeferCall", `"%s" (%s) action "%s"`, service, version, action)
 
 	return a, nil
 }
 
-// RemoteCall registers a call to a remote service in another realm.
+// DeferCallOnce registers a deferred call to a service identified by an idempotency key.
 //
-// These types of calls are done using KTP (KUSANAGI transport protocol).
+// The deferred call is registered only once per request, so calling this method
+// again with the same key, for example from a retried code path, has no effect.
 //
-// address: Public address of a gateway from another realm.
+// key: The idempotency key of the call.
 // service: The service name.
 // version: The service version.
 // action: The action name.
 // params: Optional list of parameters.
 // files: Optional list of files.
-// timeout: Optional call timeout in milliseconds.
-func (a *Action) RemoteCall(
-	address string,
+func (a *Action) DeferCallOnce(key, service, version, action string, params []*Param, files []File) (*Action, error) {
+	if key == "" {
+		return nil, fmt.Errorf("The idempotency key is empty")
+	}
+
+	if err := a.checkDeferCall(service, version, action, files); err != nil {
+		return nil, err
+	}
+
+	if !a.transport.SetDeferCallOnce(
+		key,
+		a.GetName(),
+		a.GetVersion(),
+		a.GetActionName(),
+		service,
+		version,
+		action,
+		paramsToPayload(params),
+		filesToPayload(files),
+	) {
+		a.logger.Debugf(`Deferred call already registered with key: "%s"`, key)
+	} else {
+		a.audit("DeferCallOnce", `"%s" (%s) action "%s" with key "%s"`, service, version, action, key)
+	}
+
+	return a, nil
+}
+
+// DeferCallWithOptions registers a deferred call to a service using custom options.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+// params: Optional list of parameters.
+// files: Optional list of files.
+// options: The options for the call.
+func (a *Action) DeferCallWithOptions(
 	service string,
 	version string,
 	action string,
 	params []*Param,
 	files []File,
-	timeout uint,
+	options DeferCallOptions,
 ) (*Action, error) {
-	if len(address) < 6 || address[:6] == "ktp://" {
-		return nil, fmt.Errorf(`The address must start with "ktp://": %s`, address)
+	if err := a.checkDeferCall(service, version, action, files); err != nil {
+		return nil, err
 	}
 
-	if timeout == 0 {
-		timeout = ExecutionTimeout
+	call := payload.Call{
+		Name:     service,
+		Version:  version,
+		Action:   action,
+		Caller:   a.GetActionName(),
+		Params:   paramsToPayload(params),
+		Files:    filesToPayload(files),
+		Group:    options.Group,
+		Priority: options.Priority,
 	}
 
-	// Check that the deferred call exists in the config
-	schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion())
-	if err != nil {
-		return nil, err
+	if options.Retry != nil {
+		if err := options.Retry.validate(); err != nil {
+			return nil, err
+		}
+		call.Retry = options.Retry.toPayload()
 	}
 
-	actionSchema, err := schema.GetActionSchema(a.GetActionName())
+	if !a.transport.AppendDeferCall(options.Key, a.GetName(), a.GetVersion(), call, options.DeferFiles) {
+		a.logger.Debugf(`Deferred call already registered with key: "%s"`, options.Key)
+	} else {
+		a.audit("DeferCallWithOptions", `"%s" (%s) action "%s"`, service, version, action)
+	}
+
+	return a, nil
+}
+
+// DeferCallGroup registers a deferred call to a service as part of a group.
+//
+// The deferred calls in the same group can run in parallel, and the groups run
+// in order of priority, so the order between groups is preserved.
+//
+// group: The group name.
+// priority: The priority of the group, lower priorities run first.
+// service: The service name.
+// version: The service version.
+// action: The action name.
+// params: Optional list of parameters.
+// files: Optional list of files.
+func (a *Action) DeferCallGroup(
+	group string,
+	priority int,
+	service string,
+	version string,
+	action string,
+	params []*Param,
+	files []File,
+) (*Action, error) {
+	if group == "" {
+		return nil, fmt.Errorf("The deferred call group name is empty")
+	}
+
+	return a.DeferCallWithOptions(service, version, action, params, files, DeferCallOptions{Group: group, Priority: priority})
+}
+
+// Check that a remote call can be registered for the current action.
+func (a *Action) checkRemoteCall(address, service, version, action string, files []File) error {
+	if len(address) < 6 || address[:6] != "ktp://" {
+		return fmt.Errorf(`The address must start with "ktp://": %s`, address)
+	}
+
+	// Check that the remote call exists in the config
+	schema, actionSchema, err := a.getCurrentSchemas()
 	if err != nil {
-		return nil, err
+		if a.skipSchemaValidation(err) {
+			return nil
+		}
+		return err
 	}
 
 	if !actionSchema.HasRemoteCall(address, service, version, action) {
-		return nil, fmt.Errorf(
+		return fmt.Errorf(
 			`Remote call not configured, connection to action on [%s] "%s" (%s) aborted: "%s"`,
 			address,
 			service,
@@ -714,7 +1297,38 @@ func (a *Action) RemoteCall(
 
 	// Check that the file server is enabled when one of the files is local
 	if err := a.checkFiles(schema, files); err != nil {
-		return nil, fmt.Errorf(`%v: [%s] "%s" (%s)`, err, address, service, version)
+		return fmt.Errorf(`%v: [%s] "%s" (%s)`, err, address, service, version)
+	}
+
+	return nil
+}
+
+// RemoteCall registers a call to a remote service in another realm.
+//
+// These types of calls are done using KTP (KUSANAGI transport protocol).
+//
+// address: Public address of a gateway from another realm.
+// service: The service name.
+// version: The service version.
+// action: The action name.
+// params: Optional list of parameters.
+// files: Optional list of files.
+// timeout: Optional call timeout in milliseconds.
+func (a *Action) RemoteCall(
+	address string,
+	service string,
+	version string,
+	action string,
+	params []*Param,
+	files []File,
+	timeout uint,
+) (*Action, error) {
+	if timeout == 0 {
+		timeout = ExecutionTimeout
+	}
+
+	if err := a.checkRemoteCall(address, service, version, action, files); err != nil {
+		return nil, err
 	}
 
 	a.transport.SetRemoteCall(
@@ -729,10 +1343,116 @@ func (a *Action) RemoteCall(
 		paramsToPayload(params),
 		filesToPayload(files),
 	)
+	a.audit("RemoteCall", `[%s] "%s" (%s) action "%s"`, address, service, version, action)
 
 	return a, nil
 }
 
+// CallRemote performs a call to a remote service in another realm.
+//
+// Unlike RemoteCall, which registers the call to be made by the framework after the
+// action finishes, the call is made immediately. The SDK sends a run-time call with the
+// address of the remote gateway to the framework, which makes the KTP (KUSANAGI transport
+// protocol) call, so the realm must allow direct cross-realm run-time calls.
+//
+// The result of this call is the return value from the remote action.
+// The transport returned by the remote service is merged into the current transport.
+//
+// address: Public address of a gateway from another realm.
+// service: The service name.
+// version: The service version.
+// action: The action name.
+// params: Optional list of parameters.
+// files: Optional list of files.
+// timeout: Optional call timeout in milliseconds.
+func (a *Action) CallRemote(
+	address string,
+	service string,
+	version string,
+	action string,
+	params []*Param,
+	files []File,
+	timeout uint,
+) (returnValue interface{}, err error) {
+	if timeout == 0 {
+		timeout = ExecutionTimeout
+	}
+
+	if err := a.checkRemoteCall(address, service, version, action, files); err != nil {
+		return nil, err
+	}
+
+	var (
+		transport *payload.Transport
+		duration  time.Duration
+	)
+
+	a.audit("CallRemote", `[%s] "%s" (%s) action "%s"`, address, service, version, action)
+	start := time.Now()
+
+	// Make sure the action's transport always contains the call info.
+	// The calls that fail without a reply are saved with the time spent waiting.
+	defer func() {
+		if duration == 0 {
+			duration = time.Since(start)
+		}
+
+		a.transport.SetRemoteRuntimeCall(
+			address,
+			a.GetName(),
+			a.GetVersion(),
+			a.GetActionName(),
+			service,
+			version,
+			action,
+			durationToMilliseconds(duration),
+			paramsToPayload(params),
+			filesToPayload(files),
+			timeout,
+			transport,
+		)
+	}()
+
+	// The framework makes the call to the remote gateway
+	callee := []string{service, version, action}
+	callID := a.newCallID()
+	a.logger.Debugf(`Remote call "%s" to "%s" (%s) action "%s"`, callID, service, version, action)
+	// The transport copy is only used to create the call payload
+	callTransport := a.command.GetTransport().CloneFromPool()
+	c, err := call(
+		a.state.pool,
+		a.Done(),
+		a.state.input.GetComponentAddress(),
+		a.GetActionName(),
+		callee,
+		address,
+		callID,
+		callTransport,
+		params,
+		files,
+		a.input.IsTCPEnabled(),
+		timeout,
+		a.state.binary.codec,
+	)
+	callTransport.Release()
+
+	if err != nil {
+		return nil, fmt.Errorf("Remote call failed: %v", err)
+	}
+
+	// Wait for the remote response
+	result := <-c
+	duration = result.Duration
+	if err := result.Error; err != nil {
+		return nil, fmt.Errorf("Remote call failed: %v", err)
+	}
+
+	// When the call succeeds update the transport
+	transport = result.Transport
+
+	return result.ReturnValue, nil
+}
+
 // Error adds an error for the current service.
 //
 // Adds an error object to the Transport with the specified message.
@@ -746,6 +1466,78 @@ func (a *Action) Error(message string, code int, status string) *Action {
 	}
 
 	a.transport.SetError(a.GetName(), a.GetVersion(), message, code, status)
+	a.audit("Error", `"%s" (%d %s)`, message, code, status)
+
+	return a
+}
+
+// ErrorWithSeverity adds an error with a severity level for the current service.
+//
+// Errors with the "warning" severity don't make the request fail when the
+// "fail-on-warnings" component variable is false.
+//
+// message: The error message.
+// code: The error code.
+// status: The HTTP status message.
+// severity: The error severity.
+func (a *Action) ErrorWithSeverity(message string, code int, status, severity string) *Action {
+	if status == "" {
+		status = payload.DefaultErrorStatus
+	}
+
+	a.transport.AppendError(a.GetName(), a.GetVersion(), payload.Error{
+		Message:  message,
+		Code:     code,
+		Status:   status,
+		Severity: severity,
+	})
+	a.audit("ErrorWithSeverity", `"%s" (%d %s) %s`, message, code, status, severity)
+
+	return a
+}
+
+// ErrorFrom adds an error for the current service from a Go error.
+//
+// The error message is always used as the transport error message. When the
+// error is or wraps a ServiceError its code, status and metadata are also
+// added to the transport.
+//
+// The messages and codes of the errors wrapped by the error are added to the
+// error metadata, and they can be read from the response using Error.Unwrap().
+//
+// err: The error.
+func (a *Action) ErrorFrom(err error) *Action {
+	e := payload.Error{
+		Message: err.Error(),
+		Status:  payload.DefaultErrorStatus,
+	}
+
+	var serr *ServiceError
+	if errors.As(err, &serr) {
+		e.Code = serr.Code
+		e.Severity = serr.Severity
+		if serr.Status != "" {
+			e.Status = serr.Status
+		}
+	}
+
+	// Copy the metadata to avoid changing the metadata of the service error
+	if serr != nil && len(serr.Metadata) > 0 {
+		e.Metadata = make(map[string]interface{}, len(serr.Metadata)+1)
+		for name, value := range serr.Metadata {
+			e.Metadata[name] = value
+		}
+	}
+
+	if chain := errorChainToMetadata(err); len(chain) > 0 {
+		if e.Metadata == nil {
+			e.Metadata = make(map[string]interface{})
+		}
+		e.Metadata[ErrorChainMetadata] = chain
+	}
+
+	a.transport.AppendError(a.GetName(), a.GetVersion(), e)
+	a.audit("ErrorFrom", `"%s" (%d %s)`, e.Message, e.Code, e.Status)
 
 	return a
 }
@@ -0,0 +1,145 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestActionCallRemoteChecksAddress(t *testing.T) {
+	action := newTestAction("posts", "1.0.0", "list", nil)
+	action.state.schemaPolicy = SchemaPolicyPermissive
+
+	if _, err := action.CallRemote("http://remote:8080", "users", "1.0.0", "read", nil, nil, 10); err == nil {
+		t.Fatal("expected an error for an address without the ktp scheme")
+	}
+
+	if calls := action.transport.Calls["posts"]["1.0.0"]; len(calls) != 0 {
+		t.Errorf("expected no calls in the transport, got %v", calls)
+	}
+}
+
+func TestActionCallRemoteRecordsFailedCalls(t *testing.T) {
+	action := newTestAction("posts", "1.0.0", "list", nil)
+	action.state.schemaPolicy = SchemaPolicyPermissive
+
+	// There is no framework listening so the call fails when the timeout expires
+	if _, err := action.CallRemote("ktp://remote:8080", "users", "1.0.0", "read", nil, nil, 10); err == nil {
+		t.Fatal("expected the remote call to fail")
+	}
+
+	calls := action.transport.Calls["posts"]["1.0.0"]
+	if len(calls) != 1 {
+		t.Fatalf("expected the failed call in the transport, got %v", calls)
+	}
+
+	call := calls[0]
+	if call.Gateway != "ktp://remote:8080" || call.Name != "users" || call.Action != "read" || call.Caller != "list" {
+		t.Errorf("unexpected call: %+v", call)
+	}
+
+	if call.Duration == 0 {
+		t.Error("expected the duration of the failed call")
+	}
+}
+
+func TestDurationToMilliseconds(t *testing.T) {
+	cases := []struct {
+		duration time.Duration
+		expected uint
+	}{
+		{0, 0},
+		{time.Microsecond, 1},
+		{1500 * time.Microsecond, 1},
+		{2 * time.Second, 2000},
+	}
+
+	for _, c := range cases {
+		if ms := durationToMilliseconds(c.duration); ms != c.expected {
+			t.Errorf("expected %d ms for %s, got %d", c.expected, c.duration, ms)
+		}
+	}
+}
+
+func TestActionGetEntityFromData(t *testing.T) {
+	s := newTestState("users", "1.0.0", "read", nil)
+	s.schemas = payload.Mapping{"posts": {"1.0.0": payload.Schema{Actions: map[string]payload.ActionSchema{
+		"read": {
+			EntityPath:    "result.post",
+			PathDelimiter: ".",
+			Entity: &payload.EntitySchema{
+				Field:  []payload.FieldSchema{{Name: "title"}},
+				Fields: []payload.ObjectFieldSchema{{Name: "author", Field: []payload.FieldSchema{{Name: "name"}}}},
+			},
+		},
+		"list": {},
+	}}}}
+	a := newAction(NewService(), s)
+
+	data := map[string]interface{}{"result": map[string]interface{}{"post": map[string]interface{}{
+		"id":     1,
+		"title":  "Hello",
+		"draft":  true,
+		"author": map[string]interface{}{"name": "jane", "email": "jane@example.com"},
+	}}}
+
+	// Only the primary key and the fields of the entity definition are kept
+	entity, err := a.GetEntityFromData("posts", "1.0.0", "read", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"id": 1, "title": "Hello", "author": map[string]interface{}{"name": "jane"}}
+	if !reflect.DeepEqual(entity, expected) {
+		t.Errorf("expected %v, got %v", expected, entity)
+	}
+
+	// The data is returned as is without entity path and definition
+	values := map[string]interface{}{"id": 1, "draft": true}
+	if entity, err := a.GetEntityFromData("posts", "1.0.0", "list", values); err != nil || !reflect.DeepEqual(entity, values) {
+		t.Errorf("expected the data, got %v %v", entity, err)
+	}
+
+	if _, err := a.GetEntityFromData("posts", "1.0.0", "read", []interface{}{}); err == nil {
+		t.Error("expected an error for data that is not a map")
+	}
+	if _, err := a.GetEntityFromData("posts", "1.0.0", "read", map[string]interface{}{"result": 1}); err == nil {
+		t.Error("expected an error for the invalid entity path")
+	}
+}
+
+func TestActionCallIDs(t *testing.T) {
+	transport := &payload.Transport{}
+	transport.Meta.ID = "rid"
+	a := newTestAction("users", "1.0.0", "read", transport)
+	if a.GetCallID() != "" || a.GetParentCallID() != "" {
+		t.Error("expected no call IDs before the run-time calls")
+	}
+
+	// The IDs are derived from the request ID when there is no parent call
+	for _, expected := range []string{"rid.1", "rid.2"} {
+		if id := a.newCallID(); id != expected || a.GetCallID() != expected {
+			t.Errorf("expected the call ID %s, got %s", expected, id)
+		}
+	}
+
+	// Actions called by other services derive the IDs from the parent call ID
+	s := newTestState("users", "1.0.0", "read", transport)
+	s.command.Meta.CallID = "rid.2"
+	a = newAction(NewService(), s)
+	if id := a.GetParentCallID(); id != "rid.2" {
+		t.Errorf("expected the parent call ID, got %s", id)
+	}
+	if id := a.newCallID(); id != "rid.2.1" {
+		t.Errorf("expected a nested call ID, got %s", id)
+	}
+}
@@ -10,7 +10,11 @@ package kusanagi
 
 import (
 	"errors"
+	"fmt"
 	"path"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
@@ -37,6 +41,7 @@ func newApi(c Component, s *state) *Api {
 // Api type for SDK components.
 type Api struct {
 	component Component
+	parent    interface{}
 	state     *state
 	input     cli.Input
 	schemas   payload.Mapping
@@ -55,6 +60,23 @@ func (a *Api) GetFrameworkVersion() string {
 	return a.input.GetFrameworkVersion()
 }
 
+// GetParentCallID returns the ID of the run-time call that triggered the current action.
+//
+// The result is empty when the action was not called using a run-time call.
+func (a *Api) GetParentCallID() string {
+	return a.command.Meta.CallID
+}
+
+// GetRuntimeStats returns the process statistics of the component.
+//
+// The statistics include the request counters since the component started.
+func (a *Api) GetRuntimeStats() *RuntimeStats {
+	if a.state.stats == nil {
+		return newServerStats().snapshot()
+	}
+	return a.state.stats.snapshot()
+}
+
 // GetPath returns the source file path.
 func (a *Api) GetPath() string {
 	return path.Dir(a.input.GetPath())
@@ -89,6 +111,84 @@ func (a *Api) GetVariable(name string) string {
 	return a.input.GetVariable(name)
 }
 
+// HasCLIFlag checks if a custom CLI option is defined.
+//
+// name: The option name.
+func (a *Api) HasCLIFlag(name string) bool {
+	return a.input.HasFlag(name)
+}
+
+// GetCLIFlag returns the value of a custom CLI option.
+//
+// Custom options are defined using the flag set returned by Component.Flags().
+// An empty string is returned when the option is not defined.
+//
+// name: The option name.
+func (a *Api) GetCLIFlag(name string) string {
+	return a.input.GetFlag(name)
+}
+
+// GetCLIInput returns the CLI input of the component.
+func (a *Api) GetCLIInput() cli.Input {
+	return a.input
+}
+
+// GetIntVariable returns a single component variable as an integer.
+//
+// The default value is returned when the variable doesn't exist.
+//
+// name: The name of the variable.
+// preset: The default value to use when the variable doesn't exist.
+func (a *Api) GetIntVariable(name string, preset int) (int, error) {
+	if !a.input.HasVariable(name) {
+		return preset, nil
+	}
+
+	v, err := strconv.Atoi(a.input.GetVariable(name))
+	if err != nil {
+		return preset, fmt.Errorf(`Variable "%s" is not an integer`, name)
+	}
+	return v, nil
+}
+
+// GetBoolVariable returns a single component variable as a boolean.
+//
+// Accepted values are the ones supported by strconv.ParseBool, like "true", "false", "1" or "0".
+// The default value is returned when the variable doesn't exist.
+//
+// name: The name of the variable.
+// preset: The default value to use when the variable doesn't exist.
+func (a *Api) GetBoolVariable(name string, preset bool) (bool, error) {
+	if !a.input.HasVariable(name) {
+		return preset, nil
+	}
+
+	v, err := strconv.ParseBool(a.input.GetVariable(name))
+	if err != nil {
+		return preset, fmt.Errorf(`Variable "%s" is not a boolean`, name)
+	}
+	return v, nil
+}
+
+// GetDurationVariable returns a single component variable as a duration.
+//
+// The value must be a duration string supported by time.ParseDuration, like "300ms" or "1m30s".
+// The default value is returned when the variable doesn't exist.
+//
+// name: The name of the variable.
+// preset: The default value to use when the variable doesn't exist.
+func (a *Api) GetDurationVariable(name string, preset time.Duration) (time.Duration, error) {
+	if !a.input.HasVariable(name) {
+		return preset, nil
+	}
+
+	v, err := time.ParseDuration(a.input.GetVariable(name))
+	if err != nil {
+		return preset, fmt.Errorf(`Variable "%s" is not a duration`, name)
+	}
+	return v, nil
+}
+
 // HasResource checks if a resource exists.
 //
 // name: The name of the resource.
@@ -103,6 +203,67 @@ func (a *Api) GetResource(name string) (interface{}, error) {
 	return a.component.GetResource(name)
 }
 
+// GetRequestResource returns a request resource.
+//
+// Request resources are created the first time they are used during a request, using
+// the factory registered with SetRequestResource(). The resources that implement
+// io.Closer are closed when the request finishes.
+//
+// name: Name of the resource.
+func (a *Api) GetRequestResource(name string) (interface{}, error) {
+	if a.state.resources == nil {
+		return nil, fmt.Errorf(`request resource not found: "%s"`, name)
+	}
+
+	// The factories receive the specific API type, like the action or the request
+	var api interface{} = a
+	if a.parent != nil {
+		api = a.parent
+	}
+	return a.state.resources.get(name, api)
+}
+
+// HasLocal checks if a request local value exists.
+//
+// name: The name of the value.
+func (a *Api) HasLocal(name string) bool {
+	_, exists := a.getLocal(name)
+	return exists
+}
+
+// GetLocal returns a request local value.
+//
+// Local values are shared by the callbacks of the component that handle the same
+// request, like the request and response callbacks of a middleware. The values are
+// not sent to the framework, so they are not visible to other components.
+//
+// The result is nil when the value doesn't exist.
+//
+// name: The name of the value.
+func (a *Api) GetLocal(name string) interface{} {
+	value, _ := a.getLocal(name)
+	return value
+}
+
+// SetLocal sets a request local value.
+//
+// name: The name of the value.
+// value: The value.
+func (a *Api) SetLocal(name string, value interface{}) *Api {
+	if a.state.locals != nil {
+		a.state.locals.set(a.command.GetRequestID(), name, value)
+	}
+	return a
+}
+
+// Get a request local value.
+func (a *Api) getLocal(name string) (interface{}, bool) {
+	if a.state.locals == nil {
+		return nil, false
+	}
+	return a.state.locals.get(a.command.GetRequestID(), name)
+}
+
 // GetServices return service names and versions from the mapping schemas.
 func (a *Api) GetServices() []payload.ServiceVersion {
 	if a.schemas != nil {
@@ -132,6 +293,67 @@ func (a *Api) GetServiceSchema(name, version string) (*ServiceSchema, error) {
 	return &schema, nil
 }
 
+// GetAllServiceSchemas returns copies of the schemas for all the services in the mapping.
+//
+// The schemas are sorted by service name and version. The mapping used during a request
+// doesn't change when the framework sends new mappings while the request is processed.
+func (a *Api) GetAllServiceSchemas() ([]*ServiceSchema, error) {
+	if a.schemas == nil {
+		return nil, errors.New("Service schemas are not available")
+	}
+
+	services := a.schemas.GetServices()
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Name != services[j].Name {
+			return services[i].Name < services[j].Name
+		}
+		return services[i].Version < services[j].Version
+	})
+
+	schemas := make([]*ServiceSchema, 0, len(services))
+	for _, service := range services {
+		clone, err := a.schemas[service.Name][service.Version].Clone()
+		if err != nil {
+			return nil, fmt.Errorf(`Failed to copy the schema for service "%s" (%s): %v`, service.Name, service.Version, err)
+		}
+		schemas = append(schemas, &ServiceSchema{service.Name, service.Version, *clone})
+	}
+	return schemas, nil
+}
+
+// GetRawCommand returns a copy of the command payload received from the framework.
+//
+// The raw payload gives access to fields not available through the API.
+// Changes to the returned payload don't affect the current request.
+func (a *Api) GetRawCommand() (*payload.Command, error) {
+	return a.command.Clone()
+}
+
+// GetRawReply returns a copy of the reply payload for the current request.
+//
+// The raw payload gives access to fields not available through the API.
+// Changes to the returned payload don't affect the current request.
+func (a *Api) GetRawReply() (*payload.Reply, error) {
+	if a.reply == nil {
+		return nil, errors.New("Reply payload is not available")
+	}
+
+	return a.reply.Clone()
+}
+
+// GetLogger returns the logger for the current request.
+//
+// Log messages include the request ID, and the name and version of the component
+// with the name of the action being processed, so they can be correlated.
+// The messages are written using the log level of the userland subsystem.
+func (a *Api) GetLogger() log.RequestLogger {
+	return a.logger.
+		WithSubsystem(log.UserlandSubsystem).
+		WithField("name", a.GetName()).
+		WithField("version", a.GetVersion()).
+		WithField("action", a.state.action)
+}
+
 // Log writes a value to the KUSANAGI logs.
 //
 // Given value is converted to string before being logged.
@@ -145,7 +367,7 @@ func (a *Api) Log(value interface{}, level int) (*Api, error) {
 	if err != nil {
 		return nil, err
 	}
-	a.logger.Log(level, s)
+	a.logger.WithSubsystem(log.UserlandSubsystem).Log(level, s)
 	return a, nil
 }
 
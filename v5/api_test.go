@@ -0,0 +1,145 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestApiGetRawPayloads(t *testing.T) {
+	transport := &payload.Transport{}
+	transport.Meta.ID = "rid"
+	a := newTestAction("users", "1.0.0", "read", transport)
+	a.SetProperty("user", "jane")
+
+	command, err := a.GetRawCommand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := command.GetTransport().Meta.ID; id != "rid" {
+		t.Errorf("expected the request ID in the command, got %q", id)
+	}
+
+	reply, err := a.GetRawReply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value := reply.Command.Result.Transport.Meta.Properties["user"]; value != "jane" {
+		t.Errorf("expected the property in the reply, got %q", value)
+	}
+
+	// Changes to the copies don't change the request payloads
+	command.GetTransport().Meta.ID = "changed"
+	reply.Command.Result.Transport.Meta.Properties["user"] = "changed"
+	if a.reply.Command.Result.Transport.Meta.Properties["user"] != "jane" || a.command.GetTransport().Meta.ID != "rid" {
+		t.Error("expected the request payloads not to change")
+	}
+
+	a.reply = nil
+	if _, err := a.GetRawReply(); err == nil {
+		t.Error("expected an error without reply")
+	}
+}
+
+func TestApiTypedVariables(t *testing.T) {
+	setTestVariable(t, "test-int", "42")
+	setTestVariable(t, "test-bool", "true")
+	setTestVariable(t, "test-duration", "1m30s")
+	setTestVariable(t, "test-invalid", "invalid")
+
+	a := newTestAction("users", "1.0.0", "read", nil)
+	if v, err := a.GetIntVariable("test-int", 1); err != nil || v != 42 {
+		t.Errorf("unexpected integer variable: %d %v", v, err)
+	}
+	if v, err := a.GetBoolVariable("test-bool", false); err != nil || !v {
+		t.Errorf("unexpected boolean variable: %v %v", v, err)
+	}
+	if v, err := a.GetDurationVariable("test-duration", time.Second); err != nil || v != 90*time.Second {
+		t.Errorf("unexpected duration variable: %s %v", v, err)
+	}
+
+	// The default values are used when the variables don't exist
+	if v, err := a.GetIntVariable("test-missing", 1); err != nil || v != 1 {
+		t.Errorf("expected the default integer, got %d %v", v, err)
+	}
+	if v, err := a.GetBoolVariable("test-missing", true); err != nil || !v {
+		t.Errorf("expected the default boolean, got %v %v", v, err)
+	}
+	if v, err := a.GetDurationVariable("test-missing", time.Second); err != nil || v != time.Second {
+		t.Errorf("expected the default duration, got %s %v", v, err)
+	}
+
+	// Invalid values return the default value with an error
+	if v, err := a.GetIntVariable("test-invalid", 1); err == nil || v != 1 {
+		t.Errorf("expected an error and the default integer, got %d", v)
+	}
+	if v, err := a.GetBoolVariable("test-invalid", true); err == nil || !v {
+		t.Errorf("expected an error and the default boolean, got %v", v)
+	}
+	if v, err := a.GetDurationVariable("test-invalid", time.Second); err == nil || v != time.Second {
+		t.Errorf("expected an error and the default duration, got %s", v)
+	}
+}
+
+func TestApiGetAllServiceSchemas(t *testing.T) {
+	s := newTestState("users", "1.0.0", "read", nil)
+	s.schemas = payload.Mapping{
+		"users": {
+			"2.0.0": {Address: []string{"ktp://users-2"}},
+			"1.0.0": {Address: []string{"ktp://users-1"}},
+		},
+		"posts": {"1.0.0": {Address: []string{"ktp://posts"}}},
+	}
+	a := newAction(NewService(), s)
+
+	schemas, err := a.GetAllServiceSchemas()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The schemas are sorted by name and version
+	var names []string
+	for _, schema := range schemas {
+		names = append(names, schema.GetName()+":"+schema.GetVersion())
+	}
+	if expected := "posts:1.0.0 users:1.0.0 users:2.0.0"; strings.Join(names, " ") != expected {
+		t.Errorf("expected the schemas %s, got %v", expected, names)
+	}
+
+	// The results are copies of the schemas in the mapping
+	schemas[0].payload.Address[0] = "changed"
+	if address := s.schemas["posts"]["1.0.0"].Address[0]; address != "ktp://posts" {
+		t.Errorf("expected the mapping not to change, got %s", address)
+	}
+
+	a = newTestAction("users", "1.0.0", "read", nil)
+	if _, err := a.GetAllServiceSchemas(); err == nil {
+		t.Error("expected an error without schemas")
+	}
+}
+
+func TestApiGetLogger(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	t.Cleanup(log.Enable)
+
+	a := newTestAction("users", "1.0.0", "read", nil)
+	a.GetLogger().Error("Failed")
+
+	// The messages include the correlation fields of the request
+	if expected := "Failed |test| name=users version=1.0.0 action=read"; !strings.Contains(output.String(), expected) {
+		t.Errorf("expected the message %q, got %q", expected, output.String())
+	}
+}
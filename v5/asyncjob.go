@@ -0,0 +1,132 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
+)
+
+// AsyncJobNamespace is the namespace of the transport properties that describe an accepted async job.
+const AsyncJobNamespace = "async"
+
+// AsyncJobStatusLink is the name of the transport link used to poll the status of an async job.
+const AsyncJobStatusLink = "job-status"
+
+// AsyncJobIDParam is the name of the parameter with the job ID that is sent to the worker action.
+const AsyncJobIDParam = "job_id"
+
+// AsyncJobStatusAccepted is the status of the async jobs that are accepted but not processed yet.
+const AsyncJobStatusAccepted = "accepted"
+
+// Names of the async job transport properties.
+const (
+	asyncJobIDProperty         = "job-id"
+	asyncJobStatusLinkProperty = "status-link"
+)
+
+// AsyncJob contains the information of a long-running job accepted by an action.
+type AsyncJob struct {
+	id         string
+	statusLink string
+}
+
+// GetID returns the job ID.
+func (j AsyncJob) GetID() string {
+	return j.id
+}
+
+// GetStatusLink returns the URI used to poll the status of the job.
+func (j AsyncJob) GetStatusLink() string {
+	return j.statusLink
+}
+
+// Get the return value for an accepted async job.
+func (j AsyncJob) toReturnValue() map[string]interface{} {
+	return map[string]interface{}{
+		"job_id":     j.id,
+		"status":     AsyncJobStatusAccepted,
+		"status_url": j.statusLink,
+	}
+}
+
+// AcceptAsync registers a long-running job to be processed by a worker action.
+//
+// A deferred call to the worker action of the current service is registered with the job ID
+// in the "job_id" parameter, the status link is added to the transport as the "job-status"
+// link, and the job is saved in the "async" transport properties so the response middleware
+// can reply with a "202 Accepted" response. When the action defines a return value it is set
+// to an object with the "job_id", "status" and "status_url" fields.
+//
+// jobID: The job ID.
+// statusLink: The URI used to poll the status of the job.
+// worker: The name of the action that processes the job.
+// params: Optional list of parameters for the worker action.
+func (a *Action) AcceptAsync(jobID, statusLink, worker string, params []*Param) (*Action, error) {
+	if jobID == "" {
+		return nil, fmt.Errorf("The job ID is empty")
+	} else if statusLink == "" {
+		return nil, fmt.Errorf("The job status link is empty")
+	} else if worker == "" {
+		return nil, fmt.Errorf("The worker action name is empty")
+	}
+
+	idParam, err := newParam(AsyncJobIDParam, jobID, datatypes.String, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := a.DeferCall(a.GetName(), a.GetVersion(), worker, append(append([]*Param{}, params...), idParam), nil); err != nil {
+		return nil, err
+	}
+
+	if _, err := a.SetLink(AsyncJobStatusLink, statusLink); err != nil {
+		return nil, err
+	}
+
+	a.SetProperty(PropertyName(AsyncJobNamespace, asyncJobIDProperty), jobID)
+	a.SetProperty(PropertyName(AsyncJobNamespace, asyncJobStatusLinkProperty), statusLink)
+
+	job := AsyncJob{jobID, statusLink}
+	if schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion()); err == nil {
+		if actionSchema, err := schema.GetActionSchema(a.GetActionName()); err == nil && actionSchema.HasReturn() {
+			if _, err := a.SetReturn(job.toReturnValue()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return a, nil
+}
+
+// GetAsyncJob returns the async job accepted by the action that was the origin of the request.
+//
+// The result is nil when no async job was accepted.
+func (t Transport) GetAsyncJob() *AsyncJob {
+	properties := t.GetNamespaceProperties(AsyncJobNamespace)
+	id, exists := properties[asyncJobIDProperty]
+	if !exists {
+		return nil
+	}
+	return &AsyncJob{id, properties[asyncJobStatusLinkProperty]}
+}
+
+// SetAccepted sets the HTTP response status to "202 Accepted" for an async job.
+//
+// The "Location" header is set to the job status link.
+//
+// job: The accepted async job.
+func (r *HTTPResponse) SetAccepted(job AsyncJob) *HTTPResponse {
+	r.SetStatus(202, "Accepted")
+	if link := job.GetStatusLink(); link != "" {
+		r.SetHeader("Location", link, true)
+	}
+	return r
+}
@@ -0,0 +1,114 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Action schema that is allowed to defer the calls to the worker action for the async job tests.
+var asyncJobTestSchema = payload.ActionSchema{DeferredCalls: [][]string{{"reports", "1.0.0", "build"}}}
+
+func TestActionAcceptAsync(t *testing.T) {
+	schema := asyncJobTestSchema
+	schema.Return = &payload.ReturnSchema{Type: payload.TypeObject}
+	a := newTestActionWithSchema("reports", "1.0.0", "create", schema)
+	p, err := a.NewParam("format", "pdf", payload.TypeString)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.AcceptAsync("job-1", "/reports/jobs/job-1", "build", []*Param{p}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The changes are sent to the framework in the reply transport
+	reply := a.reply.Command.Result.Transport
+
+	// The worker receives the params with the job ID
+	calls := reply.Calls["reports"]["1.0.0"]
+	if len(calls) != 1 || calls[0].Action != "build" {
+		t.Fatalf("expected the deferred call to the worker, got %v", calls)
+	}
+	params := map[string]interface{}{}
+	for _, p := range calls[0].Params {
+		params[p.Name] = p.Value
+	}
+	if !reflect.DeepEqual(params, map[string]interface{}{"format": "pdf", AsyncJobIDParam: "job-1"}) {
+		t.Errorf("unexpected worker params: %v", params)
+	}
+
+	transport := Transport{reply}
+	links := transport.GetLinks()
+	if len(links) != 1 || links[0].GetLink() != AsyncJobStatusLink || links[0].GetURI() != "/reports/jobs/job-1" {
+		t.Errorf("expected the status link, got %v", links)
+	}
+
+	job := transport.GetAsyncJob()
+	if job == nil || job.GetID() != "job-1" || job.GetStatusLink() != "/reports/jobs/job-1" {
+		t.Fatalf("expected the async job, got %v", job)
+	}
+
+	expected := map[string]interface{}{"job_id": "job-1", "status": AsyncJobStatusAccepted, "status_url": "/reports/jobs/job-1"}
+	if value := a.reply.Command.Result.Return; !reflect.DeepEqual(value, expected) {
+		t.Errorf("expected the job as return value, got %v", value)
+	}
+
+	// The return value is only set when the action defines one
+	a = newTestActionWithSchema("reports", "1.0.0", "create", asyncJobTestSchema)
+	if _, err := a.AcceptAsync("job-2", "/reports/jobs/job-2", "build", nil); err != nil {
+		t.Fatal(err)
+	}
+	if value := a.reply.Command.Result.Return; value != nil {
+		t.Errorf("expected no return value, got %v", value)
+	}
+}
+
+func TestActionAcceptAsyncErrors(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		statusLink string
+		worker     string
+	}{
+		{"missing ID", "", "/jobs/1", "build"},
+		{"missing status link", "1", "", "build"},
+		{"missing worker", "1", "/jobs/1", ""},
+		{"worker not configured", "1", "/jobs/1", "delete"},
+	}
+
+	for _, c := range cases {
+		a := newTestActionWithSchema("reports", "1.0.0", "create", asyncJobTestSchema)
+		if _, err := a.AcceptAsync(c.id, c.statusLink, c.worker, nil); err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+
+		if job := (Transport{a.reply.Command.Result.Transport}).GetAsyncJob(); job != nil {
+			t.Errorf("%s: expected no async job, got %v", c.name, job)
+		}
+	}
+}
+
+func TestHTTPResponseSetAccepted(t *testing.T) {
+	r := newHTTPResponse(&payload.HTTPResponse{Status: "200 OK", Headers: http.Header{}})
+	r.SetAccepted(AsyncJob{"job-1", "/jobs/job-1"})
+	if r.GetStatusCode() != http.StatusAccepted || r.GetHeader("Location", "") != "/jobs/job-1" {
+		t.Errorf("expected a 202 response with the location, got %s %v", r.GetStatus(), r.GetHeaders())
+	}
+
+	r = newHTTPResponse(&payload.HTTPResponse{Status: "200 OK", Headers: http.Header{}})
+	r.SetAccepted(AsyncJob{id: "job-1"})
+	if r.GetStatusCode() != http.StatusAccepted || r.HasHeader("Location") {
+		t.Errorf("expected a 202 response without location, got %s %v", r.GetStatus(), r.GetHeaders())
+	}
+}
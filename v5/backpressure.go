@@ -0,0 +1,126 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/pebbe/zmq4"
+)
+
+// ReceiveHWMVariable is the name of the component variable that sets the high water
+// mark for the incoming requests that are queued by the component socket.
+//
+// By default the number of queued requests is not limited.
+const ReceiveHWMVariable = "receive-hwm"
+
+// SendHWMVariable is the name of the component variable that sets the high water
+// mark for the outgoing responses that are queued by the component socket.
+//
+// By default the ZMQ default of 1000 messages is used. When the limit is reached for
+// a client the socket silently drops the responses to that client, so the overloaded
+// error replies are only sent when the request queue is full.
+const SendHWMVariable = "send-hwm"
+
+// RequestQueueSizeVariable is the name of the component variable that sets the maximum
+// number of received requests that wait to be processed.
+//
+// By default the queue is disabled, and the component waits until the requests can be
+// processed, so the requests are queued by the socket up to the receive high water mark.
+// When the value is greater than zero the requests that don't fit in the queue are
+// rejected with an overloaded error. Only the saturation of the incoming requests is
+// reported to the clients, see SendHWMVariable.
+const RequestQueueSizeVariable = "request-queue-size"
+
+// Size of the buffer for the received requests when the request queue is disabled.
+const defaultRequestBufferSize = 1000
+
+// Get the maximum number of requests that wait to be processed.
+//
+// The result is zero when the request queue is disabled.
+func getRequestQueueSize(input cli.Input) int {
+	return getIntVariable(input, RequestQueueSizeVariable, 0)
+}
+
+// Set the high water marks of the component socket.
+func setSocketHWM(socket *zmq4.Socket, input cli.Input) error {
+	if err := socket.SetRcvhwm(getIntVariable(input, ReceiveHWMVariable, 0)); err != nil {
+		return fmt.Errorf("Failed to set socket's receive high water mark option: %v", err)
+	}
+
+	if hwm := getIntVariable(input, SendHWMVariable, -1); hwm >= 0 {
+		if err := socket.SetSndhwm(hwm); err != nil {
+			return fmt.Errorf("Failed to set socket's send high water mark option: %v", err)
+		}
+	}
+	return nil
+}
+
+// Set the high water marks of the supervisor socket connected to the worker processes.
+//
+// The requests are sent to the workers and the responses received from them, so
+// the high water marks of the component socket are used in the opposite direction.
+func setWorkerSocketHWM(socket *zmq4.Socket, input cli.Input) error {
+	if err := socket.SetSndhwm(getIntVariable(input, ReceiveHWMVariable, 0)); err != nil {
+		return fmt.Errorf("Failed to set socket's send high water mark option: %v", err)
+	}
+
+	if hwm := getIntVariable(input, SendHWMVariable, -1); hwm >= 0 {
+		if err := socket.SetRcvhwm(hwm); err != nil {
+			return fmt.Errorf("Failed to set socket's receive high water mark option: %v", err)
+		}
+	}
+	return nil
+}
+
+// Create the error response for a request that is rejected because the component is overloaded.
+//
+// The component is overloaded when the queue of requests waiting to be processed is full.
+func (s *server) createOverloadedResponse(msg requestMsg) (responseMsg, error) {
+	cause := replyError{
+		message: fmt.Sprintf(`Component %s is overloaded, request rejected: "%s"`, s.input.GetComponentTitle(), msg.getAction()),
+		code:    503,
+		status:  "503 Service Unavailable",
+	}
+
+	response, err := createErrorResponse(msg.getFormat(s.binary), cause)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.signer != nil {
+		if response, err = signResponse(s.signer, response); err != nil {
+			return nil, err
+		}
+	}
+	return msg.makeResponseMessage(response...), nil
+}
+
+// Reply with an error to a request that can't be queued because the component is overloaded.
+func (s *server) rejectOverloaded(socket *zmq4.Socket, msg requestMsg) {
+	if err := msg.check(); err != nil {
+		log.Critical(err)
+		return
+	}
+
+	log.Warningf(`Request queue is full, request rejected: "%s"`, msg.getRequestID())
+	s.stats.done(true)
+
+	response, err := s.createOverloadedResponse(msg)
+	if err != nil {
+		log.Errorf("Failed to create overloaded response: %v", err)
+		return
+	}
+
+	if _, err := socket.SendMessage([][]byte(response)); err != nil {
+		log.Errorf("Failed to send overloaded response to client: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+func TestRequestQueueIsDisabledByDefault(t *testing.T) {
+	if size := getRequestQueueSize(cli.Input{}); size != 0 {
+		t.Errorf("expected the request queue to be disabled, got a size of %d", size)
+	}
+
+	setTestVariable(t, RequestQueueSizeVariable, "50")
+	if size := getRequestQueueSize(cli.Input{}); size != 50 {
+		t.Errorf("expected a request queue size of 50, got %d", size)
+	}
+}
@@ -0,0 +1,124 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/pebbe/zmq4"
+)
+
+// Maximum number of times the component socket bind is attempted when the address is in use.
+const bindAttempts = 5
+
+// Time to wait before the first bind retry. The time is doubled for each retry.
+const bindBackoff = 200 * time.Millisecond
+
+// Time to wait for a connection when checking if an IPC socket file is stale.
+const staleSocketDialTimeout = 100 * time.Millisecond
+
+// Get the ZMQ address to bind a TCP socket.
+//
+// IPv6 addresses are enclosed in brackets, as required by ZMQ.
+func formatTCPAddress(host string, port uint) string {
+	if isIPv6Host(host) {
+		return fmt.Sprintf("tcp://[%s]:%d", host, port)
+	}
+	return fmt.Sprintf("tcp://%s:%d", host, port)
+}
+
+// Check if a host is an IPv6 address.
+func isIPv6Host(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
+
+// Check if a ZMQ address uses an IPv6 host.
+func isIPv6Address(address string) bool {
+	return strings.HasPrefix(address, "tcp://[")
+}
+
+// Check if a socket error happened because the address is already in use.
+func isAddressInUse(err error) bool {
+	return zmq4.AsErrno(err) == zmq4.Errno(syscall.EADDRINUSE)
+}
+
+// Remove an IPC socket file when there is no process listening on it.
+//
+// The result is true when the file was removed.
+func removeStaleSocketFile(address string) bool {
+	if !strings.HasPrefix(address, "ipc://") {
+		return false
+	}
+
+	path := strings.TrimPrefix(address, "ipc://")
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return false
+	}
+
+	// The file is in use when a connection to the socket can be opened
+	conn, err := net.DialTimeout("unix", path, staleSocketDialTimeout)
+	if err == nil {
+		conn.Close()
+		return false
+	} else if !errors.Is(err, syscall.ECONNREFUSED) {
+		return false
+	}
+
+	if err := os.Remove(path); err != nil {
+		log.Warningf(`Failed to remove stale IPC socket file "%s": %v`, path, err)
+		return false
+	}
+
+	log.Warningf(`Removed stale IPC socket file: "%s"`, path)
+	return true
+}
+
+// Bind the component socket to an address.
+//
+// When the address is in use, for example because a previous process didn't remove its
+// IPC socket file or because the TCP port is not released yet during a rolling deploy,
+// the stale IPC socket file is removed and the bind is retried with an increasing backoff.
+func bindSocket(socket *zmq4.Socket, address string) (err error) {
+	// IPv6 must be enabled in the socket to bind to IPv6 addresses
+	if isIPv6Address(address) {
+		if err := socket.SetIpv6(true); err != nil {
+			return fmt.Errorf("Failed to enable IPv6 in the socket: %v", err)
+		}
+	}
+
+	backoff := bindBackoff
+	for attempt := 1; attempt <= bindAttempts; attempt++ {
+		if err = socket.Bind(address); err == nil || !isAddressInUse(err) {
+			return err
+		}
+
+		if removeStaleSocketFile(address) {
+			// Retry right away after the stale socket file is removed
+			continue
+		}
+
+		if attempt < bindAttempts {
+			log.Warningf(`Address "%s" is in use, retrying in %s (attempt %d of %d)`, address, backoff, attempt, bindAttempts)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	log.Errorf(`Address "%s" is still in use after %d attempts`, address, bindAttempts)
+	return err
+}
@@ -0,0 +1,76 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatTCPAddress(t *testing.T) {
+	cases := map[string]string{
+		"127.0.0.1": "tcp://127.0.0.1:5000",
+		"*":         "tcp://*:5000",
+		"eth0":      "tcp://eth0:5000",
+		"::1":       "tcp://[::1]:5000",
+		"fe80::1":   "tcp://[fe80::1]:5000",
+	}
+
+	for host, expected := range cases {
+		address := formatTCPAddress(host, 5000)
+		if address != expected {
+			t.Errorf("%s: expected %s, got %s", host, expected, address)
+		}
+		if isIPv6Address(address) != isIPv6Host(host) {
+			t.Errorf("%s: unexpected IPv6 address check", host)
+		}
+	}
+}
+
+func TestRemoveStaleSocketFile(t *testing.T) {
+	// Unix socket paths have a short length limit
+	directory, err := os.MkdirTemp("", "kusanagi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(directory)
+	})
+
+	path := filepath.Join(directory, "test.sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.SetUnlinkOnClose(false)
+
+	// Socket files in use are not removed
+	if removeStaleSocketFile("ipc://" + path) {
+		t.Error("expected the socket file in use not to be removed")
+	}
+
+	listener.Close()
+	if !removeStaleSocketFile("ipc://" + path) {
+		t.Error("expected the stale socket file to be removed")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the socket file not to exist, got %v", err)
+	}
+
+	// Only IPC socket files are removed
+	file := filepath.Join(directory, "test.txt")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if removeStaleSocketFile("ipc://"+file) || removeStaleSocketFile("tcp://127.0.0.1:5000") {
+		t.Error("expected the addresses not to be removed")
+	}
+}
@@ -0,0 +1,277 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+)
+
+// Kinds of HTTP request bodies that can be decoded.
+const (
+	bodyKindJSON    = "json"
+	bodyKindForm    = "form"
+	bodyKindXML     = "xml"
+	bodyKindMsgpack = "msgpack"
+	bodyKindUnknown = ""
+)
+
+// Struct tags used to get the field names when decoding URL encoded bodies.
+const (
+	formFieldTagName   = "form"
+	jsonFieldTagName   = "json"
+	formFieldTagIgnore = "-"
+)
+
+// ErrBodyTooLarge is returned when the HTTP request body is larger than the size limit.
+var ErrBodyTooLarge = errors.New("The HTTP request body is too large")
+
+// GetBodySize returns the size of the HTTP request body in bytes.
+func (r HTTPRequest) GetBodySize() int {
+	return len(r.payload.Body)
+}
+
+// GetBodyReader returns a reader for the HTTP request body.
+//
+// The body is decoded with the rest of the request payload, so it is already in memory
+// when the reader is created. Each call returns a new reader that starts at the
+// beginning of the body.
+func (r HTTPRequest) GetBodyReader() io.Reader {
+	return bytes.NewReader(r.payload.Body)
+}
+
+// GetBodyReaderWithLimit returns a reader for the HTTP request body when the body size is within a limit.
+//
+// An ErrBodyTooLarge error is returned when the body is larger than the limit.
+//
+// limit: The maximum body size in bytes.
+func (r HTTPRequest) GetBodyReaderWithLimit(limit int64) (io.Reader, error) {
+	if size := int64(len(r.payload.Body)); size > limit {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d bytes", ErrBodyTooLarge, size, limit)
+	}
+	return r.GetBodyReader(), nil
+}
+
+// Get the kind of body for a MIME type.
+func getBodyKind(mimeType string) string {
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	switch {
+	case mimeType == "application/json" || strings.HasSuffix(mimeType, "+json"):
+		return bodyKindJSON
+	case mimeType == "application/x-www-form-urlencoded":
+		return bodyKindForm
+	case mimeType == "application/xml" || mimeType == "text/xml" || strings.HasSuffix(mimeType, "+xml"):
+		return bodyKindXML
+	case mimeType == "application/msgpack" || mimeType == "application/x-msgpack":
+		return bodyKindMsgpack
+	}
+	return bodyKindUnknown
+}
+
+// Get the MIME type of the request body without the media type parameters.
+func (r HTTPRequest) getContentType() string {
+	value := r.GetHeader("Content-Type", "")
+	if mediaType, _, err := mime.ParseMediaType(value); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(value, ";", 2)[0]))
+}
+
+// DecodeBody decodes the HTTP request body into a value.
+//
+// The "Content-Type" header selects the decoding, and the supported content types are JSON,
+// XML, msgpack and URL encoded forms. Form values can be decoded into maps of strings, maps of
+// string lists or structs, where struct fields are matched using the "form" or "json" tags.
+//
+// v: A pointer to the value where the body is decoded.
+func (r HTTPRequest) DecodeBody(v interface{}) error {
+	contentType := r.getContentType()
+	kind := getBodyKind(contentType)
+
+	// URL encoded bodies can be already parsed by the gateway into the POST data
+	if kind == bodyKindForm {
+		return r.decodeFormBody(v)
+	} else if !r.HasBody() {
+		return fmt.Errorf("The HTTP request has no body")
+	}
+
+	var err error
+	switch kind {
+	case bodyKindJSON:
+		err = json.Unmarshal(r.GetBody(), v)
+	case bodyKindXML:
+		err = xml.Unmarshal(r.GetBody(), v)
+	case bodyKindMsgpack:
+		err = msgpack.Decode(r.GetBody(), v)
+	default:
+		return fmt.Errorf(`Unsupported HTTP body content type: "%s"`, contentType)
+	}
+
+	if err != nil {
+		return fmt.Errorf(`Failed to decode the "%s" HTTP body: %v`, contentType, err)
+	}
+	return nil
+}
+
+// DecodeBodyWithSchema decodes the HTTP request body into a value for an action.
+//
+// The body is decoded only when its content type is one of the MIME types
+// defined for the body in the HTTP schema of the action.
+//
+// schema: The HTTP schema of the action.
+// v: A pointer to the value where the body is decoded.
+func (r HTTPRequest) DecodeBodyWithSchema(schema *HTTPActionSchema, v interface{}) error {
+	contentType := r.getContentType()
+	if !schema.HasBodyType(contentType) {
+		return fmt.Errorf(`The HTTP body content type is not supported by the action: "%s"`, contentType)
+	}
+
+	return r.DecodeBody(v)
+}
+
+// Decode an URL encoded body into a value.
+func (r HTTPRequest) decodeFormBody(v interface{}) error {
+	values := url.Values(r.GetPostParamsArray())
+	if r.HasBody() {
+		parsed, err := url.ParseQuery(string(r.GetBody()))
+		if err != nil {
+			return fmt.Errorf("Failed to decode the URL encoded HTTP body: %v", err)
+		}
+		values = parsed
+	}
+
+	switch target := v.(type) {
+	case *url.Values:
+		*target = values
+	case *map[string][]string:
+		*target = values
+	case *map[string]string:
+		*target = make(map[string]string)
+		for name := range values {
+			(*target)[name] = values.Get(name)
+		}
+	case *map[string]interface{}:
+		*target = make(map[string]interface{})
+		for name, items := range values {
+			if len(items) == 1 {
+				(*target)[name] = items[0]
+			} else {
+				(*target)[name] = items
+			}
+		}
+	default:
+		return decodeFormStruct(values, v)
+	}
+	return nil
+}
+
+// Decode URL encoded values into a struct.
+func decodeFormStruct(values url.Values, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Cannot decode the URL encoded HTTP body into a value of type %T", v)
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			// Skip unexported fields
+			continue
+		}
+
+		name := getFormFieldName(field)
+		if name == formFieldTagIgnore {
+			continue
+		}
+
+		items, exists := values[name]
+		if !exists || len(items) == 0 {
+			continue
+		}
+
+		if err := setFormField(rv.Field(i), items); err != nil {
+			return fmt.Errorf(`Failed to decode the URL encoded field "%s": %v`, name, err)
+		}
+	}
+	return nil
+}
+
+// Get the name of a form field using the struct tags.
+func getFormFieldName(field reflect.StructField) string {
+	for _, tagName := range []string{formFieldTagName, jsonFieldTagName} {
+		if tag := strings.SplitN(field.Tag.Get(tagName), ",", 2)[0]; tag != "" {
+			return tag
+		}
+	}
+	return field.Name
+}
+
+// Set the value of a struct field from a list of form values.
+func setFormField(field reflect.Value, items []string) error {
+	if field.Kind() != reflect.Slice {
+		return setFormValue(field, items[0])
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := setFormValue(slice.Index(i), item); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// Set a value from its string representation.
+func setFormValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("Unsupported field type %s", field.Type())
+	}
+	return nil
+}
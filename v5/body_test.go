@@ -0,0 +1,198 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create an HTTP request with a body for the decoding tests.
+func newBodyTestRequest(contentType string, body []byte) *HTTPRequest {
+	headers := http.Header{}
+	if contentType != "" {
+		headers.Set("Content-Type", contentType)
+	}
+	return newHTTPRequest(&payload.HTTPRequest{Method: "POST", Headers: headers, Body: body})
+}
+
+// Body used to decode URL encoded values into structs.
+type bodyTestForm struct {
+	Name    string   `form:"name"`
+	Age     int      `json:"age,omitempty"`
+	Active  bool     `form:"active"`
+	Score   float64  `form:"score"`
+	Tags    []string `form:"tag"`
+	Skipped string   `form:"-"`
+	Default string
+	hidden  string
+}
+
+func TestGetBodyKind(t *testing.T) {
+	cases := map[string]string{
+		"application/json":                  bodyKindJSON,
+		"application/problem+json":          bodyKindJSON,
+		"Application/X-WWW-Form-Urlencoded": bodyKindForm,
+		"text/xml":                          bodyKindXML,
+		"application/atom+xml":              bodyKindXML,
+		"application/x-msgpack":             bodyKindMsgpack,
+		"text/plain":                        bodyKindUnknown,
+	}
+
+	for mimeType, expected := range cases {
+		if kind := getBodyKind(mimeType); kind != expected {
+			t.Errorf("%s: expected %q, got %q", mimeType, expected, kind)
+		}
+	}
+}
+
+func TestHTTPRequestGetBodyReader(t *testing.T) {
+	r := newBodyTestRequest("text/plain", []byte("kusanagi"))
+
+	// Each reader starts at the beginning of the body
+	for i := 0; i < 2; i++ {
+		if body, _ := io.ReadAll(r.GetBodyReader()); string(body) != "kusanagi" {
+			t.Errorf("%d: unexpected body: %s", i, body)
+		}
+	}
+
+	r = newBodyTestRequest("text/plain", nil)
+	if body, _ := io.ReadAll(r.GetBodyReader()); len(body) != 0 || r.GetBodySize() != 0 {
+		t.Errorf("expected an empty body, got %q", body)
+	}
+}
+
+func TestHTTPRequestGetBodyReaderWithLimit(t *testing.T) {
+	r := newBodyTestRequest("text/plain", []byte("kusanagi"))
+	if size := r.GetBodySize(); size != 8 {
+		t.Errorf("expected a body size of 8, got %d", size)
+	}
+
+	reader, err := r.GetBodyReaderWithLimit(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(reader); string(body) != "kusanagi" {
+		t.Errorf("unexpected body: %s", body)
+	}
+
+	if _, err := r.GetBodyReaderWithLimit(7); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("expected a body too large error, got %v", err)
+	}
+}
+
+func TestHTTPRequestDecodeBody(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name" codec:"name"`
+	}
+
+	packed, err := msgpack.Encode(map[string]string{"name": "jane"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		contentType string
+		body        []byte
+	}{
+		{"application/json; charset=utf-8", []byte(`{"name":"jane"}`)},
+		{"application/xml", []byte(`<user><name>jane</name></user>`)},
+		{"application/msgpack", packed},
+	}
+
+	for _, c := range cases {
+		var u user
+		if err := newBodyTestRequest(c.contentType, c.body).DecodeBody(&u); err != nil {
+			t.Errorf("%s: %v", c.contentType, err)
+		} else if u.Name != "jane" {
+			t.Errorf("%s: unexpected value: %v", c.contentType, u)
+		}
+	}
+
+	var u user
+	if err := newBodyTestRequest("application/json", nil).DecodeBody(&u); err == nil {
+		t.Error("expected an error without body")
+	}
+	if err := newBodyTestRequest("text/plain", []byte("jane")).DecodeBody(&u); err == nil {
+		t.Error("expected an error for an unsupported content type")
+	}
+	if err := newBodyTestRequest("application/json", []byte("{")).DecodeBody(&u); err == nil {
+		t.Error("expected an error for an invalid body")
+	}
+}
+
+func TestHTTPRequestDecodeFormBody(t *testing.T) {
+	r := newBodyTestRequest(
+		"application/x-www-form-urlencoded",
+		[]byte("name=jane&age=42&active=true&score=9.5&tag=a&tag=b&Skipped=x&Default=d&hidden=h"),
+	)
+
+	var form bodyTestForm
+	if err := r.DecodeBody(&form); err != nil {
+		t.Fatal(err)
+	}
+	expected := bodyTestForm{Name: "jane", Age: 42, Active: true, Score: 9.5, Tags: []string{"a", "b"}, Default: "d"}
+	if !reflect.DeepEqual(form, expected) {
+		t.Errorf("expected %+v, got %+v", expected, form)
+	}
+
+	var values map[string]interface{}
+	if err := r.DecodeBody(&values); err != nil {
+		t.Fatal(err)
+	}
+	if values["name"] != "jane" || !reflect.DeepEqual(values["tag"], []string{"a", "b"}) {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	if err := newBodyTestRequest("application/x-www-form-urlencoded", []byte("age=old")).DecodeBody(&form); err == nil {
+		t.Error("expected an error for an invalid field value")
+	}
+	if err := r.DecodeBody(&[]string{}); err == nil {
+		t.Error("expected an error for an unsupported value")
+	}
+}
+
+func TestHTTPRequestDecodeFormBodyFromPostData(t *testing.T) {
+	// The gateway can parse the form body into the POST data
+	headers := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	r := newHTTPRequest(&payload.HTTPRequest{
+		Method:   "POST",
+		Headers:  headers,
+		PostData: payload.HTTPRequestData{"name": {"jane"}},
+	})
+
+	var values map[string]string
+	if err := r.DecodeBody(&values); err != nil {
+		t.Fatal(err)
+	}
+	if values["name"] != "jane" {
+		t.Errorf("unexpected values: %v", values)
+	}
+}
+
+func TestHTTPRequestDecodeBodyWithSchema(t *testing.T) {
+	schema := &HTTPActionSchema{payload.HTTPActionSchema{Body: []string{"application/json"}}}
+
+	var values map[string]interface{}
+	r := newBodyTestRequest("application/json; charset=utf-8", []byte(`{"name":"jane"}`))
+	if err := r.DecodeBodyWithSchema(schema, &values); err != nil || values["name"] != "jane" {
+		t.Errorf("expected the body to be decoded, got %v %v", values, err)
+	}
+
+	r = newBodyTestRequest("application/xml", []byte(`<name>jane</name>`))
+	if err := r.DecodeBodyWithSchema(schema, &values); err == nil {
+		t.Error("expected an error for a content type not supported by the action")
+	}
+}
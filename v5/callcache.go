@@ -0,0 +1,253 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"container/list"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// CallCacheSizeVariable is the name of the component variable that sets the maximum
+// number of runtime call results to keep in the cache.
+//
+// The cache is disabled by default, or when the value is 0.
+const CallCacheSizeVariable = "call-cache-size"
+
+// CallCacheTTLVariable is the name of the component variable that sets the time
+// in milliseconds that the runtime call results are kept in the cache.
+const CallCacheTTLVariable = "call-cache-ttl"
+
+// Default values for the runtime call cache.
+const defaultCallCacheSize = 0
+const defaultCallCacheTTL = 60000
+
+// Creates the runtime call cache configured with the component variables.
+//
+// The result is nil when the cache is disabled.
+func newCallCacheFromInput(input cli.Input) *callCache {
+	size := getIntVariable(input, CallCacheSizeVariable, defaultCallCacheSize)
+	if size == 0 {
+		return nil
+	}
+
+	ttl := getIntVariable(input, CallCacheTTLVariable, defaultCallCacheTTL)
+	log.Debugf("Runtime call cache enabled. Size: %d, TTL: %dms", size, ttl)
+	return newCallCache(size, time.Duration(ttl)*time.Millisecond)
+}
+
+// Creates a new runtime call cache.
+//
+// size: The maximum number of results in the cache.
+// ttl: The time the results are kept in the cache.
+func newCallCache(size int, ttl time.Duration) *callCache {
+	return &callCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// In memory cache for the results of the runtime calls.
+//
+// The least recently used results are removed when the cache is full.
+type callCache struct {
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// Result of a runtime call saved in the cache.
+//
+// Only the return value is cached. The transport of the callee is not saved,
+// so calls that change the transport are never cached.
+type callCacheEntry struct {
+	key         string
+	returnValue interface{}
+	duration    time.Duration
+	expires     time.Time
+}
+
+// Get the prefix of the cache keys for the calls to an action.
+func getCallCachePrefix(service, version, action string) string {
+	return strings.Join([]string{service, version, action}, "\x00") + "\x00"
+}
+
+// Get the cache key for a runtime call.
+//
+// The key is generated using the callee and a hash of the parameters.
+func getCallCacheKey(service, version, action string, params []*Param) (string, error) {
+	hash, err := payload.Hash(paramsToPayload(params))
+	if err != nil {
+		return "", err
+	}
+	return getCallCachePrefix(service, version, action) + hash, nil
+}
+
+// Get a runtime call result from the cache.
+//
+// The return value of the result is a copy, so it can be changed.
+func (c *callCache) get(key string) (*callCacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+
+	entry := element.Value.(*callCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+
+	result := *entry
+	result.returnValue = copyValue(entry.returnValue)
+	return &result, true
+}
+
+// Save a runtime call result in the cache.
+func (c *callCache) set(key string, returnValue interface{}, duration time.Duration) {
+	entry := &callCacheEntry{
+		key:         key,
+		returnValue: copyValue(returnValue),
+		duration:    duration,
+		expires:     time.Now().Add(c.ttl),
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+
+	// Remove the least recently used results when the cache is full
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*callCacheEntry).key)
+	}
+}
+
+// Check if a runtime call changed the transport of the caller.
+//
+// The result of these calls can't be cached because the changes made by
+// the callee would be lost when the result is taken from the cache.
+//
+// before: The transport sent to the callee.
+// after: The transport returned by the callee.
+func hasCallSideEffects(before, after *payload.Transport) bool {
+	if after == nil {
+		return false
+	} else if before == nil {
+		before = &payload.Transport{}
+	}
+
+	if !payload.DiffTransports(before, after).IsEmpty() {
+		return true
+	}
+	return !equalValues(before.Files, after.Files) || !equalValues(before.Transactions, after.Transactions)
+}
+
+// Check if two maps are equal, considering nil and empty maps as equal.
+func equalValues[T ~map[K]V, K comparable, V any](a, b T) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Remove the results of the runtime calls to an action from the cache.
+func (c *callCache) invalidate(service, version, action string) {
+	prefix := getCallCachePrefix(service, version, action)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Get a deep copy of a value.
+//
+// Maps, slices, arrays and pointers are copied recursively, and any other value is copied as is.
+func copyValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(value)).Interface()
+}
+
+// Get a deep copy of a reflected value.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	}
+	return v
+}
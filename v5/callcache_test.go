@@ -0,0 +1,95 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestCallCacheReturnsCopies(t *testing.T) {
+	cache := newCallCache(10, time.Minute)
+
+	value := map[string]interface{}{
+		"tags":   []interface{}{"a", "b"},
+		"author": map[string]interface{}{"name": "jane"},
+	}
+	cache.set("key", value, time.Millisecond)
+
+	// Changes to the value after it is saved don't change the cached value
+	value["tags"].([]interface{})[0] = "changed"
+
+	entry, ok := cache.get("key")
+	if !ok {
+		t.Fatal("expected the result to be cached")
+	}
+
+	// Changes to the cached result don't change the cache
+	result := entry.returnValue.(map[string]interface{})
+	result["author"].(map[string]interface{})["name"] = "john"
+	result["tags"].([]interface{})[1] = "changed"
+
+	entry, _ = cache.get("key")
+	expected := map[string]interface{}{
+		"tags":   []interface{}{"a", "b"},
+		"author": map[string]interface{}{"name": "jane"},
+	}
+	if !reflect.DeepEqual(entry.returnValue, expected) {
+		t.Errorf("expected the cached value %v, got %v", expected, entry.returnValue)
+	}
+}
+
+func TestCallCacheExpiresAndInvalidates(t *testing.T) {
+	cache := newCallCache(1, time.Minute)
+
+	cache.set(getCallCachePrefix("users", "1.0.0", "read")+"a", 1, 0)
+	cache.set(getCallCachePrefix("users", "1.0.0", "read")+"b", 2, 0)
+	if _, ok := cache.get(getCallCachePrefix("users", "1.0.0", "read") + "a"); ok {
+		t.Error("expected the least recently used result to be removed")
+	}
+
+	cache.invalidate("users", "1.0.0", "read")
+	if _, ok := cache.get(getCallCachePrefix("users", "1.0.0", "read") + "b"); ok {
+		t.Error("expected the results of the action to be invalidated")
+	}
+
+	cache = newCallCache(1, 0)
+	cache.set("key", 1, 0)
+	if _, ok := cache.get("key"); ok {
+		t.Error("expected the result to expire")
+	}
+}
+
+func TestHasCallSideEffects(t *testing.T) {
+	data := payload.ServiceData{"ktp://127.0.0.1:80": {"users": {"1.0.0": {"read": []interface{}{1}}}}}
+	transactions := payload.Transactions{"c": {{Name: "users", Version: "1.0.0", Caller: "read", Action: "commit"}}}
+
+	cases := []struct {
+		name     string
+		before   *payload.Transport
+		after    *payload.Transport
+		expected bool
+	}{
+		{"no transport", &payload.Transport{}, nil, false},
+		{"no changes", &payload.Transport{Data: data}, &payload.Transport{Data: data}, false},
+		{"empty values", &payload.Transport{}, &payload.Transport{Files: payload.Files{}, Transactions: payload.Transactions{}}, false},
+		{"data", &payload.Transport{}, &payload.Transport{Data: data}, true},
+		{"transactions", &payload.Transport{}, &payload.Transport{Transactions: transactions}, true},
+		{"no snapshot", nil, &payload.Transport{Data: data}, true},
+	}
+
+	for _, c := range cases {
+		if got := hasCallSideEffects(c.before, c.after); got != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, got)
+		}
+	}
+}
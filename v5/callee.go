@@ -15,8 +15,12 @@ type Callee struct {
 	version  string
 	action   string
 	duration uint
+	attempts []uint
 	timeout  uint
 	params   []*Param
+	retry    *RetryPolicy
+	group    string
+	priority int
 }
 
 // GetDuration returns the duration of the call in milliseconds.
@@ -24,6 +28,21 @@ func (c Callee) GetDuration() uint {
 	return c.duration
 }
 
+// GetAttempts returns the duration in milliseconds of each attempt of a retried run-time call.
+//
+// The result is nil when the call was made without a retry policy.
+func (c Callee) GetAttempts() []uint {
+	return c.attempts
+}
+
+// IsPending checks if the call is not executed yet.
+//
+// Deferred calls and calls to other realms are pending, while the run-time
+// calls are completed when they are registered in the transport.
+func (c Callee) IsPending() bool {
+	return c.duration == 0
+}
+
 // IsRemote checks if the call is to a service in another Realm.
 func (c Callee) IsRemote() bool {
 	return c.gateway != ""
@@ -62,3 +81,22 @@ func (c Callee) GetParams() (params []*Param) {
 	}
 	return params
 }
+
+// GetRetryPolicy returns the retry policy of a deferred call.
+//
+// The result is nil when the call has no retry policy.
+func (c Callee) GetRetryPolicy() *RetryPolicy {
+	return c.retry
+}
+
+// GetGroup returns the group name of a deferred call.
+//
+// The result is empty when the call was registered without a group.
+func (c Callee) GetGroup() string {
+	return c.group
+}
+
+// GetPriority returns the priority of the group of a deferred call.
+func (c Callee) GetPriority() int {
+	return c.priority
+}
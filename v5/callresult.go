@@ -0,0 +1,86 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Creates a new run-time call result.
+//
+// returnValue: The value returned by the remote action.
+// transport: The transport returned by the remote action.
+// duration: The time spent waiting for the call.
+// cached: True when the result was read from the call cache.
+func newCallResult(returnValue interface{}, transport *payload.Transport, duration time.Duration, cached bool) *CallResult {
+	r := CallResult{returnValue: returnValue, duration: duration, cached: cached}
+	if transport != nil {
+		// Use a copy to avoid changes to the transport of the call result
+		r.transport = &Transport{transport.Clone()}
+	}
+	return &r
+}
+
+// CallResult contains the result of a run-time call.
+type CallResult struct {
+	returnValue interface{}
+	transport   *Transport
+	duration    time.Duration
+	cached      bool
+	callID      string
+}
+
+// GetReturnValue returns the value returned by the remote action.
+func (r CallResult) GetReturnValue() interface{} {
+	return r.returnValue
+}
+
+// GetDuration returns the time the action waited for the call result.
+func (r CallResult) GetDuration() time.Duration {
+	return r.duration
+}
+
+// GetCallID returns the ID of the run-time call.
+//
+// The result is empty when the result was read from the call cache.
+func (r CallResult) GetCallID() string {
+	return r.callID
+}
+
+// IsCached checks if the result was read from the call cache.
+func (r CallResult) IsCached() bool {
+	return r.cached
+}
+
+// HasTransport checks if the remote action returned a transport.
+func (r CallResult) HasTransport() bool {
+	return r.transport != nil
+}
+
+// GetTransport returns a snapshot of the transport returned by the remote action.
+//
+// The result is nil when the remote action didn't return a transport.
+func (r CallResult) GetTransport() *Transport {
+	return r.transport
+}
+
+// GetErrors returns the errors added to the transport by the remote action and its callees.
+func (r CallResult) GetErrors() []Error {
+	if r.transport == nil {
+		return nil
+	}
+	return r.transport.GetErrors()
+}
+
+// HasFallbacks checks if fallbacks were triggered by the remote action or its callees.
+func (r CallResult) HasFallbacks() bool {
+	return r.transport != nil && r.transport.HasFallbacks()
+}
@@ -0,0 +1,71 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestNewCallResult(t *testing.T) {
+	transport := &payload.Transport{}
+	transport.SetError("posts", "1.0.0", "Failed", 1, "500 Internal Server Error")
+	transport.AddFallback("posts", "1.0.0", "list")
+
+	r := newCallResult("value", transport, time.Second, false)
+	if r.GetReturnValue() != "value" || r.GetDuration() != time.Second || r.IsCached() {
+		t.Errorf("unexpected call result: %+v", r)
+	}
+	if !r.HasTransport() || !r.HasFallbacks() || len(r.GetErrors()) != 1 {
+		t.Error("expected the errors and fallbacks of the transport")
+	}
+
+	// The result uses a snapshot of the transport
+	transport.SetError("posts", "1.0.0", "Failed again", 2, "500 Internal Server Error")
+	if errors := r.GetErrors(); len(errors) != 1 {
+		t.Errorf("expected the transport snapshot, got %d errors", len(errors))
+	}
+
+	r = newCallResult(nil, nil, 0, false)
+	if r.HasTransport() || r.GetTransport() != nil || r.HasFallbacks() || r.GetErrors() != nil {
+		t.Error("expected a result without transport")
+	}
+}
+
+func TestActionCallWithResultCached(t *testing.T) {
+	a := newTestActionWithSchema("users", "1.0.0", "read", payload.ActionSchema{Calls: [][]string{{"posts", "1.0.0", "list"}}})
+	a.state.cache = newCallCache(10, time.Minute)
+
+	key, err := getCallCacheKey("posts", "1.0.0", "list", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.state.cache.set(key, "cached", time.Millisecond)
+
+	r, err := a.CallWithResult("posts", "1.0.0", "list", nil, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cached results don't have a call ID or a transport
+	if !r.IsCached() || r.GetReturnValue() != "cached" || r.GetCallID() != "" || r.HasTransport() {
+		t.Errorf("expected the cached call result, got %+v", r)
+	}
+
+	// The call is registered in the transport without any other change
+	transport := a.reply.Command.Result.Transport
+	if calls := transport.Calls["users"]["1.0.0"]; len(calls) != 1 || calls[0].Name != "posts" {
+		t.Errorf("expected the cached call to be registered, got %v", transport.Calls)
+	}
+	if len(transport.Data) != 0 || len(transport.Transactions) != 0 {
+		t.Errorf("expected no changes in the transport, got %+v", transport)
+	}
+}
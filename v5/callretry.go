@@ -0,0 +1,172 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/runtime"
+)
+
+// CallOptions contains the options to make a run-time call.
+type CallOptions struct {
+	// Timeout is the timeout in milliseconds for each attempt of the call.
+	Timeout uint
+	// WithoutCache disables the call cache for the call.
+	WithoutCache bool
+	// Retry is an optional policy to retry the call when it times out or fails to connect.
+	Retry *CallRetryPolicy
+}
+
+// CallRetryPolicy defines how a run-time call is retried.
+//
+// Calls are only retried when an attempt times out or fails to connect, so the errors
+// returned by the called action are never retried. When the hedge delay is not zero a
+// new attempt is started each time the delay elapses without a reply, without waiting
+// for the previous attempts to fail, and the first successful reply is used.
+type CallRetryPolicy struct {
+	// MaxAttempts is the maximum number of times the call is made, including the first one.
+	MaxAttempts uint
+	// Backoff is the time to wait before retrying a failed attempt.
+	Backoff time.Duration
+	// HedgeDelay is the time to wait for a reply before starting a new attempt.
+	HedgeDelay time.Duration
+}
+
+// Check that the retry policy values are valid.
+func (p CallRetryPolicy) validate() error {
+	if p.MaxAttempts == 0 {
+		return fmt.Errorf("The call retry policy max attempts must be greater than 0")
+	} else if p.Backoff < 0 || p.HedgeDelay < 0 {
+		return fmt.Errorf("The call retry policy durations can't be negative")
+	}
+	return nil
+}
+
+// Result of a single attempt of a run-time call.
+type callAttempt struct {
+	index   int
+	result  callResult
+	elapsed time.Duration
+}
+
+// Make the attempts of a run-time call.
+//
+// The result contains the reply of the first successful attempt, or the last failed one,
+// and the duration in milliseconds of each attempt, which is zero for the hedged attempts
+// that didn't finish before the call ended.
+//
+// a: The action making the call.
+// send: A function to send a new attempt of the call.
+func (p CallRetryPolicy) run(a *Action, send func() (<-chan callResult, error)) (callResult, []uint, error) {
+	var last callResult
+
+	// The channel is buffered so the pending hedged attempts can finish after the call ends
+	attempts := make(chan callAttempt, p.MaxAttempts)
+	elapsed := []uint{}
+	pending := 0
+
+	start := func() error {
+		c, err := send()
+		if err != nil {
+			return err
+		}
+
+		index := len(elapsed)
+		elapsed = append(elapsed, 0)
+		pending++
+		begin := time.Now()
+		go func() {
+			attempts <- callAttempt{index, <-c, time.Since(begin)}
+		}()
+		return nil
+	}
+
+	// Start a new hedged attempt each time the delay elapses without a reply
+	hedge := func() <-chan time.Time {
+		if p.HedgeDelay == 0 || uint(len(elapsed)) >= p.MaxAttempts {
+			return nil
+		}
+		return time.After(p.HedgeDelay)
+	}
+
+	if err := start(); err != nil {
+		return last, nil, err
+	}
+
+	timer := hedge()
+	for pending > 0 {
+		select {
+		case attempt := <-attempts:
+			pending--
+			elapsed[attempt.index] = durationToMilliseconds(attempt.elapsed)
+			if attempt.result.Error == nil {
+				return attempt.result, elapsed, nil
+			}
+
+			last = attempt.result
+			if !runtime.IsRetryable(last.Error) {
+				return last, elapsed, nil
+			} else if pending > 0 || uint(len(elapsed)) >= p.MaxAttempts {
+				// Wait for the hedged attempts or stop when there are no attempts left
+				continue
+			}
+
+			a.logger.Debugf("Retrying run-time call after error: %v", last.Error)
+			if p.Backoff > 0 {
+				select {
+				case <-time.After(p.Backoff):
+				case <-a.Done():
+					return last, elapsed, errors.New("Run-time call stopped")
+				}
+			}
+
+			if err := start(); err != nil {
+				return last, elapsed, err
+			}
+			timer = hedge()
+		case <-timer:
+			a.logger.Debugf("Starting hedged run-time call attempt %d", len(elapsed)+1)
+			if err := start(); err != nil {
+				return last, elapsed, err
+			}
+			timer = hedge()
+		}
+	}
+	return last, elapsed, nil
+}
+
+// CallWithOptions performs a run-time call to a service and returns the call result.
+//
+// When the options contain a retry policy the duration of each attempt is saved in the
+// transport together with the total duration of the call.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+// params: Optional list of Param objects.
+// files: Optional list of File objects.
+// options: The call options.
+func (a *Action) CallWithOptions(
+	service string,
+	version string,
+	action string,
+	params []*Param,
+	files []File,
+	options CallOptions,
+) (*CallResult, error) {
+	if options.Retry != nil {
+		if err := options.Retry.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return a.call(service, version, action, params, files, options.Timeout, !options.WithoutCache, options.Retry)
+}
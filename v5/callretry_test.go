@@ -0,0 +1,132 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/runtime"
+)
+
+// Create a function to send call attempts that reply with a list of errors for the call retry tests.
+//
+// A nil error is a successful reply. The result also returns a function to get the number of attempts.
+func newRetryTestSender(errs ...error) (func() (<-chan callResult, error), func() int) {
+	count := 0
+	send := func() (<-chan callResult, error) {
+		c := make(chan callResult, 1)
+		if count < len(errs) {
+			c <- callResult{ReturnValue: count, Error: errs[count]}
+		}
+		count++
+		return c, nil
+	}
+	return send, func() int { return count }
+}
+
+func TestCallRetryPolicyValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy CallRetryPolicy
+		valid  bool
+	}{
+		{"valid", CallRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}, true},
+		{"no attempts", CallRetryPolicy{}, false},
+		{"negative backoff", CallRetryPolicy{MaxAttempts: 1, Backoff: -1}, false},
+		{"negative hedge delay", CallRetryPolicy{MaxAttempts: 1, HedgeDelay: -1}, false},
+	}
+
+	for _, c := range cases {
+		if err := c.policy.validate(); (err == nil) != c.valid {
+			t.Errorf("%s: unexpected validation result: %v", c.name, err)
+		}
+	}
+}
+
+func TestCallRetryPolicyRun(t *testing.T) {
+	timeout := fmt.Errorf("call failed: %w", runtime.ErrTimeout)
+	failure := errors.New("Action failed")
+
+	cases := []struct {
+		name     string
+		errs     []error
+		attempts int
+		value    interface{}
+		err      error
+	}{
+		{"success", []error{nil}, 1, 0, nil},
+		{"retry", []error{timeout, runtime.ConnectionError{}, nil}, 3, 2, nil},
+		{"max attempts", []error{timeout, timeout, timeout, nil}, 3, 2, timeout},
+		// The errors returned by the called action are not retried
+		{"not retryable", []error{failure, nil}, 1, 0, failure},
+	}
+
+	a := newTestAction("users", "1.0.0", "read", nil)
+	policy := CallRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	for _, c := range cases {
+		send, count := newRetryTestSender(c.errs...)
+		result, elapsed, err := policy.run(a, send)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+
+		if count() != c.attempts || len(elapsed) != c.attempts {
+			t.Errorf("%s: expected %d attempts, got %d", c.name, c.attempts, count())
+		}
+		if result.ReturnValue != c.value || result.Error != c.err {
+			t.Errorf("%s: unexpected result: %v %v", c.name, result.ReturnValue, result.Error)
+		}
+	}
+}
+
+func TestCallRetryPolicyRunHedged(t *testing.T) {
+	// The first attempt replies after the hedged attempt
+	first := make(chan callResult, 1)
+	count := 0
+	send := func() (<-chan callResult, error) {
+		count++
+		if count == 1 {
+			return first, nil
+		}
+
+		c := make(chan callResult, 1)
+		c <- callResult{ReturnValue: "hedged"}
+		return c, nil
+	}
+	t.Cleanup(func() {
+		first <- callResult{ReturnValue: "first"}
+	})
+
+	a := newTestAction("users", "1.0.0", "read", nil)
+	policy := CallRetryPolicy{MaxAttempts: 2, HedgeDelay: time.Millisecond}
+	result, elapsed, err := policy.run(a, send)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.ReturnValue != "hedged" {
+		t.Errorf("expected the result of the hedged attempt, got %v", result.ReturnValue)
+	}
+	// The duration of the attempts that didn't finish is zero
+	if len(elapsed) != 2 || elapsed[0] != 0 {
+		t.Errorf("unexpected attempt durations: %v", elapsed)
+	}
+}
+
+func TestActionCallWithOptionsValidatesRetry(t *testing.T) {
+	a := newTestAction("users", "1.0.0", "read", nil)
+	options := CallOptions{Retry: &CallRetryPolicy{}}
+	if _, err := a.CallWithOptions("posts", "1.0.0", "list", nil, nil, options); err == nil {
+		t.Error("expected an error for the invalid retry policy")
+	}
+}
@@ -9,10 +9,15 @@
 package kusanagi
 
 import (
-	"fmt"
+	"flag"
+	"os"
+	"runtime/debug"
+	"sync"
 
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/codec"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
 )
 
 func init() {
@@ -33,11 +38,23 @@ type Component interface {
 	// factory: A callable that returns the resource value.
 	SetResource(name string, factory ResourceFactory) error
 
+	// SetLazyResource stores a resource that is created the first time it is used.
+	//
+	// name: Name of the resource.
+	// factory: A callable that returns the resource value.
+	SetLazyResource(name string, factory ResourceFactory) Component
+
 	// GetResource returns a resource.
 	//
 	// name: Name of the resource.
 	GetResource(name string) (interface{}, error)
 
+	// SetRequestResource registers a factory for a resource that is created for each request.
+	//
+	// name: Name of the resource.
+	// factory: A callable that returns the resource value for a request.
+	SetRequestResource(name string, factory RequestResourceFactory) Component
+
 	// Startup registers a callback to be called during component startup.
 	//
 	// callback: A callback to execute on startup.
@@ -48,11 +65,106 @@ type Component interface {
 	// callback: A callback to execute on shutdown.
 	Shutdown(callback Callback) Component
 
+	// Reload registers a callback to be called after the component variables are reloaded.
+	//
+	// Variables are reloaded from the variables file when the component receives a SIGHUP signal.
+	//
+	// callback: A callback to execute after the variables are reloaded.
+	Reload(callback Callback) Component
+
+	// Warmup registers a callback to be called when the first mapping is received.
+	//
+	// The callback runs in the background, so the requests are processed while the
+	// component is warmed up. When the callback fails or panics the error is logged
+	// and the component keeps running.
+	//
+	// callback: A callback to execute when the first mapping is received.
+	Warmup(callback WarmupCallback) Component
+
+	// OnMappingChange registers a callback to be called when new schemas are received.
+	//
+	// The callback receives the previous mapping, which is nil for the first
+	// mapping, and the new one. It runs in the background, in the same order the
+	// mappings are received, so the requests are processed while it runs.
+	// When the callback panics the error is logged and the component keeps running.
+	//
+	// callback: A callback to execute when the mapping changes.
+	OnMappingChange(callback MappingChangeCallback) Component
+
 	// Error registers a callback to be called error.
 	//
 	// callback: A callback to execute when the component fails to handle a request.
 	Error(callback ErrorCallback) Component
 
+	// SetSigner sets the signer used to sign the reply payloads.
+	//
+	// signer: The payload signer.
+	SetSigner(signer Signer) Component
+
+	// SetVerifier sets the verifier used to check the signatures of the request payloads.
+	//
+	// Requests with missing or invalid signatures are rejected when there is a verifier.
+	//
+	// verifier: The payload verifier.
+	SetVerifier(verifier Verifier) Component
+
+	// SetCodec sets the codec used to serialize the binary payloads.
+	//
+	// The payloads are serialized using msgpack by default. The codec is only used when it
+	// is enabled with the "payload-codec" component variable, for the requests that don't
+	// negotiate the JSON format and for the run-time calls, so the framework must support it.
+	//
+	// codec: The payload codec.
+	SetCodec(codec codec.Codec) Component
+
+	// SetRedactionPolicy sets the policy used to redact the transport before the reply is sent.
+	//
+	// The policy is applied to the transport data and the error metadata just before the
+	// reply payload is serialized. The call and transaction parameters are only redacted
+	// in the replies exported by the component, like the replayed requests.
+	//
+	// policy: The redaction policy.
+	SetRedactionPolicy(policy *RedactionPolicy) Component
+
+	// SetPayloadCompression sets the compressor used for the large reply payloads.
+	//
+	// Reply payloads with a size equal or greater than the minimum size are compressed,
+	// and the response flags tell the framework that the payload is compressed. The
+	// compressed replies of the run-time calls are decompressed automatically. By default
+	// the payloads are not compressed, and a nil compressor disables the compression.
+	//
+	// The compression is experimental: the compressed flag is not part of the framework
+	// protocol and the component can't check if the framework supports it, so it must
+	// only be enabled when the framework decompresses the replies.
+	//
+	// compressor: The payload compressor, like codec.Deflate{}.
+	// minSize: The minimum payload size in bytes, or zero to use 64KB.
+	SetPayloadCompression(compressor codec.Compressor, minSize int) Component
+
+	// SetSchemaPolicy sets how the actions behave when the schemas are missing.
+	//
+	// The policy is used for the validations of the calls, deferred calls,
+	// remote calls and return values. The strict policy is used by default, so the
+	// validations are only skipped when a permissive policy is set.
+	//
+	// policy: The schema policy.
+	SetSchemaPolicy(policy SchemaPolicy) Component
+
+	// SetRequestLimits sets the limits of the request payloads.
+	//
+	// The limits are checked when the request payload is read, before the callbacks
+	// are called, and the requests that exceed them are rejected with an error. By
+	// default there are no limits besides the ones configured in the gateway.
+	//
+	// limits: The request limits.
+	SetRequestLimits(limits RequestLimits) Component
+
+	// Flags returns the flag set used to parse the CLI options.
+	//
+	// Custom options must be added before the component runs, and they are parsed
+	// together with the SDK options. The values are available with Api.GetCLIFlag().
+	Flags() *flag.FlagSet
+
 	// Log writes a value to KUSANAGI logs.
 	//
 	// Given value is converted to string before being logged.
@@ -80,6 +192,9 @@ type ResourceFactory func(Component) (interface{}, error)
 // ErrorCallback is called whenever an error is returned while processing a framework request in userland.
 type ErrorCallback func(error) error
 
+// MappingChangeCallback is called when the component receives new schemas.
+type MappingChangeCallback func(old, new payload.Mapping)
+
 // Callback is called by components during startup and shutdown.
 type Callback func(Component) error
 
@@ -87,6 +202,9 @@ type Callback func(Component) error
 type eventsHandler struct {
 	onStartup  Callback
 	onShutdown Callback
+	onReload   Callback
+	onWarmup   WarmupCallback
+	onMapping  MappingChangeCallback
 	onError    ErrorCallback
 }
 
@@ -112,6 +230,94 @@ func (h eventsHandler) shutdown(c Component) bool {
 	return true
 }
 
+func (h eventsHandler) reload(c Component) bool {
+	if h.onReload != nil {
+		log.Info("Running reload callback...")
+		if err := h.onReload(c); err != nil {
+			log.Errorf("Reload callback failed: %v", err)
+			return false
+		}
+	}
+	return true
+}
+
+func (h eventsHandler) warmup(w *Warmup) (ok bool) {
+	if h.onWarmup != nil {
+		// Panics in the callback must not stop the component
+		defer func() {
+			if err := recover(); err != nil {
+				log.Criticalf("Warmup callback panic: %v\n%s", err, debug.Stack())
+				ok = false
+			}
+		}()
+
+		log.Info("Running warmup callback...")
+		if err := h.onWarmup(w); err != nil {
+			log.Errorf("Warmup callback failed: %v", err)
+			return false
+		}
+	}
+	return true
+}
+
+func (h eventsHandler) mappingChange(old, new payload.Mapping) {
+	if h.onMapping != nil {
+		// Panics in the callback must not stop the component
+		defer func() {
+			if err := recover(); err != nil {
+				log.Criticalf("Mapping change callback panic: %v\n%s", err, debug.Stack())
+			}
+		}()
+
+		log.Debug("Running mapping change callback...")
+		h.onMapping(old, new)
+	}
+}
+
+// Runs the mapping change callbacks in the background.
+//
+// The callbacks run one at a time, in the same order the mappings are received.
+type mappingDispatcher struct {
+	mutex   sync.Mutex
+	events  eventsHandler
+	pending [][2]payload.Mapping
+	running bool
+}
+
+// Queue a mapping change callback.
+func (d *mappingDispatcher) dispatch(old, new payload.Mapping) {
+	if d.events.onMapping == nil {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.pending = append(d.pending, [2]payload.Mapping{old, new})
+	if !d.running {
+		d.running = true
+		go d.run()
+	}
+}
+
+// Run the queued mapping change callbacks until the queue is empty.
+func (d *mappingDispatcher) run() {
+	for {
+		d.mutex.Lock()
+		if len(d.pending) == 0 {
+			d.running = false
+			d.mutex.Unlock()
+			return
+		}
+
+		change := d.pending[0]
+		d.pending = d.pending[1:]
+		d.mutex.Unlock()
+
+		d.events.mappingChange(change[0], change[1])
+	}
+}
+
 func (h eventsHandler) error(e error) bool {
 	if h.onError != nil {
 		log.Info("Running error callback...")
@@ -126,7 +332,7 @@ func (h eventsHandler) error(e error) bool {
 func newComponent(p requestProcessor) component {
 	return component{
 		events:    eventsHandler{},
-		resources: make(map[string]interface{}),
+		resources: newResourceRegistry(),
 		callbacks: make(map[string]interface{}),
 		processor: p,
 	}
@@ -134,37 +340,75 @@ func newComponent(p requestProcessor) component {
 
 type component struct {
 	events    eventsHandler
-	resources map[string]interface{}
+	resources *resourceRegistry
 	callbacks map[string]interface{}
+	// Optional resolver for the callbacks that are not assigned by name
+	resolver  callbackResolver
 	processor requestProcessor
+	signer    Signer
+	verifier  Verifier
+	codec     codec.Codec
+	redaction *RedactionPolicy
+	// Behavior of the validations when the schemas are missing
+	schemaPolicy SchemaPolicy
+	// Optional compression for the large reply payloads
+	compression *payloadCompression
+	// Limits of the request payloads
+	limits RequestLimits
+}
+
+// Get the component base for the components that embed it.
+func (c *component) base() *component {
+	return c
 }
 
 func (c *component) hasCallback(name string) bool {
-	_, ok := c.callbacks[name]
+	_, ok := c.getCallback(name)
 	return ok
 }
 
+// Resolver for the callbacks that are matched by pattern instead of by name.
+type callbackResolver interface {
+	// Get the callback for a name.
+	resolveCallback(name string) (interface{}, bool)
+
+	// Get the patterns of the names that can be resolved.
+	getCallbackPatterns() []string
+}
+
+// Get the callback for a name.
+//
+// Callbacks assigned by name have precedence over the ones found by the resolver.
+func (c *component) getCallback(name string) (interface{}, bool) {
+	if callback, ok := c.callbacks[name]; ok {
+		return callback, true
+	} else if c.resolver != nil {
+		return c.resolver.resolveCallback(name)
+	}
+	return nil, false
+}
+
 func (c *component) HasResource(name string) bool {
-	_, ok := c.resources[name]
-	return ok
+	return c.resources.has(name)
 }
 
 func (c *component) SetResource(name string, factory ResourceFactory) error {
-	resource, err := factory(c)
-	if err != nil {
-		return err
-	} else if resource == nil {
-		return fmt.Errorf("invalid result value for resource: \"%s\"", name)
-	}
-	c.resources[name] = resource
-	return nil
+	return c.resources.set(name, factory, false, c)
+}
+
+func (c *component) SetLazyResource(name string, factory ResourceFactory) Component {
+	// Lazy resources are created when they are used so registration never fails
+	_ = c.resources.set(name, factory, true, c)
+	return c
 }
 
 func (c *component) GetResource(name string) (interface{}, error) {
-	if resource, ok := c.resources[name]; ok {
-		return resource, nil
-	}
-	return nil, fmt.Errorf(`resource not found: "%s"`, name)
+	return c.resources.get(name, c)
+}
+
+func (c *component) SetRequestResource(name string, factory RequestResourceFactory) Component {
+	c.resources.setRequestFactory(name, factory)
+	return c
 }
 
 func (c *component) Startup(callback Callback) Component {
@@ -177,11 +421,45 @@ func (c *component) Shutdown(callback Callback) Component {
 	return c
 }
 
+func (c *component) Reload(callback Callback) Component {
+	c.events.onReload = callback
+	return c
+}
+
+func (c *component) Warmup(callback WarmupCallback) Component {
+	c.events.onWarmup = callback
+	return c
+}
+
+func (c *component) OnMappingChange(callback MappingChangeCallback) Component {
+	c.events.onMapping = callback
+	return c
+}
+
 func (c *component) Error(callback ErrorCallback) Component {
 	c.events.onError = callback
 	return c
 }
 
+func (c *component) SetSigner(signer Signer) Component {
+	c.signer = signer
+	return c
+}
+
+func (c *component) SetVerifier(verifier Verifier) Component {
+	c.verifier = verifier
+	return c
+}
+
+func (c *component) SetCodec(codec codec.Codec) Component {
+	c.codec = codec
+	return c
+}
+
+func (c *component) Flags() *flag.FlagSet {
+	return cli.Flags()
+}
+
 func (c *component) Log(value interface{}, level int) Component {
 	log.Log(level, value)
 	return c
@@ -197,22 +475,64 @@ func (c *component) Run() bool {
 	}
 
 	// Setup the log level before the server is created
-	log.SetLevel(input.GetLogLevel())
+	closeLogOutput, err := setupLogging(input)
+	if err != nil {
+		log.Errorf("Component error: %v", err)
+
+		return false
+	}
+	defer closeLogOutput()
+
+	// In describe mode the component is described without starting the server
+	if input.IsDescribeEnabled() {
+		if err := describeComponent(input, c, os.Stdout); err != nil {
+			log.Errorf("Component error: %v", err)
+
+			return false
+		}
+
+		return true
+	}
+
+	// When worker processes are enabled the current process only supervises
+	// the workers, and the userland callbacks are run by each worker process.
+	if input.GetWorkers() > 0 && !input.IsWorker() {
+		if err := newSupervisor(input).start(); err != nil {
+			log.Errorf("Component error: %v", err)
+
+			return false
+		}
+
+		return true
+	}
 
 	success := false
 
 	// Run the server and check that all callbacks are run successfully
 	if c.events.startup(c) {
 		server := newServer(input, c, c.processor)
-		if err := server.start(); err != nil {
+
+		// In replay mode a recorded request is processed instead of starting the server
+		run := server.start
+		if input.IsReplayEnabled() {
+			run = func() error {
+				return server.replay(input.GetReplayFile(), os.Stdout)
+			}
+		}
+
+		if err := run(); err != nil {
 			log.Errorf("Component error: %v", err)
 		} else {
 			success = true
 		}
 	}
 
+	// Resources are closed after the shutdown callback so it can still use them
+	ok := c.events.shutdown(c)
+	c.resources.close()
+
 	// Return false when shutdown fails, otherwise use the success value
-	if c.events.shutdown(c) {
+	if ok {
 		return success
 	}
 
@@ -0,0 +1,51 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestMappingDispatcherRunsCallbacksInOrder(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan payload.Mapping, 3)
+
+	dispatcher := &mappingDispatcher{events: eventsHandler{onMapping: func(old, new payload.Mapping) {
+		<-release
+
+		// Panics are recovered and the next callbacks still run
+		if new == nil {
+			panic("invalid mapping")
+		}
+		received <- new
+	}}}
+
+	first := payload.Mapping{"users": nil}
+	second := payload.Mapping{"posts": nil}
+
+	// The callbacks don't block the caller while they run
+	dispatcher.dispatch(nil, first)
+	dispatcher.dispatch(first, nil)
+	dispatcher.dispatch(nil, second)
+	close(release)
+
+	for _, expected := range []string{"users", "posts"} {
+		select {
+		case mapping := <-received:
+			if _, ok := mapping[expected]; !ok {
+				t.Errorf(`expected the mapping with the "%s" service, got %v`, expected, mapping)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected the mapping change callback to run")
+		}
+	}
+}
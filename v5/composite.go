@@ -0,0 +1,227 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// CompositeNameVariable is the name of the component variable with the name of the
+// component that runs next to the one started by the framework.
+//
+// The name of the component started by the framework is used when the variable is not set.
+const CompositeNameVariable = "composite-name"
+
+// CompositeVersionVariable is the name of the component variable with the version of the
+// component that runs next to the one started by the framework.
+//
+// The version of the component started by the framework is used when the variable is not set.
+const CompositeVersionVariable = "composite-version"
+
+// CompositeSocketVariable is the name of the component variable with the IPC socket name
+// of the component that runs next to the one started by the framework.
+//
+// A default socket name is used when the variable is not set.
+const CompositeSocketVariable = "composite-socket"
+
+// CompositePortVariable is the name of the component variable with the TCP port of the
+// component that runs next to the one started by the framework.
+//
+// The variable is required when the component started by the framework uses TCP.
+const CompositePortVariable = "composite-port"
+
+// NewComposite creates a new composite component.
+//
+// middleware: The middleware component.
+// service: The service component.
+func NewComposite(middleware *Middleware, service *Service) *Composite {
+	return &Composite{middleware: middleware, service: service}
+}
+
+// Composite runs a middleware and a service in the same process.
+//
+// The component that is started by the framework is selected by the "--component" option,
+// and the other component listens in its own socket, configured using the composite variables.
+// Both components share the resources and the mapping, which is useful for small deployments
+// and for integration testing.
+type Composite struct {
+	middleware *Middleware
+	service    *Service
+}
+
+// Run runs the middleware and the service.
+//
+// The result is false when any of the components fails.
+func (c *Composite) Run() bool {
+	// Read CLI input values
+	input, err := cli.Parse()
+	if err != nil {
+		log.Errorf("Component error: %v", err)
+
+		return false
+	}
+
+	// Setup the log level before the servers are created
+	closeLogOutput, err := setupLogging(input)
+	if err != nil {
+		log.Errorf("Component error: %v", err)
+
+		return false
+	}
+	defer closeLogOutput()
+
+	if input.IsDescribeEnabled() || input.IsReplayEnabled() || input.GetWorkers() > 0 || input.IsWorker() {
+		log.Error("Component error: Composite components can't be described, replayed or run using workers")
+
+		return false
+	}
+
+	primary, secondary, secondaryInput, err := c.getComponents(input)
+	if err != nil {
+		log.Errorf("Component error: %v", err)
+
+		return false
+	}
+
+	// The components share the resources, which are closed once by the primary component
+	primary.resources.merge(secondary.resources)
+	secondary.resources = primary.resources
+
+	success := false
+
+	// Run the servers and check that all callbacks are run successfully
+	started := primary.events.startup(primary)
+	if started {
+		started = secondary.events.startup(secondary)
+	}
+
+	if started {
+		mappings := &sharedMapping{}
+		servers := []*server{
+			newServer(input, primary, primary.processor),
+			newServer(secondaryInput, secondary, secondary.processor),
+		}
+
+		success = runServers(servers, mappings)
+	}
+
+	// Resources are closed after the shutdown callbacks so they can still use them
+	ok := primary.events.shutdown(primary)
+	if !secondary.events.shutdown(secondary) {
+		ok = false
+	}
+	primary.resources.close()
+
+	// Return false when shutdown fails, otherwise use the success value
+	if ok {
+		return success
+	}
+
+	return false
+}
+
+// Get the component started by the framework, the component that runs next to it,
+// and the CLI input for the latter.
+func (c *Composite) getComponents(input cli.Input) (primary, secondary *component, secondaryInput cli.Input, err error) {
+	if c.middleware == nil || c.service == nil {
+		return nil, nil, input, errors.New("The composite component requires a middleware and a service")
+	}
+
+	identity := cli.Identity{
+		Name:    input.GetVariable(CompositeNameVariable),
+		Version: input.GetVariable(CompositeVersionVariable),
+		Socket:  input.GetVariable(CompositeSocketVariable),
+		TCP:     uint(getIntVariable(input, CompositePortVariable, 0)),
+	}
+	if identity.Name == "" {
+		identity.Name = input.GetName()
+	}
+	if identity.Version == "" {
+		identity.Version = input.GetVersion()
+	}
+	if input.IsTCPEnabled() && identity.TCP == 0 {
+		return nil, nil, input, fmt.Errorf(`The "%s" variable is required when TCP is used`, CompositePortVariable)
+	}
+
+	switch input.GetComponent() {
+	case "middleware":
+		primary, secondary = c.middleware.base(), c.service.base()
+		identity.Component = "service"
+	case "service":
+		primary, secondary = c.service.base(), c.middleware.base()
+		identity.Component = "middleware"
+	default:
+		return nil, nil, input, fmt.Errorf(`Invalid component type: "%s"`, input.GetComponent())
+	}
+	return primary, secondary, input.WithIdentity(identity), nil
+}
+
+// Start the servers and wait until all of them are stopped.
+//
+// When a server fails the other servers are stopped. The result is false when any server fails.
+func runServers(servers []*server, mappings *sharedMapping) bool {
+	stop := make(chan struct{})
+	for _, s := range servers {
+		s.mappings = mappings
+		s.stop = stop
+	}
+
+	var (
+		wg      sync.WaitGroup
+		once    sync.Once
+		success = true
+	)
+
+	for _, s := range servers {
+		wg.Add(1)
+		go func(s *server) {
+			defer wg.Done()
+
+			if err := s.start(); err != nil {
+				log.Errorf("Component error: %v", err)
+
+				once.Do(func() {
+					success = false
+					close(stop)
+				})
+			}
+		}(s)
+	}
+
+	wg.Wait()
+	return success
+}
+
+// Mapping shared by the components that run in the same process.
+type sharedMapping struct {
+	mutex   sync.RWMutex
+	mapping payload.Mapping
+}
+
+// Set the latest mapping received by any of the components.
+func (m *sharedMapping) set(mapping payload.Mapping) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.mapping = mapping
+}
+
+// Get the latest mapping received by any of the components.
+func (m *sharedMapping) get() payload.Mapping {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.mapping
+}
@@ -0,0 +1,150 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestCompositeGetComponents(t *testing.T) {
+	middleware := NewMiddleware()
+	service := NewService()
+	c := NewComposite(middleware, service)
+
+	// The other component uses the identity of the component started by the framework by default
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0", Socket: "users"})
+	primary, secondary, secondaryInput, err := c.getComponents(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primary != service.base() || secondary != middleware.base() {
+		t.Error("expected the service to be the primary component")
+	}
+	if secondaryInput.GetComponent() != "middleware" || secondaryInput.GetName() != "users" || secondaryInput.GetVersion() != "1.0.0" {
+		t.Errorf(
+			"unexpected identity: %s %s %s",
+			secondaryInput.GetComponent(),
+			secondaryInput.GetName(),
+			secondaryInput.GetVersion(),
+		)
+	}
+	if secondaryInput.GetSocket() != "" {
+		t.Errorf("expected the default socket, got %s", secondaryInput.GetSocket())
+	}
+
+	setTestVariable(t, CompositeNameVariable, "gateway")
+	setTestVariable(t, CompositeVersionVariable, "2.0.0")
+	setTestVariable(t, CompositeSocketVariable, "gateway-socket")
+
+	input = cli.Input{}.WithIdentity(cli.Identity{Component: "middleware", Name: "users", Version: "1.0.0"})
+	primary, secondary, secondaryInput, err = c.getComponents(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primary != middleware.base() || secondary != service.base() {
+		t.Error("expected the middleware to be the primary component")
+	}
+	identity := []string{secondaryInput.GetComponent(), secondaryInput.GetName(), secondaryInput.GetVersion(), secondaryInput.GetSocket()}
+	if expected := []string{"service", "gateway", "2.0.0", "gateway-socket"}; !reflect.DeepEqual(identity, expected) {
+		t.Errorf("expected %v, got %v", expected, identity)
+	}
+
+	// The secondary component doesn't start the servers of the CLI options
+	if secondaryInput.GetConsolePort() != 0 || secondaryInput.GetOpenAPIAddress() != "" {
+		t.Error("expected the debug servers to be disabled")
+	}
+}
+
+func TestCompositeGetComponentsTCP(t *testing.T) {
+	c := NewComposite(NewMiddleware(), NewService())
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0", TCP: 8000})
+
+	// The port is required when the component uses TCP
+	if _, _, _, err := c.getComponents(input); err == nil {
+		t.Error("expected an error without the composite port")
+	}
+
+	setTestVariable(t, CompositePortVariable, "8001")
+	_, _, secondaryInput, err := c.getComponents(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secondaryInput.GetTCP() != 8001 || secondaryInput.GetSocket() != "" {
+		t.Errorf("expected the composite port, got %d", secondaryInput.GetTCP())
+	}
+}
+
+func TestCompositeGetComponentsErrors(t *testing.T) {
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	if _, _, _, err := NewComposite(nil, NewService()).getComponents(input); err == nil {
+		t.Error("expected an error without middleware")
+	}
+
+	input = cli.Input{}.WithIdentity(cli.Identity{Component: "gateway", Name: "users", Version: "1.0.0"})
+	if _, _, _, err := NewComposite(NewMiddleware(), NewService()).getComponents(input); err == nil {
+		t.Error("expected an error for an invalid component type")
+	}
+}
+
+func TestResourceRegistryMerge(t *testing.T) {
+	factory := func(value string) ResourceFactory {
+		return func(Component) (interface{}, error) {
+			return value, nil
+		}
+	}
+
+	target := newResourceRegistry()
+	if err := target.set("db", factory("target-db"), false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	source := newResourceRegistry()
+	if err := source.set("db", factory("source-db"), false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.set("cache", factory("source-cache"), true, nil); err != nil {
+		t.Fatal(err)
+	}
+	source.setRequestFactory("session", func(interface{}) (interface{}, error) {
+		return "session", nil
+	})
+
+	target.merge(source)
+
+	// Existing resources are kept
+	if value, err := target.get("db", nil); err != nil || value != "target-db" {
+		t.Errorf("expected the target resource, got %v %v", value, err)
+	}
+	if value, err := target.get("cache", nil); err != nil || value != "source-cache" {
+		t.Errorf("expected the merged resource, got %v %v", value, err)
+	}
+	if _, exists := target.getRequestFactory("session"); !exists {
+		t.Error("expected the merged request resource factory")
+	}
+	if !reflect.DeepEqual(target.names, []string{"db", "cache"}) {
+		t.Errorf("unexpected resource order: %v", target.names)
+	}
+}
+
+func TestSharedMapping(t *testing.T) {
+	m := &sharedMapping{}
+	if mapping := m.get(); mapping != nil {
+		t.Errorf("expected no mapping, got %v", mapping)
+	}
+
+	mapping := payload.Mapping{"users": {"1.0.0": payload.Schema{}}}
+	m.set(mapping)
+	if !reflect.DeepEqual(m.get(), mapping) {
+		t.Errorf("expected the mapping, got %v", m.get())
+	}
+}
@@ -0,0 +1,124 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+)
+
+// CompressionMinSizeVariable is the name of the component variable that sets the minimum
+// size in bytes that a middleware response body must have to be compressed automatically.
+//
+// Automatic compression is disabled by default, or when the variable value is zero,
+// so the responses are only compressed when the variable is set.
+const CompressionMinSizeVariable = "compression-min-size"
+
+// Default minimum body size in bytes for the automatic response compression, which disables it.
+const defaultCompressionMinSize = 0
+
+// Content encodings supported for the HTTP response body compression.
+const (
+	GzipEncoding    = "gzip"
+	DeflateEncoding = "deflate"
+)
+
+// Encodings in order of preference used when the client accepts more than one.
+var compressionEncodings = []string{GzipEncoding, DeflateEncoding}
+
+// Compress a body using a content encoding.
+func compressBody(content []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch strings.ToLower(encoding) {
+	case GzipEncoding:
+		w = gzip.NewWriter(&buf)
+	case DeflateEncoding:
+		// HTTP deflate encoding uses the zlib format
+		w = zlib.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf(`Unsupported content encoding: "%s"`, encoding)
+	}
+
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Get the preferred supported encoding that is accepted by an HTTP request.
+//
+// An empty string is returned when the request doesn't accept any of the supported encodings.
+func getAcceptedEncoding(r *HTTPRequest) string {
+	// Responses are only compressed when the client explicitly accepts an encoding
+	ranges := parseAcceptRanges(r.GetHeaderArray("Accept-Encoding", nil))
+	if len(ranges) == 0 {
+		return ""
+	}
+
+	return negotiate(ranges, compressionEncodings, matchTokenRange)
+}
+
+// Get the minimum body size for the automatic response compression.
+func getCompressionMinSize(input cli.Input) int {
+	v := input.GetVariable(CompressionMinSizeVariable)
+	if v == "" {
+		return defaultCompressionMinSize
+	}
+
+	size, err := strconv.Atoi(v)
+	if err != nil || size < 0 {
+		log.Warningf(`Invalid value for variable "%s", using default size: "%s"`, CompressionMinSizeVariable, v)
+		return defaultCompressionMinSize
+	}
+
+	return size
+}
+
+// Compress the HTTP body of a middleware response when the client accepts it.
+//
+// The body is not compressed when it is smaller than the minimum compression size
+// or when the response already has a content encoding.
+func compressResponse(r *Response, minSize int) error {
+	if minSize == 0 {
+		return nil
+	}
+
+	hr := r.GetHTTPResponse()
+	if len(hr.GetBody()) < minSize || hr.HasHeader("Content-Encoding") {
+		return nil
+	}
+
+	encoding := getAcceptedEncoding(r.GetHTTPRequest())
+	if encoding == "" {
+		return nil
+	}
+
+	if _, err := hr.SetBodyCompressed(hr.GetBody(), encoding); err != nil {
+		return err
+	}
+
+	// Caches must take into account that the body depends on the accepted encodings
+	hr.SetHeader("Vary", "Accept-Encoding", false)
+	return nil
+}
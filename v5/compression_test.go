@@ -0,0 +1,72 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestCompressionIsDisabledByDefault(t *testing.T) {
+	if size := getCompressionMinSize(cli.Input{}); size != 0 {
+		t.Errorf("expected the automatic compression to be disabled, got a minimum size of %d", size)
+	}
+}
+
+func TestGetAcceptedEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                      "",
+		"br":                    "",
+		"deflate":               DeflateEncoding,
+		"gzip, deflate":         GzipEncoding,
+		"gzip;q=0.5, deflate":   DeflateEncoding,
+		"identity, gzip;q=0":    "",
+		"deflate;q=0.2, gzip=1": DeflateEncoding,
+	}
+
+	for header, expected := range cases {
+		headers := http.Header{}
+		if header != "" {
+			headers.Set("Accept-Encoding", header)
+		}
+
+		r := newHTTPRequest(&payload.HTTPRequest{Headers: headers})
+		if encoding := getAcceptedEncoding(r); encoding != expected {
+			t.Errorf("expected %q for %q, got %q", expected, header, encoding)
+		}
+	}
+}
+
+func TestCompressBody(t *testing.T) {
+	content := bytes.Repeat([]byte("kusanagi "), 100)
+
+	body, err := compressBody(content, "GZIP")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decompressed, err := io.ReadAll(r); err != nil || !bytes.Equal(decompressed, content) {
+		t.Errorf("unexpected decompressed body: %v", err)
+	}
+
+	if _, err := compressBody(content, "br"); err == nil {
+		t.Error("expected an error for an unsupported encoding")
+	}
+}
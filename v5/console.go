@@ -0,0 +1,259 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+	"github.com/ugorji/go/codec"
+)
+
+// Name of the RPC service of the debug console.
+const consoleServiceName = "Console"
+
+// Methods supported by the debug console.
+const (
+	ConsoleMethodHelp      = consoleServiceName + ".Help"
+	ConsoleMethodCallbacks = consoleServiceName + ".Callbacks"
+	ConsoleMethodMapping   = consoleServiceName + ".Mapping"
+	ConsoleMethodResources = consoleServiceName + ".Resources"
+	ConsoleMethodStats     = consoleServiceName + ".Stats"
+	ConsoleMethodCall      = consoleServiceName + ".Call"
+)
+
+// Request ID used for the console calls that don't have one.
+const consoleRequestIDPrefix = "console-"
+
+// ConsoleCall contains the parameters of the debug console "Console.Call" method.
+//
+// The payload and the optional schemas can be binaries serialized with the
+// format of the component, or values that are serialized by the console.
+type ConsoleCall struct {
+	RequestID string      `json:"request_id"`
+	Action    string      `json:"action"`
+	Schemas   interface{} `json:"schemas"`
+	Payload   interface{} `json:"payload"`
+}
+
+// Creates a new debug console.
+//
+// The result is nil when the console is not enabled.
+func newConsole(s *server) *console {
+	port := s.input.GetConsolePort()
+	if port == 0 {
+		return nil
+	}
+	return &console{server: s, address: fmt.Sprintf("127.0.0.1:%d", port)}
+}
+
+// Debug console for the component.
+//
+// The console listens on the loopback interface for TCP connections that use the msgpack-RPC
+// protocol, where each method receives a single parameter. It allows to send test command
+// payloads to the component callbacks, and to inspect the current mapping and the state of
+// the resources, using any msgpack-RPC client.
+type console struct {
+	mutex    sync.RWMutex
+	server   *server
+	address  string
+	listener net.Listener
+	rpc      *rpc.Server
+	mapping  payload.Mapping
+	calls    int64
+}
+
+// Update the mapping to inspect with the console.
+func (c *console) update(mapping payload.Mapping) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.mapping = mapping
+}
+
+// Start listening for console connections.
+func (c *console) start() error {
+	c.rpc = rpc.NewServer()
+	if err := c.rpc.RegisterName(consoleServiceName, &consoleService{c}); err != nil {
+		return fmt.Errorf("Failed to create the debug console: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", c.address)
+	if err != nil {
+		return fmt.Errorf(`Failed to open the debug console at address "%s": %v`, c.address, err)
+	}
+	c.listener = listener
+
+	log.Warningf(`Serving debug console at address: "%s"`, c.address)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go c.rpc.ServeCodec(codec.MsgpackSpecRpc.ServerCodec(conn, newConsoleHandle()))
+		}
+	}()
+	return nil
+}
+
+// Close the console listener.
+func (c *console) close() error {
+	if c.listener == nil {
+		return nil
+	}
+	return c.listener.Close()
+}
+
+// Create the msgpack handle for the console connections.
+func newConsoleHandle() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	h.RawToString = true
+	return h
+}
+
+// Get the names of the callbacks registered in the component.
+//
+// For services the mounted handlers and the action patterns are also included.
+func (c *console) getCallbacks() []string {
+	comp := c.server.component.(*component)
+	names := []string{}
+	for name := range comp.callbacks {
+		names = append(names, name)
+	}
+
+	if comp.resolver != nil {
+		names = append(names, comp.resolver.getCallbackPatterns()...)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Get a binary for a console call value.
+//
+// Values that are not binaries are serialized with the binary format of the component.
+func (c *console) getBinary(v interface{}) ([]byte, error) {
+	if b, ok := v.([]byte); ok {
+		return b, nil
+	}
+	return c.server.binary.encode(v)
+}
+
+// Process a command payload with the component callbacks.
+func (c *console) call(call ConsoleCall) (interface{}, error) {
+	if call.Action == "" {
+		return nil, errors.New("The call method requires an action")
+	} else if call.Payload == nil {
+		return nil, errors.New("The call method requires a payload")
+	}
+
+	message, err := c.getBinary(call.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid payload: %v", err)
+	}
+
+	var schemas []byte
+	if call.Schemas != nil {
+		if schemas, err = c.getBinary(call.Schemas); err != nil {
+			return nil, fmt.Errorf("Invalid schemas: %v", err)
+		}
+	}
+
+	rid := call.RequestID
+	if rid == "" {
+		c.mutex.Lock()
+		c.calls++
+		rid = fmt.Sprintf("%s%d-%d", consoleRequestIDPrefix, time.Now().Unix(), c.calls)
+		c.mutex.Unlock()
+	}
+
+	log.Infof(`Debug console request "%s" for action: "%s"`, rid, call.Action)
+
+	msg := requestMsg{
+		[]byte{}, []byte{}, []byte{},
+		[]byte(rid),
+		[]byte(call.Action),
+		schemas,
+		message,
+		msgpackFormatFlag,
+	}
+	return c.server.process(msg)
+}
+
+// RPC methods of the debug console.
+//
+// The methods are exported to be registered in the RPC server, and the
+// parameter of the methods without parameters is ignored.
+type consoleService struct {
+	console *console
+}
+
+// Help returns the names of the console methods.
+func (s *consoleService) Help(_ interface{}, result *interface{}) error {
+	*result = []string{
+		ConsoleMethodHelp,
+		ConsoleMethodCallbacks,
+		ConsoleMethodMapping,
+		ConsoleMethodResources,
+		ConsoleMethodStats,
+		ConsoleMethodCall,
+	}
+	return nil
+}
+
+// Callbacks returns the names of the callbacks registered in the component.
+func (s *consoleService) Callbacks(_ interface{}, result *interface{}) error {
+	*result = s.console.getCallbacks()
+	return nil
+}
+
+// Mapping returns the current mapping of the component.
+func (s *consoleService) Mapping(_ interface{}, result *interface{}) error {
+	s.console.mutex.RLock()
+	defer s.console.mutex.RUnlock()
+
+	*result = s.console.mapping
+	return nil
+}
+
+// Resources returns the state of the resources of the component.
+func (s *consoleService) Resources(_ interface{}, result *interface{}) error {
+	*result = s.console.server.component.(*component).resources.states()
+	return nil
+}
+
+// Stats returns the request statistics of the component.
+func (s *consoleService) Stats(_ interface{}, result *interface{}) error {
+	stats := s.console.server.stats.snapshot()
+	*result = map[string]interface{}{
+		"goroutines": stats.GetGoroutines(),
+		"queued":     stats.GetQueueDepth(),
+		"in_flight":  stats.GetInFlight(),
+		"processed":  stats.GetProcessed(),
+		"failed":     stats.GetFailed(),
+		"uptime":     stats.GetUptime().String(),
+	}
+	return nil
+}
+
+// Call processes a command payload with the component callbacks and returns the reply.
+func (s *consoleService) Call(call ConsoleCall, result *interface{}) (err error) {
+	*result, err = s.console.call(call)
+	return err
+}
@@ -0,0 +1,106 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net"
+	"net/rpc"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+	"github.com/ugorji/go/codec"
+)
+
+// Start a debug console for a service and connect a msgpack-RPC client to it.
+func newTestConsole(t *testing.T, service *Service) (*console, *rpc.Client) {
+	t.Helper()
+
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	c := &console{server: newServer(input, service.base(), service.processor), address: "127.0.0.1:0"}
+	if err := c.start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.close() })
+
+	conn, err := net.Dial("tcp", c.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := rpc.NewClientWithCodec(codec.MsgpackSpecRpc.ClientCodec(conn, newConsoleHandle()))
+	t.Cleanup(func() { client.Close() })
+	return c, client
+}
+
+func TestConsoleInspectsComponent(t *testing.T) {
+	service := NewService()
+	service.Action("read", func(a *Action) (*Action, error) { return a, nil })
+
+	c, client := newTestConsole(t, service)
+
+	var methods []string
+	if err := client.Call(ConsoleMethodHelp, nil, &methods); err != nil {
+		t.Fatal(err)
+	}
+	if len(methods) != 6 || methods[0] != ConsoleMethodHelp {
+		t.Errorf("unexpected console methods: %v", methods)
+	}
+
+	var callbacks []string
+	if err := client.Call(ConsoleMethodCallbacks, nil, &callbacks); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(callbacks, []string{"read"}) {
+		t.Errorf("unexpected callbacks: %v", callbacks)
+	}
+
+	c.update(payload.Mapping{"posts": {"1.0.0": payload.Schema{Address: []string{"ipc://posts"}}}})
+
+	var mapping map[string]interface{}
+	if err := client.Call(ConsoleMethodMapping, nil, &mapping); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mapping["posts"]; !ok {
+		t.Errorf("expected the mapping with the posts service, got %v", mapping)
+	}
+}
+
+func TestConsoleCall(t *testing.T) {
+	service := NewService()
+	service.Action("read", func(a *Action) (*Action, error) {
+		return a.SetProperty("console", "yes"), nil
+	})
+
+	_, client := newTestConsole(t, service)
+
+	var reply interface{}
+	if err := client.Call(ConsoleMethodCall, ConsoleCall{Payload: map[string]interface{}{}}, &reply); err == nil {
+		t.Error("expected an error for a call without action")
+	}
+
+	command := payload.NewCommand("read", "service")
+	command.Command.Arguments = &payload.CommandArguments{
+		Transport: &payload.Transport{Meta: payload.TransportMeta{Gateway: []string{"ktp://internal", "http://public"}}},
+	}
+
+	if err := client.Call(ConsoleMethodCall, ConsoleCall{Action: "read", Payload: command}, &reply); err != nil {
+		t.Fatal(err)
+	}
+
+	var result payload.Reply
+	b, _ := formatMsgpack.encode(reply)
+	if err := formatMsgpack.decode(b, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Command == nil || result.Command.Result.Transport.Meta.Properties["console"] != "yes" {
+		t.Errorf("unexpected reply: %v", reply)
+	}
+}
@@ -0,0 +1,306 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+// ContractReplySuffix is the suffix of the files with the expected replies of the contract cases.
+//
+// The expected reply for a recorded request saved as "name.json" is read from "name.reply.json".
+const ContractReplySuffix = ".reply" + recordJSONExt
+
+// Path of the attributes in the reply payload.
+var contractAttributesPath = []string{"cr", "r", "a"}
+
+// Default process execution timeout for the contract cases.
+const defaultContractTimeout = 10 * time.Second
+
+// ContractCase contains a recorded request and the reply expected for it.
+type ContractCase struct {
+	// Name identifies the case in the results
+	Name string
+	// Request is the recorded request in JSON as saved by the record mode
+	Request []byte
+	// Reply is the expected reply in JSON as written by the replay mode
+	Reply []byte
+}
+
+// LoadContractCases reads the contract cases from a directory.
+//
+// Each case is a recorded request in JSON saved as "name.json", with the expected
+// reply saved as "name.reply.json". Requests without expected reply are ignored.
+// The cases are sorted by name.
+//
+// directory: The directory with the recorded requests and replies.
+func LoadContractCases(directory string) ([]ContractCase, error) {
+	paths, err := filepath.Glob(filepath.Join(directory, "*"+ContractReplySuffix))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the contract cases: %v", err)
+	}
+
+	sort.Strings(paths)
+	cases := make([]ContractCase, 0, len(paths))
+	for _, path := range paths {
+		name := strings.TrimSuffix(path, ContractReplySuffix)
+		reply, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the contract reply: %v", err)
+		}
+
+		request, err := os.ReadFile(name + recordJSONExt)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("Failed to read the contract request: %v", err)
+		}
+
+		cases = append(cases, ContractCase{filepath.Base(name), request, reply})
+	}
+	return cases, nil
+}
+
+// ContractResult contains the result of a contract case.
+type ContractResult struct {
+	// Name of the case
+	Name string
+	// Mismatches between the expected and the actual replies
+	Mismatches []string
+	// Error is set when the case can't be processed
+	Error error
+}
+
+// Passed checks if the reply is compatible with the expected reply.
+func (r ContractResult) Passed() bool {
+	return r.Error == nil && len(r.Mismatches) == 0
+}
+
+// ContractReporter reports the failed contract cases.
+//
+// The testing.T and testing.B types implement this interface.
+type ContractReporter interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// NewContractHarness creates a new harness to run contract cases against a component.
+//
+// component: The service or middleware with the callbacks to check.
+func NewContractHarness(component Component) *ContractHarness {
+	return &ContractHarness{component: component, timeout: defaultContractTimeout}
+}
+
+// ContractHarness replays recorded requests against a component in-process and checks
+// that the replies are wire compatible with the expected replies.
+//
+// The replies are compatible when they have the same structure and their values have
+// the same types, so values like request IDs or durations can change between runs. The
+// attributes of the replies must have the same values.
+type ContractHarness struct {
+	component Component
+	mapping   []byte
+	timeout   time.Duration
+}
+
+// WithMapping sets the mapping used for all the cases.
+//
+// By default the cases use the schemas saved with each recorded request.
+//
+// mapping: The mapping with the service schemas in JSON.
+func (h *ContractHarness) WithMapping(mapping []byte) *ContractHarness {
+	h.mapping = mapping
+	return h
+}
+
+// WithTimeout sets the process execution timeout for each case.
+//
+// timeout: The timeout.
+func (h *ContractHarness) WithTimeout(timeout time.Duration) *ContractHarness {
+	h.timeout = timeout
+	return h
+}
+
+// Run processes the contract cases and returns the results.
+//
+// cases: The contract cases.
+func (h *ContractHarness) Run(cases ...ContractCase) []ContractResult {
+	c, ok := h.component.(interface{ base() *component })
+	if !ok {
+		err := fmt.Errorf("The component is not supported: %T", h.component)
+		results := make([]ContractResult, len(cases))
+		for i, cc := range cases {
+			results[i] = ContractResult{Name: cc.Name, Error: err}
+		}
+		return results
+	}
+
+	s := newServer(cli.Input{}, c.base(), c.base().processor)
+	s.timeout = h.timeout
+	// Recorded requests don't keep the signatures
+	s.verifier = nil
+
+	results := make([]ContractResult, 0, len(cases))
+	for _, cc := range cases {
+		results = append(results, h.run(s, cc))
+	}
+	return results
+}
+
+// Check processes the contract cases and reports the cases that fail.
+//
+// reporter: The reporter for the failed cases, for example a *testing.T.
+// cases: The contract cases.
+func (h *ContractHarness) Check(reporter ContractReporter, cases ...ContractCase) {
+	reporter.Helper()
+	for _, result := range h.Run(cases...) {
+		if result.Error != nil {
+			reporter.Errorf(`Contract "%s" failed: %v`, result.Name, result.Error)
+		}
+		for _, mismatch := range result.Mismatches {
+			reporter.Errorf(`Contract "%s" failed: %s`, result.Name, mismatch)
+		}
+	}
+}
+
+// Process a contract case.
+func (h *ContractHarness) run(s *server, cc ContractCase) ContractResult {
+	result := ContractResult{Name: cc.Name}
+
+	var expected interface{}
+	if err := json.Unmarshal(cc.Reply, &expected); err != nil {
+		result.Error = fmt.Errorf("Failed to parse the expected reply: %v", err)
+		return result
+	}
+
+	msg, err := parseRecordedRequest(cc.Request, true)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if h.mapping != nil {
+		msg[msgSchemasPart] = h.mapping
+	}
+
+	reply, err := s.process(msg)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	// Normalize the reply to have the same value types as the expected reply
+	actual, err := normalizeJSON(reply)
+	if err != nil {
+		result.Error = fmt.Errorf("Failed to read the reply: %v", err)
+		return result
+	}
+
+	result.Mismatches = compareWire(nil, expected, actual)
+	return result
+}
+
+// Serialize a value to JSON and deserialize it again.
+func normalizeJSON(value interface{}) (result interface{}, err error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// Get the JSON type name of a value.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Get the location of a value in a JSON document.
+func wireLocation(path []string) string {
+	return "/" + strings.Join(path, "/")
+}
+
+// Compare the structure of two JSON values and get the differences.
+func compareWire(path []string, expected, actual interface{}) (mismatches []string) {
+	location := wireLocation(path)
+	if expectedType, actualType := jsonTypeName(expected), jsonTypeName(actual); expectedType != actualType {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", location, expectedType, actualType)}
+	}
+
+	// The attributes are used by the framework so their values must match
+	if reflect.DeepEqual(path, contractAttributesPath) {
+		if !reflect.DeepEqual(expected, actual) {
+			return []string{fmt.Sprintf("%s: expected attributes %v, got %v", location, expected, actual)}
+		}
+		return nil
+	}
+
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a := actual.(map[string]interface{})
+		names := make([]string, 0, len(e)+len(a))
+		for name := range e {
+			names = append(names, name)
+		}
+		for name := range a {
+			if _, exists := e[name]; !exists {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			// Copy the path to avoid sharing the backing array between fields
+			fieldPath := append(append([]string{}, path...), name)
+			expectedValue, expectedExists := e[name]
+			actualValue, actualExists := a[name]
+			switch {
+			case !actualExists:
+				mismatches = append(mismatches, fmt.Sprintf("%s: missing", wireLocation(fieldPath)))
+			case !expectedExists:
+				mismatches = append(mismatches, fmt.Sprintf("%s: unexpected", wireLocation(fieldPath)))
+			default:
+				mismatches = append(mismatches, compareWire(fieldPath, expectedValue, actualValue)...)
+			}
+		}
+	case []interface{}:
+		a := actual.([]interface{})
+		if len(e) != len(a) {
+			return []string{fmt.Sprintf("%s: expected %d items, got %d", location, len(e), len(a))}
+		}
+
+		for i := range e {
+			itemPath := append(append([]string{}, path...), fmt.Sprint(i))
+			mismatches = append(mismatches, compareWire(itemPath, e[i], a[i])...)
+		}
+	}
+	return mismatches
+}
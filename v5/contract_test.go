@@ -0,0 +1,157 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a service that sets transport properties for the contract tests.
+func newContractTestService(properties map[string]string) *Service {
+	service := NewService()
+	service.SetSchemaPolicy(SchemaPolicyCLIOnlyPermissive)
+	service.Action("read", func(a *Action) (*Action, error) {
+		for name, value := range properties {
+			a.SetProperty(name, value)
+		}
+		return a, nil
+	})
+	return service
+}
+
+// Create a contract case with the reply of a service for the contract tests.
+func newContractTestCase(t *testing.T, service *Service) ContractCase {
+	t.Helper()
+
+	command := payload.NewCommand("read", "service")
+	command.Command.Arguments = &payload.CommandArguments{Transport: &payload.Transport{}}
+	data, err := formatJSON.encode(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := json.Marshal(recordedRequest{RequestID: "rid", Action: "read", Format: "json", Command: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Use the reply of the service as the expected reply
+	s := newServer(cli.Input{}, service.base(), service.processor)
+	msg, err := parseRecordedRequest(request, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := s.process(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := json.Marshal(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ContractCase{"read", request, expected}
+}
+
+func TestContractHarness(t *testing.T) {
+	cc := newContractTestCase(t, newContractTestService(map[string]string{"user": "jane"}))
+
+	// Values can change when the structure of the reply is the same
+	results := NewContractHarness(newContractTestService(map[string]string{"user": "john"})).Run(cc)
+	if len(results) != 1 || !results[0].Passed() {
+		t.Fatalf("expected the case to pass, got %+v", results)
+	}
+
+	results = NewContractHarness(newContractTestService(map[string]string{"user": "jane", "role": "admin"})).Run(cc)
+	if results[0].Passed() || len(results[0].Mismatches) != 1 {
+		t.Errorf("expected a mismatch for the new property, got %+v", results[0])
+	}
+
+	cc.Reply = []byte("invalid")
+	if results = NewContractHarness(newContractTestService(nil)).Run(cc); results[0].Error == nil {
+		t.Error("expected an error for the invalid reply")
+	}
+}
+
+func TestCompareWire(t *testing.T) {
+	expected := map[string]interface{}{
+		"name":  "jane",
+		"tags":  []interface{}{"a"},
+		"cr":    map[string]interface{}{"r": map[string]interface{}{"a": map[string]interface{}{"k": "v"}}},
+		"count": 1.0,
+	}
+
+	cases := []struct {
+		name       string
+		actual     map[string]interface{}
+		mismatches []string
+	}{
+		{"compatible", map[string]interface{}{
+			"name":  "john",
+			"tags":  []interface{}{"b"},
+			"cr":    map[string]interface{}{"r": map[string]interface{}{"a": map[string]interface{}{"k": "v"}}},
+			"count": 2.0,
+		}, nil},
+		{"different", map[string]interface{}{
+			"name":  1.0,
+			"tags":  []interface{}{},
+			"cr":    map[string]interface{}{"r": map[string]interface{}{"a": map[string]interface{}{"k": "x"}}},
+			"extra": true,
+		}, []string{
+			"/count: missing",
+			"/cr/r/a: expected attributes map[k:v], got map[k:x]",
+			"/extra: unexpected",
+			"/name: expected string, got number",
+			"/tags: expected 1 items, got 0",
+		}},
+	}
+
+	for _, c := range cases {
+		if mismatches := compareWire(nil, expected, c.actual); !reflect.DeepEqual(mismatches, c.mismatches) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.mismatches, mismatches)
+		}
+	}
+}
+
+func TestLoadContractCases(t *testing.T) {
+	directory := t.TempDir()
+	files := map[string]string{
+		"b.json":       "{}",
+		"b.reply.json": "{}",
+		"a.json":       "{}",
+		"a.reply.json": "{}",
+		// Requests without reply and replies without request are ignored
+		"c.json":       "{}",
+		"d.reply.json": "{}",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases, err := LoadContractCases(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, cc := range cases {
+		names = append(names, cc.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("expected the cases sorted by name, got %v", names)
+	}
+}
@@ -0,0 +1,138 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// DeferCallOptions contains the options to register a deferred call.
+type DeferCallOptions struct {
+	// Key is an optional idempotency key, so the call is registered only once per request.
+	Key string
+	// Retry is an optional policy to retry the call when it fails.
+	Retry *RetryPolicy
+	// DeferFiles adds the call files to the transport when the reply is sent,
+	// so the files of the calls that are dropped are never added.
+	DeferFiles bool
+	// Group is an optional name to group deferred calls that can run in parallel.
+	Group string
+	// Priority orders the groups of deferred calls, lower priorities run first.
+	Priority int
+}
+
+// RetryPolicy defines how a deferred call is retried when it fails.
+//
+// The time to wait before each retry is the backoff multiplied by the multiplier
+// for each previous retry, limited by the max backoff when it is not zero.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the call is made, including the first one.
+	MaxAttempts uint
+	// Backoff is the time to wait before the first retry.
+	Backoff time.Duration
+	// Multiplier increases the backoff for each retry. A zero value keeps the backoff constant.
+	Multiplier float64
+	// MaxBackoff is the maximum time to wait between retries.
+	MaxBackoff time.Duration
+}
+
+// Check that the retry policy values are valid.
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts == 0 {
+		return fmt.Errorf("The retry policy max attempts must be greater than 0")
+	} else if p.Backoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("The retry policy backoff can't be negative")
+	} else if p.Multiplier != 0 && p.Multiplier < 1 {
+		return fmt.Errorf("The retry policy multiplier must be greater or equal than 1: %v", p.Multiplier)
+	} else if p.MaxBackoff != 0 && p.MaxBackoff < p.Backoff {
+		return fmt.Errorf("The retry policy max backoff must be greater or equal than the backoff: %s", p.MaxBackoff)
+	}
+	return nil
+}
+
+// Convert the retry policy to a payload.
+func (p RetryPolicy) toPayload() *payload.Retry {
+	return &payload.Retry{
+		Attempts:   p.MaxAttempts,
+		Backoff:    uint(p.Backoff.Milliseconds()),
+		Multiplier: p.Multiplier,
+		MaxBackoff: uint(p.MaxBackoff.Milliseconds()),
+	}
+}
+
+// Create a retry policy from a payload.
+func payloadToRetryPolicy(r *payload.Retry) *RetryPolicy {
+	if r == nil {
+		return nil
+	}
+
+	return &RetryPolicy{
+		MaxAttempts: r.Attempts,
+		Backoff:     time.Duration(r.Backoff) * time.Millisecond,
+		Multiplier:  r.Multiplier,
+		MaxBackoff:  time.Duration(r.MaxBackoff) * time.Millisecond,
+	}
+}
+
+// DeferredCallGroup contains deferred calls that can run in parallel.
+type DeferredCallGroup struct {
+	name     string
+	priority int
+	callers  []Caller
+}
+
+// GetName returns the name of the group.
+//
+// The name is empty for calls registered without a group.
+func (g DeferredCallGroup) GetName() string {
+	return g.name
+}
+
+// GetPriority returns the priority of the group.
+func (g DeferredCallGroup) GetPriority() int {
+	return g.priority
+}
+
+// GetCallers returns the deferred calls of the group in the order they were registered.
+func (g DeferredCallGroup) GetCallers() []Caller {
+	return g.callers
+}
+
+// GroupDeferredCalls groups the deferred calls by the group name and sorts the groups by priority.
+//
+// The calls in a group can run in parallel, while the groups must run one after the other in
+// the order of the result to preserve the order between groups. Groups with the same priority
+// keep the order in which their first call was registered. Each call registered without a group
+// is returned in a group of its own.
+//
+// callers: The deferred calls, for example the result of Transport.GetCalls().
+func GroupDeferredCalls(callers []Caller) []DeferredCallGroup {
+	var groups []DeferredCallGroup
+	index := make(map[string]int)
+	for _, c := range callers {
+		callee := c.GetCallee()
+		if name := callee.GetGroup(); name != "" {
+			if i, exists := index[name]; exists {
+				groups[i].callers = append(groups[i].callers, c)
+				continue
+			}
+			index[name] = len(groups)
+		}
+		groups = append(groups, DeferredCallGroup{callee.GetGroup(), callee.GetPriority(), []Caller{c}})
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].priority < groups[j].priority
+	})
+	return groups
+}
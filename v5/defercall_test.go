@@ -0,0 +1,166 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Action schema with deferred calls for the deferred call tests.
+var deferCallTestSchema = payload.ActionSchema{DeferredCalls: [][]string{{"mails", "1.0.0", "send"}, {"reports", "1.0.0", "build"}}}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy RetryPolicy
+		fails  bool
+	}{
+		{"valid", RetryPolicy{MaxAttempts: 3, Backoff: time.Second, Multiplier: 2, MaxBackoff: time.Minute}, false},
+		{"constant backoff", RetryPolicy{MaxAttempts: 3, Backoff: time.Second}, false},
+		{"no attempts", RetryPolicy{}, true},
+		{"negative backoff", RetryPolicy{MaxAttempts: 3, Backoff: -time.Second}, true},
+		{"small multiplier", RetryPolicy{MaxAttempts: 3, Multiplier: 0.5}, true},
+		{"small max backoff", RetryPolicy{MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: time.Second}, true},
+	}
+
+	for _, c := range cases {
+		if err := c.policy.validate(); (err != nil) != c.fails {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+		}
+	}
+}
+
+func TestRetryPolicyPayload(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: 100 * time.Millisecond, Multiplier: 2, MaxBackoff: time.Second}
+	if p := payloadToRetryPolicy(policy.toPayload()); !reflect.DeepEqual(*p, policy) {
+		t.Errorf("expected %+v, got %+v", policy, *p)
+	}
+
+	if p := payloadToRetryPolicy(nil); p != nil {
+		t.Errorf("expected no retry policy, got %+v", p)
+	}
+}
+
+func TestActionDeferCallWithOptions(t *testing.T) {
+	a := newTestActionWithSchema("users", "1.0.0", "create", deferCallTestSchema)
+	policy := &RetryPolicy{MaxAttempts: 3, Backoff: time.Second}
+	options := DeferCallOptions{Key: "welcome", Retry: policy}
+
+	for i := 0; i < 2; i++ {
+		if _, err := a.DeferCallWithOptions("mails", "1.0.0", "send", nil, nil, options); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The calls with the same key are registered once
+	calls := (Transport{a.reply.Command.Result.Transport}).GetCalls()
+	if len(calls) != 1 {
+		t.Fatalf("expected one deferred call, got %d", len(calls))
+	}
+	if p := calls[0].GetCallee().GetRetryPolicy(); p == nil || !reflect.DeepEqual(*p, *policy) {
+		t.Errorf("expected the retry policy, got %+v", p)
+	}
+
+	options = DeferCallOptions{Retry: &RetryPolicy{}}
+	if _, err := a.DeferCallWithOptions("mails", "1.0.0", "send", nil, nil, options); err == nil {
+		t.Error("expected an error for the invalid retry policy")
+	}
+}
+
+func TestActionDeferCallWithDeferredFiles(t *testing.T) {
+	file, err := NewFile("report", "http://127.0.0.1:8000/files/a", "text/plain", "a.txt", 10, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := newTestActionWithSchema("users", "1.0.0", "create", deferCallTestSchema)
+	options := DeferCallOptions{DeferFiles: true}
+	if _, err := a.DeferCallWithOptions("reports", "1.0.0", "build", nil, []File{*file}, options); err != nil {
+		t.Fatal(err)
+	}
+
+	// The files are added to the transport when the calls are final
+	reply := a.reply.Command.Result.Transport
+	if len(reply.Files) != 0 {
+		t.Errorf("expected the files to be deferred, got %v", reply.Files)
+	}
+	reply.RegisterDeferredFiles()
+	if len(reply.Files) == 0 {
+		t.Error("expected the deferred files in the transport")
+	}
+
+	a = newTestActionWithSchema("users", "1.0.0", "create", deferCallTestSchema)
+	if _, err := a.DeferCallWithOptions("reports", "1.0.0", "build", nil, []File{*file}, DeferCallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.reply.Command.Result.Transport.Files) == 0 {
+		t.Error("expected the files in the transport")
+	}
+}
+
+func TestGroupDeferredCalls(t *testing.T) {
+	a := newTestActionWithSchema("users", "1.0.0", "create", deferCallTestSchema)
+	if _, err := a.DeferCallGroup("", 1, "mails", "1.0.0", "send", nil, nil); err == nil {
+		t.Error("expected an error for the empty group name")
+	}
+
+	a.DeferCallGroup("notify", 2, "mails", "1.0.0", "send", nil, nil)
+	a.DeferCall("reports", "1.0.0", "build", nil, nil)
+	a.DeferCallGroup("audit", 1, "mails", "1.0.0", "send", nil, nil)
+	a.DeferCallGroup("notify", 2, "reports", "1.0.0", "build", nil, nil)
+
+	// Calls without group run first in a group of their own
+	groups := GroupDeferredCalls((Transport{a.reply.Command.Result.Transport}).GetCalls())
+	expected := []struct {
+		name     string
+		priority int
+		services []string
+	}{
+		{"", 0, []string{"reports"}},
+		{"audit", 1, []string{"mails"}},
+		{"notify", 2, []string{"mails", "reports"}},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %d groups, got %d", len(expected), len(groups))
+	}
+
+	for i, g := range groups {
+		var names []string
+		for _, c := range g.GetCallers() {
+			names = append(names, c.GetCallee().GetName())
+		}
+
+		e := expected[i]
+		if g.GetName() != e.name || g.GetPriority() != e.priority || !reflect.DeepEqual(names, e.services) {
+			t.Errorf("%d: expected %v, got %s %d %v", i, e, g.GetName(), g.GetPriority(), names)
+		}
+	}
+}
+
+func TestActionDeferCallOnce(t *testing.T) {
+	a := newTestActionWithSchema("users", "1.0.0", "create", deferCallTestSchema)
+	if _, err := a.DeferCallOnce("", "mails", "1.0.0", "send", nil, nil); err == nil {
+		t.Error("expected an error for the empty key")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := a.DeferCallOnce("welcome", "mails", "1.0.0", "send", nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.DeferCallOnce("report", "reports", "1.0.0", "build", nil, nil)
+
+	if calls := (Transport{a.reply.Command.Result.Transport}).GetCalls(); len(calls) != 2 {
+		t.Errorf("expected the calls to be registered once, got %d", len(calls))
+	}
+}
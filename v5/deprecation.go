@@ -0,0 +1,82 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DeprecationWarningsVariable is the name of the component variable that enables
+// a warning in the logs each time a deprecated action is called.
+//
+// The warning includes the service and action that originated the request.
+const DeprecationWarningsVariable = "deprecation-warnings"
+
+// DeprecationEnforceVariable is the name of the component variable that enables
+// the rejection of the requests to deprecated actions after their sunset date.
+const DeprecationEnforceVariable = "deprecation-enforce"
+
+// DeprecationErrorVariable is the name of the component variable that sets the
+// error message for the requests rejected after the sunset date of an action.
+const DeprecationErrorVariable = "deprecation-error"
+
+// Prefix for the action tags that contain the sunset date.
+const sunsetTagPrefix = "sunset:"
+
+// Layout for the sunset dates without time.
+const sunsetDateLayout = "2006-01-02"
+
+// Status used for the requests rejected after the sunset date.
+const sunsetErrorStatus = "410 Gone"
+
+// Check the deprecation of the action being called.
+//
+// A warning is logged when deprecation warnings are enabled, and an error
+// is returned when the action is called after its sunset date and the
+// deprecation enforcement is enabled.
+func checkDeprecation(a *Action) error {
+	schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion())
+	if err != nil {
+		return nil
+	}
+
+	actionSchema, err := schema.GetActionSchema(a.GetActionName())
+	if err != nil || !actionSchema.IsDeprecated() {
+		return nil
+	}
+
+	if warn, _ := strconv.ParseBool(a.GetVariable(DeprecationWarningsVariable)); warn {
+		a.logger.Warningf(`Deprecated action "%s" called by %s`, a.GetActionName(), getDeprecationCaller(a))
+	}
+
+	if enforce, _ := strconv.ParseBool(a.GetVariable(DeprecationEnforceVariable)); !enforce {
+		return nil
+	}
+
+	sunset, err := actionSchema.GetSunset()
+	if err != nil {
+		a.logger.Warning(err.Error())
+		return nil
+	} else if sunset == nil || time.Now().Before(*sunset) {
+		return nil
+	}
+
+	message := a.GetVariable(DeprecationErrorVariable)
+	if message == "" {
+		message = fmt.Sprintf(`The action "%s" is no longer available since %s`, a.GetActionName(), sunset.Format(sunsetDateLayout))
+	}
+	return NewServiceError(message, 0, sunsetErrorStatus)
+}
+
+// Get a description of the caller of the current action using the origin of the transport.
+func getDeprecationCaller(a *Action) string {
+	return a.GetOrigin().String()
+}
@@ -0,0 +1,75 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Flag used to mark the action schemas as deprecated in the deprecation tests.
+var deprecatedTestFlag = true
+
+// Action schemas for the deprecation tests.
+var deprecationTestSchemas = map[string]payload.ActionSchema{
+	"read":    {},
+	"list":    {Deprecated: &deprecatedTestFlag},
+	"search":  {Deprecated: &deprecatedTestFlag, Tags: []string{"sunset:2000-01-01"}},
+	"find":    {Deprecated: &deprecatedTestFlag, Sunset: "2999-01-01T00:00:00Z"},
+	"invalid": {Deprecated: &deprecatedTestFlag, Sunset: "soon"},
+}
+
+func TestCheckDeprecation(t *testing.T) {
+	if err := checkDeprecation(newTestActionWithSchema("users", "1.0.0", "search", deprecationTestSchemas["search"])); err != nil {
+		t.Errorf("expected the enforcement to be disabled by default, got %v", err)
+	}
+
+	setTestVariable(t, DeprecationWarningsVariable, "true")
+	setTestVariable(t, DeprecationEnforceVariable, "true")
+
+	cases := []struct {
+		action string
+		fails  bool
+	}{
+		{"read", false},
+		// Deprecated actions without sunset date are still available
+		{"list", false},
+		{"search", true},
+		{"find", false},
+		{"invalid", false},
+	}
+
+	for _, c := range cases {
+		if err := checkDeprecation(newTestActionWithSchema("users", "1.0.0", c.action, deprecationTestSchemas[c.action])); (err != nil) != c.fails {
+			t.Errorf("%s: unexpected error: %v", c.action, err)
+		}
+	}
+}
+
+func TestCheckDeprecationError(t *testing.T) {
+	setTestVariable(t, DeprecationEnforceVariable, "true")
+
+	err := checkDeprecation(newTestActionWithSchema("users", "1.0.0", "search", deprecationTestSchemas["search"]))
+	serr, ok := err.(*ServiceError)
+	if !ok {
+		t.Fatalf("expected a service error, got %v", err)
+	}
+	if serr.Status != sunsetErrorStatus {
+		t.Errorf("unexpected status: %s", serr.Status)
+	}
+	if expected := `The action "search" is no longer available since 2000-01-01`; serr.Message != expected {
+		t.Errorf("unexpected message: %s", serr.Message)
+	}
+
+	setTestVariable(t, DeprecationErrorVariable, "Use the find action")
+	if err := checkDeprecation(newTestActionWithSchema("users", "1.0.0", "search", deprecationTestSchemas["search"])); err == nil || err.(*ServiceError).Message != "Use the find action" {
+		t.Errorf("expected the error message of the variable, got %v", err)
+	}
+}
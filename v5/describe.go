@@ -0,0 +1,89 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Description of a component printed in describe mode.
+type componentDescription struct {
+	Component string                          `json:"component"`
+	Name      string                          `json:"name"`
+	Version   string                          `json:"version"`
+	Callbacks []string                        `json:"callbacks"`
+	Schemas   map[string]payload.ActionSchema `json:"schemas,omitempty"`
+	Missing   []string                        `json:"missing,omitempty"`
+}
+
+// Read the service mappings from a JSON file.
+func readMappingFile(path string) (payload.Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the mapping file: %v", err)
+	}
+
+	var mapping payload.Mapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("Failed to parse the mapping file: %v", err)
+	}
+	return mapping, nil
+}
+
+// Write the description of a component as JSON.
+//
+// The description contains the callbacks registered in the component, and for services
+// it also contains the schemas of the actions when a mapping file is given. Actions that
+// don't exist in the mapping are listed as missing, which allows to verify at deploy time
+// that the component matches its configuration.
+func describeComponent(input cli.Input, c *component, out io.Writer) error {
+	description := componentDescription{
+		Component: input.GetComponent(),
+		Name:      input.GetName(),
+		Version:   input.GetVersion(),
+		Callbacks: []string{},
+	}
+
+	for name := range c.callbacks {
+		description.Callbacks = append(description.Callbacks, name)
+	}
+	sort.Strings(description.Callbacks)
+
+	if path := input.GetMappingFile(); path != "" && input.GetComponent() == "service" {
+		mapping, err := readMappingFile(path)
+		if err != nil {
+			return err
+		}
+
+		schema, err := mapping.GetSchema(input.GetName(), input.GetVersion())
+		if err != nil {
+			return fmt.Errorf("The mapping file has no schema for the service: %v", err)
+		}
+
+		description.Schemas = make(map[string]payload.ActionSchema)
+		for _, name := range description.Callbacks {
+			if actionSchema, exists := schema.Actions[name]; exists {
+				description.Schemas[name] = actionSchema
+			} else {
+				description.Missing = append(description.Missing, name)
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(description)
+}
@@ -0,0 +1,109 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+// Set the mapping file CLI option for the describe tests.
+func setTestMappingFile(t *testing.T, contents string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := flag.Set("mapping-file", path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		flag.Set("mapping-file", "")
+	})
+}
+
+// Create a service with callbacks for the describe tests.
+func newDescribeTestService() *Service {
+	callback := func(action *Action) (*Action, error) {
+		return action, nil
+	}
+	return NewService().Action("update", callback).Action("read", callback)
+}
+
+func TestDescribeComponent(t *testing.T) {
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	var output bytes.Buffer
+	if err := describeComponent(input, &newDescribeTestService().component, &output); err != nil {
+		t.Fatal(err)
+	}
+
+	var description componentDescription
+	if err := json.Unmarshal(output.Bytes(), &description); err != nil {
+		t.Fatal(err)
+	}
+
+	// The callbacks are sorted and without mapping file there are no schemas
+	if !reflect.DeepEqual(description.Callbacks, []string{"read", "update"}) {
+		t.Errorf("unexpected callbacks: %v", description.Callbacks)
+	}
+	if description.Name != "users" || description.Version != "1.0.0" || description.Schemas != nil {
+		t.Errorf("unexpected description: %s", output.String())
+	}
+}
+
+func TestDescribeComponentWithMapping(t *testing.T) {
+	setTestMappingFile(t, `{"users": {"1.0.0": {"ac": {"read": {}}}}}`)
+
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	var output bytes.Buffer
+	if err := describeComponent(input, &newDescribeTestService().component, &output); err != nil {
+		t.Fatal(err)
+	}
+
+	var description componentDescription
+	if err := json.Unmarshal(output.Bytes(), &description); err != nil {
+		t.Fatal(err)
+	}
+
+	// Callbacks without schema are listed as missing
+	if _, exists := description.Schemas["read"]; !exists || len(description.Schemas) != 1 {
+		t.Errorf("expected the schema of the read action, got %v", description.Schemas)
+	}
+	if !reflect.DeepEqual(description.Missing, []string{"update"}) {
+		t.Errorf("expected the update action to be missing, got %v", description.Missing)
+	}
+
+	// The mapping must contain the service
+	input = cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "posts", Version: "1.0.0"})
+	if err := describeComponent(input, &newDescribeTestService().component, &output); err == nil {
+		t.Error("expected an error for the missing service")
+	}
+}
+
+func TestReadMappingFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	if _, err := readMappingFile(path); err == nil {
+		t.Error("expected an error for the missing file")
+	}
+
+	if err := os.WriteFile(path, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readMappingFile(path); err == nil {
+		t.Error("expected an error for the invalid JSON")
+	}
+}
@@ -0,0 +1,144 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package devgateway
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+	"github.com/pebbe/zmq4"
+)
+
+// Identity frame used to send the requests to the components.
+var forwardIdentity = []byte("devgateway")
+
+// Frame of the component responses that contains the reply payload.
+const replyPayloadPart = 4
+
+// Client sends command payloads to the components using ZMQ.
+type client struct {
+	mutex   sync.Mutex
+	context *zmq4.Context
+	mapping []byte
+	// Addresses of the components that already received the mapping
+	mapped map[string]bool
+}
+
+// Creates a new component client.
+//
+// mapping: The serialized mapping to send to the components.
+func newClient(mapping []byte) (*client, error) {
+	zctx, err := zmq4.NewContext()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the ZMQ context: %v", err)
+	}
+	return &client{context: zctx, mapping: mapping, mapped: make(map[string]bool)}, nil
+}
+
+// Get the mapping frame for a component.
+//
+// The mapping is sent with the first request to each component, and again after
+// a request fails, because the component might have been restarted without it.
+func (c *client) getMappingFrame(address string) []byte {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.mapped[address] {
+		return []byte{}
+	}
+	c.mapped[address] = true
+	return c.mapping
+}
+
+// Send the mapping again with the next request to a component.
+func (c *client) resetMapping(address string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.mapped, address)
+}
+
+// Send a command to a component and wait for the reply.
+//
+// The mapping is sent again to the component with the next request when the request fails.
+//
+// address: The ZMQ address of the component.
+// rid: The request ID.
+// action: The component action to process.
+// command: The command payload.
+// timeout: The maximum time to wait for the reply.
+func (c *client) send(address, rid, action string, command payload.Command, timeout time.Duration) (*payload.Reply, error) {
+	reply, err := c.exchange(address, rid, action, command, timeout)
+	if err != nil {
+		c.resetMapping(address)
+	}
+	return reply, err
+}
+
+// Send a command to a component and read the reply.
+func (c *client) exchange(address, rid, action string, command payload.Command, timeout time.Duration) (*payload.Reply, error) {
+	message, err := msgpack.Encode(command)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to serialize the command: %v", err)
+	}
+
+	socket, err := c.context.NewSocket(zmq4.DEALER)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the socket: %v", err)
+	}
+	defer socket.Close()
+
+	if err := socket.SetLinger(0); err != nil {
+		return nil, fmt.Errorf("Failed to set socket's linger option: %v", err)
+	}
+
+	if err := socket.Connect(address); err != nil {
+		return nil, fmt.Errorf(`Failed to connect to the component at address "%s": %v`, address, err)
+	}
+
+	frames := [][]byte{forwardIdentity, {}, []byte(rid), []byte(action), c.getMappingFrame(address), message}
+	if _, err := socket.SendMessage(frames); err != nil {
+		return nil, fmt.Errorf("Failed to send the command: %v", err)
+	}
+
+	poller := zmq4.NewPoller()
+	poller.Add(socket, zmq4.POLLIN)
+	polled, err := poller.Poll(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to wait for the reply: %v", err)
+	} else if len(polled) == 0 {
+		return nil, fmt.Errorf(`The component at address "%s" didn't reply after %s`, address, timeout)
+	}
+
+	response, err := socket.RecvMessageBytes(0)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the reply: %v", err)
+	} else if len(response) <= replyPayloadPart {
+		return nil, fmt.Errorf("Invalid reply length: %d", len(response))
+	}
+
+	var reply payload.Reply
+	if err := msgpack.Decode(response[replyPayloadPart], &reply); err != nil {
+		return nil, fmt.Errorf("Failed to read the reply: %v", err)
+	} else if reply.Error != nil {
+		return nil, errors.New(reply.Error.GetMessage())
+	} else if reply.Command == nil {
+		return nil, errors.New("The reply doesn't contain a command result")
+	}
+	return &reply, nil
+}
+
+// Close terminates the ZMQ context.
+func (c *client) close() error {
+	return c.context.Term()
+}
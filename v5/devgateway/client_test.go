@@ -0,0 +1,60 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package devgateway
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestClientSendsMappingOnce(t *testing.T) {
+	mapping := []byte("mapping")
+	c, err := newClient(mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+
+	if frame := c.getMappingFrame("ipc://a"); !bytes.Equal(frame, mapping) {
+		t.Errorf("expected the mapping with the first request, got %q", frame)
+	}
+	if frame := c.getMappingFrame("ipc://a"); len(frame) != 0 {
+		t.Errorf("expected an empty mapping frame, got %q", frame)
+	}
+
+	// Each component receives the mapping
+	if frame := c.getMappingFrame("ipc://b"); !bytes.Equal(frame, mapping) {
+		t.Errorf("expected the mapping for a new address, got %q", frame)
+	}
+}
+
+func TestClientResendsMappingAfterFailure(t *testing.T) {
+	mapping := []byte("mapping")
+	c, err := newClient(mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+
+	address := "ipc://@kusanagi-devgateway-test"
+	c.getMappingFrame(address)
+
+	// There is no component listening, so the request fails when the timeout expires
+	command := payload.NewCommand("request", "middleware")
+	if _, err := c.send(address, "rid", "request", command, time.Millisecond); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+
+	if frame := c.getMappingFrame(address); !bytes.Equal(frame, mapping) {
+		t.Errorf("expected the mapping to be sent again after a failure, got %q", frame)
+	}
+}
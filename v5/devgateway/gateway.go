@@ -0,0 +1,437 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+// Package devgateway provides a minimal HTTP gateway emulator for local development.
+//
+// The emulator translates HTTP requests into command payloads and sends them to
+// middleware and service components that run locally, without a full framework
+// installation. Requests are routed using the path "/{service}/{version}/{action}",
+// and the query and form values are sent to the action as string params.
+//
+// Only the request and response middlewares and a single service action call are
+// emulated. Run-time, deferred and remote calls made by the services are not supported.
+package devgateway
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/format"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/json"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// DefaultTimeout is the default time to wait for the component replies.
+const DefaultTimeout = 30 * time.Second
+
+// Version of the framework reported by the emulator.
+const frameworkVersion = "5.0.0"
+
+// Protocol of the requests handled by the emulator.
+const protocol = "urn:kusanagi:protocol:http"
+
+// Endpoint contains the information to contact a component.
+type Endpoint struct {
+	// Name of the component
+	Name string
+	// Version of the component
+	Version string
+	// Address is the ZMQ address of the component, for example "tcp://127.0.0.1:5010"
+	Address string
+}
+
+// Config contains the gateway emulator settings.
+type Config struct {
+	// Address is the HTTP address to listen to, for example "127.0.0.1:8080"
+	Address string
+	// MappingFile is the path to a JSON file with the service schemas mapping
+	MappingFile string
+	// Services contains the service components to route the requests to
+	Services []Endpoint
+	// Middlewares contains the middleware components to call for each request, in order
+	Middlewares []Endpoint
+	// Timeout is the time to wait for each component reply
+	Timeout time.Duration
+}
+
+// LoadMapping reads a service schemas mapping from a JSON file.
+//
+// path: The path to the mapping file.
+func LoadMapping(path string) (payload.Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to read the mapping file "%s": %v`, path, err)
+	}
+
+	var mapping payload.Mapping
+	if err := json.Decode(data, &mapping); err != nil {
+		return nil, fmt.Errorf(`Failed to read the mapping file "%s": %v`, path, err)
+	}
+	return mapping, nil
+}
+
+// New creates a new gateway emulator.
+//
+// config: The gateway emulator settings.
+func New(config Config) (*Gateway, error) {
+	mapping := payload.Mapping{}
+	if config.MappingFile != "" {
+		m, err := LoadMapping(config.MappingFile)
+		if err != nil {
+			return nil, err
+		}
+		mapping = m
+	}
+
+	schemas, err := msgpack.Encode(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to serialize the mapping: %v", err)
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	c, err := newClient(schemas)
+	if err != nil {
+		return nil, err
+	}
+	return &Gateway{config: config, client: c}, nil
+}
+
+// Gateway is an HTTP gateway emulator.
+//
+// The gateway implements http.Handler, so it can also be used with a custom HTTP server.
+type Gateway struct {
+	config Config
+	client *client
+	mutex  sync.Mutex
+	server *http.Server
+}
+
+// ListenAndServe starts listening for HTTP requests.
+//
+// The call blocks until the gateway is closed.
+func (g *Gateway) ListenAndServe() error {
+	g.mutex.Lock()
+	g.server = &http.Server{Addr: g.config.Address, Handler: g}
+	server := g.server
+	g.mutex.Unlock()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Close stops the HTTP server and releases the gateway resources.
+func (g *Gateway) Close() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.server != nil {
+		if err := g.server.Close(); err != nil {
+			return err
+		}
+		g.server = nil
+	}
+	return g.client.close()
+}
+
+// Get the service endpoint for a service name and version.
+func (g *Gateway) getService(name, version string) (*Endpoint, error) {
+	for _, e := range g.config.Services {
+		if e.Name == name && e.Version == version {
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf(`Service not found: "%s" (%s)`, name, version)
+}
+
+// Get the HTTP address of the gateway.
+func (g *Gateway) getAddress() []string {
+	address := "http://" + g.config.Address
+	return []string{address, address}
+}
+
+// ServeHTTP handles an HTTP request.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request, err := newHTTPRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	call, err := newCallInfo(r.URL.Path, request)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	meta := payload.Meta{
+		Version:  frameworkVersion,
+		ID:       newRequestID(),
+		Datetime: format.TimeToString(time.Now()),
+		Type:     1,
+		Protocol: protocol,
+		Gateway:  g.getAddress(),
+		Client:   r.RemoteAddr,
+	}
+
+	var attributes map[string]string
+	for _, m := range g.config.Middlewares {
+		args := payload.CommandArguments{Meta: meta, Request: request, C: call}
+		if attributes != nil {
+			args.A = attributes
+		}
+
+		reply, err := g.send(m, meta.ID, "request", args)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		result := reply.Command.Result
+		if result.Response != nil {
+			// The middleware replied to the request without calling the service
+			writeResponse(w, result.Response)
+			return
+		}
+
+		if result.Call != nil {
+			call = result.Call
+		}
+
+		if result.Request != nil {
+			request = result.Request
+		}
+
+		if result.Attributes != nil {
+			attributes = result.Attributes
+		}
+	}
+
+	transport, value, err := g.callService(meta, call, request)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	response := newDefaultResponse(transport, value)
+	for i := len(g.config.Middlewares) - 1; i >= 0; i-- {
+		args := payload.CommandArguments{
+			Meta:      meta,
+			Request:   request,
+			Response:  response,
+			Transport: transport,
+			Return:    value,
+		}
+		if attributes != nil {
+			args.A = attributes
+		}
+
+		reply, err := g.send(g.config.Middlewares[i], meta.ID, "response", args)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if reply.Command.Result.Response != nil {
+			response = reply.Command.Result.Response
+		}
+	}
+
+	writeResponse(w, response)
+}
+
+// Call the service action and return the resulting transport and return value.
+func (g *Gateway) callService(meta payload.Meta, call *payload.CallInfo, request *payload.HTTPRequest) (*payload.Transport, interface{}, error) {
+	service, err := g.getService(call.Service, call.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := format.TimeToString(time.Now())
+	transport := &payload.Transport{
+		Meta: payload.TransportMeta{
+			ID:        meta.ID,
+			Version:   meta.Version,
+			Datetime:  now,
+			StartTime: now,
+			Gateway:   meta.Gateway,
+			Origin:    []string{call.Service, call.Version, call.Action},
+			Level:     1,
+		},
+	}
+
+	if len(request.Body) > 0 {
+		transport.Body = &payload.File{
+			Name:     "body",
+			Path:     "file:///body",
+			Mime:     request.Headers.Get("Content-Type"),
+			Filename: "body",
+			Size:     uint(len(request.Body)),
+		}
+	}
+
+	args := payload.CommandArguments{Meta: meta, Transport: transport, Params: call.Params}
+	args.SetAction(call.Action)
+
+	reply, err := g.send(*service, meta.ID, call.Action, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transport = reply.GetTransport()
+	if transport == nil {
+		return nil, nil, fmt.Errorf(`The service "%s" (%s) didn't reply with a transport`, call.Service, call.Version)
+	}
+	transport.Meta.EndTime = format.TimeToString(time.Now())
+	return transport, reply.GetReturnValue(), nil
+}
+
+// Send a command to a component.
+func (g *Gateway) send(e Endpoint, rid, action string, args payload.CommandArguments) (*payload.Reply, error) {
+	command := payload.NewCommand(action, e.Name)
+	command.Command.Arguments = &args
+
+	reply, err := g.client.send(e.Address, rid, action, command, g.config.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf(`Component "%s" (%s) failed: %v`, e.Name, e.Version, err)
+	}
+	return reply, nil
+}
+
+// Create a new unique request ID.
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(id)
+}
+
+// Create the HTTP request payload for a request.
+func newHTTPRequest(r *http.Request) (*payload.HTTPRequest, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the request body: %v", err)
+	}
+
+	request := payload.HTTPRequest{
+		Version:  fmt.Sprintf("%d.%d", r.ProtoMajor, r.ProtoMinor),
+		Method:   r.Method,
+		URL:      requestURL(r),
+		Query:    payload.HTTPRequestData(r.URL.Query()),
+		PostData: payload.HTTPRequestData{},
+		Headers:  r.Header.Clone(),
+		Body:     body,
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		// Restore the body so the form values can be parsed
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		if err := r.ParseForm(); err != nil {
+			return nil, fmt.Errorf("Failed to read the request form: %v", err)
+		}
+		request.PostData = payload.HTTPRequestData(r.PostForm)
+	}
+	return &request, nil
+}
+
+// Get the full URL of a request.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.RequestURI())
+}
+
+// Create the call info for a request from the path "/{service}/{version}/{action}".
+//
+// path: The request path.
+// request: The HTTP request payload with the query and form values to use as params.
+func newCallInfo(path string, request *payload.HTTPRequest) (*payload.CallInfo, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf(`Invalid path "%s", expected "/{service}/{version}/{action}"`, path)
+	}
+
+	call := payload.CallInfo{Service: parts[0], Version: parts[1], Action: parts[2]}
+	for _, values := range []payload.HTTPRequestData{request.Query, request.PostData} {
+		for name, v := range values {
+			for _, value := range v {
+				call.Params = append(call.Params, payload.Param{Name: name, Value: value, Type: "string"})
+			}
+		}
+	}
+	return &call, nil
+}
+
+// Create the response used when no middleware sets the HTTP response.
+//
+// The response body contains the transport errors when there are errors, otherwise
+// it contains the return value of the action or the transport data.
+func newDefaultResponse(transport *payload.Transport, value interface{}) *payload.HTTPResponse {
+	response := payload.NewHTTPResponse()
+	response.Headers.Set("Content-Type", "application/json")
+
+	var body interface{}
+	if len(transport.Errors) > 0 {
+		response.Status = "500 Internal Server Error"
+		body = transport.Errors
+	} else if value != nil {
+		body = value
+	} else {
+		transport.Load()
+		body = transport.Data
+	}
+
+	data, err := json.Encode(body)
+	if err != nil {
+		response.Status = "500 Internal Server Error"
+		data = []byte(strconv.Quote(err.Error()))
+	}
+	response.Body = data
+	return response
+}
+
+// Write an HTTP response payload.
+func writeResponse(w http.ResponseWriter, response *payload.HTTPResponse) {
+	for name, values := range response.Headers {
+		for _, v := range values {
+			w.Header().Add(name, v)
+		}
+	}
+
+	code := http.StatusOK
+	if parts := strings.SplitN(response.Status, " ", 2); len(parts) > 0 {
+		if c, err := strconv.Atoi(parts[0]); err == nil {
+			code = c
+		}
+	}
+
+	w.WriteHeader(code)
+	w.Write(response.Body)
+}
+
+// Write an error response.
+func writeError(w http.ResponseWriter, code int, err error) {
+	http.Error(w, err.Error(), code)
+}
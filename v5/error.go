@@ -8,14 +8,72 @@
 
 package kusanagi
 
+import (
+	"strconv"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// FailOnWarningsVariable is the name of the component variable that sets if the
+// errors with warning severity make the request fail.
+//
+// By default warnings are handled like any other error. When the value is false the
+// warnings are moved to the transport warnings before the reply is sent to the gateway.
+const FailOnWarningsVariable = "fail-on-warnings"
+
+// ErrorDedupeVariable is the name of the component variable that enables
+// the de-duplication of the transport errors.
+//
+// Errors of a service with the same message, code and severity are collapsed into
+// a single error, and the number of times the error happened is saved as its count.
+const ErrorDedupeVariable = "error-dedupe"
+
+// MaxErrorsVariable is the name of the component variable that sets the maximum number
+// of errors that a service can add to the transport.
+//
+// The errors that exceed the limit are replaced by a single error with the number of
+// omitted errors. By default the number of errors is not limited.
+const MaxErrorsVariable = "max-errors-per-service"
+
+// Error severity levels.
+//
+// Errors without severity are handled as errors with the "error" severity.
+const (
+	ErrorSeverityWarning = payload.SeverityWarning
+	ErrorSeverityError   = payload.SeverityError
+	ErrorSeverityFatal   = payload.SeverityFatal
+)
+
+// Check if the errors with warning severity must make the request fail.
+func isFailOnWarnings(input cli.Input) bool {
+	fail, err := strconv.ParseBool(input.GetVariable(FailOnWarningsVariable))
+	if err != nil {
+		return true
+	}
+	return fail
+}
+
+// Check if the transport errors must be de-duplicated.
+func isErrorDedupe(input cli.Input) bool {
+	dedupe, _ := strconv.ParseBool(input.GetVariable(ErrorDedupeVariable))
+	return dedupe
+}
+
 // Error represents an error for a service call.
 type Error struct {
-	address string
-	service string
-	version string
-	message string
-	code    int
-	status  string
+	address  string
+	service  string
+	version  string
+	message  string
+	code     int
+	status   string
+	metadata map[string]interface{}
+	severity string
+	count    int
+	omitted  int
+	// Chain for the errors that are unwrapped from another error
+	chain *[]errorCause
 }
 
 // GetAddress returns the gateway address for the service.
@@ -47,3 +105,74 @@ func (e Error) GetCode() int {
 func (e Error) GetStatus() string {
 	return e.status
 }
+
+// GetMetadata returns the error metadata.
+func (e Error) GetMetadata() map[string]interface{} {
+	return e.metadata
+}
+
+// GetSeverity returns the error severity.
+func (e Error) GetSeverity() string {
+	if e.severity == "" {
+		return ErrorSeverityError
+	}
+	return e.severity
+}
+
+// GetCount returns the number of times the error happened.
+//
+// The count is greater than one when the transport errors are de-duplicated.
+func (e Error) GetCount() int {
+	if e.count < 1 {
+		return 1
+	}
+	return e.count
+}
+
+// IsOverflow checks if the error replaces the errors that exceeded the maximum number of errors.
+func (e Error) IsOverflow() bool {
+	return e.omitted > 0
+}
+
+// GetOmitted returns the number of errors that were omitted because the service
+// exceeded the maximum number of errors.
+func (e Error) GetOmitted() int {
+	return e.omitted
+}
+
+// Get the errors wrapped by the error.
+func (e Error) getChain() []errorCause {
+	if e.chain != nil {
+		return *e.chain
+	}
+	return errorChainFromMetadata(e.metadata)
+}
+
+func (e Error) Error() string {
+	return e.message
+}
+
+// Unwrap returns the error wrapped by the error, or nil when the error doesn't wrap other errors.
+//
+// The wrapped errors are the errors that were wrapped by the Go error
+// used to add the error to the transport with Action.ErrorFrom().
+// They have the same service and status as the error, and only the
+// errors that were service errors have a code.
+func (e Error) Unwrap() error {
+	chain := e.getChain()
+	if len(chain) == 0 {
+		return nil
+	}
+
+	next := chain[1:]
+	return Error{
+		address:  e.address,
+		service:  e.service,
+		version:  e.version,
+		message:  chain[0].message,
+		code:     chain[0].code,
+		status:   e.status,
+		severity: e.severity,
+		chain:    &next,
+	}
+}
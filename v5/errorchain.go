@@ -0,0 +1,117 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+)
+
+// ErrorChainMetadata is the name of the error metadata value that contains the
+// messages and codes of the errors wrapped by an error added with Action.ErrorFrom().
+//
+// The chain is a list of objects with a "message" and an optional "code",
+// where the first item is the error wrapped by the transport error.
+const ErrorChainMetadata = "chain"
+
+// Names of the values of each item in the error chain metadata.
+const (
+	errorChainMessage = "message"
+	errorChainCode    = "code"
+)
+
+// A link in a chain of wrapped errors.
+type errorCause struct {
+	message string
+	code    int
+}
+
+// Get the errors wrapped by an error as metadata items.
+//
+// The codes are only available for the errors that are service errors.
+// Each cause is added once, so wrappers that don't change the message
+// or the code of the error they wrap are skipped.
+func errorChainToMetadata(err error) (chain []interface{}) {
+	seen := map[errorCause]bool{{err.Error(), 0}: true}
+	if serr, ok := err.(*ServiceError); ok {
+		seen[errorCause{serr.Message, serr.Code}] = true
+	}
+
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		c := errorCause{message: cause.Error()}
+		if serr, ok := cause.(*ServiceError); ok {
+			c = errorCause{serr.Message, serr.Code}
+		}
+
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+
+		item := map[string]interface{}{errorChainMessage: c.message}
+		if c.code != 0 {
+			item[errorChainCode] = c.code
+		}
+		chain = append(chain, item)
+	}
+	return chain
+}
+
+// Get the error chain from the metadata of an error.
+//
+// Invalid items in the chain are ignored.
+func errorChainFromMetadata(metadata map[string]interface{}) (chain []errorCause) {
+	items, _ := metadata[ErrorChainMetadata].([]interface{})
+	for _, v := range items {
+		item, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		message, ok := item[errorChainMessage].(string)
+		if !ok {
+			continue
+		}
+
+		chain = append(chain, errorCause{message, errorChainCodeToInt(item[errorChainCode])})
+	}
+	return chain
+}
+
+// Convert an error code from the metadata to an integer.
+//
+// The type of the code depends on the format used to serialize the transport.
+func errorChainCodeToInt(v interface{}) int {
+	switch code := v.(type) {
+	case int:
+		return code
+	case int8:
+		return int(code)
+	case int16:
+		return int(code)
+	case int32:
+		return int(code)
+	case int64:
+		return int(code)
+	case uint:
+		return int(code)
+	case uint8:
+		return int(code)
+	case uint16:
+		return int(code)
+	case uint32:
+		return int(code)
+	case uint64:
+		return int(code)
+	case float32:
+		return int(code)
+	case float64:
+		return int(code)
+	}
+	return 0
+}
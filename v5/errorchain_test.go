@@ -0,0 +1,62 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestActionErrorFromKeepsWrappedMessage(t *testing.T) {
+	action := newTestAction("posts", "1.0.0", "list", nil)
+
+	serr := NewServiceError("Post not found", 404, "404 Not Found")
+	cause := fmt.Errorf("Failed to load post: %w", serr)
+	action.ErrorFrom(fmt.Errorf("%w", cause))
+
+	errs := getTransportErrors(action.transport.Errors)
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+
+	e := errs[0]
+	if e.GetMessage() != "Failed to load post: Post not found" {
+		t.Errorf("expected the message of the wrapping error, got %q", e.GetMessage())
+	}
+	if e.GetCode() != 404 || e.GetStatus() != "404 Not Found" {
+		t.Errorf("expected the code and status of the service error, got %d %s", e.GetCode(), e.GetStatus())
+	}
+
+	// The wrapper that doesn't change the message is skipped, and each cause is added once
+	var chain []errorCause
+	for err := errors.Unwrap(e); err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, errorCause{err.(Error).GetMessage(), err.(Error).GetCode()})
+	}
+
+	expected := []errorCause{{"Post not found", 404}}
+	if !reflect.DeepEqual(chain, expected) {
+		t.Errorf("expected the chain %v, got %v", expected, chain)
+	}
+}
+
+func TestActionErrorFromServiceError(t *testing.T) {
+	action := newTestAction("posts", "1.0.0", "list", nil)
+	action.ErrorFrom(NewServiceError("Invalid post", 3, "400 Bad Request"))
+
+	errs := getTransportErrors(action.transport.Errors)
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+
+	if e := errs[0]; e.GetMessage() != "Invalid post" || e.GetCode() != 3 || errors.Unwrap(e) != nil {
+		t.Errorf("unexpected error: %q %d %v", e.GetMessage(), e.GetCode(), errors.Unwrap(e))
+	}
+}
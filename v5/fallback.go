@@ -0,0 +1,243 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Fallback represents the fallbacks triggered for the actions of a service.
+type Fallback struct {
+	service string
+	version string
+	actions []string
+}
+
+// GetName returns the service name.
+func (f Fallback) GetName() string {
+	return f.service
+}
+
+// GetVersion returns the service version.
+func (f Fallback) GetVersion() string {
+	return f.version
+}
+
+// GetActions returns the names of the actions where the fallbacks were triggered.
+func (f Fallback) GetActions() []string {
+	return append([]string{}, f.actions...)
+}
+
+// HasAction checks if a fallback was triggered for an action.
+//
+// name: The action name.
+func (f Fallback) HasAction(name string) bool {
+	for _, action := range f.actions {
+		if action == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FallbackSchema contains the fallback values defined for an action.
+type FallbackSchema struct {
+	payload payload.FallbackSchema
+}
+
+// GetProperties returns the fallback transport properties.
+func (s FallbackSchema) GetProperties() map[string]string {
+	properties := make(map[string]string)
+	for name, value := range s.payload.Properties {
+		properties[name] = value
+	}
+	return properties
+}
+
+// GetData returns the fallback data objects.
+func (s FallbackSchema) GetData() (data []map[string]interface{}) {
+	for _, object := range s.payload.Data {
+		data = append(data, fallbackObjectToMap(object))
+	}
+	return data
+}
+
+// GetRelations returns the fallback relations.
+func (s FallbackSchema) GetRelations() (relations []FallbackRelation) {
+	for _, r := range s.payload.Relations {
+		relations = append(relations, FallbackRelation{r})
+	}
+	return relations
+}
+
+// GetLinks returns the fallback links.
+func (s FallbackSchema) GetLinks() map[string]string {
+	links := make(map[string]string)
+	for name, uri := range s.payload.Links {
+		links[name] = uri
+	}
+	return links
+}
+
+// GetErrors returns the fallback errors.
+func (s FallbackSchema) GetErrors() (errors []FallbackError) {
+	for _, e := range s.payload.Errors {
+		errors = append(errors, FallbackError{e})
+	}
+	return errors
+}
+
+// Convert a fallback object to a map of values.
+func fallbackObjectToMap(object payload.FallbackObject) map[string]interface{} {
+	values := make(map[string]interface{})
+	for name, v := range object {
+		values[name] = fallbackValueToInterface(v)
+	}
+	return values
+}
+
+// Convert a fallback value to its native value.
+func fallbackValueToInterface(v payload.FallbackValue) interface{} {
+	items, ok := v.GetItems()
+	if !ok {
+		value, _ := v.GetValue()
+		return value
+	}
+
+	values := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		values = append(values, fallbackValueToInterface(item))
+	}
+	return values
+}
+
+// FallbackRelation contains a relation defined in an action fallback.
+type FallbackRelation struct {
+	payload payload.FallbackRelation
+}
+
+// GetPrimaryKey returns the primary key of the local entity.
+func (r FallbackRelation) GetPrimaryKey() string {
+	v, _ := r.payload.GetPrimaryKey()
+	return v
+}
+
+// GetRemoteService returns the name of the remote service.
+func (r FallbackRelation) GetRemoteService() string {
+	v, _ := r.payload.GetRemoteService()
+	return v
+}
+
+// IsOneToMany checks if the relation is a "one-to-many" relation.
+func (r FallbackRelation) IsOneToMany() bool {
+	return r.payload.IsOneToMany()
+}
+
+// GetForeignKey returns the foreign key of a "one-to-one" relation.
+func (r FallbackRelation) GetForeignKey() string {
+	v, _ := r.payload.GetForeignKey()
+	return v
+}
+
+// GetForeignKeys returns the foreign keys of a "one-to-many" relation.
+func (r FallbackRelation) GetForeignKeys() []string {
+	v, _ := r.payload.GetForeignKeys()
+	return v
+}
+
+// FallbackError contains an error defined in an action fallback.
+type FallbackError struct {
+	payload payload.FallbackError
+}
+
+// GetMessage returns the error message.
+func (e FallbackError) GetMessage() string {
+	v, _ := e.payload.GetMessage()
+	return v
+}
+
+// GetCode returns the error code.
+func (e FallbackError) GetCode() int {
+	v, _ := e.payload.GetCode()
+	return v
+}
+
+// GetStatus returns the HTTP status of the error.
+func (e FallbackError) GetStatus() string {
+	v, _ := e.payload.GetStatus()
+	return v
+}
+
+// ApplyFallback applies the fallback defined for the current action to the transport.
+//
+// Actions can call this method when they decide to run in a degraded mode. The fallback
+// properties, data, relations, links and errors are added to the transport, and the
+// fallback is registered in the transport as triggered for the action.
+// An error is returned when the action has no fallback.
+func (a *Action) ApplyFallback() (*Action, error) {
+	schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	actionSchema, err := schema.GetActionSchema(a.GetActionName())
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := actionSchema.GetFallback()
+	if fallback == nil {
+		return nil, fmt.Errorf(`The action "%s" has no fallback`, a.GetActionName())
+	}
+
+	for name, value := range fallback.GetProperties() {
+		a.SetProperty(name, value)
+	}
+
+	if data := fallback.GetData(); len(data) > 0 {
+		if actionSchema.IsCollection() {
+			_, err = a.SetCollection(data)
+		} else {
+			_, err = a.SetEntity(data[0])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to apply the fallback data: %v", err)
+		}
+	}
+
+	for _, r := range fallback.GetRelations() {
+		if r.IsOneToMany() {
+			_, err = a.RelateMany(r.GetPrimaryKey(), r.GetRemoteService(), r.GetForeignKeys())
+		} else {
+			_, err = a.RelateOne(r.GetPrimaryKey(), r.GetRemoteService(), r.GetForeignKey())
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to apply the fallback relations: %v", err)
+		}
+	}
+
+	for name, uri := range fallback.GetLinks() {
+		if _, err := a.SetLink(name, uri); err != nil {
+			return nil, fmt.Errorf("Failed to apply the fallback links: %v", err)
+		}
+	}
+
+	for _, e := range fallback.GetErrors() {
+		a.Error(e.GetMessage(), e.GetCode(), e.GetStatus())
+	}
+
+	a.reply.Command.Result.Transport.AddFallback(a.GetName(), a.GetVersion(), a.GetActionName())
+	a.audit("ApplyFallback", `action "%s"`, a.GetActionName())
+
+	return a, nil
+}
@@ -0,0 +1,108 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestTransportGetFallbacks(t *testing.T) {
+	if (Transport{&payload.Transport{}}).HasFallbacks() {
+		t.Error("expected no fallbacks")
+	}
+
+	p := &payload.Transport{}
+	p.Meta.Fallbacks = []payload.Fallback{{"users", "1.0.0", []interface{}{"read", "list"}}}
+	transport := Transport{p}
+	if !transport.HasFallbacks() {
+		t.Fatal("expected the fallbacks")
+	}
+
+	fallbacks := transport.GetFallbacks()
+	if len(fallbacks) != 1 {
+		t.Fatalf("expected one fallback, got %d", len(fallbacks))
+	}
+
+	f := fallbacks[0]
+	if f.GetName() != "users" || f.GetVersion() != "1.0.0" {
+		t.Errorf("unexpected fallback service: %s (%s)", f.GetName(), f.GetVersion())
+	}
+	if actions := f.GetActions(); !reflect.DeepEqual(actions, []string{"read", "list"}) {
+		t.Errorf("unexpected fallback actions: %v", actions)
+	}
+	if !f.HasAction("list") || f.HasAction("create") {
+		t.Error("unexpected fallback action check")
+	}
+}
+
+func TestFallbackSchema(t *testing.T) {
+	schema := FallbackSchema{payload.FallbackSchema{
+		Data: []payload.FallbackObject{{
+			"id":   {Type: payload.TypeInteger, Value: int64(1)},
+			"tags": {Type: payload.TypeArray, Items: []payload.FallbackValue{{Value: "admin"}}},
+		}},
+		Relations: []payload.FallbackRelation{
+			{"1", "posts", []interface{}{"1", "2"}},
+			{"1", "profiles", "1"},
+		},
+		Errors: []payload.FallbackError{{"Service unavailable", int64(503), "503 Service Unavailable"}},
+	}}
+
+	expected := []map[string]interface{}{{"id": int64(1), "tags": []interface{}{"admin"}}}
+	if data := schema.GetData(); !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %v, got %v", expected, data)
+	}
+
+	relations := schema.GetRelations()
+	if !relations[0].IsOneToMany() || !reflect.DeepEqual(relations[0].GetForeignKeys(), []string{"1", "2"}) {
+		t.Error("expected a one-to-many relation")
+	}
+	if relations[1].IsOneToMany() || relations[1].GetRemoteService() != "profiles" || relations[1].GetForeignKey() != "1" {
+		t.Error("expected a one-to-one relation")
+	}
+
+	e := schema.GetErrors()[0]
+	if e.GetMessage() != "Service unavailable" || e.GetCode() != 503 || e.GetStatus() != "503 Service Unavailable" {
+		t.Errorf("unexpected fallback error: %v", e)
+	}
+}
+
+func TestActionApplyFallback(t *testing.T) {
+	if _, err := newTestActionWithSchema("users", "1.0.0", "read", payload.ActionSchema{}).ApplyFallback(); err == nil {
+		t.Error("expected an error for the action without fallback")
+	}
+
+	a := newTestActionWithSchema("users", "1.0.0", "read", payload.ActionSchema{Fallback: &payload.FallbackSchema{
+		Properties: map[string]string{"mode": "degraded"},
+		Data:       []payload.FallbackObject{{"id": {Type: payload.TypeInteger, Value: int64(1)}}},
+		Relations:  []payload.FallbackRelation{{"1", "posts", []interface{}{"1", "2"}}},
+		Links:      map[string]string{"self": "/users/1"},
+		Errors:     []payload.FallbackError{{"Service unavailable", int64(503), "503 Service Unavailable"}},
+	}})
+	if _, err := a.ApplyFallback(); err != nil {
+		t.Fatal(err)
+	}
+
+	reply := a.reply.Command.Result.Transport
+	if value := reply.Meta.Properties["mode"]; value != "degraded" {
+		t.Errorf("expected the fallback property, got %q", value)
+	}
+	if len(reply.Data) == 0 || len(reply.Relations) == 0 || len(reply.Links) == 0 || len(reply.Errors) == 0 {
+		t.Errorf("expected the fallback data, relations, links and errors, got %+v", reply)
+	}
+
+	// The fallback is registered as triggered for the action
+	fallbacks := (Transport{reply}).GetFallbacks()
+	if len(fallbacks) != 1 || !fallbacks[0].HasAction("read") {
+		t.Errorf("expected the triggered fallback, got %v", fallbacks)
+	}
+}
@@ -9,8 +9,14 @@
 package kusanagi
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io/ioutil"
 	"mime"
 	"net/http"
@@ -23,6 +29,29 @@ import (
 
 var ErrFileNotExist = errors.New("File doesn't exist")
 
+// Supported file checksum algorithms.
+const (
+	ChecksumMD5    = "md5"
+	ChecksumSHA1   = "sha1"
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+)
+
+// Get a hash for a checksum algorithm.
+func newChecksumHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf(`Unsupported checksum algorithm: "%s"`, algorithm)
+}
+
 func checkLocalFileExist(path string) error {
 	// Remove the schema from the path
 	if path[:7] == "file://" {
@@ -125,6 +154,13 @@ type File struct {
 	filename string
 	size     uint
 	token    string
+	checksum *payload.Checksum
+	encoding string
+	// Custom attributes of the file
+	attributes map[string]string
+	// Ranged downloads support
+	ranges bool
+	etag   string
 }
 
 // GetName returns the name of the file parameter.
@@ -157,6 +193,174 @@ func (f File) GetToken() string {
 	return f.token
 }
 
+// HasChecksum checks if the file has a checksum.
+func (f File) HasChecksum() bool {
+	return f.checksum != nil && f.checksum.Digest != ""
+}
+
+// GetChecksum returns the algorithm and the hexadecimal digest of the file checksum.
+//
+// The values are empty when the file doesn't have a checksum.
+func (f File) GetChecksum() (algorithm, digest string) {
+	if f.checksum == nil {
+		return "", ""
+	}
+	return f.checksum.Algorithm, f.checksum.Digest
+}
+
+// GetEncoding returns the content encoding of the file, like "gzip".
+func (f File) GetEncoding() string {
+	return f.encoding
+}
+
+// HasAttribute checks if a custom attribute exists.
+//
+// name: The attribute name.
+func (f File) HasAttribute(name string) bool {
+	_, exists := f.attributes[name]
+	return exists
+}
+
+// GetAttribute returns the value of a custom attribute.
+//
+// name: The attribute name.
+// preset: The default value to use when the attribute doesn't exist.
+func (f File) GetAttribute(name, preset string) string {
+	if v, exists := f.attributes[name]; exists {
+		return v
+	}
+	return preset
+}
+
+// GetAttributes returns a copy of the custom attributes of the file.
+func (f File) GetAttributes() map[string]string {
+	attrs := make(map[string]string, len(f.attributes))
+	for name, value := range f.attributes {
+		attrs[name] = value
+	}
+	return attrs
+}
+
+// WithChecksum creates a new file parameter with a checksum.
+//
+// algorithm: The checksum algorithm, like "sha256".
+// digest: The hexadecimal digest of the file contents.
+func (f File) WithChecksum(algorithm, digest string) (*File, error) {
+	if _, err := newChecksumHash(algorithm); err != nil {
+		return nil, err
+	} else if _, err := hex.DecodeString(digest); err != nil || digest == "" {
+		return nil, fmt.Errorf(`Invalid checksum digest: "%s"`, digest)
+	}
+
+	file := f.copy()
+	file.checksum = &payload.Checksum{Algorithm: strings.ToLower(algorithm), Digest: strings.ToLower(digest)}
+	return file, nil
+}
+
+// WithComputedChecksum creates a new file parameter with a checksum of the file contents.
+//
+// The file contents are read to calculate the checksum.
+//
+// algorithm: The checksum algorithm, like "sha256".
+func (f File) WithComputedChecksum(algorithm string) (*File, error) {
+	digest, err := f.computeChecksum(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return f.WithChecksum(algorithm, digest)
+}
+
+// WithEncoding creates a new file parameter with a content encoding.
+//
+// encoding: The content encoding, like "gzip".
+func (f File) WithEncoding(encoding string) *File {
+	file := f.copy()
+	file.encoding = encoding
+	return file
+}
+
+// WithAttribute creates a new file parameter with a custom attribute.
+//
+// name: The attribute name.
+// value: The attribute value.
+func (f File) WithAttribute(name, value string) *File {
+	file := f.copy()
+	file.attributes = f.GetAttributes()
+	file.attributes[name] = value
+	return file
+}
+
+// WithRangeSupport creates a new file parameter that supports ranged downloads.
+//
+// The file size must be the total size of the file contents so the ranges can be resolved.
+//
+// etag: An optional entity tag that identifies the file contents.
+func (f File) WithRangeSupport(etag string) *File {
+	file := f.copy()
+	file.ranges = true
+	file.etag = etag
+	return file
+}
+
+// AcceptsRanges checks if the file supports ranged downloads.
+func (f File) AcceptsRanges() bool {
+	return f.ranges
+}
+
+// GetETag returns the entity tag that identifies the file contents.
+func (f File) GetETag() string {
+	return f.etag
+}
+
+// VerifyChecksum reads the file contents and checks that they match the file checksum.
+func (f File) VerifyChecksum() error {
+	if !f.HasChecksum() {
+		return errors.New("The file doesn't have a checksum")
+	}
+
+	digest, err := f.computeChecksum(f.checksum.Algorithm)
+	if err != nil {
+		return err
+	} else if !strings.EqualFold(digest, f.checksum.Digest) {
+		return fmt.Errorf(`The checksum of the file "%s" doesn't match its contents`, f.name)
+	}
+	return nil
+}
+
+// Calculate the hexadecimal digest of the file contents.
+func (f File) computeChecksum(algorithm string) (string, error) {
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := f.Read()
+	if err != nil {
+		return "", err
+	}
+
+	h.Write(contents)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Create a copy of the file.
+func (f File) copy() *File {
+	file := f
+	return &file
+}
+
+// Copy the metadata of the file to another file.
+func (f File) copyMetadata(file *File) *File {
+	if file != nil {
+		file.checksum = f.checksum
+		file.encoding = f.encoding
+		file.attributes = f.attributes
+		file.ranges = f.ranges
+		file.etag = f.etag
+	}
+	return file
+}
+
 // Exists checks if file exists.
 func (f File) Exists() bool {
 	return f.path != "" && f.path[:7] != "file://"
@@ -201,7 +405,7 @@ func (f File) Read() (contents []byte, err error) {
 // name: Name of the new file parameter.
 func (f File) CopyWithName(name string) *File {
 	file, _ := NewFile(name, f.GetPath(), f.GetMime(), f.GetFilename(), f.GetSize(), f.GetToken())
-	return file
+	return f.copyMetadata(file)
 }
 
 // CopyWithMime creates a new file parameter with a new MIME type.
@@ -209,30 +413,40 @@ func (f File) CopyWithName(name string) *File {
 // mime: MIME type of the new file parameter.
 func (f File) CopyWithMime(mimeType string) *File {
 	file, _ := NewFile(f.GetName(), f.GetPath(), mimeType, f.GetFilename(), f.GetSize(), f.GetToken())
-	return file
+	return f.copyMetadata(file)
 }
 
 // Converts a file to a file payload.
 func fileToPayload(f File) payload.File {
 	return payload.File{
-		Name:     f.GetName(),
-		Path:     f.GetPath(),
-		Mime:     f.GetMime(),
-		Filename: f.GetFilename(),
-		Size:     f.GetSize(),
-		Token:    f.GetToken(),
+		Name:       f.GetName(),
+		Path:       f.GetPath(),
+		Mime:       f.GetMime(),
+		Filename:   f.GetFilename(),
+		Size:       f.GetSize(),
+		Token:      f.GetToken(),
+		Checksum:   f.checksum,
+		Encoding:   f.encoding,
+		Attributes: f.attributes,
+		Ranges:     f.ranges,
+		ETag:       f.etag,
 	}
 }
 
 // Converts a file payload to a file.
 func payloadToFile(f *payload.File) File {
 	return File{
-		name:     f.Name,
-		path:     f.Path,
-		mime:     f.GetMime(),
-		filename: f.Filename,
-		size:     f.Size,
-		token:    f.Token,
+		name:       f.Name,
+		path:       f.Path,
+		mime:       f.GetMime(),
+		filename:   f.Filename,
+		size:       f.Size,
+		token:      f.Token,
+		checksum:   f.Checksum,
+		encoding:   f.Encoding,
+		attributes: f.Attributes,
+		ranges:     f.Ranges,
+		etag:       f.ETag,
 	}
 }
 
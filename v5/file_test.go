@@ -0,0 +1,98 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// SHA-256 digest of the contents of the file used in the file tests.
+const testFileDigest = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+// Create a local file with the contents "hello" for the file tests.
+func newChecksumTestFile(t *testing.T) *File {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := NewFile("document", "file://"+path, "text/plain", "hello.txt", 5, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestFileChecksum(t *testing.T) {
+	file := newChecksumTestFile(t)
+	if file.HasChecksum() || file.VerifyChecksum() == nil {
+		t.Error("expected the file without checksum")
+	}
+
+	computed, err := file.WithComputedChecksum("SHA256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algorithm, digest := computed.GetChecksum(); algorithm != ChecksumSHA256 || digest != testFileDigest {
+		t.Errorf("unexpected checksum: %s %s", algorithm, digest)
+	}
+	if err := computed.VerifyChecksum(); err != nil {
+		t.Errorf("expected the checksum to match, got %v", err)
+	}
+
+	// The checksum is kept when the file is copied
+	copied := computed.CopyWithName("other")
+	if _, digest := copied.GetChecksum(); digest != testFileDigest {
+		t.Errorf("expected the checksum in the copy, got %q", digest)
+	}
+
+	invalid, err := file.WithChecksum(ChecksumMD5, "00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invalid.VerifyChecksum() == nil {
+		t.Error("expected the checksum not to match")
+	}
+
+	if _, err := file.WithChecksum("crc32", "00"); err == nil {
+		t.Error("expected an error for the unsupported algorithm")
+	}
+	if _, err := file.WithChecksum(ChecksumSHA1, "not-hex"); err == nil {
+		t.Error("expected an error for the invalid digest")
+	}
+}
+
+func TestFileAttributes(t *testing.T) {
+	file := newChecksumTestFile(t)
+	tagged := file.WithAttribute("owner", "jane").WithEncoding("gzip")
+
+	// The original file is not changed
+	if file.HasAttribute("owner") || file.GetEncoding() != "" {
+		t.Error("expected the original file not to change")
+	}
+
+	if !tagged.HasAttribute("owner") || tagged.GetAttribute("owner", "") != "jane" || tagged.GetEncoding() != "gzip" {
+		t.Error("expected the file attributes and encoding")
+	}
+	if value := tagged.GetAttribute("missing", "none"); value != "none" {
+		t.Errorf("expected the default value, got %q", value)
+	}
+
+	// The metadata is kept in the payload
+	p := fileToPayload(*tagged)
+	f := payloadToFile(&p)
+	if !reflect.DeepEqual(f.GetAttributes(), map[string]string{"owner": "jane"}) || f.GetEncoding() != "gzip" {
+		t.Errorf("expected the metadata from the payload, got %v %q", f.GetAttributes(), f.GetEncoding())
+	}
+}
@@ -0,0 +1,138 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+// FeatureFlagNamespace is the namespace of the transport properties that set feature flags.
+//
+// For example the property "flags::new-checkout" sets the "new-checkout" flag.
+const FeatureFlagNamespace = "flags"
+
+// FeatureFlagVariablePrefix is the prefix of the component variables that set feature flags.
+//
+// For example the variable "flag:new-checkout" sets the "new-checkout" flag.
+const FeatureFlagVariablePrefix = "flag:"
+
+// FeatureFlagEnvPrefix is the prefix of the environment variables that set feature flags.
+//
+// The rest of the name is the flag name in upper case with the "-" replaced by "_",
+// so for example the variable "KUSANAGI_FLAG_NEW_CHECKOUT" sets the "new-checkout" flag.
+const FeatureFlagEnvPrefix = "KUSANAGI_FLAG_"
+
+// Sources of the feature flag values.
+const (
+	FeatureFlagSourceTransport   = "transport"
+	FeatureFlagSourceVariable    = "variable"
+	FeatureFlagSourceEnvironment = "environment"
+)
+
+// Get the name of the environment variable for a feature flag.
+func getFeatureFlagEnvName(name string) string {
+	return FeatureFlagEnvPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// Creates the feature flags for a request.
+//
+// input: The CLI input with the component variables.
+// properties: The transport properties of the request, or nil when there is no transport.
+func newFeatureFlags(input cli.Input, properties map[string]string) *FeatureFlags {
+	return &FeatureFlags{input, getNamespaceProperties(properties, FeatureFlagNamespace)}
+}
+
+// FeatureFlags gives access to the feature flags of the current request.
+//
+// Flag values are read from the transport properties in the "flags" namespace, the
+// component variables with the "flag:" prefix and the environment variables with the
+// "KUSANAGI_FLAG_" prefix. When a flag is set by more than one source the transport
+// properties have precedence over the component variables, which have precedence
+// over the environment variables.
+type FeatureFlags struct {
+	input      cli.Input
+	properties map[string]string
+}
+
+// Get the value of a flag and the source that sets it.
+func (f FeatureFlags) lookup(name string) (value, source string, exists bool) {
+	if value, exists = f.properties[name]; exists {
+		return value, FeatureFlagSourceTransport, true
+	}
+
+	if variable := FeatureFlagVariablePrefix + name; f.input.HasVariable(variable) {
+		return f.input.GetVariable(variable), FeatureFlagSourceVariable, true
+	}
+
+	if value, exists = os.LookupEnv(getFeatureFlagEnvName(name)); exists {
+		return value, FeatureFlagSourceEnvironment, true
+	}
+	return "", "", false
+}
+
+// Has checks if a feature flag is set.
+//
+// name: The flag name.
+func (f FeatureFlags) Has(name string) bool {
+	_, _, exists := f.lookup(name)
+	return exists
+}
+
+// IsEnabled checks if a feature flag is enabled.
+//
+// Flags are disabled when they are not set or when the value is not a valid boolean.
+//
+// name: The flag name.
+func (f FeatureFlags) IsEnabled(name string) bool {
+	value, _, _ := f.lookup(name)
+	enabled, _ := strconv.ParseBool(strings.TrimSpace(value))
+	return enabled
+}
+
+// Get returns the value of a feature flag.
+//
+// name: The flag name.
+// preset: The default value to use when the flag is not set.
+func (f FeatureFlags) Get(name, preset string) string {
+	if value, _, exists := f.lookup(name); exists {
+		return value
+	}
+	return preset
+}
+
+// GetSource returns the source that sets a feature flag.
+//
+// The source can be "transport", "variable" or "environment", and it
+// is empty when the flag is not set.
+//
+// name: The flag name.
+func (f FeatureFlags) GetSource(name string) string {
+	_, source, _ := f.lookup(name)
+	return source
+}
+
+// Flags returns the feature flags for the current request.
+//
+// Transport properties are only available to actions and response middlewares.
+func (a *Api) Flags() *FeatureFlags {
+	var properties map[string]string
+	switch parent := a.parent.(type) {
+	case *Action:
+		properties = parent.transport.Meta.Properties
+	case *Response:
+		if t := parent.command.Command.Arguments.Transport; t != nil {
+			properties = t.Meta.Properties
+		}
+	}
+	return newFeatureFlags(a.input, properties)
+}
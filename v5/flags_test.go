@@ -0,0 +1,89 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestGetFeatureFlagEnvName(t *testing.T) {
+	if name := getFeatureFlagEnvName("new-checkout"); name != "KUSANAGI_FLAG_NEW_CHECKOUT" {
+		t.Errorf("unexpected environment variable name: %s", name)
+	}
+}
+
+func TestFeatureFlagsPrecedence(t *testing.T) {
+	t.Setenv("KUSANAGI_FLAG_TEST_ENV", "true")
+	t.Setenv("KUSANAGI_FLAG_TEST_VARIABLE", "false")
+	t.Setenv("KUSANAGI_FLAG_TEST_TRANSPORT", "false")
+	setTestVariable(t, FeatureFlagVariablePrefix+"test-variable", "true")
+	setTestVariable(t, FeatureFlagVariablePrefix+"test-transport", "false")
+
+	properties := map[string]string{
+		PropertyName(FeatureFlagNamespace, "test-transport"): "true",
+		"test-other": "true",
+	}
+	flags := newFeatureFlags(cli.Input{}, properties)
+
+	cases := []struct {
+		name    string
+		enabled bool
+		source  string
+	}{
+		{"test-env", true, FeatureFlagSourceEnvironment},
+		{"test-variable", true, FeatureFlagSourceVariable},
+		{"test-transport", true, FeatureFlagSourceTransport},
+		// Properties outside the namespace are not flags
+		{"test-other", false, ""},
+	}
+
+	for _, c := range cases {
+		if enabled := flags.IsEnabled(c.name); enabled != c.enabled {
+			t.Errorf("%s: expected %v, got %v", c.name, c.enabled, enabled)
+		}
+		if source := flags.GetSource(c.name); source != c.source {
+			t.Errorf("%s: expected the source %q, got %q", c.name, c.source, source)
+		}
+		if flags.Has(c.name) != (c.source != "") {
+			t.Errorf("%s: unexpected flag check", c.name)
+		}
+	}
+}
+
+func TestFeatureFlagsValues(t *testing.T) {
+	flags := newFeatureFlags(cli.Input{}, map[string]string{
+		PropertyName(FeatureFlagNamespace, "variant"): "blue",
+		PropertyName(FeatureFlagNamespace, "beta"):    " 1 ",
+	})
+
+	if value := flags.Get("variant", "red"); value != "blue" {
+		t.Errorf("unexpected flag value: %s", value)
+	}
+	if value := flags.Get("test-missing", "red"); value != "red" {
+		t.Errorf("expected the default value, got %s", value)
+	}
+
+	// Values that are not booleans are disabled
+	if flags.IsEnabled("variant") || !flags.IsEnabled("beta") {
+		t.Error("unexpected enabled flags")
+	}
+}
+
+func TestActionFlags(t *testing.T) {
+	transport := &payload.Transport{}
+	transport.Meta.Properties = map[string]string{PropertyName(FeatureFlagNamespace, "beta"): "true"}
+
+	a := newTestAction("users", "1.0.0", "read", transport)
+	if flags := a.Flags(); !flags.IsEnabled("beta") || flags.GetSource("beta") != FeatureFlagSourceTransport {
+		t.Error("expected the flag from the transport")
+	}
+}
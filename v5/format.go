@@ -0,0 +1,106 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"strconv"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/codec"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+)
+
+// CanonicalSerializationVariable is the name of the component variable that enables
+// the serialization of the reply payloads with the map keys sorted.
+//
+// Canonical payloads are the same for equal values, so they can be hashed or signed.
+const CanonicalSerializationVariable = "canonical-serialization"
+
+// PayloadCodecVariable is the name of the component variable that enables the
+// custom codec of the component for the payloads exchanged with the framework.
+//
+// The value must be the name of the codec set with Component.SetCodec(). The gateway
+// frames are serialized using msgpack by default, even when the component has a custom
+// codec, because the codec must also be supported by the framework.
+const PayloadCodecVariable = "payload-codec"
+
+// Check if the payloads must be serialized with the map keys sorted.
+func isCanonicalSerialization(input cli.Input) bool {
+	canonical, _ := strconv.ParseBool(input.GetVariable(CanonicalSerializationVariable))
+	return canonical
+}
+
+// Flags used in multipart requests to negotiate the payload serialization format.
+var msgpackFormatFlag = []byte("\x00")
+var jsonFormatFlag = []byte("\x01")
+
+// Serialization format used for the payloads of a request and its response.
+type wireFormat struct {
+	codec codec.Codec
+}
+
+// Supported serialization formats.
+//
+// The binary format is msgpack unless the custom codec of the component is enabled.
+var (
+	formatMsgpack = wireFormat{codec.Msgpack{}}
+	formatJSON    = wireFormat{codec.JSON{}}
+)
+
+// Get the binary serialization format for the payloads exchanged with the framework.
+//
+// The msgpack format is used unless the codec is enabled with the payload codec variable.
+func getBinaryFormat(input cli.Input, c codec.Codec) wireFormat {
+	name := input.GetVariable(PayloadCodecVariable)
+	if c == nil {
+		if name != "" && name != formatMsgpack.String() {
+			log.Warningf(`The payload codec "%s" is not set in the component, using msgpack`, name)
+		}
+		return formatMsgpack
+	}
+
+	if name != c.Name() {
+		log.Debugf(`The payload codec "%s" is disabled, set the "%s" variable to enable it`, c.Name(), PayloadCodecVariable)
+		return formatMsgpack
+	}
+	return wireFormat{c}
+}
+
+// Check if the format is JSON.
+func (f wireFormat) isJSON() bool {
+	_, ok := f.codec.(codec.JSON)
+	return ok
+}
+
+func (f wireFormat) String() string {
+	return f.codec.Name()
+}
+
+// Serialize a value using the current format.
+func (f wireFormat) encode(v interface{}) ([]byte, error) {
+	return f.codec.Encode(v)
+}
+
+// Serialize a value using the current format with the map keys sorted.
+//
+// The keys are sorted only when the codec supports canonical serialization.
+func (f wireFormat) encodeCanonical(v interface{}) ([]byte, error) {
+	return codec.EncodeCanonical(f.codec, v)
+}
+
+// Deserialize a value using the current format.
+//
+// Compressed payloads are decompressed before they are deserialized.
+func (f wireFormat) decode(b []byte, v interface{}) error {
+	b, err := codec.Decompress(b)
+	if err != nil {
+		return err
+	}
+	return f.codec.Decode(b, v)
+}
@@ -0,0 +1,49 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/codec"
+)
+
+// Codec used to check that custom codecs are opt-in.
+type testCodec struct {
+	codec.Msgpack
+}
+
+func (testCodec) Name() string {
+	return "test"
+}
+
+func TestBinaryFormatIsMsgpackByDefault(t *testing.T) {
+	if format := getBinaryFormat(cli.Input{}, nil); format != formatMsgpack {
+		t.Errorf("expected msgpack, got %s", format)
+	}
+
+	// The custom codec is not used until it is enabled
+	if format := getBinaryFormat(cli.Input{}, testCodec{}); format != formatMsgpack {
+		t.Errorf("expected msgpack for a disabled codec, got %s", format)
+	}
+}
+
+func TestBinaryFormatWithEnabledCodec(t *testing.T) {
+	setTestVariable(t, PayloadCodecVariable, "test")
+
+	if format := getBinaryFormat(cli.Input{}, testCodec{}); format.String() != "test" {
+		t.Errorf("expected the custom codec, got %s", format)
+	}
+
+	// The variable must match the name of the codec
+	if format := getBinaryFormat(cli.Input{}, codec.JSON{}); format != formatMsgpack {
+		t.Errorf("expected msgpack for a codec with a different name, got %s", format)
+	}
+}
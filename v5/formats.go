@@ -0,0 +1,50 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import "sync"
+
+// FormatValidator validates that a parameter value is valid for a format.
+//
+// The validator must return an error when the value is not valid.
+type FormatValidator func(value interface{}) error
+
+// Mutex to guard the registered format validators.
+var formatsMutex sync.RWMutex
+
+// Custom format validators by format name.
+var formatValidators = make(map[string]FormatValidator)
+
+// RegisterFormat registers a validator for a custom parameter format.
+//
+// The validator is called for each parameter of an action that has the format
+// defined in its schema. The parameters are validated after the before action
+// hooks and before the action callback is called. A previously registered
+// validator for the same format is replaced.
+//
+// name: The format name, for example "email".
+// validator: The function to validate the parameter values.
+func RegisterFormat(name string, validator FormatValidator) {
+	formatsMutex.Lock()
+	defer formatsMutex.Unlock()
+
+	if validator == nil {
+		delete(formatValidators, name)
+	} else {
+		formatValidators[name] = validator
+	}
+}
+
+// Get the validator for a custom parameter format.
+func getFormatValidator(name string) FormatValidator {
+	formatsMutex.RLock()
+	defer formatsMutex.RUnlock()
+
+	return formatValidators[name]
+}
@@ -0,0 +1,101 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Validate that a value is a lower case string without spaces.
+func validateTestSlug(value interface{}) error {
+	s, ok := value.(string)
+	if !ok || s != strings.ToLower(s) || strings.Contains(s, " ") {
+		return errors.New("the value is not a slug")
+	}
+	return nil
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat("test-slug", validateTestSlug)
+	t.Cleanup(func() {
+		RegisterFormat("test-slug", nil)
+	})
+
+	schema := ParamSchema{payload.ParamSchema{Name: "slug", Format: "test-slug"}}
+	if !schema.HasFormatValidator() {
+		t.Fatal("expected the format to have a validator")
+	}
+	if err := schema.ValidateFormat("a-slug"); err != nil {
+		t.Errorf("expected the value to be valid, got %v", err)
+	}
+	if err := schema.ValidateFormat("Not A Slug"); err == nil || !strings.Contains(err.Error(), "test-slug") {
+		t.Errorf("expected a format error, got %v", err)
+	}
+
+	// Formats without validators accept any value
+	schema = ParamSchema{payload.ParamSchema{Name: "slug", Format: "test-unknown"}}
+	if schema.HasFormatValidator() || schema.ValidateFormat("Not A Slug") != nil {
+		t.Error("expected the value to be valid without a validator")
+	}
+
+	RegisterFormat("test-slug", nil)
+	if getFormatValidator("test-slug") != nil {
+		t.Error("expected the validator to be removed")
+	}
+}
+
+func TestActionValidateParamFormats(t *testing.T) {
+	RegisterFormat("test-slug", validateTestSlug)
+	t.Cleanup(func() {
+		RegisterFormat("test-slug", nil)
+	})
+
+	schema := payload.ActionSchema{Params: map[string]payload.ParamSchema{
+		"slug":  {Name: "slug", Format: "test-slug"},
+		"title": {Name: "title"},
+	}}
+	title := payload.Param{Name: "title", Value: "Not A Slug", Type: payload.TypeString}
+
+	a := newTestActionWithParams("posts", "1.0.0", "create", schema, payload.Param{Name: "slug", Value: "first-post", Type: payload.TypeString}, title)
+	if err := a.validateParams(); err != nil {
+		t.Errorf("expected the params to be valid, got %v", err)
+	}
+
+	a = newTestActionWithParams("posts", "1.0.0", "create", schema, payload.Param{Name: "slug", Value: "First Post", Type: payload.TypeString}, title)
+	if err := a.validateParams(); err == nil || !strings.Contains(err.Error(), `"slug"`) {
+		t.Errorf("expected a validation error for the slug, got %v", err)
+	}
+
+	// The error is always for the first invalid param in name order
+	schema = payload.ActionSchema{Params: map[string]payload.ParamSchema{
+		"alias": {Name: "alias", Format: "test-slug"},
+		"slug":  {Name: "slug", Format: "test-slug"},
+		"title": {Name: "title", Format: "test-slug"},
+	}}
+	for i := 0; i < 10; i++ {
+		a := newTestActionWithParams("posts", "1.0.0", "create", schema,
+			title,
+			payload.Param{Name: "slug", Value: "First Post", Type: payload.TypeString},
+			payload.Param{Name: "alias", Value: "Other Post", Type: payload.TypeString},
+		)
+		if err := a.validateParams(); err == nil || !strings.Contains(err.Error(), `"alias"`) {
+			t.Fatalf("expected a validation error for the alias, got %v", err)
+		}
+	}
+
+	// The params are not validated without schema
+	a = newTestAction("posts", "1.0.0", "create", nil)
+	if err := a.validateParams(); err != nil {
+		t.Errorf("expected no validation without schema, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+	"github.com/pebbe/zmq4"
+)
+
+// HeartbeatAddressVariable is the name of the component variable with the ZMQ address
+// where the heartbeats are published, for example "tcp://monitor:5555".
+//
+// The component connects a PUB socket to the address, so the monitoring system must
+// bind a SUB socket. Heartbeats are disabled when the variable is not set.
+const HeartbeatAddressVariable = "heartbeat-address"
+
+// HeartbeatIntervalVariable is the name of the component variable with the number
+// of milliseconds between heartbeats.
+const HeartbeatIntervalVariable = "heartbeat-interval"
+
+// HeartbeatTopic is the topic frame of the heartbeat messages.
+const HeartbeatTopic = "kusanagi.heartbeat"
+
+// Default number of milliseconds between heartbeats.
+const defaultHeartbeatInterval = 5000
+
+// Heartbeat contains the status of a component sent by the heartbeat publisher.
+//
+// Heartbeats are published as a multipart message where the first frame is the
+// topic and the second frame is the heartbeat serialized with msgpack.
+type Heartbeat struct {
+	Component  string `json:"component"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Framework  string `json:"framework"`
+	Address    string `json:"address"`
+	PID        int    `json:"pid"`
+	Time       string `json:"time"`
+	Uptime     int64  `json:"uptime"`
+	Goroutines int    `json:"goroutines"`
+	Queued     int    `json:"queued"`
+	InFlight   int64  `json:"in_flight"`
+	Processed  int64  `json:"processed"`
+	Failed     int64  `json:"failed"`
+	HeapAlloc  uint64 `json:"heap_alloc"`
+}
+
+// Creates a new heartbeat publisher.
+//
+// The result is nil when the heartbeats are not enabled.
+func newHeartbeatPublisher(input cli.Input, stats *serverStats) *heartbeatPublisher {
+	address := input.GetVariable(HeartbeatAddressVariable)
+	if address == "" {
+		return nil
+	}
+
+	interval := getIntVariable(input, HeartbeatIntervalVariable, defaultHeartbeatInterval)
+	if interval == 0 {
+		interval = defaultHeartbeatInterval
+	}
+
+	return &heartbeatPublisher{
+		input:    input,
+		stats:    stats,
+		address:  address,
+		interval: time.Duration(interval) * time.Millisecond,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Heartbeat publisher sends the component status periodically to a ZMQ address.
+type heartbeatPublisher struct {
+	input    cli.Input
+	stats    *serverStats
+	address  string
+	interval time.Duration
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// Create the heartbeat with the current status of the component.
+func (h *heartbeatPublisher) heartbeat() Heartbeat {
+	stats := h.stats.snapshot()
+	return Heartbeat{
+		Component:  h.input.GetComponent(),
+		Name:       h.input.GetName(),
+		Version:    h.input.GetVersion(),
+		Framework:  h.input.GetFrameworkVersion(),
+		Address:    getListenAddress(h.input),
+		PID:        os.Getpid(),
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		Uptime:     stats.GetUptime().Milliseconds(),
+		Goroutines: stats.GetGoroutines(),
+		Queued:     stats.GetQueueDepth(),
+		InFlight:   stats.GetInFlight(),
+		Processed:  stats.GetProcessed(),
+		Failed:     stats.GetFailed(),
+		HeapAlloc:  stats.GetMemStats().HeapAlloc,
+	}
+}
+
+// Start publishing the heartbeats.
+//
+// The socket is created with the server context and it is closed when the publisher stops.
+func (h *heartbeatPublisher) start(zctx *zmq4.Context) error {
+	socket, err := zctx.NewSocket(zmq4.PUB)
+	if err != nil {
+		return fmt.Errorf("Failed to create the heartbeat socket: %v", err)
+	}
+
+	if err := socket.SetLinger(0); err != nil {
+		socket.Close()
+		return fmt.Errorf("Failed to set socket's linger option: %v", err)
+	}
+
+	if err := socket.Connect(h.address); err != nil {
+		socket.Close()
+		return fmt.Errorf(`Failed to connect to the heartbeat address "%s": %v`, h.address, err)
+	}
+
+	log.Debugf(`Publishing heartbeats every %s to address: "%s"`, h.interval, h.address)
+	go func() {
+		defer close(h.stopped)
+		defer socket.Close()
+
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+
+		for {
+			h.publish(socket)
+
+			select {
+			case <-ticker.C:
+			case <-h.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Send a heartbeat.
+func (h *heartbeatPublisher) publish(socket *zmq4.Socket) {
+	data, err := msgpack.Encode(h.heartbeat())
+	if err != nil {
+		log.Errorf("Failed to serialize the heartbeat: %v", err)
+		return
+	}
+
+	// PUB sockets drop the messages when there are no subscribers, so sending doesn't block
+	if _, err := socket.SendMessageDontwait(HeartbeatTopic, data); err != nil {
+		log.Debugf("Failed to publish the heartbeat: %v", err)
+	}
+}
+
+// Stop publishing the heartbeats and wait until the socket is closed.
+func (h *heartbeatPublisher) stop() {
+	close(h.done)
+	<-h.stopped
+}
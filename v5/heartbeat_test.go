@@ -0,0 +1,96 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+)
+
+func TestHeartbeatPublisherIsDisabledByDefault(t *testing.T) {
+	if h := newHeartbeatPublisher(cli.Input{}, newServerStats()); h != nil {
+		t.Errorf("expected the heartbeats to be disabled, got %v", h)
+	}
+}
+
+func TestHeartbeatPublisherInterval(t *testing.T) {
+	setTestVariable(t, HeartbeatAddressVariable, "tcp://monitor:5555")
+
+	cases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", defaultHeartbeatInterval * time.Millisecond},
+		{"250", 250 * time.Millisecond},
+		{"0", defaultHeartbeatInterval * time.Millisecond},
+		{"invalid", defaultHeartbeatInterval * time.Millisecond},
+	}
+
+	for _, c := range cases {
+		setTestVariable(t, HeartbeatIntervalVariable, c.value)
+
+		h := newHeartbeatPublisher(cli.Input{}, newServerStats())
+		if h == nil {
+			t.Fatal("expected the heartbeats to be enabled")
+		}
+		if h.address != "tcp://monitor:5555" {
+			t.Errorf("unexpected heartbeat address: %s", h.address)
+		}
+		if h.interval != c.expected {
+			t.Errorf("%q: expected an interval of %s, got %s", c.value, c.expected, h.interval)
+		}
+	}
+}
+
+func TestHeartbeatPublisherHeartbeat(t *testing.T) {
+	setTestVariable(t, HeartbeatAddressVariable, "tcp://monitor:5555")
+
+	stats := newServerStats()
+	stats.begin()
+	stats.end()
+	stats.done(false)
+	stats.begin()
+	stats.end()
+	stats.done(true)
+
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	hb := newHeartbeatPublisher(input, stats).heartbeat()
+	if hb.Component != "service" || hb.Name != "users" || hb.Version != "1.0.0" {
+		t.Errorf("unexpected component identity: %s %s %s", hb.Component, hb.Name, hb.Version)
+	}
+	if hb.Address != getListenAddress(input) || hb.PID != os.Getpid() {
+		t.Errorf("unexpected process: %s %d", hb.Address, hb.PID)
+	}
+	if hb.Processed != 2 || hb.Failed != 1 || hb.InFlight != 0 {
+		t.Errorf("unexpected request counts: %d %d %d", hb.Processed, hb.Failed, hb.InFlight)
+	}
+	if _, err := time.Parse(time.RFC3339, hb.Time); err != nil {
+		t.Errorf("invalid heartbeat time: %v", err)
+	}
+
+	// The heartbeats are serialized with the field names used by the monitoring systems
+	data, err := msgpack.Encode(hb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]interface{}
+	if err := msgpack.Decode(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"component", "name", "version", "address", "pid", "uptime", "in_flight", "heap_alloc"} {
+		if _, exists := fields[name]; !exists {
+			t.Errorf("expected the heartbeat field %s, got %v", name, fields)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestMain(m *testing.M) {
+	// The tests check the errors without writing them to the output
+	log.Disable()
+	os.Exit(m.Run())
+}
+
+// Set a component variable for the duration of a test.
+func setTestVariable(t *testing.T, name, value string) {
+	t.Helper()
+
+	if err := flag.Set("var", name+"="+value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		flag.Set("var", name+"=")
+	})
+}
+
+// Create the state of a service request for the tests.
+func newTestState(name, version, action string, transport *payload.Transport) *state {
+	if transport == nil {
+		transport = &payload.Transport{}
+	}
+	if len(transport.Meta.Gateway) == 0 {
+		transport.Meta.Gateway = []string{"ktp://internal", "http://public"}
+	}
+
+	s := &state{
+		id:      "test",
+		action:  action,
+		command: payload.NewCommand("action", "service"),
+		format:  formatMsgpack,
+		binary:  formatMsgpack,
+		input:   cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: name, Version: version}),
+		logger:  log.NewRequestLogger("test"),
+		ctx:     context.Background(),
+	}
+	s.command.Command.Arguments = &payload.CommandArguments{Transport: transport}
+	s.reply = payload.NewActionReply(&s.command)
+	return s
+}
+
+// Create an action for the tests without running the component.
+func newTestAction(name, version, action string, transport *payload.Transport) *Action {
+	return newAction(NewService(), newTestState(name, version, action, transport))
+}
+
+// Create an action for the tests with the schema of the action in the mapping.
+func newTestActionWithSchema(name, version, action string, schema payload.ActionSchema) *Action {
+	return newTestActionWithParams(name, version, action, schema)
+}
+
+// Create an action for the tests with parameters and the schema of the action in the mapping.
+func newTestActionWithParams(name, version, action string, schema payload.ActionSchema, params ...payload.Param) *Action {
+	s := newTestState(name, version, action, nil)
+	s.command.Command.Arguments.Params = params
+	s.schemas = payload.Mapping{name: {version: payload.Schema{
+		Actions: map[string]payload.ActionSchema{action: schema},
+	}}}
+	return newAction(NewService(), s)
+}
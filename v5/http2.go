@@ -0,0 +1,140 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Names of the HTTP/2 pseudo-headers.
+const (
+	PseudoHeaderMethod    = ":method"
+	PseudoHeaderScheme    = ":scheme"
+	PseudoHeaderAuthority = ":authority"
+	PseudoHeaderPath      = ":path"
+	PseudoHeaderProtocol  = ":protocol"
+)
+
+// Default urgency of the HTTP requests as defined by RFC 9218.
+const DefaultStreamUrgency = 3
+
+// Maximum urgency value, which is the lowest priority.
+const maxStreamUrgency = 7
+
+// StreamPriority contains the priority of an HTTP request as defined by RFC 9218.
+type StreamPriority struct {
+	// Urgency is a value between 0 and 7, where 0 is the highest priority
+	Urgency int
+	// Incremental is true when the response can be processed incrementally
+	Incremental bool
+}
+
+// IsHTTP2 checks if the request used HTTP/2.
+//
+// Requests upgraded to HTTP/2 over cleartext TCP (h2c) are also HTTP/2 requests.
+func (r HTTPRequest) IsHTTP2() bool {
+	version := r.GetProtocolVersion()
+	return version == "2" || version == "2.0"
+}
+
+// IsH2C checks if the request used HTTP/2 over cleartext TCP.
+func (r HTTPRequest) IsH2C() bool {
+	return r.IsHTTP2() && r.GetPseudoHeader(PseudoHeaderScheme, "") == "http"
+}
+
+// IsH2CUpgrade checks if an HTTP/1.1 request asks to upgrade the connection to HTTP/2 over cleartext TCP.
+func (r HTTPRequest) IsH2CUpgrade() bool {
+	if r.IsHTTP2() {
+		return false
+	}
+
+	for _, value := range strings.Split(r.GetHeader("Upgrade", ""), ",") {
+		if strings.EqualFold(strings.TrimSpace(value), "h2c") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPseudoHeader returns an HTTP/2 pseudo-header.
+//
+// The pseudo-headers are read from the request headers when the gateway provides them.
+// Otherwise, for HTTP/2 requests the values of the ":method", ":scheme", ":authority"
+// and ":path" pseudo-headers are taken from the request method and URL.
+//
+// name: The pseudo-header name, with or without the ":" prefix.
+// preset: A default value to use when the pseudo-header doesn't exist.
+func (r HTTPRequest) GetPseudoHeader(name, preset string) string {
+	name = ":" + strings.TrimPrefix(strings.ToLower(name), ":")
+	if value := r.GetHeader(name, ""); value != "" {
+		return value
+	}
+
+	if !r.IsHTTP2() {
+		return preset
+	}
+
+	var value string
+	switch name {
+	case PseudoHeaderMethod:
+		value = r.GetMethod()
+	case PseudoHeaderScheme:
+		value = r.url.Scheme
+	case PseudoHeaderAuthority:
+		value = r.url.Host
+	case PseudoHeaderPath:
+		value = r.url.RequestURI()
+	}
+
+	if value == "" {
+		return preset
+	}
+	return value
+}
+
+// GetPseudoHeaders returns the HTTP/2 pseudo-headers.
+//
+// The result is empty when the request didn't use HTTP/2 and the gateway doesn't provide pseudo-headers.
+func (r HTTPRequest) GetPseudoHeaders() map[string]string {
+	headers := make(map[string]string)
+	for _, name := range []string{PseudoHeaderMethod, PseudoHeaderScheme, PseudoHeaderAuthority, PseudoHeaderPath, PseudoHeaderProtocol} {
+		if value := r.GetPseudoHeader(name, ""); value != "" {
+			headers[name] = value
+		}
+	}
+	return headers
+}
+
+// GetStreamPriority returns the priority of the request.
+//
+// The priority is read from the "Priority" header as defined by RFC 9218,
+// for example "u=1, i". The result is false when the header is not present.
+// Invalid or unknown parameters are ignored and the default values are used.
+func (r HTTPRequest) GetStreamPriority() (StreamPriority, bool) {
+	priority := StreamPriority{Urgency: DefaultStreamUrgency}
+	header := r.GetHeader("Priority", "")
+	if header == "" {
+		return priority, false
+	}
+
+	for _, param := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		switch strings.TrimSpace(name) {
+		case "u":
+			if urgency, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && urgency >= 0 && urgency <= maxStreamUrgency {
+				priority.Urgency = urgency
+			}
+		case "i":
+			// Boolean parameters without value are true
+			priority.Incremental = value == "" || strings.TrimSpace(value) == "?1"
+		}
+	}
+	return priority, true
+}
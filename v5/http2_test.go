@@ -0,0 +1,104 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create an HTTP request for the HTTP/2 tests.
+func newHTTP2TestRequest(version, url string, headers http.Header) *HTTPRequest {
+	return newHTTPRequest(&payload.HTTPRequest{Version: version, Method: "GET", URL: url, Headers: headers})
+}
+
+func TestHTTPRequestHTTP2(t *testing.T) {
+	cases := []struct {
+		name    string
+		request *HTTPRequest
+		http2   bool
+		h2c     bool
+		upgrade bool
+	}{
+		{"http/1.1", newHTTP2TestRequest("1.1", "https://example.com/", nil), false, false, false},
+		{"http/2", newHTTP2TestRequest("2.0", "https://example.com/", nil), true, false, false},
+		{"h2c", newHTTP2TestRequest("2", "http://example.com/", nil), true, true, false},
+		{"upgrade", newHTTP2TestRequest("1.1", "http://example.com/", http.Header{"Upgrade": {"websocket, H2C"}}), false, false, true},
+		{"upgrade http/2", newHTTP2TestRequest("2", "http://example.com/", http.Header{"Upgrade": {"h2c"}}), true, true, false},
+	}
+
+	for _, c := range cases {
+		if value := c.request.IsHTTP2(); value != c.http2 {
+			t.Errorf("%s: expected HTTP/2 %v, got %v", c.name, c.http2, value)
+		}
+		if value := c.request.IsH2C(); value != c.h2c {
+			t.Errorf("%s: expected h2c %v, got %v", c.name, c.h2c, value)
+		}
+		if value := c.request.IsH2CUpgrade(); value != c.upgrade {
+			t.Errorf("%s: expected h2c upgrade %v, got %v", c.name, c.upgrade, value)
+		}
+	}
+}
+
+func TestHTTPRequestGetPseudoHeaders(t *testing.T) {
+	r := newHTTP2TestRequest("2.0", "https://example.com:8080/users?id=1", http.Header{":protocol": {"websocket"}})
+	expected := map[string]string{
+		PseudoHeaderMethod:    "GET",
+		PseudoHeaderScheme:    "https",
+		PseudoHeaderAuthority: "example.com:8080",
+		PseudoHeaderPath:      "/users?id=1",
+		PseudoHeaderProtocol:  "websocket",
+	}
+	if headers := r.GetPseudoHeaders(); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected %v, got %v", expected, headers)
+	}
+	if value := r.GetPseudoHeader("method", ""); value != "GET" {
+		t.Errorf("expected the pseudo-header without prefix, got %q", value)
+	}
+
+	// The pseudo-headers are not available for HTTP/1.1 unless the gateway provides them
+	r = newHTTP2TestRequest("1.1", "https://example.com/", http.Header{":scheme": {"http"}})
+	if headers := r.GetPseudoHeaders(); !reflect.DeepEqual(headers, map[string]string{PseudoHeaderScheme: "http"}) {
+		t.Errorf("unexpected HTTP/1.1 pseudo-headers: %v", headers)
+	}
+	if value := r.GetPseudoHeader(PseudoHeaderPath, "/"); value != "/" {
+		t.Errorf("expected the default value, got %q", value)
+	}
+}
+
+func TestHTTPRequestGetStreamPriority(t *testing.T) {
+	cases := []struct {
+		header   string
+		priority StreamPriority
+		exists   bool
+	}{
+		{"", StreamPriority{DefaultStreamUrgency, false}, false},
+		{"u=1, i", StreamPriority{1, true}, true},
+		{"i=?0, u=0", StreamPriority{0, false}, true},
+		{"i=?1", StreamPriority{DefaultStreamUrgency, true}, true},
+		// Invalid values use the defaults
+		{"u=9", StreamPriority{DefaultStreamUrgency, false}, true},
+		{"u=high, x=1", StreamPriority{DefaultStreamUrgency, false}, true},
+	}
+
+	for _, c := range cases {
+		headers := http.Header{}
+		if c.header != "" {
+			headers.Set("Priority", c.header)
+		}
+
+		priority, exists := newHTTP2TestRequest("2.0", "https://example.com/", headers).GetStreamPriority()
+		if priority != c.priority || exists != c.exists {
+			t.Errorf("%q: expected %+v %v, got %+v %v", c.header, c.priority, c.exists, priority, exists)
+		}
+	}
+}
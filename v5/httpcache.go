@@ -0,0 +1,185 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CacheNamespace is the namespace of the transport properties used for HTTP caching.
+const CacheNamespace = "cache"
+
+// CacheControlProperty is the name of the property in the cache namespace that sets
+// the value of the "Cache-Control" header, for example "cache::control".
+const CacheControlProperty = "control"
+
+// CacheControlTagPrefix is the prefix of the action tags that set the value of the
+// "Cache-Control" header, for example "cache-control:public, max-age=60".
+const CacheControlTagPrefix = "cache-control:"
+
+// Prefix of the weak entity tags.
+const weakETagPrefix = "W/"
+
+// Number of bytes of the body hash used for the entity tags.
+const etagHashSize = 16
+
+// ComputeETag computes an entity tag for a body.
+//
+// The tag is a quoted hash of the contents, and it is prefixed with "W/" when it is weak.
+//
+// body: The contents.
+// weak: Creates a weak entity tag.
+func ComputeETag(body []byte, weak bool) string {
+	hash := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(hash[:etagHashSize]) + `"`
+	if weak {
+		return weakETagPrefix + etag
+	}
+	return etag
+}
+
+// Check if an entity tag matches a list of entity tags from an "If-None-Match" header.
+//
+// The comparison is weak, so weak and strong tags match when their values are equal.
+func matchETag(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, weakETagPrefix)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, weakETagPrefix) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotModified checks if the request conditions are met for a "304 Not Modified" response.
+//
+// The "If-None-Match" header is compared with the entity tag, and when the header is not
+// present the "If-Modified-Since" header is compared with the last modification time. Only
+// GET and HEAD requests can be not modified.
+//
+// etag: The entity tag of the current contents.
+// lastModified: The last modification time of the contents, or a zero time when it is not known.
+func (r HTTPRequest) IsNotModified(etag string, lastModified time.Time) bool {
+	if !r.IsMethod(http.MethodGet) && !r.IsMethod(http.MethodHead) {
+		return false
+	}
+
+	if header := r.GetHeader("If-None-Match", ""); header != "" {
+		return etag != "" && matchETag(header, etag)
+	}
+
+	if header := r.GetHeader("If-Modified-Since", ""); header != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(header)
+		// The header has a precision of seconds
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// SetETag sets the "ETag" header using a hash of the response body.
+//
+// The result is the entity tag. The header is not changed when it is already set.
+func (r *HTTPResponse) SetETag() string {
+	if etag := r.GetHeader("ETag", ""); etag != "" {
+		return etag
+	}
+
+	etag := ComputeETag(r.GetBody(), false)
+	r.SetHeader("ETag", etag, true)
+	return etag
+}
+
+// SetLastModified sets the "Last-Modified" header.
+//
+// lastModified: The last modification time of the contents.
+func (r *HTTPResponse) SetLastModified(lastModified time.Time) *HTTPResponse {
+	return r.SetHeader("Last-Modified", lastModified.UTC().Format(http.TimeFormat), true)
+}
+
+// SetNotModified changes the response to a "304 Not Modified" response without body.
+//
+// The response headers are kept so the caching headers are sent to the client.
+func (r *HTTPResponse) SetNotModified() *HTTPResponse {
+	r.SetStatus(http.StatusNotModified, http.StatusText(http.StatusNotModified))
+	return r.SetBody([]byte{})
+}
+
+// GetCacheControl returns the "Cache-Control" value for the response of the current request.
+//
+// The value is read from the "cache::control" transport property, or from the tag with the
+// "cache-control:" prefix of the action that was the origin of the request. The result is
+// empty when there is no value for the response.
+func (r *Response) GetCacheControl() string {
+	transport := r.GetTransport()
+	if transport == nil {
+		return ""
+	}
+
+	if value := transport.GetProperty(PropertyName(CacheNamespace, CacheControlProperty), ""); value != "" {
+		return value
+	}
+
+	origin := transport.GetOrigin()
+	if origin.IsEmpty() {
+		return ""
+	}
+
+	schema, err := r.GetServiceSchema(origin.Service, origin.Version)
+	if err != nil {
+		return ""
+	}
+
+	actionSchema, err := schema.GetActionSchema(origin.Action)
+	if err != nil {
+		return ""
+	}
+
+	for _, tag := range actionSchema.GetTags() {
+		if strings.HasPrefix(tag, CacheControlTagPrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(tag, CacheControlTagPrefix))
+		}
+	}
+	return ""
+}
+
+// ApplyHTTPCaching sets the caching headers of the HTTP response and evaluates the request conditions.
+//
+// The "ETag" header is computed from the response body, the "Cache-Control" header is set when
+// there is a value for the response, and the "Last-Modified" header is set when the last
+// modification time is not zero. Only successful responses are changed, and when the request
+// conditions are met the response is changed to a "304 Not Modified" response.
+// The result is true when the response is not modified.
+//
+// lastModified: The last modification time of the contents, or a zero time when it is not known.
+func (r *Response) ApplyHTTPCaching(lastModified time.Time) bool {
+	response := r.GetHTTPResponse()
+	if response.GetStatusCode() != http.StatusOK {
+		return false
+	}
+
+	etag := response.SetETag()
+	if value := r.GetCacheControl(); value != "" {
+		response.SetHeader("Cache-Control", value, true)
+	}
+	if !lastModified.IsZero() {
+		response.SetLastModified(lastModified)
+	}
+
+	if !r.GetHTTPRequest().IsNotModified(etag, lastModified) {
+		return false
+	}
+
+	response.SetNotModified()
+	return true
+}
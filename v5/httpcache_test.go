@@ -0,0 +1,216 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a middleware response for the HTTP caching tests.
+func newCacheTestResponse(method string, headers http.Header, tags []string) *Response {
+	transport := &payload.Transport{}
+	transport.Meta.Origin = []string{"users", "1.0.0", "read"}
+
+	s := newTestState("gateway", "1.0.0", "response", transport)
+	s.command = payload.NewCommand("response", "middleware")
+	s.command.Command.Arguments = &payload.CommandArguments{
+		Request:   &payload.HTTPRequest{Method: method, URL: "http://example.com/users/1", Headers: headers},
+		Response:  &payload.HTTPResponse{Status: "200 OK", Headers: http.Header{}, Body: []byte(`{"id":1}`)},
+		Transport: transport,
+	}
+	s.reply = payload.NewResponseReply(&s.command)
+	s.schemas = payload.Mapping{"users": {"1.0.0": payload.Schema{
+		Actions: map[string]payload.ActionSchema{"read": {Tags: tags}},
+	}}}
+	return newResponse(NewMiddleware(), s)
+}
+
+func TestComputeETag(t *testing.T) {
+	etag := ComputeETag([]byte("kusanagi"), false)
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) || len(etag) != etagHashSize*2+2 {
+		t.Errorf("expected a quoted hash, got %s", etag)
+	}
+
+	if etag != ComputeETag([]byte("kusanagi"), false) {
+		t.Error("expected the same tag for the same contents")
+	}
+
+	if etag == ComputeETag([]byte("other"), false) {
+		t.Error("expected a different tag for different contents")
+	}
+
+	if weak := ComputeETag([]byte("kusanagi"), true); weak != weakETagPrefix+etag {
+		t.Errorf("expected a weak tag, got %s", weak)
+	}
+}
+
+func TestMatchETag(t *testing.T) {
+	cases := []struct {
+		header string
+		etag   string
+		match  bool
+	}{
+		{`"a"`, `"a"`, true},
+		{`"b"`, `"a"`, false},
+		{`"b", "a"`, `"a"`, true},
+		{`W/"a"`, `"a"`, true},
+		{`"a"`, `W/"a"`, true},
+		{`*`, `"a"`, true},
+		{`"ab"`, `"a"`, false},
+	}
+
+	for _, c := range cases {
+		if match := matchETag(c.header, c.etag); match != c.match {
+			t.Errorf("expected %v for %s and %s, got %v", c.match, c.header, c.etag, match)
+		}
+	}
+}
+
+func TestHTTPRequestIsNotModified(t *testing.T) {
+	modified := time.Date(2023, 1, 2, 10, 30, 15, 500, time.UTC)
+	etag := `"a"`
+
+	cases := []struct {
+		name     string
+		method   string
+		headers  http.Header
+		modified time.Time
+		expected bool
+	}{
+		{"no conditions", http.MethodGet, http.Header{}, modified, false},
+		{"matching tag", http.MethodGet, http.Header{"If-None-Match": {`"a"`}}, modified, true},
+		{"matching tag with HEAD", http.MethodHead, http.Header{"If-None-Match": {`"a"`}}, modified, true},
+		{"matching tag with POST", http.MethodPost, http.Header{"If-None-Match": {`"a"`}}, modified, false},
+		{"different tag", http.MethodGet, http.Header{"If-None-Match": {`"b"`}}, modified, false},
+		{
+			"tag has precedence over the date",
+			http.MethodGet,
+			http.Header{"If-None-Match": {`"b"`}, "If-Modified-Since": {modified.Format(http.TimeFormat)}},
+			modified,
+			false,
+		},
+		{"same date", http.MethodGet, http.Header{"If-Modified-Since": {modified.Format(http.TimeFormat)}}, modified, true},
+		{
+			"later date",
+			http.MethodGet,
+			http.Header{"If-Modified-Since": {modified.Add(time.Hour).Format(http.TimeFormat)}},
+			modified,
+			true,
+		},
+		{
+			"earlier date",
+			http.MethodGet,
+			http.Header{"If-Modified-Since": {modified.Add(-time.Hour).Format(http.TimeFormat)}},
+			modified,
+			false,
+		},
+		{"unknown modification time", http.MethodGet, http.Header{"If-Modified-Since": {modified.Format(http.TimeFormat)}}, time.Time{}, false},
+		{"invalid date", http.MethodGet, http.Header{"If-Modified-Since": {"yesterday"}}, modified, false},
+	}
+
+	for _, c := range cases {
+		r := newHTTPRequest(&payload.HTTPRequest{Method: c.method, Headers: c.headers})
+		if result := r.IsNotModified(etag, c.modified); result != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, result)
+		}
+	}
+}
+
+func TestHTTPResponseSetETag(t *testing.T) {
+	r := newHTTPResponse(&payload.HTTPResponse{Status: "200 OK", Body: []byte("kusanagi")})
+	etag := r.SetETag()
+	if etag != ComputeETag([]byte("kusanagi"), false) || r.GetHeader("ETag", "") != etag {
+		t.Errorf("expected the tag of the body, got %s", etag)
+	}
+
+	// Existing tags are kept
+	r = newHTTPResponse(&payload.HTTPResponse{Headers: http.Header{"Etag": {`"custom"`}}})
+	if etag := r.SetETag(); etag != `"custom"` {
+		t.Errorf("expected the existing tag, got %s", etag)
+	}
+}
+
+func TestHTTPResponseSetNotModified(t *testing.T) {
+	r := newHTTPResponse(&payload.HTTPResponse{Status: "200 OK", Body: []byte("kusanagi")})
+	r.SetHeader("ETag", `"a"`, true)
+	r.SetNotModified()
+
+	if r.GetStatusCode() != http.StatusNotModified || len(r.GetBody()) != 0 {
+		t.Errorf("expected an empty 304 response, got %s", r.GetStatus())
+	}
+
+	if r.GetHeader("ETag", "") != `"a"` {
+		t.Error("expected the headers to be kept")
+	}
+}
+
+func TestResponseGetCacheControl(t *testing.T) {
+	r := newCacheTestResponse(http.MethodGet, http.Header{}, nil)
+	if value := r.GetCacheControl(); value != "" {
+		t.Errorf("expected no value, got %s", value)
+	}
+
+	r = newCacheTestResponse(http.MethodGet, http.Header{}, []string{"public", "cache-control: max-age=60"})
+	if value := r.GetCacheControl(); value != "max-age=60" {
+		t.Errorf("expected the value of the action tag, got %s", value)
+	}
+
+	// The transport property has precedence over the action tags
+	r.command.Command.Arguments.Transport.Meta.Properties = map[string]string{
+		PropertyName(CacheNamespace, CacheControlProperty): "no-store",
+	}
+	if value := r.GetCacheControl(); value != "no-store" {
+		t.Errorf("expected the value of the property, got %s", value)
+	}
+}
+
+func TestResponseApplyHTTPCaching(t *testing.T) {
+	modified := time.Date(2023, 1, 2, 10, 30, 15, 0, time.UTC)
+
+	r := newCacheTestResponse(http.MethodGet, http.Header{}, []string{"cache-control:max-age=60"})
+	if r.ApplyHTTPCaching(modified) {
+		t.Error("expected the response to be modified")
+	}
+
+	response := r.GetHTTPResponse()
+	etag := response.GetHeader("ETag", "")
+	if etag != ComputeETag([]byte(`{"id":1}`), false) {
+		t.Errorf("unexpected tag: %s", etag)
+	}
+	if value := response.GetHeader("Cache-Control", ""); value != "max-age=60" {
+		t.Errorf("unexpected cache control: %s", value)
+	}
+	if value := response.GetHeader("Last-Modified", ""); value != modified.Format(http.TimeFormat) {
+		t.Errorf("unexpected last modification time: %s", value)
+	}
+
+	// The conditional request gets a not modified response
+	r = newCacheTestResponse(http.MethodGet, http.Header{"If-None-Match": {etag}}, nil)
+	if !r.ApplyHTTPCaching(time.Time{}) {
+		t.Error("expected the response to be not modified")
+	}
+	if response := r.GetHTTPResponse(); response.GetStatusCode() != http.StatusNotModified || len(response.GetBody()) != 0 {
+		t.Errorf("expected an empty 304 response, got %s", response.GetStatus())
+	}
+
+	// Only successful responses are changed
+	r = newCacheTestResponse(http.MethodGet, http.Header{"If-None-Match": {etag}}, nil)
+	r.GetHTTPResponse().SetStatus(http.StatusNotFound, "Not Found")
+	if r.ApplyHTTPCaching(modified) {
+		t.Error("expected the error response to be kept")
+	}
+	if response := r.GetHTTPResponse(); response.GetHeader("ETag", "") != "" {
+		t.Error("expected no caching headers for the error response")
+	}
+}
@@ -0,0 +1,104 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+// Creates the HTTP request info for an action.
+//
+// The HTTP method and path are read from the HTTP schema of the origin action
+// when the schema of the origin service is available in the mapping.
+func newHTTPRequestInfo(a *Action) *HTTPRequestInfo {
+	t := a.transport
+	gateway := t.GetGateway()
+	origin := t.GetOrigin()
+	info := HTTPRequestInfo{
+		requestID: t.Meta.ID,
+		timestamp: t.Meta.Datetime,
+		gateway:   gateway[1],
+		origin:    origin,
+	}
+
+	if schema, err := a.GetServiceSchema(origin[0], origin[1]); err == nil {
+		if actionSchema, err := schema.GetActionSchema(origin[2]); err == nil {
+			http := actionSchema.GetHTTPSchema()
+			info.route = true
+			info.method = http.GetMethod()
+			info.path = getOpenAPIPath(schema.GetBasePath(), http.GetPath())
+		}
+	}
+	return &info
+}
+
+// HTTPRequestInfo contains read-only information about the HTTP request received by the gateway.
+//
+// The information is read from the transport meta that the gateway sends with the request.
+// HTTP headers are only available to the request middlewares, because the gateway doesn't
+// send them to the services.
+type HTTPRequestInfo struct {
+	requestID string
+	timestamp string
+	gateway   string
+	origin    []string
+	route     bool
+	method    string
+	path      string
+}
+
+// GetRequestID returns the UUID of the request.
+func (i HTTPRequestInfo) GetRequestID() string {
+	return i.requestID
+}
+
+// GetTimestamp returns the time when the gateway received the request.
+func (i HTTPRequestInfo) GetTimestamp() string {
+	return i.timestamp
+}
+
+// GetGatewayAddress returns the public address of the gateway that received the request.
+func (i HTTPRequestInfo) GetGatewayAddress() string {
+	return i.gateway
+}
+
+// GetOrigin returns the service that was the origin of the request.
+//
+// The result contains the name, version and action of the service.
+func (i HTTPRequestInfo) GetOrigin() []string {
+	return append([]string(nil), i.origin...)
+}
+
+// IsOrigin checks if the action is the one that the gateway resolved for the request.
+//
+// Actions called with run-time calls are not the origin of the request.
+//
+// a: The action to check.
+func (i HTTPRequestInfo) IsOrigin(a *Action) bool {
+	return i.origin[0] == a.GetName() && i.origin[1] == a.GetVersion() && i.origin[2] == a.GetActionName()
+}
+
+// HasRoute checks if the HTTP method and path of the request are available.
+//
+// The route is only available when the schema of the origin service is in the mapping.
+func (i HTTPRequestInfo) HasRoute() bool {
+	return i.route
+}
+
+// GetMethod returns the HTTP method resolved to the origin action.
+//
+// An empty string is returned when the route is not available.
+func (i HTTPRequestInfo) GetMethod() string {
+	return i.method
+}
+
+// GetPath returns the HTTP path resolved to the origin action.
+//
+// The path includes the base path of the service, and it contains
+// the placeholders for the path parameters of the action.
+// An empty string is returned when the route is not available.
+func (i HTTPRequestInfo) GetPath() string {
+	return i.path
+}
@@ -0,0 +1,58 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestActionGetHTTPRequestInfo(t *testing.T) {
+	transport := &payload.Transport{Meta: payload.TransportMeta{
+		ID:       "f1b27da9-c6a9-4c7b-9f0c-6f6a4c0e0b6d",
+		Datetime: "2023-01-01T00:00:00.000000+00:00",
+		Gateway:  []string{"ktp://127.0.0.1:77", "http://127.0.0.1:80"},
+		Origin:   []string{"users", "1.0.0", "read"},
+	}}
+	s := newTestState("users", "1.0.0", "read", transport)
+	s.schemas = payload.Mapping{"users": {"1.0.0": payload.Schema{
+		HTTP:    payload.HTTPSchema{BasePath: "/1.0.0"},
+		Actions: map[string]payload.ActionSchema{"read": {HTTP: payload.HTTPActionSchema{Path: "/users/{id}"}}},
+	}}}
+	a := newAction(NewService(), s)
+
+	info := a.GetHTTPRequestInfo()
+	if info.GetRequestID() != transport.Meta.ID || info.GetTimestamp() != transport.Meta.Datetime {
+		t.Errorf("expected the request ID and timestamp of the transport, got %+v", info)
+	}
+	if v := info.GetGatewayAddress(); v != "http://127.0.0.1:80" {
+		t.Errorf("expected the public gateway address, got %q", v)
+	}
+	if v := info.GetOrigin(); !reflect.DeepEqual(v, transport.Meta.Origin) || !info.IsOrigin(a) {
+		t.Errorf("expected the action to be the origin, got %v", v)
+	}
+	if !info.HasRoute() || info.GetMethod() != "GET" || info.GetPath() != "/1.0.0/users/{id}" {
+		t.Errorf("expected the route of the origin action, got %s %s", info.GetMethod(), info.GetPath())
+	}
+}
+
+func TestActionGetHTTPRequestInfoWithoutOriginSchema(t *testing.T) {
+	transport := &payload.Transport{Meta: payload.TransportMeta{Origin: []string{"posts", "1.0.0", "list"}}}
+	a := newAction(NewService(), newTestState("users", "1.0.0", "read", transport))
+
+	info := a.GetHTTPRequestInfo()
+	if info.IsOrigin(a) {
+		t.Error("expected the action not to be the origin")
+	}
+	if info.HasRoute() || info.GetMethod() != "" || info.GetPath() != "" {
+		t.Errorf("expected no route, got %s %s", info.GetMethod(), info.GetPath())
+	}
+}
@@ -0,0 +1,304 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// File extension of the journal entries that are being written.
+const journalTempExt = ".tmp"
+
+// File extension of the journal entries that can't be processed.
+const journalInvalidExt = ".invalid"
+
+// Maximum number of times a journal entry is processed when the component starts.
+//
+// Entries that are still in the journal after the last attempt made the component
+// crash each time, so they are renamed instead of being processed again.
+const journalMaxAttempts = 3
+
+// JournalSyncVariable is the name of the component variable that enables flushing
+// the journal changes to the storage device.
+//
+// By default each change is flushed, so the requests are recovered after a power failure.
+// When the value is false the changes are only written, which is faster and is enough to
+// recover the requests after a crash of the component process.
+const JournalSyncVariable = "journal-sync"
+
+// Check if the journal changes must be flushed to the storage device.
+func isJournalSync(input cli.Input) bool {
+	sync, err := strconv.ParseBool(input.GetVariable(JournalSyncVariable))
+	if err != nil {
+		return true
+	}
+	return sync
+}
+
+// Creates a new request journal.
+//
+// The directory is created when it doesn't exist.
+//
+// directory: The directory where the requests are saved.
+// sync: Enables flushing the journal changes to the storage device.
+func newRequestJournal(directory string, sync bool) (*requestJournal, error) {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf(`Failed to create the journal directory "%s": %v`, directory, err)
+	}
+	return &requestJournal{directory, sync}, nil
+}
+
+// Request journal saves the accepted requests until they are processed.
+//
+// Each request is saved in a file with the same format as the msgpack recorded requests,
+// and the file is removed when the request is processed. The requests that are in the
+// journal when the component starts were not processed because the component crashed,
+// so they are processed again before the component starts listening for requests.
+//
+// The delivery is at-least-once: a request that was processed before the crash but
+// not removed from the journal is processed again, so the action callbacks must
+// be idempotent. The responses of the recovered requests are discarded because
+// the clients that sent them are no longer waiting for them.
+//
+// Entries are renamed with the number of the attempt before they are processed again,
+// so the entries that make the component crash are discarded after a few attempts.
+type requestJournal struct {
+	directory string
+	sync      bool
+}
+
+// Entry of the request journal.
+type journalEntry struct {
+	// Path to the entry file
+	path string
+	// Number of times the entry was processed when the component started
+	attempts int
+}
+
+// Write a file and optionally flush it to the storage device.
+func writeJournalFile(path string, data []byte, sync bool) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if sync {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// Flush the directory entries to the storage device, so the renamed files are kept after a crash.
+func syncDir(directory string) error {
+	d, err := os.Open(directory)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// Save a request message.
+//
+// The result is the path to the journal entry.
+//
+// msg: The request message.
+// format: The serialization format of the request payloads.
+// schemas: The mapping used by the request.
+func (j *requestJournal) add(msg requestMsg, format wireFormat, schemas payload.Mapping) (string, error) {
+	// The mapping is only sent when it changes so the current mapping is saved with each request
+	mapping := msg.getSchemas()
+	if mapping == nil && schemas != nil {
+		var err error
+		if mapping, err = format.encode(schemas); err != nil {
+			return "", fmt.Errorf("Failed to serialize the journal schemas: %v", err)
+		}
+	}
+
+	data, err := encodeRecordedRequest(msg, mapping)
+	if err != nil {
+		return "", err
+	}
+
+	// The entry is renamed after it is written so partial entries are never processed
+	path := filepath.Join(j.directory, fmt.Sprintf("%d-%s%s", time.Now().UnixNano(), msg.getRequestID(), recordMsgpackExt))
+	if err := writeJournalFile(path+journalTempExt, data, j.sync); err != nil {
+		return "", fmt.Errorf("Failed to save the journal entry: %v", err)
+	}
+
+	if err := os.Rename(path+journalTempExt, path); err != nil {
+		return "", fmt.Errorf("Failed to save the journal entry: %v", err)
+	}
+
+	if err := j.syncDir(); err != nil {
+		return "", fmt.Errorf("Failed to save the journal entry: %v", err)
+	}
+	return path, nil
+}
+
+// Flush the journal directory entries to the storage device when sync is enabled.
+func (j *requestJournal) syncDir() error {
+	if !j.sync {
+		return nil
+	}
+	return syncDir(j.directory)
+}
+
+// Rename a journal entry and flush the change to the storage device.
+//
+// entry: The journal entry.
+// path: The new path to the journal entry.
+func (j *requestJournal) rename(entry journalEntry, path string) error {
+	if err := os.Rename(entry.path, path); err != nil {
+		return err
+	}
+	return j.syncDir()
+}
+
+// Mark a journal entry before it is processed again.
+//
+// The entry is renamed with the number of the attempt, and the result is the path to the entry.
+//
+// entry: The journal entry.
+func (j *requestJournal) attempt(entry journalEntry) (string, error) {
+	base := strings.TrimSuffix(entry.path, filepath.Ext(entry.path))
+	if entry.attempts > 0 {
+		base = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+
+	path := fmt.Sprintf("%s%s.%d", base, recordMsgpackExt, entry.attempts+1)
+	if err := j.rename(entry, path); err != nil {
+		return "", fmt.Errorf("Failed to mark the journal entry: %v", err)
+	}
+	return path, nil
+}
+
+// Discard a journal entry that can't be processed.
+//
+// entry: The journal entry.
+func (j *requestJournal) discard(entry journalEntry) error {
+	if err := j.rename(entry, entry.path+journalInvalidExt); err != nil {
+		return fmt.Errorf("Failed to discard the journal entry: %v", err)
+	}
+	return nil
+}
+
+// Remove a journal entry.
+//
+// path: The path to the journal entry.
+func (j *requestJournal) remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed to remove the journal entry: %v", err)
+	}
+	return nil
+}
+
+// Get the journal entries in the order the requests were received.
+//
+// The entries that were processed when the component started have the number of the attempt as extension.
+func (j *requestJournal) pending() ([]journalEntry, error) {
+	entries, err := os.ReadDir(j.directory)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the journal directory: %v", err)
+	}
+
+	var pending []journalEntry
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		if strings.HasSuffix(name, recordMsgpackExt) {
+			pending = append(pending, journalEntry{filepath.Join(j.directory, name), 0})
+		} else if ext := filepath.Ext(name); strings.HasSuffix(strings.TrimSuffix(name, ext), recordMsgpackExt) {
+			if attempts, err := strconv.Atoi(ext[1:]); err == nil && attempts > 0 {
+				pending = append(pending, journalEntry{filepath.Join(j.directory, name), attempts})
+			}
+		}
+	}
+	return pending, nil
+}
+
+// Process the requests that were left in the journal by a previous run of the component.
+//
+// The entries that can't be read, or that were processed too many times, are renamed
+// so they are not processed again. The responses of the processed requests are discarded.
+func (s *server) recoverJournal() error {
+	entries, err := s.journal.pending()
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	log.Server.Warningf("Processing %d requests left in the journal", len(entries))
+
+	// The recovered requests are already in the journal and they were verified when they were accepted
+	j, verifier := s.journal, s.verifier
+	s.journal, s.verifier = nil, nil
+	defer func() {
+		s.journal, s.verifier = j, verifier
+	}()
+
+	for _, entry := range entries {
+		if entry.attempts >= journalMaxAttempts {
+			log.Server.Errorf(`Discarding the journal entry "%s" after %d attempts`, entry.path, entry.attempts)
+			if err := j.discard(entry); err != nil {
+				return err
+			}
+			continue
+		}
+
+		msg, err := readRecordedRequest(entry.path)
+		if err != nil {
+			log.Server.Errorf("Failed to recover the journal entry: %v", err)
+			if err := j.discard(entry); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// The entry is marked before it is processed so the attempts are counted when the component crashes
+		path, err := j.attempt(entry)
+		if err != nil {
+			return err
+		}
+
+		// There is no client waiting for the response, so only the errors are logged
+		log.Server.Infof(`Processing journal request "%s" for action: "%s"`, msg.getRequestID(), msg.getAction())
+		if _, err := s.process(msg); err != nil {
+			log.Server.Errorf(`Failed to process journal request "%s": %v`, msg.getRequestID(), err)
+		}
+
+		if err := j.remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a request message for the journal tests.
+func newJournalTestMsg(t *testing.T, rid string) requestMsg {
+	t.Helper()
+
+	command := payload.NewCommand("read", "service")
+	command.Command.Arguments = &payload.CommandArguments{Transport: &payload.Transport{
+		Meta: payload.TransportMeta{Gateway: []string{"ktp://internal", "http://public"}},
+	}}
+	message, err := formatMsgpack.encode(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return requestMsg{{}, {}, {}, []byte(rid), []byte("read"), nil, message, msgpackFormatFlag}
+}
+
+// Create a service server with a journal for the tests.
+//
+// The action callback receives the names of the files in the journal directory.
+func newJournalTestServer(t *testing.T, callback func(files []string)) *server {
+	t.Helper()
+
+	journal, err := newRequestJournal(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewService()
+	service.Action("read", func(a *Action) (*Action, error) {
+		callback(readJournalDir(t, journal.directory))
+		return a, nil
+	})
+
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	s := newServer(input, service.base(), service.processor)
+	s.journal = journal
+	return s
+}
+
+// Get the sorted names of the files in a journal directory.
+func readJournalDir(t *testing.T, directory string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRequestJournalAdd(t *testing.T) {
+	// The entries are saved the same way when the changes are not flushed
+	for _, sync := range []bool{true, false} {
+		j, err := newRequestJournal(filepath.Join(t.TempDir(), "journal"), sync)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path, err := j.add(newJournalTestMsg(t, "rid-1"), formatMsgpack, payload.Mapping{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The temporary file is renamed once it is written
+		if names := readJournalDir(t, j.directory); len(names) != 1 || names[0] != filepath.Base(path) {
+			t.Fatalf("sync %v: expected the journal entry, got %v", sync, names)
+		}
+
+		msg, err := readRecordedRequest(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.getRequestID() != "rid-1" || msg.getAction() != "read" {
+			t.Errorf("sync %v: unexpected journal request: %s %s", sync, msg.getRequestID(), msg.getAction())
+		}
+
+		if err := j.remove(path); err != nil {
+			t.Fatal(err)
+		}
+		if names := readJournalDir(t, j.directory); len(names) != 0 {
+			t.Errorf("sync %v: expected the entry to be removed, got %v", sync, names)
+		}
+	}
+}
+
+func TestJournalSyncVariable(t *testing.T) {
+	if !isJournalSync(cli.Input{}) {
+		t.Error("expected the journal to be synced by default")
+	}
+
+	setTestVariable(t, JournalSyncVariable, "false")
+	if isJournalSync(cli.Input{}) {
+		t.Error("expected the journal sync to be disabled")
+	}
+}
+
+func TestRequestJournalPending(t *testing.T) {
+	j, err := newRequestJournal(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{
+		"1-a.msgpack",
+		"2-b.msgpack.2",
+		"3-c.msgpack.tmp",
+		"4-d.msgpack.invalid",
+		"5-e.msgpack.x",
+		"6-f.msgpack.1",
+		"7-g.json",
+	} {
+		if err := os.WriteFile(filepath.Join(j.directory, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := j.pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []journalEntry{
+		{filepath.Join(j.directory, "1-a.msgpack"), 0},
+		{filepath.Join(j.directory, "2-b.msgpack.2"), 2},
+		{filepath.Join(j.directory, "6-f.msgpack.1"), 1},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, entries)
+	}
+	for i, entry := range entries {
+		if entry != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], entry)
+		}
+	}
+
+	// The attempts replace the previous attempt number
+	path, err := j.attempt(entries[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "2-b.msgpack.3" {
+		t.Errorf("unexpected entry name: %s", path)
+	}
+}
+
+func TestServerRecoverJournal(t *testing.T) {
+	var processing [][]string
+	s := newJournalTestServer(t, func(files []string) {
+		processing = append(processing, files)
+	})
+	j := s.journal
+
+	if _, err := j.add(newJournalTestMsg(t, "rid-1"), formatMsgpack, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entry processed in the previous attempt is processed again
+	path, err := j.add(newJournalTestMsg(t, "rid-2"), formatMsgpack, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entry that made the component crash in every attempt is discarded
+	path, err = j.add(newJournalTestMsg(t, "rid-3"), formatMsgpack, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, path+".3"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entry that can't be read is discarded
+	if err := os.WriteFile(filepath.Join(j.directory, "9-invalid.msgpack"), []byte("invalid"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.recoverJournal(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(processing) != 2 {
+		t.Fatalf("expected 2 requests to be processed, got %d", len(processing))
+	}
+
+	// The entries are marked with the attempt before they are processed
+	if files := processing[0]; !hasJournalFile(files, "-rid-1.msgpack.1") {
+		t.Errorf("expected the first entry to be marked, got %v", files)
+	}
+	if files := processing[1]; !hasJournalFile(files, "-rid-2.msgpack.2") {
+		t.Errorf("expected the second entry to be marked, got %v", files)
+	}
+
+	files := readJournalDir(t, j.directory)
+	if len(files) != 2 || !hasJournalFile(files, "-rid-3.msgpack.3.invalid") || files[1] != "9-invalid.msgpack.invalid" {
+		t.Errorf("expected only the discarded entries, got %v", files)
+	}
+
+	if s.journal != j {
+		t.Error("expected the journal to be restored")
+	}
+}
+
+// Check if a journal file name ends with a suffix.
+func hasJournalFile(files []string, suffix string) bool {
+	for _, name := range files {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Prefix for the environment variables that set the CLI options.
+const envPrefix = "KUSANAGI_"
+
+// Prefix for the environment variables that set the component variables.
+const envVarPrefix = envPrefix + "VAR_"
+
+// Get the name of the environment variable for a CLI option.
+//
+// The name is the option name in upper case with the "-" replaced by "_",
+// so for example the "log-level" option uses the "KUSANAGI_LOG_LEVEL" variable.
+func getEnvName(name string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// Set the values of the CLI options that are not given as arguments using environment variables.
+//
+// The CLI arguments have precedence over the environment variables, which have precedence
+// over the default values. Component variables are set using one environment variable
+// for each variable, where the name of the component variable follows the "KUSANAGI_VAR_"
+// prefix, for example "KUSANAGI_VAR_rate-limit=100". The component variables given as
+// CLI arguments have precedence over the ones set using environment variables.
+func parseEnv() error {
+	// Get the names of the options given as arguments
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	for _, o := range options {
+		// Component variables use one environment variable per component variable
+		if given[o.name] || given[o.shortName] || o.name == "var" {
+			continue
+		}
+
+		value, exists := os.LookupEnv(getEnvName(o.name))
+		if !exists {
+			continue
+		}
+
+		if err := flag.Set(o.name, value); err != nil {
+			return fmt.Errorf(`invalid value for environment variable "%s"`, getEnvName(o.name))
+		}
+	}
+
+	// Set the custom options that are not given as arguments
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		if err != nil || given[f.Name] || isOption(f.Name) {
+			return
+		}
+
+		if value, exists := os.LookupEnv(getEnvName(f.Name)); exists {
+			if flag.Set(f.Name, value) != nil {
+				err = fmt.Errorf(`invalid value for environment variable "%s"`, getEnvName(f.Name))
+			}
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Add the component variables that are not given as arguments
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, envVarPrefix) {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(env, envVarPrefix), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+
+		if _, exists := vars[parts[0]]; !exists {
+			vars[parts[0]] = parts[1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package cli
+
+import (
+	"flag"
+	"os"
+	"testing"
+)
+
+// Use a new flag set with some of the options for the environment tests.
+//
+// The options set by other tests would be considered as given arguments otherwise.
+// The new flag set shares the option values with the current one.
+func useTestFlags(t *testing.T, names ...string) {
+	t.Helper()
+
+	current := flag.CommandLine
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	for _, name := range names {
+		f := current.Lookup(name)
+		if f == nil {
+			t.Fatalf("option not found: %s", name)
+		}
+		flags.Var(f.Value, f.Name, f.Usage)
+	}
+
+	flag.CommandLine = flags
+	t.Cleanup(func() {
+		flag.CommandLine = current
+	})
+}
+
+func TestGetEnvName(t *testing.T) {
+	if name := getEnvName("log-level"); name != "KUSANAGI_LOG_LEVEL" {
+		t.Errorf("unexpected environment variable name: %s", name)
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	useTestFlags(t, "name", "log-buffer")
+	currentName, currentBuffer := *name, *logBuffer
+	t.Cleanup(func() {
+		*name, *logBuffer = currentName, currentBuffer
+		delete(vars, "test-limit")
+		delete(vars, "test-given")
+	})
+
+	// The options and variables given as arguments have precedence
+	if err := flag.Set("name", "cli-name"); err != nil {
+		t.Fatal(err)
+	}
+	vars["test-given"] = "cli"
+
+	t.Setenv("KUSANAGI_NAME", "env-name")
+	t.Setenv("KUSANAGI_LOG_BUFFER", "42")
+	t.Setenv("KUSANAGI_VAR_test-limit", "100")
+	t.Setenv("KUSANAGI_VAR_test-given", "env")
+
+	if err := parseEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	if *name != "cli-name" {
+		t.Errorf("expected the name argument, got %s", *name)
+	}
+	if *logBuffer != 42 {
+		t.Errorf("expected the log buffer from the environment, got %d", *logBuffer)
+	}
+	if value := vars["test-limit"]; value != "100" {
+		t.Errorf("expected the variable from the environment, got %q", value)
+	}
+	if value := vars["test-given"]; value != "cli" {
+		t.Errorf("expected the variable argument, got %q", value)
+	}
+}
+
+func TestParseEnvInvalid(t *testing.T) {
+	useTestFlags(t, "tcp")
+	t.Setenv("KUSANAGI_TCP", "invalid")
+	if err := parseEnv(); err == nil {
+		t.Error("expected an error for the invalid value")
+	}
+}
@@ -58,6 +58,46 @@ func PrintHelp(out io.Writer) {
 		fmt.Fprintln(w, option)
 	}
 	w.Flush()
+
+	// Add the custom options defined by the component
+	custom := false
+	flag.VisitAll(func(f *flag.Flag) {
+		if isOption(f.Name) {
+			return
+		}
+
+		if !custom {
+			fmt.Fprintf(out, "\ncomponent options:\n")
+			custom = true
+		}
+		fmt.Fprintf(w, "  --%s\t\t%s", f.Name, f.Usage)
+		if f.DefValue != "" {
+			fmt.Fprintf(w, " (default: %v)", f.DefValue)
+		}
+		fmt.Fprintln(w)
+	})
+	w.Flush()
+	fmt.Fprintf(out, "\noptions can also be set using KUSANAGI_* environment variables, for example KUSANAGI_LOG_LEVEL,\n")
+	fmt.Fprintf(out, "and component variables using KUSANAGI_VAR_NAME=VALUE. Options given as arguments take precedence.\n")
+}
+
+// Flags returns the flag set used to parse the CLI options.
+//
+// Custom options can be added to the flag set before the component runs, so they
+// are parsed together with the SDK options. Custom options can also be set using
+// KUSANAGI_* environment variables.
+func Flags() *flag.FlagSet {
+	return flag.CommandLine
+}
+
+// Check if a flag name belongs to an SDK option.
+func isOption(name string) bool {
+	for _, o := range options {
+		if o.name == name || o.shortName == name {
+			return true
+		}
+	}
+	return false
 }
 
 type keyValue map[string]string
@@ -0,0 +1,54 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Custom option registered for the flag tests.
+var testRegion = Flags().String("test-region", "eu", "Region for the tests")
+
+func TestCustomFlags(t *testing.T) {
+	useTestFlags(t, "name", "test-region")
+	t.Cleanup(func() {
+		Flags().Set("test-region", "eu")
+	})
+
+	input := Input{}
+	if !input.HasFlag("test-region") || input.GetFlag("test-region") != "eu" {
+		t.Errorf("expected the default value of the custom option, got %q", input.GetFlag("test-region"))
+	}
+
+	// SDK options are not custom options
+	if input.HasFlag("name") || input.GetFlag("name") != "" || input.HasFlag("missing") {
+		t.Error("expected only custom options")
+	}
+
+	// Custom options can be set using environment variables
+	t.Setenv("KUSANAGI_TEST_REGION", "us")
+	if err := parseEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if *testRegion != "us" {
+		t.Errorf("expected the value from the environment, got %q", *testRegion)
+	}
+}
+
+func TestPrintHelpCustomFlags(t *testing.T) {
+	var output bytes.Buffer
+	PrintHelp(&output)
+
+	help := output.String()
+	if !strings.Contains(help, "component options:") || !strings.Contains(help, "--test-region") {
+		t.Errorf("expected the custom options in the help, got %q", help)
+	}
+}
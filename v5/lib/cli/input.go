@@ -9,13 +9,24 @@
 package cli
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
 )
 
+// DefaultBindHost is the address where the TCP socket is bound by default.
+const DefaultBindHost = "127.0.0.1"
+
+// BindAllInterfaces is the bind host value to bind the TCP socket to all the interfaces.
+const BindAllInterfaces = "*"
+
 // List of CLI options.
 var address = stringOption(
 	"a", "address",
@@ -53,6 +64,18 @@ var logLevel = uintOption(
 	0,
 	false,
 )
+var logOutput = stringOption(
+	"o", "log-output",
+	"Log output as \"stdout\", \"syslog\", \"syslog+udp://HOST:PORT\", \"udp://HOST:PORT\" or \"unixgram:///PATH\"",
+	"",
+	false,
+)
+var logBuffer = uintOption(
+	"B", "log-buffer",
+	"Number of log lines to buffer so logging never blocks, discarding the oldest lines when the buffer is full",
+	0,
+	false,
+)
 var name = stringOption(
 	"n", "name",
 	"Component name",
@@ -71,6 +94,12 @@ var tcp = uintOption(
 	0,
 	false,
 )
+var bindHost = stringOption(
+	"b", "bind",
+	"IP address, including IPv6 addresses, network interface name or \"*\" to bind the TCP socket",
+	DefaultBindHost,
+	false,
+)
 var timeout = intOption(
 	"T", "timeout",
 	"Process execution timeout per request in milliseconds",
@@ -88,6 +117,79 @@ var vars = keyValueOption(
 	"Component variables",
 	false,
 )
+var varsFile = stringOption(
+	"F", "var-file",
+	"File with component variables as NAME=VALUE lines",
+	"",
+	false,
+)
+var workers = uintOption(
+	"W", "workers",
+	"Number of worker processes to handle requests, or 0 to handle them in a single process",
+	0,
+	false,
+)
+var workerAddress = stringOption(
+	"w", "worker-address",
+	"Internal address used by worker processes to receive requests",
+	"",
+	false,
+)
+var describe = boolOption(
+	"d", "describe",
+	"Print the component actions and their schemas as JSON and exit",
+	false,
+	false,
+)
+var mappingFile = stringOption(
+	"M", "mapping-file",
+	"JSON file with the service mappings used to print the action schemas in describe mode",
+	"",
+	false,
+)
+var record = stringOption(
+	"r", "record",
+	"Directory where the incoming request payloads are recorded",
+	"",
+	false,
+)
+var replay = stringOption(
+	"R", "replay",
+	"Recorded request file to process using the component callbacks and exit",
+	"",
+	false,
+)
+var journal = stringOption(
+	"j", "journal",
+	"Directory where the accepted requests are saved until they are processed, to process them again after a crash (at-least-once, the responses of the recovered requests are discarded)",
+	"",
+	false,
+)
+var openAPIAddress = stringOption(
+	"O", "openapi-address",
+	"Address as IP:PORT to serve the OpenAPI document generated from the mappings",
+	"",
+	false,
+)
+var pprofPort = uintOption(
+	"P", "pprof-port",
+	"Local TCP port to serve the profiling endpoints when the \"pprof\" variable is enabled",
+	0,
+	false,
+)
+var consolePort = uintOption(
+	"C", "console-port",
+	"Local TCP port to serve the debug console",
+	0,
+	false,
+)
+
+// Mutex to guard the component variables when they are reloaded.
+var varsMutex sync.RWMutex
+
+// Variables given as CLI arguments.
+// They are used as base values when the variables are reloaded from file.
+var cliVars = keyValue{}
 
 func init() {
 	// Don't print usage help on error
@@ -102,6 +204,18 @@ func newErrInvalid(name string) error {
 	return fmt.Errorf(`invalid value for option: "%s"`, name)
 }
 
+// Check that the TCP bind host is an IP address, a network interface name or "*".
+//
+// Host names are not valid because ZMQ can only bind to addresses and interfaces.
+func validateBindHost(host string) error {
+	if host == BindAllInterfaces || net.ParseIP(host) != nil {
+		return nil
+	} else if _, err := net.InterfaceByName(host); err == nil {
+		return nil
+	}
+	return fmt.Errorf(`invalid value for option "bind": "%s" is not an IP address or a network interface`, host)
+}
+
 // Parse processes and validates command line options.
 //
 // The result is an input object that allows access to the CLI option values.
@@ -113,6 +227,11 @@ func Parse() (Input, error) {
 		return input, err
 	}
 
+	// Set the options that are not given as arguments from the environment
+	if err := parseEnv(); err != nil {
+		return input, err
+	}
+
 	// Validate the option values when no help must be displayed
 	if *help {
 		PrintHelp(os.Stderr)
@@ -122,14 +241,17 @@ func Parse() (Input, error) {
 			return input, newErrRequired("component")
 		} else if v := *component; v != "service" && v != "middleware" {
 			return input, newErrInvalid("component")
-		} else if address == nil || *address == "" {
+		} else if !*describe && *replay == "" && (address == nil || *address == "") {
+			// The address and framework version are not used in describe and replay modes
 			return input, newErrRequired("address")
 		} else if name == nil || *name == "" {
 			return input, newErrRequired("name")
-		} else if frameworkVersion == nil || *frameworkVersion == "" {
+		} else if !*describe && *replay == "" && (frameworkVersion == nil || *frameworkVersion == "") {
 			return input, newErrRequired("framework-version")
 		} else if version == nil || *version == "" {
 			return input, newErrRequired("version")
+		} else if err := validateBindHost(input.GetBindHost()); err != nil {
+			return input, err
 		}
 	}
 
@@ -140,13 +262,98 @@ func Parse() (Input, error) {
 		path = os.Args[0]
 	}
 
+	// Keep the CLI variables to be able to reload the variables from file
+	for name, value := range vars {
+		cliVars[name] = value
+	}
+
 	input.path = path
+
+	// Load the initial variable values from file
+	if input.HasVariablesFile() {
+		if err := input.ReloadVariables(); err != nil {
+			return input, err
+		}
+	}
 	return input, nil
 }
 
+// Read the variables from a file.
+//
+// Each line in the file must have the NAME=VALUE format.
+// Empty lines and lines starting with "#" are ignored.
+func readVariablesFile(path string) (keyValue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open variables file: %v", err)
+	}
+	defer f.Close()
+
+	values := keyValue{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if err := values.Set(line); err != nil {
+			return nil, fmt.Errorf("invalid variable in line %d of variables file", n)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read variables file: %v", err)
+	}
+	return values, nil
+}
+
+// Identity overrides the component identity read from the CLI options.
+//
+// It is used to run more than one component in the same process.
+type Identity struct {
+	Component string
+	Name      string
+	Version   string
+	Socket    string
+	TCP       uint
+}
+
 // Input contains the CLI input values
 type Input struct {
-	path string
+	path     string
+	identity *Identity
+}
+
+// WithIdentity returns a copy of the input for a component with a different identity.
+//
+// The OpenAPI, profiling and console servers are disabled in the copy so they are only
+// started by the component that uses the CLI options, and the journal of the copy is
+// saved in a subdirectory named after the component type.
+//
+// identity: The component identity.
+func (i Input) WithIdentity(identity Identity) Input {
+	i.identity = &identity
+	return i
+}
+
+// HasFlag checks if a custom CLI option is defined.
+//
+// name: The option name.
+func (i Input) HasFlag(name string) bool {
+	return !isOption(name) && flag.Lookup(name) != nil
+}
+
+// GetFlag returns the value of a custom CLI option.
+//
+// An empty string is returned when the option is not defined.
+//
+// name: The option name.
+func (i Input) GetFlag(name string) string {
+	if !i.HasFlag(name) {
+		return ""
+	}
+	return flag.Lookup(name).Value.String()
 }
 
 // GetPath returns the path to the file being executed.
@@ -163,7 +370,9 @@ func (i Input) MustDisplayHelp() bool {
 
 // GetComponent returns the component type.
 func (i Input) GetComponent() string {
-	if component == nil {
+	if i.identity != nil {
+		return i.identity.Component
+	} else if component == nil {
 		return ""
 	}
 	return *component
@@ -171,7 +380,9 @@ func (i Input) GetComponent() string {
 
 // GetName returns the component name.
 func (i Input) GetName() string {
-	if name == nil {
+	if i.identity != nil {
+		return i.identity.Name
+	} else if name == nil {
 		return ""
 	}
 	return *name
@@ -179,7 +390,9 @@ func (i Input) GetName() string {
 
 // GetVersion returns the component version.
 func (i Input) GetVersion() string {
-	if version == nil {
+	if i.identity != nil {
+		return i.identity.Version
+	} else if version == nil {
 		return ""
 	}
 	return *version
@@ -200,12 +413,22 @@ func (i Input) GetFrameworkVersion() string {
 
 // GetTCP returns the port to use for TCP connections.
 func (i Input) GetTCP() uint {
-	if tcp == nil {
+	if i.identity != nil {
+		return i.identity.TCP
+	} else if tcp == nil {
 		return 0
 	}
 	return *tcp
 }
 
+// GetBindHost returns the IP address or network interface where the TCP socket is bound.
+func (i Input) GetBindHost() string {
+	if bindHost == nil || *bindHost == "" {
+		return DefaultBindHost
+	}
+	return *bindHost
+}
+
 // IsTCPEnabled checks if TCP connections should be used instead of IPC.
 func (i Input) IsTCPEnabled() bool {
 	return i.GetTCP() != 0
@@ -221,7 +444,11 @@ func (i Input) GetComponentAddress() string {
 
 // GetSocket returns the ZMQ socket name.
 func (i Input) GetSocket() string {
-	if socket == nil || i.IsTCPEnabled() {
+	if i.IsTCPEnabled() {
+		return ""
+	} else if i.identity != nil {
+		return i.identity.Socket
+	} else if socket == nil {
 		return ""
 	}
 	return *socket
@@ -244,6 +471,9 @@ func (i Input) IsDebugEnabled() bool {
 //
 // name: The name of the variable.
 func (i Input) HasVariable(name string) bool {
+	varsMutex.RLock()
+	defer varsMutex.RUnlock()
+
 	_, exists := vars[name]
 	return exists
 }
@@ -252,11 +482,17 @@ func (i Input) HasVariable(name string) bool {
 //
 // name: The name of the variable.
 func (i Input) GetVariable(name string) string {
+	varsMutex.RLock()
+	defer varsMutex.RUnlock()
+
 	return vars[name]
 }
 
 // GetVariables returns all the engine variables.
 func (i Input) GetVariables() map[string]string {
+	varsMutex.RLock()
+	defer varsMutex.RUnlock()
+
 	variables := make(map[string]string)
 	if vars != nil {
 		for name, value := range vars {
@@ -266,11 +502,178 @@ func (i Input) GetVariables() map[string]string {
 	return variables
 }
 
+// HasVariablesFile checks if a file with component variables is defined.
+func (i Input) HasVariablesFile() bool {
+	return i.GetVariablesFile() != ""
+}
+
+// GetVariablesFile returns the path to the file with the component variables.
+func (i Input) GetVariablesFile() string {
+	if varsFile == nil {
+		return ""
+	}
+	return *varsFile
+}
+
+// ReloadVariables reads the variable values from the variables file.
+//
+// Values in the file take precedence over the values given as CLI arguments.
+// Variables are not changed when the file can't be read.
+func (i Input) ReloadVariables() error {
+	values, err := readVariablesFile(i.GetVariablesFile())
+	if err != nil {
+		return err
+	}
+
+	varsMutex.Lock()
+	defer varsMutex.Unlock()
+
+	// Remove the current variables and use the CLI values as base
+	for name := range vars {
+		delete(vars, name)
+	}
+	for name, value := range cliVars {
+		vars[name] = value
+	}
+	for name, value := range values {
+		vars[name] = value
+	}
+	return nil
+}
+
 // HasLogging checks if logging is enabled.
 func (i Input) HasLogging() bool {
 	return logLevel != nil
 }
 
+// GetLogOutput returns the output where the log messages are written.
+func (i Input) GetLogOutput() string {
+	if logOutput == nil {
+		return ""
+	}
+	return *logOutput
+}
+
+// GetLogBuffer returns the number of log lines to buffer before they are written to the output.
+func (i Input) GetLogBuffer() uint {
+	if logBuffer == nil {
+		return 0
+	}
+	return *logBuffer
+}
+
+// GetWorkers returns the number of worker processes to handle requests.
+//
+// Requests are handled in a single process when the number of workers is zero.
+func (i Input) GetWorkers() uint {
+	if workers == nil {
+		return 0
+	}
+	return *workers
+}
+
+// GetWorkerAddress returns the internal address used by a worker process to receive requests.
+func (i Input) GetWorkerAddress() string {
+	if workerAddress == nil {
+		return ""
+	}
+	return *workerAddress
+}
+
+// IsWorker checks if the current process is a worker process started by a supervisor.
+func (i Input) IsWorker() bool {
+	return i.GetWorkerAddress() != ""
+}
+
+// IsDescribeEnabled checks if the component must print its description instead of running.
+func (i Input) IsDescribeEnabled() bool {
+	if describe == nil {
+		return false
+	}
+	return *describe
+}
+
+// GetMappingFile returns the path to the JSON file with the service mappings.
+func (i Input) GetMappingFile() string {
+	if mappingFile == nil {
+		return ""
+	}
+	return *mappingFile
+}
+
+// GetRecordDirectory returns the directory where the incoming request payloads are recorded.
+func (i Input) GetRecordDirectory() string {
+	if record == nil {
+		return ""
+	}
+	return *record
+}
+
+// IsRecordEnabled checks if the incoming request payloads must be recorded.
+func (i Input) IsRecordEnabled() bool {
+	return i.GetRecordDirectory() != ""
+}
+
+// GetReplayFile returns the path to the recorded request to replay.
+func (i Input) GetReplayFile() string {
+	if replay == nil {
+		return ""
+	}
+	return *replay
+}
+
+// IsReplayEnabled checks if a recorded request must be replayed instead of running the component.
+func (i Input) IsReplayEnabled() bool {
+	return i.GetReplayFile() != ""
+}
+
+// GetJournalDirectory returns the directory where the accepted requests are saved until they are processed.
+func (i Input) GetJournalDirectory() string {
+	if journal == nil || *journal == "" {
+		return ""
+	} else if i.identity != nil {
+		return filepath.Join(*journal, i.identity.Component)
+	}
+	return *journal
+}
+
+// IsJournalEnabled checks if the accepted requests must be saved until they are processed.
+//
+// The requests left in the journal are processed again when the component starts, so a
+// request can be processed more than once, and the responses of these requests are discarded.
+func (i Input) IsJournalEnabled() bool {
+	return i.GetJournalDirectory() != ""
+}
+
+// GetOpenAPIAddress returns the address where the OpenAPI document is served.
+func (i Input) GetOpenAPIAddress() string {
+	if openAPIAddress == nil || i.identity != nil {
+		return ""
+	}
+	return *openAPIAddress
+}
+
+// IsOpenAPIEnabled checks if the OpenAPI document must be served.
+func (i Input) IsOpenAPIEnabled() bool {
+	return i.GetOpenAPIAddress() != ""
+}
+
+// GetPprofPort returns the local TCP port where the profiling endpoints are served.
+func (i Input) GetPprofPort() uint {
+	if pprofPort == nil || i.identity != nil {
+		return 0
+	}
+	return *pprofPort
+}
+
+// GetConsolePort returns the local TCP port where the debug console is served.
+func (i Input) GetConsolePort() uint {
+	if consolePort == nil || i.identity != nil {
+		return 0
+	}
+	return *consolePort
+}
+
 // GetLogLevel returns the log level.
 //
 // The INFO level is returned when no log level is defined.
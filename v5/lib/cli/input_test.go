@@ -0,0 +1,129 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package cli
+
+import (
+	"flag"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Write a variables file for the input tests.
+func writeTestVariablesFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "vars")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadVariablesFile(t *testing.T) {
+	path := writeTestVariablesFile(t, "# Comment\n\nlimit=10\n  url=http://a?b=c\n")
+	values, err := readVariablesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := keyValue{"limit": "10", "url": "http://a?b=c"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	if _, err := readVariablesFile(writeTestVariablesFile(t, "limit=10\ninvalid\n")); err == nil {
+		t.Error("expected an error for the invalid line")
+	}
+	if _, err := readVariablesFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for the missing file")
+	}
+}
+
+func TestInputReloadVariables(t *testing.T) {
+	currentVars, currentCLIVars, currentFile := keyValue{}, cliVars, *varsFile
+	for name, value := range vars {
+		currentVars[name] = value
+	}
+	t.Cleanup(func() {
+		for name := range vars {
+			delete(vars, name)
+		}
+		for name, value := range currentVars {
+			vars[name] = value
+		}
+		cliVars, *varsFile = currentCLIVars, currentFile
+	})
+
+	cliVars = keyValue{"limit": "1", "name": "cli"}
+	*varsFile = writeTestVariablesFile(t, "limit=10\n")
+	vars["removed"] = "true"
+
+	// The file values have precedence over the CLI values
+	input := Input{}
+	if err := input.ReloadVariables(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(input.GetVariables(), map[string]string{"limit": "10", "name": "cli"}) {
+		t.Errorf("unexpected variables: %v", input.GetVariables())
+	}
+
+	// The variables don't change when the file can't be read
+	*varsFile = filepath.Join(t.TempDir(), "missing")
+	if err := input.ReloadVariables(); err == nil {
+		t.Error("expected an error for the missing file")
+	}
+	if value := input.GetVariable("limit"); value != "10" {
+		t.Errorf("expected the variables not to change, got %q", value)
+	}
+}
+
+func TestValidateBindHost(t *testing.T) {
+	hosts := []string{BindAllInterfaces, DefaultBindHost, "0.0.0.0", "::1", "::"}
+	if interfaces, err := net.Interfaces(); err == nil && len(interfaces) > 0 {
+		hosts = append(hosts, interfaces[0].Name)
+	}
+
+	for _, host := range hosts {
+		if err := validateBindHost(host); err != nil {
+			t.Errorf("%s: expected no error, got %v", host, err)
+		}
+	}
+
+	// Host names can't be used to bind the socket
+	for _, host := range []string{"", "localhost", "example.com", "[::1]", "300.0.0.1"} {
+		if err := validateBindHost(host); err == nil {
+			t.Errorf("%q: expected an error", host)
+		}
+	}
+}
+
+func TestInputWorkers(t *testing.T) {
+	t.Cleanup(func() {
+		flag.Set("workers", "0")
+		flag.Set("worker-address", "")
+	})
+
+	input := Input{}
+	if input.GetWorkers() != 0 || input.IsWorker() {
+		t.Error("expected a single process by default")
+	}
+
+	if err := flag.Set("workers", "4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := flag.Set("worker-address", "ipc://@kusanagi-test-workers"); err != nil {
+		t.Fatal(err)
+	}
+	if input.GetWorkers() != 4 || !input.IsWorker() || input.GetWorkerAddress() != "ipc://@kusanagi-test-workers" {
+		t.Errorf("unexpected worker options: %d %q", input.GetWorkers(), input.GetWorkerAddress())
+	}
+}
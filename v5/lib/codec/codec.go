@@ -0,0 +1,93 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package codec
+
+import (
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/json"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+)
+
+// Codec serializes and deserializes the payloads.
+type Codec interface {
+	// Name returns the name of the serialization format.
+	Name() string
+
+	// Encode serializes a value.
+	Encode(v interface{}) ([]byte, error)
+
+	// Decode deserializes a value.
+	Decode(b []byte, v interface{}) error
+}
+
+// CanonicalCodec is a codec that can serialize values with the map keys sorted.
+//
+// The result of the canonical serialization is the same for equal values,
+// which allows to hash or sign the serialized payloads.
+type CanonicalCodec interface {
+	Codec
+
+	// EncodeCanonical serializes a value with the map keys sorted.
+	EncodeCanonical(v interface{}) ([]byte, error)
+}
+
+// EncodeCanonical serializes a value with the map keys sorted when the codec supports it.
+//
+// The value is serialized using the codec Encode method when the codec is not canonical.
+func EncodeCanonical(c Codec, v interface{}) ([]byte, error) {
+	if cc, ok := c.(CanonicalCodec); ok {
+		return cc.EncodeCanonical(v)
+	}
+	return c.Encode(v)
+}
+
+// Msgpack is the msgpack codec.
+type Msgpack struct{}
+
+// Name returns the name of the serialization format.
+func (Msgpack) Name() string {
+	return "msgpack"
+}
+
+// Encode serializes a value as msgpack.
+func (Msgpack) Encode(v interface{}) ([]byte, error) {
+	return msgpack.Encode(v)
+}
+
+// EncodeCanonical serializes a value as msgpack with the map keys sorted.
+func (Msgpack) EncodeCanonical(v interface{}) ([]byte, error) {
+	return msgpack.EncodeCanonical(v)
+}
+
+// Decode deserializes a msgpack value.
+func (Msgpack) Decode(b []byte, v interface{}) error {
+	return msgpack.Decode(b, v)
+}
+
+// JSON is the JSON codec.
+type JSON struct{}
+
+// Name returns the name of the serialization format.
+func (JSON) Name() string {
+	return "json"
+}
+
+// Encode serializes a value as JSON.
+func (JSON) Encode(v interface{}) ([]byte, error) {
+	return json.Encode(v)
+}
+
+// EncodeCanonical serializes a value as JSON with the map keys sorted.
+func (JSON) EncodeCanonical(v interface{}) ([]byte, error) {
+	return json.EncodeCanonical(v)
+}
+
+// Decode deserializes a JSON value.
+func (JSON) Decode(b []byte, v interface{}) error {
+	return json.Decode(b, v)
+}
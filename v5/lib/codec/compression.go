@@ -0,0 +1,190 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package codec
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// CompressedMarker is the first byte of the compressed payloads.
+//
+// The byte is never used by msgpack and it is not valid JSON, so compressed
+// payloads can't be confused with the serialized payloads.
+const CompressedMarker = 0xc1
+
+// DefaultMaxDecompressedSize is the default maximum size in bytes of the decompressed payloads.
+const DefaultMaxDecompressedSize = 64 * 1024 * 1024
+
+// Compressor compresses the serialized payloads.
+//
+// Compressors for algorithms like LZ4 or zstd can be implemented using
+// third party packages and registered with RegisterCompressor.
+type Compressor interface {
+	// Name returns the name of the compression algorithm.
+	//
+	// The name is saved in the compressed payloads, so it must have less than 256 bytes.
+	Name() string
+
+	// Compress compresses a payload.
+	Compress(b []byte) ([]byte, error)
+
+	// Decompress decompresses a payload.
+	//
+	// Implementations should limit the size of the decompressed payloads, because
+	// the compressed payloads are received from other components.
+	Decompress(b []byte) ([]byte, error)
+}
+
+// Compressors that can be used to decompress the payloads, by name.
+var (
+	compressorsMutex sync.RWMutex
+	compressors      = map[string]Compressor{"deflate": Deflate{}}
+)
+
+// RegisterCompressor registers a compressor to be able to decompress its payloads.
+//
+// c: The compressor.
+func RegisterCompressor(c Compressor) {
+	compressorsMutex.Lock()
+	defer compressorsMutex.Unlock()
+
+	compressors[c.Name()] = c
+}
+
+// GetCompressor returns a registered compressor.
+//
+// name: The name of the compression algorithm.
+func GetCompressor(name string) (Compressor, bool) {
+	compressorsMutex.RLock()
+	defer compressorsMutex.RUnlock()
+
+	c, ok := compressors[name]
+	return c, ok
+}
+
+// IsCompressed checks if a payload is compressed.
+//
+// b: The payload.
+func IsCompressed(b []byte) bool {
+	return len(b) > 1 && b[0] == CompressedMarker
+}
+
+// Compress compresses a payload and adds a header with the compression algorithm.
+//
+// c: The compressor.
+// b: The payload.
+func Compress(c Compressor, b []byte) ([]byte, error) {
+	name := c.Name()
+	if name == "" || len(name) > 255 {
+		return nil, fmt.Errorf(`Invalid compressor name: "%s"`, name)
+	}
+
+	data, err := c.Compress(b)
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to compress the payload using "%s": %v`, name, err)
+	}
+
+	compressed := make([]byte, 0, len(data)+len(name)+2)
+	compressed = append(compressed, CompressedMarker, byte(len(name)))
+	compressed = append(compressed, name...)
+	return append(compressed, data...), nil
+}
+
+// Decompress decompresses a payload that was compressed using Compress.
+//
+// The payload is returned without changes when it is not compressed.
+//
+// b: The payload.
+func Decompress(b []byte) ([]byte, error) {
+	if !IsCompressed(b) {
+		return b, nil
+	}
+
+	size := int(b[1])
+	if len(b) < size+2 {
+		return nil, errors.New("The compressed payload header is not valid")
+	}
+
+	name := string(b[2 : size+2])
+	c, ok := GetCompressor(name)
+	if !ok {
+		return nil, fmt.Errorf(`Unknown payload compression: "%s"`, name)
+	}
+
+	data, err := c.Decompress(b[size+2:])
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to decompress the payload using "%s": %v`, name, err)
+	}
+	return data, nil
+}
+
+// Deflate is the compressor for the deflate algorithm.
+type Deflate struct {
+	// Compression level, where zero uses the fastest compression
+	Level int
+	// Maximum size in bytes of the decompressed payloads, where zero uses DefaultMaxDecompressedSize
+	MaxSize int
+}
+
+// Name returns the name of the compression algorithm.
+func (Deflate) Name() string {
+	return "deflate"
+}
+
+// Compress compresses a payload using deflate.
+func (d Deflate) Compress(b []byte) ([]byte, error) {
+	level := d.Level
+	if level == 0 {
+		level = flate.BestSpeed
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses a deflate payload.
+//
+// An error is returned when the decompressed payload exceeds the maximum size.
+func (d Deflate) Decompress(b []byte) ([]byte, error) {
+	maxSize := d.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDecompressedSize
+	}
+
+	r := flate.NewReader(bytes.NewReader(b))
+	defer r.Close()
+
+	// One more byte is read to know when the payload exceeds the maximum size
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("The decompressed payload exceeds the maximum size of %d bytes", maxSize)
+	}
+	return data, nil
+}
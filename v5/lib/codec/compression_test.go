@@ -0,0 +1,149 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// Compressor that doesn't change the payloads.
+type identityCompressor struct{}
+
+func (identityCompressor) Name() string {
+	return "identity"
+}
+
+func (identityCompressor) Compress(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+func (identityCompressor) Decompress(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+// Compressor that always fails.
+type failingCompressor struct{}
+
+func (failingCompressor) Name() string {
+	return "failing"
+}
+
+func (failingCompressor) Compress([]byte) ([]byte, error) {
+	return nil, errors.New("compression failed")
+}
+
+func (failingCompressor) Decompress([]byte) ([]byte, error) {
+	return nil, errors.New("decompression failed")
+}
+
+func TestCompressAndDecompress(t *testing.T) {
+	payload := bytes.Repeat([]byte("kusanagi"), 1000)
+
+	compressed, err := Compress(Deflate{}, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsCompressed(compressed) || len(compressed) >= len(payload) {
+		t.Fatalf("expected a smaller compressed payload, got %d bytes", len(compressed))
+	}
+
+	if name := string(compressed[2 : 2+compressed[1]]); name != "deflate" {
+		t.Errorf("expected the compressor name in the header, got %q", name)
+	}
+
+	decompressed, err := Decompress(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decompressed, payload) {
+		t.Error("expected the original payload")
+	}
+}
+
+func TestDecompressUncompressedPayloads(t *testing.T) {
+	// Msgpack maps and JSON objects never start with the marker
+	for _, payload := range [][]byte{nil, {0x80}, []byte(`{"a":1}`), {CompressedMarker}} {
+		result, err := Decompress(payload)
+		if err != nil || !bytes.Equal(result, payload) {
+			t.Errorf("expected the payload %v without changes, got %v %v", payload, result, err)
+		}
+	}
+}
+
+func TestDecompressErrors(t *testing.T) {
+	RegisterCompressor(failingCompressor{})
+
+	compressed, err := Compress(identityCompressor{}, []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]byte{
+		"invalid header":     {CompressedMarker, 10, 'a'},
+		"unknown compressor": compressed,
+		"failed":             append([]byte{CompressedMarker, 7}, "failingpayload"...),
+		"invalid data":       append([]byte{CompressedMarker, 7}, "deflate\xff\xff"...),
+	}
+
+	for name, payload := range cases {
+		if _, err := Decompress(payload); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCompressErrors(t *testing.T) {
+	if _, err := Compress(failingCompressor{}, []byte("payload")); err == nil {
+		t.Error("expected the compression error")
+	}
+
+	if _, err := Compress(namedCompressor(""), []byte("payload")); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+
+	if _, err := Compress(namedCompressor(string(bytes.Repeat([]byte("a"), 256))), []byte("payload")); err == nil {
+		t.Error("expected an error for a long name")
+	}
+}
+
+// Compressor with a custom name.
+type namedCompressor string
+
+func (c namedCompressor) Name() string {
+	return string(c)
+}
+
+func (namedCompressor) Compress(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+func (namedCompressor) Decompress(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+func TestDeflateMaxSize(t *testing.T) {
+	payload := bytes.Repeat([]byte("a"), 1024)
+
+	data, err := Deflate{}.Compress(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := (Deflate{MaxSize: 1024}).Decompress(data); err != nil || len(result) != 1024 {
+		t.Errorf("expected the payload with the maximum size, got %d bytes: %v", len(result), err)
+	}
+
+	if _, err := (Deflate{MaxSize: 1023}).Decompress(data); err == nil {
+		t.Error("expected an error for a payload larger than the maximum size")
+	}
+}
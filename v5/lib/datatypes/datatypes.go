@@ -9,6 +9,8 @@
 package datatypes
 
 import (
+	"encoding/json"
+	"math"
 	"reflect"
 
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
@@ -82,3 +84,57 @@ func ResolveType(value interface{}) string {
 	}
 	return valueType
 }
+
+// Normalize converts the numeric values decoded from a payload to deterministic Go types.
+//
+// Decoded payloads can contain numbers as int64, uint64, float32, float64 or json.Number
+// values depending on the serialization and the size of the number. Integers are converted
+// to int64, unless they are unsigned integers that don't fit in an int64, and floats are
+// converted to float64. When the type of the value is "integer" the floats without decimals
+// are also converted to int64. Arrays and objects are normalized recursively.
+//
+// value: The decoded value.
+// valueType: The KUSANAGI data type of the value, or empty when the type is unknown.
+func Normalize(value interface{}, valueType string) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		} else if f, err := v.Float64(); err == nil {
+			return normalizeFloat(f, valueType)
+		}
+		return v.String()
+	case []interface{}:
+		for i, item := range v {
+			v[i] = Normalize(item, "")
+		}
+		return v
+	case map[string]interface{}:
+		for name, item := range v {
+			v[name] = Normalize(item, "")
+		}
+		return v
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u)
+		}
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		return normalizeFloat(rv.Float(), valueType)
+	}
+	return value
+}
+
+// Convert a float to an integer when the type is integer and the float has no decimals.
+func normalizeFloat(f float64, valueType string) interface{} {
+	if valueType == Integer && f == math.Trunc(f) && f >= math.MinInt64 && f <= math.MaxInt64 {
+		return int64(f)
+	}
+	return f
+}
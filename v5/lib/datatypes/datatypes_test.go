@@ -0,0 +1,50 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package datatypes
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		value     interface{}
+		valueType string
+		expected  interface{}
+	}{
+		{uint64(42), Integer, int64(42)},
+		{int8(-3), "", int64(-3)},
+		{uint64(math.MaxUint64), Integer, uint64(math.MaxUint64)},
+		{float32(1.5), Float, float64(1.5)},
+		{float64(7), Integer, int64(7)},
+		{float64(7), Float, float64(7)},
+		{json.Number("12"), "", int64(12)},
+		{json.Number("1.25"), "", float64(1.25)},
+		{"12", String, "12"},
+	}
+
+	for _, c := range cases {
+		if v := Normalize(c.value, c.valueType); v != c.expected {
+			t.Errorf("expected %v (%T) for %v (%T), got %v (%T)", c.expected, c.expected, c.value, c.value, v, v)
+		}
+	}
+}
+
+func TestNormalizeNested(t *testing.T) {
+	value := map[string]interface{}{
+		"items": []interface{}{uint64(1), float32(2.5)},
+	}
+
+	items := Normalize(value, Object).(map[string]interface{})["items"].([]interface{})
+	if items[0] != int64(1) || items[1] != float64(2.5) {
+		t.Errorf("unexpected normalized items: %#v", items)
+	}
+}
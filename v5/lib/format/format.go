@@ -0,0 +1,49 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package format
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeLayout is the layout of the date and time values used by the framework.
+//
+// The times are in UTC with microsecond precision, for example "2006-01-02T15:04:05.000000+00:00",
+// which is a valid RFC 3339 date and time.
+const TimeLayout = "2006-01-02T15:04:05.000000+00:00"
+
+// TimeToString converts a time to the string format used by the framework.
+func TimeToString(t time.Time) string {
+	return t.UTC().Format(TimeLayout)
+}
+
+// StringToTime converts a date and time string to a time.
+//
+// The string can use the framework format or any other RFC 3339 format.
+func StringToTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// DurationToString converts a duration to a string like "1h30m0s".
+func DurationToString(d time.Duration) string {
+	return d.String()
+}
+
+// StringToDuration converts a string to a duration.
+//
+// The string can be a duration like "1h30m" or "250ms", or an integer with the milliseconds.
+func StringToDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	return time.ParseDuration(value)
+}
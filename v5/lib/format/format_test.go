@@ -0,0 +1,50 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeToString(t *testing.T) {
+	tm := time.Date(2023, 1, 2, 3, 4, 5, 123456789, time.FixedZone("CET", 3600))
+	if v := TimeToString(tm); v != "2023-01-02T02:04:05.123456+00:00" {
+		t.Errorf("unexpected time string: %s", v)
+	}
+
+	parsed, err := StringToTime(TimeToString(tm))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !parsed.Equal(tm.Truncate(time.Microsecond)) {
+		t.Errorf("expected %v, got %v", tm, parsed)
+	}
+}
+
+func TestStringToDuration(t *testing.T) {
+	tt := []struct {
+		value    string
+		expected time.Duration
+		fails    bool
+	}{
+		{"1h30m", 90 * time.Minute, false},
+		{"250ms", 250 * time.Millisecond, false},
+		{"1500", 1500 * time.Millisecond, false},
+		{"1 hour", 0, true},
+	}
+
+	for _, tc := range tt {
+		d, err := StringToDuration(tc.value)
+		if tc.fails != (err != nil) {
+			t.Errorf("unexpected error for %q: %v", tc.value, err)
+		} else if d != tc.expected {
+			t.Errorf("expected %v for %q, got %v", tc.expected, tc.value, d)
+		}
+	}
+}
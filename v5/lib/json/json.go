@@ -11,6 +11,9 @@ package json
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
 )
 
 // Serialize a value to a JSON representation.
@@ -44,3 +47,51 @@ func Dump(value interface{}) string {
 
 	return v
 }
+
+// Encode serializes a value as a JSON binary.
+//
+// The encoding uses the same struct tags as the msgpack encoding so payloads
+// can be exchanged in both formats.
+func Encode(v interface{}) ([]byte, error) {
+	var (
+		h   codec.JsonHandle
+		buf bytes.Buffer
+	)
+
+	enc := codec.NewEncoder(&buf, &h)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// EncodeCanonical serializes a value as a JSON binary with the map keys sorted.
+//
+// The result is the same for equal values, which allows to hash or sign the binaries.
+func EncodeCanonical(v interface{}) ([]byte, error) {
+	var (
+		h   codec.JsonHandle
+		buf bytes.Buffer
+	)
+
+	h.Canonical = true
+
+	enc := codec.NewEncoder(&buf, &h)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decode a JSON binary value to its original type.
+func Decode(b []byte, v interface{}) error {
+	var h codec.JsonHandle
+
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+
+	dec := codec.NewDecoderBytes(b, &h)
+
+	return dec.Decode(v)
+}
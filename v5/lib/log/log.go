@@ -16,6 +16,7 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"sort"
 	"time"
 
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/json"
@@ -42,18 +43,22 @@ func init() {
 }
 
 // SetOutput changes the logging output.
+//
+// The messages are written with their levels when the output is a LevelWriter.
 func SetOutput(w io.Writer) {
+	lw, _ := w.(LevelWriter)
+	levelOutput.Store(&lw)
 	log.SetOutput(w)
 }
 
 // Disable logging.
 func Disable() {
-	log.SetOutput(ioutil.Discard)
+	SetOutput(ioutil.Discard)
 }
 
 // Enable logging.
 func Enable() {
-	log.SetOutput(os.Stdout)
+	SetOutput(os.Stdout)
 }
 
 // The level currently selected.
@@ -86,17 +91,24 @@ func getLogPrefix(level int) string {
 	return fmt.Sprintf("%s [%s] [SDK]", timestamp, levels[level])
 }
 
+// Write a log message without checking the log level.
+func write(level int, message string) {
+	if !writeLevel(level, getLogPrefix(level)+" "+message) {
+		log.Println(getLogPrefix(level), message)
+	}
+}
+
 // Log writes a log message.
 func Log(level int, v ...interface{}) {
 	if level <= currentLevel {
-		log.Println(getLogPrefix(level), fmt.Sprint(v...))
+		write(level, fmt.Sprint(v...))
 	}
 }
 
 // Logf writes a log message for a level with format.
 func Logf(level int, format string, v ...interface{}) {
 	if level <= currentLevel {
-		log.Println(getLogPrefix(level), fmt.Sprintf(format, v...))
+		write(level, fmt.Sprintf(format, v...))
 	}
 }
 
@@ -186,7 +198,7 @@ func NewRequestLogger(rid string) RequestLogger {
 		rid = "-"
 	}
 
-	return RequestLogger{rid, fmt.Sprintf(" |%s|", rid)}
+	return RequestLogger{rid: rid, suffix: fmt.Sprintf(" |%s|", rid)}
 }
 
 // RequestLogger is a logger with request ID support.
@@ -194,6 +206,17 @@ func NewRequestLogger(rid string) RequestLogger {
 type RequestLogger struct {
 	rid    string
 	suffix string
+	fields []field
+	// Write the messages of all levels
+	verbose bool
+	// Subsystem that selects the log level
+	subsystem string
+}
+
+// A field contains a name and value that is added to the log messages.
+type field struct {
+	name  string
+	value interface{}
 }
 
 // RID returns the request ID.
@@ -201,89 +224,181 @@ func (r RequestLogger) RID() string {
 	return r.rid
 }
 
+// WithField returns a copy of the logger that adds a field to every log message.
+//
+// The field value is replaced when the logger already has a field with the same name.
+//
+// name: The field name.
+// value: The field value.
+func (r RequestLogger) WithField(name string, value interface{}) RequestLogger {
+	fields := make([]field, 0, len(r.fields)+1)
+	replaced := false
+	for _, f := range r.fields {
+		if f.name == name {
+			f.value = value
+			replaced = true
+		}
+		fields = append(fields, f)
+	}
+
+	if !replaced {
+		fields = append(fields, field{name, value})
+	}
+
+	// Fields are added after the request ID in the order they were defined
+	suffix := fmt.Sprintf(" |%s|", r.rid)
+	for _, f := range fields {
+		suffix += fmt.Sprintf(" %s=%v", f.name, f.value)
+	}
+
+	return RequestLogger{r.rid, suffix, fields, r.verbose, r.subsystem}
+}
+
+// WithVerbose returns a copy of the logger that writes the messages of all levels.
+//
+// Verbose loggers ignore the current log level, so they can be used to write
+// the DEBUG messages of some requests without changing the level of the component.
+//
+// verbose: True to write the messages of all levels.
+func (r RequestLogger) WithVerbose(verbose bool) RequestLogger {
+	r.verbose = verbose
+	return r
+}
+
+// WithSubsystem returns a copy of the logger that uses the log level of a subsystem.
+//
+// subsystem: The subsystem name.
+func (r RequestLogger) WithSubsystem(subsystem string) RequestLogger {
+	r.subsystem = subsystem
+	return r
+}
+
+// GetSubsystem returns the name of the subsystem used to select the log level.
+func (r RequestLogger) GetSubsystem() string {
+	return r.subsystem
+}
+
+// IsVerbose checks if the logger writes the messages of all levels.
+func (r RequestLogger) IsVerbose() bool {
+	return r.verbose
+}
+
+// WithFields returns a copy of the logger that adds the fields to every log message.
+//
+// The fields are added sorted by name.
+//
+// fields: The field names and values.
+func (r RequestLogger) WithFields(fields map[string]interface{}) RequestLogger {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	logger := r
+	for _, name := range names {
+		logger = logger.WithField(name, fields[name])
+	}
+	return logger
+}
+
 // Emergency logs an emergency message.
 func (r RequestLogger) Emergency(v ...interface{}) {
-	Emergency(append(v, r.suffix)...)
+	r.log(EMERGENCY, append(v, r.suffix)...)
 }
 
 // Emergencyf logs a emergency message with format.
 func (r RequestLogger) Emergencyf(format string, v ...interface{}) {
-	Emergencyf(format+r.suffix, v...)
+	r.logf(EMERGENCY, format+r.suffix, v...)
 }
 
 // Alert logs an alert message.
 func (r RequestLogger) Alert(v ...interface{}) {
-	Alert(append(v, r.suffix)...)
+	r.log(ALERT, append(v, r.suffix)...)
 }
 
 // Alertf logs a alert message with format.
 func (r RequestLogger) Alertf(format string, v ...interface{}) {
-	Alertf(format+r.suffix, v...)
+	r.logf(ALERT, format+r.suffix, v...)
 }
 
 // Critical logs a critical message.
 func (r RequestLogger) Critical(v ...interface{}) {
-	Critical(append(v, r.suffix)...)
+	r.log(CRITICAL, append(v, r.suffix)...)
 }
 
 // Criticalf logs a critical message with format.
 func (r RequestLogger) Criticalf(format string, v ...interface{}) {
-	Criticalf(format+r.suffix, v...)
+	r.logf(CRITICAL, format+r.suffix, v...)
 }
 
 // Error logs an error message.
 func (r RequestLogger) Error(v ...interface{}) {
-	Error(append(v, r.suffix)...)
+	r.log(ERROR, append(v, r.suffix)...)
 }
 
 // Errorf logs an error message with format.
 func (r RequestLogger) Errorf(format string, v ...interface{}) {
-	Errorf(format+r.suffix, v...)
+	r.logf(ERROR, format+r.suffix, v...)
 }
 
 // Warning logs a warning message.
 func (r RequestLogger) Warning(v ...interface{}) {
-	Warning(append(v, r.suffix)...)
+	r.log(WARNING, append(v, r.suffix)...)
 }
 
 // Warningf logs a warning message with format.
 func (r RequestLogger) Warningf(format string, v ...interface{}) {
-	Warningf(format+r.suffix, v...)
+	r.logf(WARNING, format+r.suffix, v...)
 }
 
 // Notice logs a notice message.
 func (r RequestLogger) Notice(v ...interface{}) {
-	Notice(append(v, r.suffix)...)
+	r.log(NOTICE, append(v, r.suffix)...)
 }
 
 // Noticef logs a notice message with format.
 func (r RequestLogger) Noticef(format string, v ...interface{}) {
-	Noticef(format+r.suffix, v...)
+	r.logf(NOTICE, format+r.suffix, v...)
 }
 
 // Info logs an info message.
 func (r RequestLogger) Info(v ...interface{}) {
-	Info(append(v, r.suffix)...)
+	r.log(INFO, append(v, r.suffix)...)
 }
 
 // Infof logs an info message with format.
 func (r RequestLogger) Infof(format string, v ...interface{}) {
-	Infof(format+r.suffix, v...)
+	r.logf(INFO, format+r.suffix, v...)
 }
 
 // Debug logs a debug message.
 func (r RequestLogger) Debug(v ...interface{}) {
-	Debug(append(v, r.suffix)...)
+	r.log(DEBUG, append(v, r.suffix)...)
 }
 
 // Debugf logs a debug message with format.
 func (r RequestLogger) Debugf(format string, v ...interface{}) {
-	Debugf(format+r.suffix, v...)
+	r.logf(DEBUG, format+r.suffix, v...)
 }
 
 // Log a message.
 func (r RequestLogger) Log(level int, v ...interface{}) {
-	Log(level, append(v, r.suffix)...)
+	r.log(level, append(v, r.suffix)...)
+}
+
+// Write a log message, ignoring the log level when the logger is verbose.
+func (r RequestLogger) log(level int, v ...interface{}) {
+	if r.verbose || IsEnabled(level, r.subsystem) {
+		write(level, fmt.Sprint(v...))
+	}
+}
+
+// Write a log message with format, ignoring the log level when the logger is verbose.
+func (r RequestLogger) logf(level int, format string, v ...interface{}) {
+	if r.verbose || IsEnabled(level, r.subsystem) {
+		write(level, fmt.Sprintf(format, v...))
+	}
 }
 
 // ValueToLogString returns a string representation of a value.
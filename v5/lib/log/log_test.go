@@ -0,0 +1,37 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package log
+
+import "testing"
+
+func TestRequestLoggerWithField(t *testing.T) {
+	base := NewRequestLogger("rid")
+	logger := base.WithField("name", "users").WithField("version", "1.0.0")
+	if expected := " |rid| name=users version=1.0.0"; logger.suffix != expected {
+		t.Errorf("expected %q, got %q", expected, logger.suffix)
+	}
+
+	// Existing fields keep their position when they are replaced
+	logger = logger.WithField("name", "posts")
+	if expected := " |rid| name=posts version=1.0.0"; logger.suffix != expected {
+		t.Errorf("expected %q, got %q", expected, logger.suffix)
+	}
+
+	// The original logger doesn't change
+	if expected := " |rid|"; base.suffix != expected {
+		t.Errorf("expected %q, got %q", expected, base.suffix)
+	}
+}
+
+func TestRequestLoggerWithFields(t *testing.T) {
+	logger := NewRequestLogger("rid").WithFields(map[string]interface{}{"version": "1.0.0", "name": "users", "attempt": 2})
+	if expected := " |rid| attempt=2 name=users version=1.0.0"; logger.suffix != expected {
+		t.Errorf("expected %q, got %q", expected, logger.suffix)
+	}
+}
@@ -0,0 +1,238 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package log
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Names of the log outputs.
+const (
+	StdoutOutput   = "stdout"
+	SyslogOutput   = "syslog"
+	UDPOutput      = "udp"
+	UnixgramOutput = "unixgram"
+)
+
+// LevelWriter is implemented by the log outputs that keep the level of the messages.
+type LevelWriter interface {
+	io.Writer
+
+	// WriteLevel writes a log message with its level.
+	//
+	// level: The log level.
+	// message: The log message, including the prefix.
+	WriteLevel(level int, message string) error
+}
+
+// Output that receives the messages with their levels, when the current output supports it.
+var levelOutput atomic.Value
+
+// Write a log line to the output that keeps the level of the messages.
+//
+// The result is false when the current output doesn't keep the levels.
+func writeLevel(level int, message string) bool {
+	// The value is a pointer because atomic values can't store nil interfaces
+	w, _ := levelOutput.Load().(*LevelWriter)
+	if w == nil || *w == nil {
+		return false
+	}
+
+	// The messages are discarded on error to avoid stalling the component
+	_ = (*w).WriteLevel(level, message)
+	return true
+}
+
+// Parse a log output value into a network and an address.
+//
+// Values have the format "NAME" or "NAME://ADDRESS", where the network of the syslog output
+// can be selected using "syslog+udp://HOST:PORT", "syslog+tcp://HOST:PORT" or
+// "syslog+unixgram:///PATH". The local syslog is used when the syslog address is empty.
+func parseOutput(value string) (name, network, address string) {
+	name, address, _ = strings.Cut(value, "://")
+	name, network, _ = strings.Cut(name, "+")
+	return name, network, address
+}
+
+// NewOutput creates a log output.
+//
+// The outputs are "stdout", "syslog" for the local syslog, "syslog+udp://HOST:PORT" and
+// "syslog+tcp://HOST:PORT" for a remote syslog, "udp://HOST:PORT" to send each log line as an
+// UDP datagram and "unixgram:///PATH" to send each log line to a Unix datagram socket.
+//
+// value: The log output.
+// tag: The tag of the syslog messages.
+func NewOutput(value, tag string) (io.Writer, error) {
+	name, network, address := parseOutput(value)
+	switch name {
+	case "", StdoutOutput:
+		return os.Stdout, nil
+	case SyslogOutput:
+		return NewSyslogWriter(network, address, tag)
+	case UDPOutput, UnixgramOutput:
+		if address == "" {
+			return nil, fmt.Errorf(`The log output requires an address: "%s"`, value)
+		}
+		return NewDatagramWriter(name, address)
+	}
+	return nil, fmt.Errorf(`Invalid log output: "%s"`, value)
+}
+
+// NewDatagramWriter creates a log output that sends each log line as a datagram.
+//
+// network: The network name, which can be "udp" or "unixgram".
+// address: The address where the log lines are sent.
+func NewDatagramWriter(network, address string) (io.Writer, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to connect the log output to "%s": %v`, address, err)
+	}
+	return conn, nil
+}
+
+// NewBufferedWriter creates a log output that writes the log lines in the background.
+//
+// The log lines are kept in a ring buffer until they are written, and when the buffer is
+// full the oldest lines are discarded, so logging never blocks while the output is slow.
+//
+// w: The output where the log lines are written.
+// size: The maximum number of log lines in the buffer.
+func NewBufferedWriter(w io.Writer, size int) *BufferedWriter {
+	if size < 1 {
+		size = 1
+	}
+
+	b := &BufferedWriter{
+		output: w,
+		lines:  make([]bufferedLine, size),
+		done:   make(chan struct{}),
+	}
+	b.ready = sync.NewCond(&b.mutex)
+
+	go b.run()
+	return b
+}
+
+// Log line kept by the buffered writer.
+type bufferedLine struct {
+	level   int
+	message []byte
+}
+
+// BufferedWriter is a log output that writes the log lines in the background using a ring buffer.
+type BufferedWriter struct {
+	// The counter is the first field so it is aligned for the atomic operations
+	dropped uint64
+	output  io.Writer
+	mutex   sync.Mutex
+	ready   *sync.Cond
+	lines   []bufferedLine
+	head    int
+	count   int
+	closed  bool
+	done    chan struct{}
+}
+
+// Add a log line to the buffer, discarding the oldest line when the buffer is full.
+func (b *BufferedWriter) push(level int, message []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	if b.count == len(b.lines) {
+		b.head = (b.head + 1) % len(b.lines)
+		b.count--
+		atomic.AddUint64(&b.dropped, 1)
+	}
+
+	b.lines[(b.head+b.count)%len(b.lines)] = bufferedLine{level, message}
+	b.count++
+	b.ready.Signal()
+}
+
+// Write adds a log line to the buffer.
+//
+// The line is copied because the logger reuses the buffer.
+func (b *BufferedWriter) Write(p []byte) (int, error) {
+	b.push(NOTSET, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+// WriteLevel adds a log line with its level to the buffer.
+//
+// The level is only used when the output keeps the level of the messages.
+//
+// level: The log level.
+// message: The log message, including the prefix.
+func (b *BufferedWriter) WriteLevel(level int, message string) error {
+	if _, ok := b.output.(LevelWriter); !ok {
+		b.push(NOTSET, []byte(message+"\n"))
+	} else {
+		b.push(level, []byte(message))
+	}
+	return nil
+}
+
+// Dropped returns the number of log lines that were discarded because the buffer was full.
+func (b *BufferedWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// Close writes the log lines in the buffer and stops the writer.
+func (b *BufferedWriter) Close() error {
+	b.mutex.Lock()
+	if !b.closed {
+		b.closed = true
+		b.ready.Signal()
+	}
+	b.mutex.Unlock()
+
+	<-b.done
+	return nil
+}
+
+// Write the log lines until the writer is closed.
+func (b *BufferedWriter) run() {
+	defer close(b.done)
+
+	w, _ := b.output.(LevelWriter)
+	for {
+		b.mutex.Lock()
+		for b.count == 0 && !b.closed {
+			b.ready.Wait()
+		}
+
+		if b.count == 0 {
+			b.mutex.Unlock()
+			return
+		}
+
+		line := b.lines[b.head]
+		b.lines[b.head] = bufferedLine{}
+		b.head = (b.head + 1) % len(b.lines)
+		b.count--
+		b.mutex.Unlock()
+
+		// Write errors are ignored because there is no other place to report them
+		if w != nil && line.level != NOTSET {
+			_ = w.WriteLevel(line.level, string(line.message))
+		} else {
+			_, _ = b.output.Write(line.message)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"testing"
+)
+
+// Log output that waits until it is released to write the lines for the output tests.
+type gatedWriter struct {
+	mutex   sync.Mutex
+	release chan struct{}
+	lines   []string
+	levels  []int
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.release
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+// Log output that keeps the levels of the messages for the output tests.
+type levelTestWriter struct {
+	gatedWriter
+}
+
+func (w *levelTestWriter) WriteLevel(level int, message string) error {
+	w.levels = append(w.levels, level)
+	_, err := w.Write([]byte(message))
+	return err
+}
+
+func TestParseOutput(t *testing.T) {
+	cases := map[string][3]string{
+		"stdout":                       {"stdout", "", ""},
+		"syslog":                       {"syslog", "", ""},
+		"syslog+udp://127.0.0.1:514":   {"syslog", "udp", "127.0.0.1:514"},
+		"unixgram:///var/run/log.sock": {"unixgram", "", "/var/run/log.sock"},
+	}
+
+	for value, expected := range cases {
+		name, network, address := parseOutput(value)
+		if result := [3]string{name, network, address}; result != expected {
+			t.Errorf("%s: expected %v, got %v", value, expected, result)
+		}
+	}
+}
+
+func TestNewOutput(t *testing.T) {
+	for _, value := range []string{"", StdoutOutput} {
+		if w, err := NewOutput(value, "test"); err != nil || w != os.Stdout {
+			t.Errorf("%q: expected stdout, got %v %v", value, w, err)
+		}
+	}
+
+	for _, value := range []string{"udp", "unixgram://", "file:///tmp/log"} {
+		if _, err := NewOutput(value, "test"); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestDatagramOutput(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := NewOutput("udp://"+conn.LocalAddr().String(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(w, "message")
+
+	// Each log line is sent as a datagram
+	buffer := make([]byte, 64)
+	n, _, err := conn.ReadFrom(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message := string(buffer[:n]); message != "message" {
+		t.Errorf("unexpected datagram: %q", message)
+	}
+}
+
+func TestBufferedWriterDropsOldestLines(t *testing.T) {
+	output := &gatedWriter{release: make(chan struct{})}
+	b := NewBufferedWriter(output, 2)
+
+	// Writes don't block while the output is slow
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(b, "%d\n", i)
+	}
+
+	close(output.release)
+	b.Close()
+
+	if written := uint64(len(output.lines)); written+b.Dropped() != 5 || b.Dropped() < 2 {
+		t.Errorf("unexpected lines: %v with %d dropped", output.lines, b.Dropped())
+	}
+	if last := output.lines[len(output.lines)-1]; last != "5\n" {
+		t.Errorf("expected the newest line to be written, got %q", last)
+	}
+
+	// Lines written after the writer is closed are discarded
+	fmt.Fprint(b, "closed\n")
+	if last := output.lines[len(output.lines)-1]; last != "5\n" {
+		t.Errorf("expected no lines after close, got %q", last)
+	}
+}
+
+func TestBufferedWriterLevels(t *testing.T) {
+	output := &levelTestWriter{gatedWriter{release: make(chan struct{})}}
+	close(output.release)
+
+	b := NewBufferedWriter(output, 10)
+	b.WriteLevel(WARNING, "warning")
+	b.Close()
+	if len(output.levels) != 1 || output.levels[0] != WARNING || output.lines[0] != "warning" {
+		t.Errorf("expected the message with its level, got %v %v", output.levels, output.lines)
+	}
+
+	// Outputs that don't keep the levels receive the lines
+	var buffer bytes.Buffer
+	b = NewBufferedWriter(&buffer, 10)
+	b.WriteLevel(WARNING, "warning")
+	b.Close()
+	if buffer.String() != "warning\n" {
+		t.Errorf("expected a log line, got %q", buffer.String())
+	}
+}
@@ -0,0 +1,209 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Names of the subsystems that can have their own log level.
+const (
+	ServerSubsystem      = "server"
+	WorkerSubsystem      = "worker"
+	PayloadSubsystem     = "payload"
+	RuntimeCallSubsystem = "runtime-call"
+	UserlandSubsystem    = "userland"
+)
+
+// Loggers for the SDK subsystems.
+var (
+	Server      = NewSubsystemLogger(ServerSubsystem)
+	Worker      = NewSubsystemLogger(WorkerSubsystem)
+	Payload     = NewSubsystemLogger(PayloadSubsystem)
+	RuntimeCall = NewSubsystemLogger(RuntimeCallSubsystem)
+)
+
+var subsystems = map[string]bool{
+	ServerSubsystem:      true,
+	WorkerSubsystem:      true,
+	PayloadSubsystem:     true,
+	RuntimeCallSubsystem: true,
+	UserlandSubsystem:    true,
+}
+
+// The log levels selected for the subsystems.
+// Subsystems without level use the current log level.
+var subsystemLevels = map[string]int{}
+
+// SetSubsystemLevel changes the log level of a subsystem.
+//
+// subsystem: The subsystem name.
+// level: The log level.
+func SetSubsystemLevel(subsystem string, level int) {
+	subsystemLevels[subsystem] = level
+}
+
+// GetSubsystemLevel returns the log level of a subsystem.
+//
+// The current log level is returned when the subsystem doesn't have a level.
+//
+// subsystem: The subsystem name.
+func GetSubsystemLevel(subsystem string) int {
+	if level, exists := subsystemLevels[subsystem]; exists {
+		return level
+	}
+	return currentLevel
+}
+
+// ResetSubsystemLevels removes the log levels of all the subsystems.
+func ResetSubsystemLevels() {
+	subsystemLevels = map[string]int{}
+}
+
+// IsEnabled checks if the messages of a level are written for a subsystem.
+//
+// level: The log level.
+// subsystem: The subsystem name, or empty to use the current log level.
+func IsEnabled(level int, subsystem string) bool {
+	return level <= GetSubsystemLevel(subsystem)
+}
+
+// ParseLevel returns the log level for a level name or a numeric syslog severity value.
+//
+// Level names are case-insensitive, for example "debug" or "WARNING".
+//
+// value: The level name or value.
+func ParseLevel(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if level, err := strconv.Atoi(value); err == nil {
+		if _, exists := levels[level]; exists {
+			return level, nil
+		}
+	}
+
+	for level, name := range levels {
+		if strings.EqualFold(name, value) {
+			return level, nil
+		}
+	}
+	return NOTSET, fmt.Errorf(`Invalid log level: "%s"`, value)
+}
+
+// ParseSubsystemLevels parses a list of subsystem log levels.
+//
+// The list contains comma separated subsystem names and levels, for
+// example "server=debug,payload=warning".
+//
+// value: The list of subsystem levels.
+func ParseSubsystemLevels(value string) (map[string]int, error) {
+	result := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf(`Invalid subsystem log level: "%s"`, item)
+		}
+
+		subsystem := strings.ToLower(strings.TrimSpace(parts[0]))
+		if !subsystems[subsystem] {
+			return nil, fmt.Errorf(`Unknown log subsystem: "%s"`, subsystem)
+		}
+
+		level, err := ParseLevel(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		result[subsystem] = level
+	}
+	return result, nil
+}
+
+// NewSubsystemLogger creates a new logger for a subsystem.
+func NewSubsystemLogger(subsystem string) SubsystemLogger {
+	return SubsystemLogger{subsystem}
+}
+
+// SubsystemLogger is a logger that writes the messages using the log level of a subsystem.
+type SubsystemLogger struct {
+	subsystem string
+}
+
+// Name returns the subsystem name.
+func (s SubsystemLogger) Name() string {
+	return s.subsystem
+}
+
+// Log writes a log message.
+func (s SubsystemLogger) Log(level int, v ...interface{}) {
+	if IsEnabled(level, s.subsystem) {
+		write(level, fmt.Sprint(v...))
+	}
+}
+
+// Logf writes a log message for a level with format.
+func (s SubsystemLogger) Logf(level int, format string, v ...interface{}) {
+	if IsEnabled(level, s.subsystem) {
+		write(level, fmt.Sprintf(format, v...))
+	}
+}
+
+// Critical logs a critical message.
+func (s SubsystemLogger) Critical(v ...interface{}) {
+	s.Log(CRITICAL, v...)
+}
+
+// Criticalf logs a critical message with format.
+func (s SubsystemLogger) Criticalf(format string, v ...interface{}) {
+	s.Logf(CRITICAL, format, v...)
+}
+
+// Error logs an error message.
+func (s SubsystemLogger) Error(v ...interface{}) {
+	s.Log(ERROR, v...)
+}
+
+// Errorf logs an error message with format.
+func (s SubsystemLogger) Errorf(format string, v ...interface{}) {
+	s.Logf(ERROR, format, v...)
+}
+
+// Warning logs a warning message.
+func (s SubsystemLogger) Warning(v ...interface{}) {
+	s.Log(WARNING, v...)
+}
+
+// Warningf logs a warning message with format.
+func (s SubsystemLogger) Warningf(format string, v ...interface{}) {
+	s.Logf(WARNING, format, v...)
+}
+
+// Info logs an info message.
+func (s SubsystemLogger) Info(v ...interface{}) {
+	s.Log(INFO, v...)
+}
+
+// Infof logs an info message with format.
+func (s SubsystemLogger) Infof(format string, v ...interface{}) {
+	s.Logf(INFO, format, v...)
+}
+
+// Debug logs a debug message.
+func (s SubsystemLogger) Debug(v ...interface{}) {
+	s.Log(DEBUG, v...)
+}
+
+// Debugf logs a debug message with format.
+func (s SubsystemLogger) Debugf(format string, v ...interface{}) {
+	s.Logf(DEBUG, format, v...)
+}
@@ -0,0 +1,84 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package log
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]int{
+		"debug":     DEBUG,
+		" WARNING ": WARNING,
+		"7":         DEBUG,
+		"0":         EMERGENCY,
+	}
+
+	for value, expected := range cases {
+		if level, err := ParseLevel(value); err != nil || level != expected {
+			t.Errorf("%q: expected %d, got %d %v", value, expected, level, err)
+		}
+	}
+
+	for _, value := range []string{"", "8", "-1", "verbose"} {
+		if _, err := ParseLevel(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestParseSubsystemLevels(t *testing.T) {
+	levels, err := ParseSubsystemLevels("server=debug, Payload=4,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{ServerSubsystem: DEBUG, PayloadSubsystem: WARNING}
+	if !reflect.DeepEqual(levels, expected) {
+		t.Errorf("expected %v, got %v", expected, levels)
+	}
+
+	for _, value := range []string{"server", "unknown=debug", "server=verbose"} {
+		if _, err := ParseSubsystemLevels(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestSubsystemLogger(t *testing.T) {
+	var output bytes.Buffer
+	SetOutput(&output)
+	currentLevel := GetLevel()
+	t.Cleanup(func() {
+		Enable()
+		SetLevel(currentLevel)
+		ResetSubsystemLevels()
+	})
+
+	SetLevel(ERROR)
+	SetSubsystemLevel(PayloadSubsystem, DEBUG)
+
+	// Subsystems without level use the current log level
+	Server.Debug("server message")
+	Payload.Debug("payload message")
+	if value := output.String(); strings.Contains(value, "server message") || !strings.Contains(value, "payload message") {
+		t.Errorf("unexpected log output: %q", value)
+	}
+	if !IsEnabled(DEBUG, PayloadSubsystem) || IsEnabled(DEBUG, ServerSubsystem) || !IsEnabled(ERROR, "") {
+		t.Error("unexpected enabled log levels")
+	}
+
+	ResetSubsystemLevels()
+	if level := GetSubsystemLevel(PayloadSubsystem); level != ERROR {
+		t.Errorf("expected the current log level, got %d", level)
+	}
+}
@@ -0,0 +1,60 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// NewSyslogWriter creates a log output that writes the messages to syslog.
+//
+// The levels of the messages are used as syslog severities.
+//
+// network: The network to connect to a remote syslog, or empty to use the local syslog.
+// address: The address of the remote syslog.
+// tag: The tag of the syslog messages.
+func NewSyslogWriter(network, address, tag string) (LevelWriter, error) {
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to syslog: %v", err)
+	}
+	return syslogWriter{w}, nil
+}
+
+// Log output that writes the messages to syslog.
+type syslogWriter struct {
+	*syslog.Writer
+}
+
+// WriteLevel writes a log message using the level as syslog severity.
+//
+// level: The log level.
+// message: The log message, including the prefix.
+func (w syslogWriter) WriteLevel(level int, message string) error {
+	switch level {
+	case EMERGENCY:
+		return w.Emerg(message)
+	case ALERT:
+		return w.Alert(message)
+	case CRITICAL:
+		return w.Crit(message)
+	case ERROR:
+		return w.Err(message)
+	case WARNING:
+		return w.Warning(message)
+	case NOTICE:
+		return w.Notice(message)
+	case DEBUG:
+		return w.Debug(message)
+	}
+	return w.Info(message)
+}
@@ -0,0 +1,20 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+//go:build windows || plan9
+
+package log
+
+import "errors"
+
+// NewSyslogWriter creates a log output that writes the messages to syslog.
+//
+// Syslog is not supported in this platform so an error is always returned.
+func NewSyslogWriter(network, address, tag string) (LevelWriter, error) {
+	return nil, errors.New("Syslog is not supported in this platform")
+}
@@ -32,6 +32,26 @@ func Encode(v interface{}) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// EncodeCanonical serializes a value as a msgpack binary with the map keys sorted.
+//
+// The result is the same for equal values, which allows to hash or sign the binaries.
+func EncodeCanonical(v interface{}) ([]byte, error) {
+	var (
+		h   codec.MsgpackHandle
+		buf bytes.Buffer
+	)
+
+	h.WriteExt = true
+	h.Canonical = true
+
+	enc := codec.NewEncoder(&buf, &h)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 // Decode a msgkpack binary value to its original type.
 func Decode(b []byte, v interface{}) error {
 	var h codec.MsgpackHandle
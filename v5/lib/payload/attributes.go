@@ -0,0 +1,108 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import "strings"
+
+// AttributeNamespaceSeparator separates the namespace from the name in the attribute names.
+const AttributeNamespaceSeparator = ":"
+
+// Character used to escape the separator within the namespaces and the names of the attributes.
+const attributeEscape = "\\"
+
+// Escapes the separator and the escape character in an attribute namespace or name.
+var attributeEscaper = strings.NewReplacer(
+	attributeEscape, attributeEscape+attributeEscape,
+	AttributeNamespaceSeparator, attributeEscape+AttributeNamespaceSeparator,
+)
+
+// AttributeKey returns the name of an attribute within a namespace.
+//
+// The attributes are sent to the framework as a flat map, so the namespaced
+// attributes are saved using the namespace as prefix of the attribute name.
+// The separator is escaped within the namespace and the name, so both can
+// contain it. The name is returned without changes when the namespace is empty.
+//
+// namespace: The attribute namespace.
+// name: The attribute name.
+func AttributeKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return attributeEscaper.Replace(namespace) + AttributeNamespaceSeparator + attributeEscaper.Replace(name)
+}
+
+// SplitAttributeKey returns the namespace and the name of an attribute.
+//
+// The key is split at the first separator that is not escaped.
+// The namespace is empty for flat attributes.
+//
+// key: The attribute name as saved in the attributes map.
+func SplitAttributeKey(key string) (namespace, name string) {
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case attributeEscape[0]:
+			// Skip the escaped character
+			i++
+		case AttributeNamespaceSeparator[0]:
+			return unescapeAttribute(key[:i]), unescapeAttribute(key[i+1:])
+		}
+	}
+	return "", key
+}
+
+// Removes the escape characters from an attribute namespace or name.
+func unescapeAttribute(s string) string {
+	if !strings.Contains(s, attributeEscape) {
+		return s
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == attributeEscape[0] && i+1 < len(s) {
+			i++
+		}
+		result.WriteByte(s[i])
+	}
+	return result.String()
+}
+
+// GetAttributesIn returns the attributes within a namespace.
+//
+// The names of the attributes in the result don't include the namespace.
+// An empty namespace returns the flat attributes.
+//
+// attributes: The attributes map.
+// namespace: The attribute namespace.
+func GetAttributesIn(attributes map[string]string, namespace string) map[string]string {
+	result := make(map[string]string)
+	for key, value := range attributes {
+		if ns, name := SplitAttributeKey(key); ns == namespace {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// GroupAttributes returns the attributes grouped by namespace.
+//
+// Flat attributes are grouped using an empty namespace.
+//
+// attributes: The attributes map.
+func GroupAttributes(attributes map[string]string) map[string]map[string]string {
+	groups := make(map[string]map[string]string)
+	for key, value := range attributes {
+		namespace, name := SplitAttributeKey(key)
+		if groups[namespace] == nil {
+			groups[namespace] = make(map[string]string)
+		}
+		groups[namespace][name] = value
+	}
+	return groups
+}
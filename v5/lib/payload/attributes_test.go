@@ -0,0 +1,60 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttributeKey(t *testing.T) {
+	cases := []struct {
+		namespace string
+		name      string
+		key       string
+	}{
+		{"", "name", "name"},
+		{"ns", "name", "ns:name"},
+		{"ns", "a:b", `ns:a\:b`},
+		{"ns:x", "name", `ns\:x:name`},
+		{`ns\`, `a\:b`, `ns\\:a\\\:b`},
+	}
+
+	for _, c := range cases {
+		key := AttributeKey(c.namespace, c.name)
+		if key != c.key {
+			t.Errorf("expected key %q for %q and %q, got %q", c.key, c.namespace, c.name, key)
+		}
+
+		if namespace, name := SplitAttributeKey(key); namespace != c.namespace || name != c.name {
+			t.Errorf("expected %q and %q for key %q, got %q and %q", c.namespace, c.name, key, namespace, name)
+		}
+	}
+}
+
+func TestGetAttributesInWithSeparatorInName(t *testing.T) {
+	attributes := map[string]string{
+		"flat":                    "1",
+		AttributeKey("ns", "a:b"): "2",
+		AttributeKey("ns:a", "b"): "3",
+	}
+
+	if result := GetAttributesIn(attributes, "ns"); !reflect.DeepEqual(result, map[string]string{"a:b": "2"}) {
+		t.Errorf("unexpected attributes in namespace: %v", result)
+	}
+
+	expected := map[string]map[string]string{
+		"":     {"flat": "1"},
+		"ns":   {"a:b": "2"},
+		"ns:a": {"b": "3"},
+	}
+	if groups := GroupAttributes(attributes); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("unexpected attribute groups: %v", groups)
+	}
+}
@@ -0,0 +1,178 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/semver"
+)
+
+// Types of calls that can be defined in the action schemas.
+const (
+	CallTypeRuntime  = "call"
+	CallTypeDeferred = "deferred-call"
+	CallTypeRemote   = "remote-call"
+)
+
+// Caller contains the information of an action that calls another action.
+type Caller struct {
+	Service string
+	Version string
+	Action  string
+	// Type of call, which can be "call", "deferred-call" or "remote-call"
+	Type string
+	// Public address of the gateway for remote calls
+	Gateway string
+}
+
+// CallGraphError describes a call defined in an action schema that references
+// a service or an action that doesn't exist in the mapping.
+type CallGraphError struct {
+	Caller  Caller
+	Service string
+	Version string
+	Action  string
+	Message string
+}
+
+func (e CallGraphError) Error() string {
+	return fmt.Sprintf(
+		`Invalid %s from "%s" (%s) action "%s" to "%s" (%s) action "%s": %s`,
+		e.Caller.Type,
+		e.Caller.Service,
+		e.Caller.Version,
+		e.Caller.Action,
+		e.Service,
+		e.Version,
+		e.Action,
+		e.Message,
+	)
+}
+
+// Check if a call version references a version.
+//
+// Both values can be version patterns.
+func matchVersion(callVersion, version string) bool {
+	return callVersion == "*" ||
+		callVersion == version ||
+		semver.New(callVersion).Match(version) ||
+		semver.New(version).Match(callVersion)
+}
+
+// Check if a call defined in a schema references an action.
+//
+// call: The service, version and action of the call.
+func matchCall(call []string, service, version, action string) bool {
+	if len(call) != 3 {
+		return false
+	}
+	return (call[0] == "*" || call[0] == service) &&
+		matchVersion(call[1], version) &&
+		(call[2] == "*" || call[2] == action)
+}
+
+// Visit the calls defined in the action schemas of the mapping.
+//
+// The callback receives the caller and the service, version and action of the call.
+// The mapping is traversed in a deterministic order.
+func (m Mapping) visitCalls(callback func(caller Caller, call []string)) {
+	services := m.GetServices()
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Name != services[j].Name {
+			return services[i].Name < services[j].Name
+		}
+		return services[i].Version < services[j].Version
+	})
+
+	for _, sv := range services {
+		actions := m[sv.Name][sv.Version].Actions
+		names := make([]string, 0, len(actions))
+		for name := range actions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			schema := actions[name]
+			caller := Caller{Service: sv.Name, Version: sv.Version, Action: name}
+
+			caller.Type = CallTypeRuntime
+			for _, call := range schema.Calls {
+				callback(caller, call)
+			}
+
+			caller.Type = CallTypeDeferred
+			for _, call := range schema.DeferredCalls {
+				callback(caller, call)
+			}
+
+			caller.Type = CallTypeRemote
+			for _, call := range schema.RemoteCalls {
+				if len(call) == 4 {
+					caller.Gateway = call[0]
+					callback(caller, call[1:])
+				}
+			}
+		}
+	}
+}
+
+// GetCallers returns the actions that define a call to an action.
+//
+// Run-time, deferred and remote calls are checked, and calls to
+// version patterns match the versions resolved by the pattern.
+//
+// service: The name of the called service.
+// version: The version of the called service.
+// action: The name of the called action.
+func (m Mapping) GetCallers(service, version, action string) (callers []Caller) {
+	m.visitCalls(func(caller Caller, call []string) {
+		if matchCall(call, service, version, action) {
+			callers = append(callers, caller)
+		}
+	})
+	return callers
+}
+
+// ValidateCallGraph checks that the run-time and deferred calls defined in
+// the mapping reference services and actions that exist in the mapping.
+//
+// Remote calls are not validated because they reference services in other realms.
+// The result is empty when all the calls are valid.
+func (m Mapping) ValidateCallGraph() (errors []CallGraphError) {
+	m.visitCalls(func(caller Caller, call []string) {
+		if caller.Type == CallTypeRemote {
+			return
+		}
+
+		e := CallGraphError{Caller: caller}
+		if len(call) != 3 {
+			e.Message = fmt.Sprintf("the call definition is invalid: %v", call)
+			errors = append(errors, e)
+			return
+		}
+
+		e.Service, e.Version, e.Action = call[0], call[1], call[2]
+		if e.Service == "*" {
+			return
+		}
+
+		schema, err := m.GetSchema(e.Service, e.Version)
+		if err != nil {
+			e.Message = "the service doesn't exist"
+			errors = append(errors, e)
+		} else if _, exists := schema.Actions[e.Action]; !exists && e.Action != "*" {
+			e.Message = "the action doesn't exist"
+			errors = append(errors, e)
+		}
+	})
+	return errors
+}
@@ -0,0 +1,66 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import "testing"
+
+func newCallGraphMapping() Mapping {
+	return Mapping{
+		"users": {
+			"1.0.0": Schema{Actions: map[string]ActionSchema{
+				"read": {},
+			}},
+		},
+		"posts": {
+			"1.0.0": Schema{Actions: map[string]ActionSchema{
+				"list": {
+					Calls:         [][]string{{"users", "1.*", "read"}},
+					DeferredCalls: [][]string{{"users", "1.0.0", "missing"}},
+				},
+				"sync": {
+					Calls:       [][]string{{"comments", "1.0.0", "list"}},
+					RemoteCalls: [][]string{{"ktp://other:80", "users", "1.0.0", "read"}},
+				},
+			}},
+		},
+	}
+}
+
+func TestMappingGetCallers(t *testing.T) {
+	callers := newCallGraphMapping().GetCallers("users", "1.0.0", "read")
+
+	expected := []Caller{
+		{Service: "posts", Version: "1.0.0", Action: "list", Type: CallTypeRuntime},
+		{Service: "posts", Version: "1.0.0", Action: "sync", Type: CallTypeRemote, Gateway: "ktp://other:80"},
+	}
+	if len(callers) != len(expected) {
+		t.Fatalf("expected %d callers, got %d: %v", len(expected), len(callers), callers)
+	}
+
+	for i, c := range expected {
+		if callers[i] != c {
+			t.Errorf("expected caller %v, got %v", c, callers[i])
+		}
+	}
+}
+
+func TestMappingValidateCallGraph(t *testing.T) {
+	errors := newCallGraphMapping().ValidateCallGraph()
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errors), errors)
+	}
+
+	if e := errors[0]; e.Caller.Action != "list" || e.Action != "missing" {
+		t.Errorf("unexpected error for a missing action: %v", e)
+	}
+
+	if e := errors[1]; e.Caller.Action != "sync" || e.Service != "comments" {
+		t.Errorf("unexpected error for a missing service: %v", e)
+	}
+}
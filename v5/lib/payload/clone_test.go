@@ -0,0 +1,139 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Create a transport with data, relations and files for many services.
+func newBenchmarkTransport() *Transport {
+	t := &Transport{Meta: TransportMeta{Gateway: []string{"", "a"}}}
+	for i := 0; i < 50; i++ {
+		service := fmt.Sprintf("service-%d", i)
+		for j := 0; j < 10; j++ {
+			t.SetData(service, "1.0.0", "read", map[string]interface{}{"id": j})
+			t.SetRelateOne(service, fmt.Sprint(j), "users", fmt.Sprint(i))
+			t.appendFiles("a", service, "1.0.0", "read", File{Name: fmt.Sprintf("file-%d", j)})
+		}
+	}
+	return t
+}
+
+func TestTransportClone(t *testing.T) {
+	original := newBenchmarkTransport()
+	original.Meta.Properties = map[string]string{"name": "value"}
+	original.appendCalls("service-0", "1.0.0", Call{Name: "users", Version: "1.0.0", Action: "read"})
+	original.Errors = Errors{"a": {"service-0": {"1.0.0": []Error{{Message: "error"}}}}}
+
+	clone := original.Clone()
+	if clone.shared != 0 || original.shared != 0 {
+		t.Error("expected the clone to not share any section")
+	}
+
+	// The calls and errors are copied once
+	if n := len(clone.Calls["service-0"]["1.0.0"]); n != 1 {
+		t.Errorf("expected the clone to have 1 call, got %d", n)
+	}
+	if n := len(clone.Errors["a"]["service-0"]["1.0.0"]); n != 1 {
+		t.Errorf("expected the clone to have 1 error, got %d", n)
+	}
+
+	// Changes made directly to the clone values must not change the original
+	clone.Data["a"]["service-0"]["1.0.0"]["read"][0] = "new"
+	clone.Files["a"]["service-0"]["1.0.0"]["read"][0].Name = "new"
+	clone.Relations["a"]["service-0"]["0"]["a"]["users"] = "new"
+	clone.Meta.Properties["name"] = "new"
+	clone.appendCalls("service-0", "1.0.0", Call{Name: "posts"})
+	clone.Errors["a"]["service-0"]["1.0.0"] = nil
+
+	if original.Data["a"]["service-0"]["1.0.0"]["read"][0] == "new" {
+		t.Error("expected the original data to be unchanged")
+	}
+	if original.Files["a"]["service-0"]["1.0.0"]["read"][0].Name == "new" {
+		t.Error("expected the original files to be unchanged")
+	}
+	if original.Relations["a"]["service-0"]["0"]["a"]["users"] == "new" {
+		t.Error("expected the original relations to be unchanged")
+	}
+	if original.Meta.Properties["name"] != "value" {
+		t.Error("expected the original properties to be unchanged")
+	}
+	if n := len(original.Calls["service-0"]["1.0.0"]); n != 1 {
+		t.Errorf("expected the original to have 1 call, got %d", n)
+	}
+	if n := len(original.Errors["a"]["service-0"]["1.0.0"]); n != 1 {
+		t.Errorf("expected the original to have 1 error, got %d", n)
+	}
+}
+
+func TestTransportCloneSharedCopyOnWrite(t *testing.T) {
+	original := newBenchmarkTransport()
+	clone := original.CloneShared()
+
+	clone.SetData("service-0", "1.0.0", "read", "new")
+	clone.SetRelateOne("service-0", "99", "users", "1")
+	clone.appendFiles("a", "service-0", "1.0.0", "read", File{Name: "new"})
+
+	if n := len(original.Data["a"]["service-0"]["1.0.0"]["read"]); n != 10 {
+		t.Errorf("expected the original data to have 10 values, got %d", n)
+	}
+	if _, exists := original.Relations["a"]["service-0"]["99"]; exists {
+		t.Error("expected the original relations to be unchanged")
+	}
+	if n := len(original.Files["a"]["service-0"]["1.0.0"]["read"]); n != 10 {
+		t.Errorf("expected the original files to have 10 values, got %d", n)
+	}
+
+	// Changes to the original must not change the clone
+	original.SetData("service-1", "1.0.0", "read", "new")
+	if n := len(clone.Data["a"]["service-1"]["1.0.0"]["read"]); n != 10 {
+		t.Errorf("expected the clone data to have 10 values, got %d", n)
+	}
+}
+
+// Keeps the benchmark results so the copies are not optimized away.
+var benchmarkTransport *Transport
+
+func BenchmarkTransportClone(b *testing.B) {
+	t := newBenchmarkTransport()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkTransport = t.Clone()
+	}
+}
+
+func BenchmarkTransportCloneShared(b *testing.B) {
+	t := newBenchmarkTransport()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkTransport = t.CloneShared()
+	}
+}
+
+func BenchmarkTransportCloneSharedAndSetData(b *testing.B) {
+	t := newBenchmarkTransport()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.CloneShared().SetData("service-0", "1.0.0", "read", "value")
+	}
+}
+
+func BenchmarkTransportCloneFromPool(b *testing.B) {
+	t := newBenchmarkTransport()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.CloneFromPool().Release()
+	}
+}
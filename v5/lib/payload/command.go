@@ -8,6 +8,8 @@
 
 package payload
 
+import "github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+
 // NewCommand creates a new command payload.
 func NewCommand(name, scope string) Command {
 	return Command{
@@ -26,6 +28,23 @@ type Command struct {
 	Meta    CommandMeta `json:"m"`
 }
 
+// Clone creates a deep copy of the command.
+//
+// The copy is created by serializing the command, so values are the ones
+// that would be received from the framework for the same payload.
+func (c Command) Clone() (*Command, error) {
+	data, err := msgpack.Encode(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var clone Command
+	if err := msgpack.Decode(data, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
 // GetName returns the name of the command.
 func (c Command) GetName() string {
 	return c.Command.Name
@@ -94,6 +113,8 @@ type CommandArguments struct {
 	Params    ActionParams  `json:"p,omitempty"` // TODO: The specs seem to be wrong here
 	Files     ActionFiles   `json:"f,omitempty"`
 	Return    interface{}   `json:"rv,omitempty"`
+	// Public address of the remote gateway for the remote run-time calls
+	Gateway string `json:"g,omitempty"`
 }
 
 // GetCall returns the info for the call.
@@ -139,7 +160,8 @@ func (a *CommandArguments) SetCallee(callee []string) {
 
 // CommandMeta contains the meta-data associated with the command.
 type CommandMeta struct {
-	Scope string `json:"s"`
+	Scope  string `json:"s"`
+	CallID string `json:"ci,omitempty"`
 }
 
 // Meta contains the meta-data associated with the payload.
@@ -0,0 +1,195 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// TransportDiff contains the values added to a transport between two snapshots.
+//
+// Links and relations whose value changed are included with the new value.
+// The JSON serialization uses readable names instead of the payload names.
+type TransportDiff struct {
+	Data      ServiceData `json:"data,omitempty"`
+	Links     Links       `json:"links,omitempty"`
+	Relations Relations   `json:"relations,omitempty"`
+	Calls     Calls       `json:"calls,omitempty"`
+	Errors    Errors      `json:"errors,omitempty"`
+	Warnings  Errors      `json:"warnings,omitempty"`
+}
+
+// IsEmpty checks if the diff doesn't contain changes.
+func (d TransportDiff) IsEmpty() bool {
+	return len(d.Data) == 0 &&
+		len(d.Links) == 0 &&
+		len(d.Relations) == 0 &&
+		len(d.Calls) == 0 &&
+		len(d.Errors) == 0 &&
+		len(d.Warnings) == 0
+}
+
+// JSON serializes the diff as indented JSON.
+func (d TransportDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// DiffTransports compares two snapshots of a transport and returns the values added to the second one.
+//
+// Nil transports are considered empty. Values in lists are compared by value, so a value
+// is only considered added when the list of the first transport doesn't contain it.
+//
+// before: The transport before the changes.
+// after: The transport after the changes.
+func DiffTransports(before, after *Transport) TransportDiff {
+	if before == nil {
+		before = &Transport{}
+	}
+	if after == nil {
+		after = &Transport{}
+	}
+
+	before.load()
+	after.load()
+
+	return TransportDiff{
+		Data:      diffServiceData(before.Data, after.Data),
+		Links:     diffLinks(before.Links, after.Links),
+		Relations: diffRelations(before.Relations, after.Relations),
+		Calls:     diffCalls(before.Calls, after.Calls),
+		Errors:    diffErrors(before.Errors, after.Errors),
+		Warnings:  diffErrors(before.Warnings, after.Warnings),
+	}
+}
+
+// Get the values of a list that are not in another list.
+//
+// Each value of the first list matches a single value of the second one,
+// so repeated values are considered added when they are repeated more times.
+func diffList[T any](before, after []T) (added []T) {
+	matched := make([]bool, len(before))
+next:
+	for _, value := range after {
+		for i, previous := range before {
+			if !matched[i] && reflect.DeepEqual(previous, value) {
+				matched[i] = true
+				continue next
+			}
+		}
+		added = append(added, value)
+	}
+	return added
+}
+
+func diffServiceData(before, after ServiceData) ServiceData {
+	diff := ServiceData{}
+	for address, services := range after {
+		for service, versions := range services {
+			for version, actions := range versions {
+				for action, values := range actions {
+					added := diffList(before[address][service][version][action], values)
+					if len(added) == 0 {
+						continue
+					}
+
+					if diff[address] == nil {
+						diff[address] = make(map[string]map[string]map[string][]interface{})
+					}
+					if diff[address][service] == nil {
+						diff[address][service] = make(map[string]map[string][]interface{})
+					}
+					if diff[address][service][version] == nil {
+						diff[address][service][version] = make(map[string][]interface{})
+					}
+					diff[address][service][version][action] = added
+				}
+			}
+		}
+	}
+	return diff
+}
+
+func diffLinks(before, after Links) Links {
+	diff := Links{}
+	for address, services := range after {
+		for service, links := range services {
+			for link, uri := range links {
+				if previous, exists := before[address][service][link]; exists && previous == uri {
+					continue
+				}
+
+				diff.add(address, service, link, uri)
+			}
+		}
+	}
+	return diff
+}
+
+func diffRelations(before, after Relations) Relations {
+	diff := Relations{}
+	for address, services := range after {
+		for service, pks := range services {
+			for pk, remoteAddresses := range pks {
+				for remoteAddress, remoteServices := range remoteAddresses {
+					for remoteService, foreignKey := range remoteServices {
+						previous, exists := before[address][service][pk][remoteAddress][remoteService]
+						if exists && reflect.DeepEqual(previous, foreignKey) {
+							continue
+						}
+
+						diff.add(address, service, pk, remoteAddress, remoteService, foreignKey)
+					}
+				}
+			}
+		}
+	}
+	return diff
+}
+
+func diffCalls(before, after Calls) Calls {
+	diff := Calls{}
+	for service, versions := range after {
+		for version, calls := range versions {
+			added := diffList(before[service][version], calls)
+			if len(added) == 0 {
+				continue
+			}
+
+			if diff[service] == nil {
+				diff[service] = make(map[string][]Call)
+			}
+			diff[service][version] = added
+		}
+	}
+	return diff
+}
+
+func diffErrors(before, after Errors) Errors {
+	diff := Errors{}
+	for address, services := range after {
+		for service, versions := range services {
+			for version, errors := range versions {
+				added := diffList(before[address][service][version], errors)
+				if len(added) == 0 {
+					continue
+				}
+
+				if diff[address] == nil {
+					diff[address] = make(map[string]map[string][]Error)
+				}
+				if diff[address][service] == nil {
+					diff[address][service] = make(map[string][]Error)
+				}
+				diff[address][service][version] = added
+			}
+		}
+	}
+	return diff
+}
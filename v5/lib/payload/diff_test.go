@@ -0,0 +1,53 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffTransports(t *testing.T) {
+	before := &Transport{
+		Data:  ServiceData{"a": {"users": {"1.0.0": {"read": {map[string]interface{}{"id": 1}}}}}},
+		Links: Links{"a": {"users": {"self": "/users/1", "list": "/users"}}},
+	}
+
+	after := &Transport{
+		Data: ServiceData{"a": {"users": {"1.0.0": {"read": {
+			map[string]interface{}{"id": 1},
+			map[string]interface{}{"id": 2},
+		}}}}},
+		Links:     Links{"a": {"users": {"self": "/users/2", "list": "/users"}}},
+		Relations: Relations{},
+		Errors:    Errors{"a": {"users": {"1.0.0": {{Message: "Failed"}}}}},
+	}
+	after.Relations.add("a", "users", "2", "a", "posts", "7")
+
+	diff := DiffTransports(before, after)
+	expected := TransportDiff{
+		Data:      ServiceData{"a": {"users": {"1.0.0": {"read": {map[string]interface{}{"id": 2}}}}}},
+		Links:     Links{"a": {"users": {"self": "/users/2"}}},
+		Relations: Relations{"a": {"users": {"2": {"a": {"posts": "7"}}}}},
+		Calls:     Calls{},
+		Errors:    Errors{"a": {"users": {"1.0.0": {{Message: "Failed"}}}}},
+		Warnings:  Errors{},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+
+	if !DiffTransports(after, after).IsEmpty() {
+		t.Error("expected an empty diff for the same transport")
+	}
+
+	if DiffTransports(nil, after).IsEmpty() {
+		t.Error("expected changes when the first transport is nil")
+	}
+}
@@ -14,11 +14,25 @@ const DefaultErrorStatus = "500 Internal Server Error"
 // DefaultErrorMessage contains the default message to use for errors.
 const DefaultErrorMessage = "Unknown Error"
 
+// Error severity levels.
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+	SeverityFatal   = "fatal"
+)
+
+// ErrorOverflowMessage is the message of the error added when the errors of a service are capped.
+const ErrorOverflowMessage = "Too many errors"
+
 // Error represents a reply that is returned when there is an error during command execution.
 type Error struct {
-	Message string `json:"m"`
-	Code    int    `json:"c"`
-	Status  string `json:"s"`
+	Message  string                 `json:"m"`
+	Code     int                    `json:"c"`
+	Status   string                 `json:"s"`
+	Metadata map[string]interface{} `json:"M,omitempty"`
+	Severity string                 `json:"v,omitempty"`
+	Count    int                    `json:"n,omitempty"`
+	Omitted  int                    `json:"o,omitempty"`
 }
 
 // GetMessage returns the error message.
@@ -41,3 +55,52 @@ func (e Error) GetStatus() string {
 	}
 	return e.Status
 }
+
+// GetMetadata returns the error metadata.
+func (e Error) GetMetadata() map[string]interface{} {
+	return e.Metadata
+}
+
+// GetSeverity returns the error severity.
+//
+// The severity is "error" when the error doesn't have a severity.
+func (e Error) GetSeverity() string {
+	if e.Severity == "" {
+		return SeverityError
+	}
+	return e.Severity
+}
+
+// IsWarning checks if the error is a warning.
+func (e Error) IsWarning() bool {
+	return e.Severity == SeverityWarning
+}
+
+// GetCount returns the number of times the error happened.
+//
+// The count is greater than one when duplicated errors were collapsed into a single error.
+func (e Error) GetCount() int {
+	if e.Count < 1 {
+		return 1
+	}
+	return e.Count
+}
+
+// IsOverflow checks if the error is the marker added when the errors of a service are capped.
+func (e Error) IsOverflow() bool {
+	return e.Omitted > 0
+}
+
+// GetOmitted returns the number of errors omitted when the errors of a service are capped.
+func (e Error) GetOmitted() int {
+	return e.Omitted
+}
+
+// Check if two errors are duplicates.
+func (e Error) isDuplicate(other Error) bool {
+	return e.Message == other.Message &&
+		e.Code == other.Code &&
+		e.GetSeverity() == other.GetSeverity() &&
+		!e.IsOverflow() &&
+		!other.IsOverflow()
+}
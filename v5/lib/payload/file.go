@@ -10,12 +10,23 @@ package payload
 
 // File represents a file parameter.
 type File struct {
-	Name     string `json:"n"`
-	Path     string `json:"p"`
-	Mime     string `json:"m"`
-	Filename string `json:"f"`
-	Size     uint   `json:"s"`
-	Token    string `json:"t,omitempty"`
+	Name       string            `json:"n"`
+	Path       string            `json:"p"`
+	Mime       string            `json:"m"`
+	Filename   string            `json:"f"`
+	Size       uint              `json:"s"`
+	Token      string            `json:"t,omitempty"`
+	Checksum   *Checksum         `json:"c,omitempty"`
+	Encoding   string            `json:"e,omitempty"`
+	Attributes map[string]string `json:"a,omitempty"`
+	Ranges     bool              `json:"ar,omitempty"`
+	ETag       string            `json:"et,omitempty"`
+}
+
+// Checksum contains the digest of the file contents.
+type Checksum struct {
+	Algorithm string `json:"a"`
+	Digest    string `json:"d"`
 }
 
 // GetMime returns the mime type of the file.
@@ -0,0 +1,45 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+)
+
+// Hash returns the SHA-256 hash of a payload as an hexadecimal string.
+//
+// The payload is serialized as msgpack with the map keys sorted,
+// so the hash is the same for payloads with equal values.
+func Hash(v interface{}) (string, error) {
+	b, err := msgpack.EncodeCanonical(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Hash returns the SHA-256 hash of the command payload.
+func (c Command) Hash() (string, error) {
+	return Hash(c)
+}
+
+// Hash returns the SHA-256 hash of the reply payload.
+func (r Reply) Hash() (string, error) {
+	return Hash(r)
+}
+
+// Hash returns the SHA-256 hash of the transport payload.
+func (t Transport) Hash() (string, error) {
+	return Hash(t)
+}
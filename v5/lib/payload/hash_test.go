@@ -0,0 +1,89 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashIsCanonical(t *testing.T) {
+	// Maps with many keys are iterated in a different order each time
+	a := map[string]interface{}{}
+	b := map[string]interface{}{}
+	for i := 0; i < 50; i++ {
+		a[fmt.Sprintf("key-%d", i)] = i
+		b[fmt.Sprintf("key-%d", 49-i)] = 49 - i
+	}
+
+	expected, err := Hash(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) != 64 {
+		t.Errorf("expected an hexadecimal SHA-256 hash, got %s", expected)
+	}
+
+	for i := 0; i < 10; i++ {
+		if hash, err := Hash(b); err != nil {
+			t.Fatal(err)
+		} else if hash != expected {
+			t.Fatalf("expected the same hash for equal values, got %s and %s", expected, hash)
+		}
+	}
+
+	b["key-0"] = "changed"
+	if hash, _ := Hash(b); hash == expected {
+		t.Error("expected a different hash for different values")
+	}
+}
+
+func TestTransportHash(t *testing.T) {
+	newTransport := func() *Transport {
+		transport := &Transport{}
+		transport.Meta.Gateway = []string{"ktp://internal", "http://public"}
+		transport.Meta.Properties = map[string]string{"a": "1", "b": "2", "c": "3"}
+		transport.SetData("users", "1.0.0", "read", map[string]interface{}{"id": 1, "name": "jane"})
+		return transport
+	}
+
+	expected, err := newTransport().Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash, err := newTransport().Hash(); err != nil || hash != expected {
+		t.Errorf("expected the same hash for equal transports, got %s %v", hash, err)
+	}
+
+	transport := newTransport()
+	transport.Meta.Properties["a"] = "changed"
+	if hash, _ := transport.Hash(); hash == expected {
+		t.Error("expected a different hash for a different transport")
+	}
+}
+
+func TestCommandHash(t *testing.T) {
+	command := NewCommand("read", "service")
+	command.Command.Arguments = &CommandArguments{Transport: &Transport{}}
+	hash, err := command.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := NewCommand("write", "service")
+	other.Command.Arguments = &CommandArguments{Transport: &Transport{}}
+	if otherHash, _ := other.Hash(); otherHash == hash {
+		t.Error("expected a different hash for a different command")
+	}
+
+	reply := NewActionReply(&command)
+	if _, err := reply.Hash(); err != nil {
+		t.Error(err)
+	}
+}
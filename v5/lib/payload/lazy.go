@@ -0,0 +1,191 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/json"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+	"github.com/ugorji/go/codec"
+)
+
+// Transport type without the custom serialization methods.
+type transportAlias Transport
+
+// Transport payload with the data and files sections kept as raw values.
+type rawTransport struct {
+	Meta         TransportMeta `json:"m"`
+	Body         *File         `json:"b,omitempty"`
+	Files        codec.Raw     `json:"f,omitempty"`
+	Data         codec.Raw     `json:"d,omitempty"`
+	Relations    Relations     `json:"r,omitempty"`
+	Links        Links         `json:"l,omitempty"`
+	Transactions Transactions  `json:"t,omitempty"`
+	Calls        Calls         `json:"C,omitempty"`
+	Errors       Errors        `json:"e,omitempty"`
+	Warnings     Errors        `json:"w,omitempty"`
+}
+
+// Serialized sections of a transport that are decoded the first time they are used.
+//
+// The decoded values are kept so the copies of the transport value that share the
+// sections get the same values, no matter which copy decodes them first.
+type lazySections struct {
+	mutex sync.Mutex
+	// Serialized values of the sections
+	rawFiles []byte
+	rawData  []byte
+	// Decoded values of the sections
+	files  Files
+	data   ServiceData
+	loaded bool
+	err    error
+}
+
+// Decode the sections once and return the decoded values.
+func (l *lazySections) decode() (Files, ServiceData, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.loaded {
+		l.loaded = true
+		if err := decodeRaw(l.rawFiles, &l.files); err != nil {
+			l.err = fmt.Errorf("Failed to decode the transport files: %v", err)
+		} else if err := decodeRaw(l.rawData, &l.data); err != nil {
+			l.err = fmt.Errorf("Failed to decode the transport data: %v", err)
+		}
+		l.rawFiles = nil
+		l.rawData = nil
+	}
+	return l.files, l.data, l.err
+}
+
+// Value that reports an error when it is encoded or decoded.
+//
+// Codec selfers can't return errors, so the error is reported by the codec when it
+// calls the marshaler, and it is returned by the encode functions. The codec only
+// uses the marshalers of the types that implement the unmarshalers too.
+type codecError struct {
+	err error
+}
+
+// MarshalBinary returns the error for the binary formats.
+func (e codecError) MarshalBinary() ([]byte, error) {
+	return nil, e.err
+}
+
+// UnmarshalBinary returns the error for the binary formats.
+func (e *codecError) UnmarshalBinary([]byte) error {
+	return e.err
+}
+
+// MarshalJSON returns the error for the JSON format.
+func (e codecError) MarshalJSON() ([]byte, error) {
+	return nil, e.err
+}
+
+// UnmarshalJSON returns the error for the JSON format.
+func (e *codecError) UnmarshalJSON([]byte) error {
+	return e.err
+}
+
+// Check if a raw value contains a null value.
+func isRawNull(raw []byte) bool {
+	raw = bytes.TrimSpace(raw)
+	// JSON null or msgpack nil
+	return len(raw) == 0 || bytes.Equal(raw, []byte("null")) || (len(raw) == 1 && raw[0] == 0xc0)
+}
+
+// Decode a raw value serialized as JSON or msgpack.
+//
+// The sections are maps, so JSON values start with a curly bracket, which
+// is not a valid first byte for a msgpack map.
+func decodeRaw(raw []byte, v interface{}) error {
+	if isRawNull(raw) {
+		return nil
+	}
+
+	if bytes.TrimSpace(raw)[0] == '{' {
+		return json.Decode(raw, v)
+	}
+	return msgpack.Decode(raw, v)
+}
+
+// CodecDecodeSelf decodes the transport keeping the data and files serialized.
+//
+// The sections are decoded when they are used for the first time, so the requests that
+// only read the transport meta don't pay the cost of decoding large transports.
+func (t *Transport) CodecDecodeSelf(d *codec.Decoder) {
+	var raw rawTransport
+	d.MustDecode(&raw)
+
+	*t = Transport{
+		Meta:         raw.Meta,
+		Body:         raw.Body,
+		Relations:    raw.Relations,
+		Links:        raw.Links,
+		Transactions: raw.Transactions,
+		Calls:        raw.Calls,
+		Errors:       raw.Errors,
+		Warnings:     raw.Warnings,
+	}
+	if isRawNull(raw.Files) && isRawNull(raw.Data) {
+		return
+	}
+
+	// The raw values reference the decoder buffer so they must be copied
+	t.lazy = &lazySections{
+		rawFiles: append([]byte(nil), raw.Files...),
+		rawData:  append([]byte(nil), raw.Data...),
+	}
+}
+
+// CodecEncodeSelf encodes the transport decoding the sections that were not used.
+//
+// The encoding fails with the decoding error when the sections can't be decoded.
+func (t *Transport) CodecEncodeSelf(e *codec.Encoder) {
+	if err := t.Load(); err != nil {
+		e.MustEncode(codecError{err})
+		return
+	}
+
+	e.MustEncode((*transportAlias)(t))
+}
+
+// Load decodes the transport sections that are not decoded yet.
+//
+// Transport sections are decoded automatically when they are used through the transport
+// methods, so it must only be called before reading the data or files fields directly.
+func (t *Transport) Load() error {
+	lazy := t.lazy
+	if lazy == nil {
+		return nil
+	}
+
+	files, data, err := lazy.decode()
+	if err != nil {
+		// The sections are left empty and the error is returned on each call
+		return err
+	}
+
+	t.Files = files
+	t.Data = data
+	t.lazy = nil
+	return nil
+}
+
+// Decode the transport sections ignoring the errors.
+//
+// The sections that can't be decoded are left empty, and the error is returned by Load.
+func (t *Transport) load() {
+	_ = t.Load()
+}
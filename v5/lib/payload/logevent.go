@@ -0,0 +1,67 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+// LogEvent contains a log event recorded by an action in the transport.
+type LogEvent struct {
+	Service  string                 `json:"s"`
+	Version  string                 `json:"v"`
+	Action   string                 `json:"a"`
+	Level    int                    `json:"l"`
+	Message  string                 `json:"m"`
+	Fields   map[string]interface{} `json:"f,omitempty"`
+	Datetime string                 `json:"d"`
+}
+
+// Check if two events are the same event.
+func (e LogEvent) equals(other LogEvent) bool {
+	return e.Datetime == other.Datetime &&
+		e.Service == other.Service &&
+		e.Version == other.Version &&
+		e.Action == other.Action &&
+		e.Level == other.Level &&
+		e.Message == other.Message
+}
+
+// AddLogEvent records a log event in the transport.
+//
+// The event is discarded when the transport already contains the maximum number of events.
+// The result is false when the event is discarded.
+//
+// event: The log event.
+// limit: The maximum number of events in the transport.
+func (t *Transport) AddLogEvent(event LogEvent, limit int) bool {
+	if len(t.Meta.Events) >= limit {
+		return false
+	}
+
+	t.Meta.Events = append(t.Meta.Events, event)
+	return true
+}
+
+// Merge log events, skipping the events that already exist in the target.
+//
+// Run-time call transports contain the events of the caller, so they
+// must not be added again when the transports are merged.
+func mergeLogEvents(target, events []LogEvent) []LogEvent {
+	for _, event := range events {
+		exists := false
+		for _, current := range target {
+			if current.equals(event) {
+				exists = true
+				break
+			}
+		}
+
+		if !exists {
+			target = append(target, event)
+		}
+	}
+	return target
+}
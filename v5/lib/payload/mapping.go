@@ -10,7 +10,9 @@ package payload
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/semver"
 )
 
@@ -61,7 +63,28 @@ func (m Mapping) GetSchema(name, version string) (*Schema, error) {
 			return &schema, nil
 		}
 	}
-	return nil, fmt.Errorf(`cannot resolve schema for service: "%s" (%s)`, name, version)
+	available := m.GetVersions(name)
+	sort.Strings(available)
+	return nil, &SchemaNotFoundError{Name: name, Version: version, Available: available}
+}
+
+// SchemaNotFoundError is returned when the schema for a service version is not in the mapping.
+type SchemaNotFoundError struct {
+	// Name is the requested service name.
+	Name string
+	// Version is the requested version or version pattern.
+	Version string
+	// Available contains the sorted versions of the service that exist in the mapping.
+	Available []string
+}
+
+func (e *SchemaNotFoundError) Error() string {
+	return fmt.Sprintf(`cannot resolve schema for service: "%s" (%s)`, e.Name, e.Version)
+}
+
+// HasService checks if the mapping contains any version of the requested service.
+func (e *SchemaNotFoundError) HasService() bool {
+	return len(e.Available) > 0
 }
 
 // ServiceVersion contains the name and version of a service.
@@ -78,6 +101,20 @@ type Schema struct {
 	Actions map[string]ActionSchema `json:"ac"`
 }
 
+// Clone returns a deep copy of the schema.
+func (s Schema) Clone() (*Schema, error) {
+	data, err := msgpack.Encode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var clone Schema
+	if err := msgpack.Decode(data, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
 // GetAddress returns the internal address of the hosts.
 func (s Schema) GetAddress() []string {
 	return s.Address
@@ -121,6 +158,7 @@ type ActionSchema struct {
 	RemoteCalls   [][]string             `json:"rc,omitempty"`
 	Fallback      *FallbackSchema        `json:"F,omitempty"`
 	Deprecated    *bool                  `json:"D,omitempty"`
+	Sunset        string                 `json:"su,omitempty"`
 	HTTP          HTTPActionSchema       `json:"h,omitempty"`
 	Params        map[string]ParamSchema `json:"p,omitempty"`
 	Files         map[string]FileSchema  `json:"f,omitempty"`
@@ -269,9 +307,16 @@ func (e FallbackError) GetCode() (int, bool) {
 		return 0, false
 	}
 
-	// JSON decodes the numbers as float
-	v, ok := e[1].(float64)
-	return int(v), ok
+	// JSON decodes the numbers as float and msgpack as signed or unsigned integers
+	switch v := e[1].(type) {
+	case float64:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	}
+	return 0, false
 }
 
 // GetStatus returns the status message of the errror.
@@ -332,6 +377,8 @@ type FileSchema struct {
 	Min          uint           `json:"mn,omitempty"`
 	ExclusiveMin bool           `json:"en,omitempty"`
 	HTTP         HTTPFileSchema `json:"h,omitempty"`
+	Checksum     *bool          `json:"cr,omitempty"`
+	Algorithm    string         `json:"ca,omitempty"`
 }
 
 // HTTPFileSchema contains the HTTP schema definition for a file.
@@ -371,7 +418,13 @@ type RelationSchema struct {
 }
 
 // ReturnSchema contains the schema for the return value.
+//
+// Object return values can define their fields, and array return values
+// can define the schema for their items.
 type ReturnSchema struct {
-	Type       string `json:"t"`
-	AllowEmpty bool   `json:"e,omitempty"`
+	Type       string              `json:"t"`
+	AllowEmpty bool                `json:"e,omitempty"`
+	Field      []FieldSchema       `json:"f,omitempty"`
+	Fields     []ObjectFieldSchema `json:"F,omitempty"`
+	Items      *ReturnSchema       `json:"i,omitempty"`
 }
@@ -0,0 +1,43 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMappingGetSchemaNotFound(t *testing.T) {
+	mapping := Mapping{
+		"users": {
+			"2.0.0": Schema{},
+			"1.0.0": Schema{},
+		},
+	}
+
+	_, err := mapping.GetSchema("users", "3.*")
+
+	var notFound *SchemaNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected a schema not found error, got: %v", err)
+	}
+
+	if notFound.Name != "users" || notFound.Version != "3.*" {
+		t.Errorf("unexpected service: %s (%s)", notFound.Name, notFound.Version)
+	}
+
+	if expected := []string{"1.0.0", "2.0.0"}; !reflect.DeepEqual(notFound.Available, expected) {
+		t.Errorf("expected available versions %v, got: %v", expected, notFound.Available)
+	}
+
+	if _, err := mapping.GetSchema("posts", "1.0.0"); !errors.As(err, &notFound) || notFound.HasService() {
+		t.Errorf("expected a schema not found error without versions, got: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+// PruneFilter decides if the values of a service are kept in the transport.
+//
+// The result is false when the values of the service must be removed.
+type PruneFilter func(address, service string) bool
+
+// Prune removes the values of the services that don't match a filter.
+//
+// The data, links, relations, files, errors and warnings of the services are removed.
+// Relations are also removed when the related service doesn't match the filter.
+// The result is true when any value was removed.
+//
+// keep: The filter for the services to keep.
+func (t *Transport) Prune(keep PruneFilter) (removed bool) {
+	t.Unshare()
+
+	for address, services := range t.Data {
+		for service := range services {
+			if !keep(address, service) {
+				delete(services, service)
+				removed = true
+			}
+		}
+		if len(services) == 0 {
+			delete(t.Data, address)
+		}
+	}
+
+	for address, services := range t.Links {
+		for service := range services {
+			if !keep(address, service) {
+				delete(services, service)
+				removed = true
+			}
+		}
+		if len(services) == 0 {
+			delete(t.Links, address)
+		}
+	}
+
+	if pruneRelations(t.Relations, keep) {
+		removed = true
+	}
+
+	for address, services := range t.Files {
+		for service := range services {
+			if !keep(address, service) {
+				delete(services, service)
+				removed = true
+			}
+		}
+		if len(services) == 0 {
+			delete(t.Files, address)
+		}
+	}
+
+	if pruneErrors(t.Errors, keep) {
+		removed = true
+	}
+	if pruneErrors(t.Warnings, keep) {
+		removed = true
+	}
+	return removed
+}
+
+func pruneRelations(relations Relations, keep PruneFilter) (removed bool) {
+	for address, services := range relations {
+		for service, pks := range services {
+			if !keep(address, service) {
+				delete(services, service)
+				removed = true
+				continue
+			}
+
+			for pk, remoteAddresses := range pks {
+				for remoteAddress, remoteServices := range remoteAddresses {
+					for remoteService := range remoteServices {
+						if !keep(remoteAddress, remoteService) {
+							delete(remoteServices, remoteService)
+							removed = true
+						}
+					}
+					if len(remoteServices) == 0 {
+						delete(remoteAddresses, remoteAddress)
+					}
+				}
+				if len(remoteAddresses) == 0 {
+					delete(pks, pk)
+				}
+			}
+			if len(pks) == 0 {
+				delete(services, service)
+			}
+		}
+		if len(services) == 0 {
+			delete(relations, address)
+		}
+	}
+	return removed
+}
+
+func pruneErrors(errors Errors, keep PruneFilter) (removed bool) {
+	for address, services := range errors {
+		for service := range services {
+			if !keep(address, service) {
+				delete(services, service)
+				removed = true
+			}
+		}
+		if len(services) == 0 {
+			delete(errors, address)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,49 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTransportPrune(t *testing.T) {
+	transport := &Transport{
+		Data: ServiceData{"a": {
+			"users":   {"1.0.0": {"read": {map[string]interface{}{"id": 1}}}},
+			"secrets": {"1.0.0": {"read": {map[string]interface{}{"id": 2}}}},
+		}},
+		Links:     Links{"a": {"users": {"self": "/users/1"}, "secrets": {"self": "/secrets/2"}}},
+		Relations: Relations{},
+		Errors:    Errors{"a": {"secrets": {"1.0.0": {{Message: "Failed"}}}}},
+	}
+	transport.Relations.add("a", "users", "1", "a", "secrets", "2")
+	transport.Relations.add("a", "users", "1", "a", "posts", "7")
+
+	keep := func(address, service string) bool {
+		return service != "secrets"
+	}
+	if !transport.Prune(keep) {
+		t.Fatal("expected values to be removed")
+	}
+
+	expected := &Transport{
+		Data:      ServiceData{"a": {"users": {"1.0.0": {"read": {map[string]interface{}{"id": 1}}}}}},
+		Links:     Links{"a": {"users": {"self": "/users/1"}}},
+		Relations: Relations{"a": {"users": {"1": {"a": {"posts": "7"}}}}},
+		Errors:    Errors{},
+	}
+	if !reflect.DeepEqual(transport, expected) {
+		t.Errorf("unexpected transport: %+v", transport)
+	}
+
+	if transport.Prune(keep) {
+		t.Error("expected no values to be removed")
+	}
+}
@@ -11,6 +11,8 @@ package payload
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
 )
 
 // NewErrorReply creates a new error reply payload.
@@ -44,12 +46,22 @@ func NewRequestReply(c *Command) *Reply {
 }
 
 // NewResponseReply creates a new command reply for a response.
+//
+// The attributes are copied so the changes in the reply don't change the command attributes.
 func NewResponseReply(c *Command) *Reply {
+	var attributes map[string]string
+	if source := c.GetAttributes(); source != nil {
+		attributes = make(map[string]string, len(source))
+		for name, value := range source {
+			attributes[name] = value
+		}
+	}
+
 	return &Reply{
 		Command: &CommandReply{
 			Name: c.GetName(),
 			Result: CommandResult{
-				Attributes: c.GetAttributes(),
+				Attributes: attributes,
 				Response:   c.GetResponse(),
 			},
 		},
@@ -74,6 +86,23 @@ type Reply struct {
 	Command *CommandReply `json:"cr,omitempty"`
 }
 
+// Clone creates a deep copy of the reply.
+//
+// The copy is created by serializing the reply, so values are the ones
+// that would be sent to the framework for the same payload.
+func (r *Reply) Clone() (*Reply, error) {
+	data, err := msgpack.Encode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var clone Reply
+	if err := msgpack.Decode(data, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
 // IsError checks if the reply is an error reply.
 func (r *Reply) IsError() bool {
 	return r.Error != nil
@@ -156,6 +185,7 @@ func (r CommandReply) IsAction() bool {
 type CommandResult struct {
 	Attributes map[string]string `json:"a,omitempty"`
 	Call       *CallInfo         `json:"c,omitempty"`
+	Request    *HTTPRequest      `json:"r,omitempty"`
 	Response   *HTTPResponse     `json:"R,omitempty"`
 	Transport  *Transport        `json:"T,omitempty"`
 	Return     interface{}       `json:"rv,omitempty"`
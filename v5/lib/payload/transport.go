@@ -8,7 +8,12 @@
 
 package payload
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
 
 // TransactionCommit defines the command type for commit transactions.
 const TransactionCommit = "commit"
@@ -33,6 +38,9 @@ func transactionKey(command string) string {
 }
 
 func mergeRuntimeCallTransportData(source, target *Transport) {
+	// The source values are added to the target so none of them can be shared
+	source.ownData()
+	target.ownData()
 	if target.Data == nil {
 		target.Data = ServiceData{}
 	}
@@ -41,6 +49,8 @@ func mergeRuntimeCallTransportData(source, target *Transport) {
 }
 
 func mergeRuntimeCallTransportRelations(source, target *Transport) {
+	source.ownRelations()
+	target.ownRelations()
 	if target.Relations == nil {
 		target.Relations = Relations{}
 	}
@@ -76,7 +86,16 @@ func mergeRuntimeCallTransportErrors(source, target *Transport) {
 	target.Errors.merge(source.Errors)
 }
 
+func mergeRuntimeCallTransportWarnings(source, target *Transport) {
+	if target.Warnings == nil {
+		target.Warnings = Errors{}
+	}
+	target.Warnings.merge(source.Warnings)
+}
+
 func mergeRuntimeCallTransportFiles(source, target *Transport) {
+	source.ownFiles()
+	target.ownFiles()
 	if target.Files == nil {
 		target.Files = Files{}
 	}
@@ -88,6 +107,7 @@ func mergeRuntimeCallTransportFiles(source, target *Transport) {
 // source: The transport payload to merge.
 // target: The target transport payload where to merge.
 func mergeRuntimeCallTransport(source, target *Transport) {
+	source.load()
 	target.Meta.merge(source.Meta)
 
 	if source.Data != nil {
@@ -114,6 +134,10 @@ func mergeRuntimeCallTransport(source, target *Transport) {
 		mergeRuntimeCallTransportErrors(source, target)
 	}
 
+	if source.Warnings != nil {
+		mergeRuntimeCallTransportWarnings(source, target)
+	}
+
 	if source.Files != nil {
 		mergeRuntimeCallTransportFiles(source, target)
 	}
@@ -123,6 +147,22 @@ func mergeRuntimeCallTransport(source, target *Transport) {
 	}
 }
 
+// Sections of the transport that can be shared between transport copies.
+const (
+	sharedData uint32 = 1 << iota
+	sharedRelations
+	sharedFiles
+
+	sharedAll = sharedData | sharedRelations | sharedFiles
+)
+
+// Pool of transports for the copies that are released after use.
+var transportPool = sync.Pool{
+	New: func() interface{} {
+		return new(Transport)
+	},
+}
+
 // Transport contains the transport payload data.
 type Transport struct {
 	reply        *Reply
@@ -135,10 +175,17 @@ type Transport struct {
 	Transactions Transactions  `json:"t,omitempty"`
 	Calls        Calls         `json:"C,omitempty"`
 	Errors       Errors        `json:"e,omitempty"`
+	Warnings     Errors        `json:"w,omitempty"`
+
+	// Sections that are shared with copies of the transport
+	shared uint32
+	// Sections that are not decoded yet
+	lazy *lazySections
 }
 
 // Append files to the transport.
 func (t *Transport) appendFiles(address, service, version, action string, files ...File) {
+	t.ownFiles()
 	if t.Files == nil {
 		t.Files = Files{}
 	}
@@ -148,6 +195,7 @@ func (t *Transport) appendFiles(address, service, version, action string, files
 
 // Add a relation to the transport.
 func (t *Transport) setRelation(address, service, pk, remoteAddress, remoteService string, foreignKey interface{}) {
+	t.ownRelations()
 	if t.Relations == nil {
 		t.Relations = Relations{}
 	}
@@ -168,12 +216,9 @@ func (t *Transport) appendCalls(service, version string, calls ...Call) {
 //
 // The returned transport won't keep references to the original transport values.
 func (t *Transport) Clone() *Transport {
-	transport := Transport{Meta: t.Meta}
-
-	if t.Body != nil {
-		body := *t.Body
-		transport.Body = &body
-	}
+	t.load()
+	transport := &Transport{}
+	t.cloneInto(transport)
 
 	if t.Files != nil {
 		transport.Files = t.Files.clone()
@@ -187,6 +232,61 @@ func (t *Transport) Clone() *Transport {
 		transport.Relations = t.Relations.clone()
 	}
 
+	return transport
+}
+
+// CloneShared creates a copy of the transport that shares the data, relations and files.
+//
+// The shared sections are copied by the transport that changes them using the transport
+// methods, so Unshare must be called before changing the Data, Relations or Files fields
+// of any of the transports directly.
+func (t *Transport) CloneShared() *Transport {
+	transport := &Transport{}
+	t.shareInto(transport)
+	return transport
+}
+
+// CloneFromPool creates a copy of the transport using a transport from a pool.
+//
+// The copy shares the data, relations and files like the copies created with CloneShared,
+// and it must be returned to the pool calling Release when it is not used anymore.
+func (t *Transport) CloneFromPool() *Transport {
+	transport := transportPool.Get().(*Transport)
+	t.shareInto(transport)
+	return transport
+}
+
+// Release returns a transport created with CloneFromPool to the pool.
+//
+// The transport must not be used after it is released.
+func (t *Transport) Release() {
+	*t = Transport{}
+	transportPool.Put(t)
+}
+
+// Copy the transport into another transport sharing the data, relations and files.
+//
+// The shared sections are copied by the first transport that changes them.
+func (t *Transport) shareInto(transport *Transport) {
+	t.load()
+	atomic.StoreUint32(&t.shared, sharedAll)
+
+	t.cloneInto(transport)
+	transport.Files = t.Files
+	transport.Data = t.Data
+	transport.Relations = t.Relations
+	transport.shared = sharedAll
+}
+
+// Copy the transport values into another transport, except the data, relations and files.
+func (t *Transport) cloneInto(transport *Transport) {
+	transport.Meta = t.Meta.clone()
+
+	if t.Body != nil {
+		body := *t.Body
+		transport.Body = &body
+	}
+
 	if t.Links != nil {
 		transport.Links = t.Links.clone()
 	}
@@ -203,7 +303,58 @@ func (t *Transport) Clone() *Transport {
 		transport.Errors = t.Errors.clone()
 	}
 
-	return &transport
+	if t.Warnings != nil {
+		transport.Warnings = t.Warnings.clone()
+	}
+}
+
+// Take ownership of a shared section of the transport.
+//
+// The result is true when the section was shared and it must be copied before it is changed.
+func (t *Transport) own(section uint32) bool {
+	for {
+		shared := atomic.LoadUint32(&t.shared)
+		if shared&section == 0 {
+			return false
+		}
+
+		if atomic.CompareAndSwapUint32(&t.shared, shared, shared&^section) {
+			return true
+		}
+	}
+}
+
+// Copy the data when it is shared with other transports.
+func (t *Transport) ownData() {
+	t.load()
+	if t.own(sharedData) && t.Data != nil {
+		t.Data = t.Data.clone()
+	}
+}
+
+// Copy the relations when they are shared with other transports.
+func (t *Transport) ownRelations() {
+	if t.own(sharedRelations) && t.Relations != nil {
+		t.Relations = t.Relations.clone()
+	}
+}
+
+// Copy the files when they are shared with other transports.
+func (t *Transport) ownFiles() {
+	t.load()
+	if t.own(sharedFiles) && t.Files != nil {
+		t.Files = t.Files.clone()
+	}
+}
+
+// Unshare copies the sections of the transport that are shared with its copies.
+//
+// Transport copies share the data, relations and files until they are changed using
+// the transport methods, so it must be called before changing them directly.
+func (t *Transport) Unshare() {
+	t.ownData()
+	t.ownRelations()
+	t.ownFiles()
 }
 
 // GetGateway returns the gateway addresses.
@@ -279,6 +430,7 @@ func (t *Transport) SetData(name, version, action string, data interface{}) {
 		t.reply.Command.Result.Transport.SetData(name, version, action, data)
 	}
 
+	t.ownData()
 	if t.Data == nil {
 		t.Data = ServiceData{}
 	}
@@ -348,6 +500,35 @@ func (t *Transport) SetRelateManyRemote(service, pk, address, remote string, fks
 	t.setRelation(t.GetGateway()[1], service, pk, address, remote, fks)
 }
 
+// RemoveRelation removes a relation, or some of the foreign keys of a "one-to-many" relation.
+//
+// The whole relation is removed when no foreign keys are given. A "one-to-one"
+// relation is only removed when its foreign key is one of the given keys.
+// The result is false when the relation doesn't exist or when nothing is removed.
+//
+// service: The name of the local service.
+// pk: The primary key of the local entity.
+// address: The address of the remote gateway, or empty for local relations.
+// remote: The name of the remote service.
+// fks: Optional primary keys of the remote entities to remove.
+func (t *Transport) RemoveRelation(service, pk, address, remote string, fks []string) bool {
+	if t.reply != nil {
+		t.reply.Command.Result.Transport.RemoveRelation(service, pk, address, remote, fks)
+	}
+
+	gateway := t.GetGateway()[1]
+	if address == "" {
+		address = gateway
+	}
+
+	if t.Relations == nil {
+		return false
+	}
+
+	t.ownRelations()
+	return t.Relations.remove(gateway, service, pk, address, remote, fks)
+}
+
 // SetLink adds a link.
 //
 // service: The name of the Service.
@@ -406,6 +587,7 @@ func (t *Transport) SetTransaction(command, service, version, action, target str
 // files: Optional files to send.
 // timeout: Optional timeout for the call.
 // transport: Optional transport payload.
+// attempts: Optional durations of each attempt when the call was retried.
 func (t *Transport) SetCall(
 	service string,
 	version string,
@@ -418,6 +600,7 @@ func (t *Transport) SetCall(
 	files []File,
 	timeout uint,
 	transport *Transport,
+	attempts ...uint,
 ) error {
 	if duration == 0 {
 		return errors.New("duration is required when adding run-time calls to transport")
@@ -436,10 +619,11 @@ func (t *Transport) SetCall(
 			files,
 			timeout,
 			transport,
+			attempts...,
 		)
 	}
 
-	call := Call{
+	t.addRuntimeCall(service, version, Call{
 		Name:     calleeService,
 		Version:  calleeVersion,
 		Action:   calleeAction,
@@ -448,20 +632,90 @@ func (t *Transport) SetCall(
 		Timeout:  timeout,
 		Params:   params,
 		Files:    files,
+		Attempts: attempts,
+	}, transport)
+	return nil
+}
+
+// SetRemoteRuntimeCall adds a remote call that was executed by the SDK.
+//
+// Current transport payload is used when the optional transport is not given.
+//
+// address: The address of the remote Gateway.
+// service: The name of the Service.
+// version: The version of the Service.
+// action: The name of the action making the call.
+// callee_service: The called service.
+// callee_version: The called version.
+// callee_action: The called action.
+// duration: The call duration.
+// params: Optional parameters to send.
+// files: Optional files to send.
+// timeout: Optional timeout for the call.
+// transport: Optional transport payload.
+func (t *Transport) SetRemoteRuntimeCall(
+	address string,
+	service string,
+	version string,
+	action string,
+	calleeService string,
+	calleeVersion string,
+	calleeAction string,
+	duration uint,
+	params []Param,
+	files []File,
+	timeout uint,
+	transport *Transport,
+) error {
+	if duration == 0 {
+		return errors.New("duration is required when adding remote calls to transport")
 	}
+
+	if t.reply != nil {
+		t.reply.Command.Result.Transport.SetRemoteRuntimeCall(
+			address,
+			service,
+			version,
+			action,
+			calleeService,
+			calleeVersion,
+			calleeAction,
+			duration,
+			params,
+			files,
+			timeout,
+			transport,
+		)
+	}
+
+	t.addRuntimeCall(service, version, Call{
+		Gateway:  address,
+		Name:     calleeService,
+		Version:  calleeVersion,
+		Action:   calleeAction,
+		Caller:   action,
+		Duration: duration,
+		Timeout:  timeout,
+		Params:   params,
+		Files:    files,
+	}, transport)
+	return nil
+}
+
+// Add an executed call and merge the transport returned by the call.
+func (t *Transport) addRuntimeCall(service, version string, call Call, transport *Transport) {
 	if transport != nil {
 		// When a transport is present add the call to it and then merge it into the current transport
 		transport.appendCalls(service, version, call)
 		mergeRuntimeCallTransport(transport, t)
 		// Update the transport in the reply payload with the runtime transport
 		if t.reply != nil {
-			t.reply.Command.Result.Transport = t.Clone()
+			t.reply.Command.Result.Transport = t.CloneShared()
 		}
 	} else {
 		// When there is no transport just add the call to current transport
 		t.appendCalls(service, version, call)
 	}
-	return nil
 }
 
 // SetDeferCall adds a deferred call.
@@ -484,33 +738,180 @@ func (t *Transport) SetDeferCall(
 	params []Param,
 	files []File,
 ) {
-	if t.reply != nil {
-		t.reply.Command.Result.Transport.SetDeferCall(
-			service,
-			version,
-			action,
-			calleeService,
-			calleeVersion,
-			calleeAction,
-			params,
-			files,
-		)
-	}
+	t.AppendDeferCall("", service, version, Call{
+		Name:    calleeService,
+		Version: calleeVersion,
+		Action:  calleeAction,
+		Caller:  action,
+		Params:  params,
+		Files:   files,
+	}, false)
+}
 
-	t.appendCalls(service, version, Call{
+// SetDeferCallOnce adds a deferred call identified by an idempotency key.
+//
+// The call is not added when a deferred call with the same key was already
+// registered by the service. The result is false when the call is skipped.
+//
+// key: The idempotency key of the call.
+// service: The name of the Service.
+// version: The version of the Service.
+// action: The name of the action making the call.
+// callee_service: The called service.
+// callee_version: The called version.
+// callee_action: The called action.
+// params: Optional parameters to send.
+// files: Optional files to send.
+func (t *Transport) SetDeferCallOnce(
+	key string,
+	service string,
+	version string,
+	action string,
+	calleeService string,
+	calleeVersion string,
+	calleeAction string,
+	params []Param,
+	files []File,
+) bool {
+	return t.AppendDeferCall(key, service, version, Call{
 		Name:    calleeService,
 		Version: calleeVersion,
 		Action:  calleeAction,
 		Caller:  action,
 		Params:  params,
 		Files:   files,
-	})
+	}, false)
+}
+
+// AppendDeferCall adds a deferred call payload.
+//
+// When the key is not empty the call is not added if a deferred call with the same
+// key was already registered by the service. The result is false when the call is skipped.
+//
+// The files of the call are added to the transport files when the call is added,
+// unless they are deferred, in which case they are added by RegisterDeferredFiles().
+//
+// key: Optional idempotency key of the call.
+// service: The name of the Service.
+// version: The version of the Service.
+// call: The call payload.
+// deferFiles: Add the call files to the transport files later.
+func (t *Transport) AppendDeferCall(key, service, version string, call Call, deferFiles bool) bool {
+	if key != "" && t.Calls.hasKey(service, version, key) {
+		return false
+	}
+
+	if t.reply != nil {
+		t.reply.Command.Result.Transport.AppendDeferCall(key, service, version, call, deferFiles)
+	}
+
+	call.key = key
+	call.pendingFiles = deferFiles && len(call.Files) > 0
+	t.appendCalls(service, version, call)
 	//When there are files included in the call add them to the transport payload
-	if len(files) > 0 {
-		t.appendFiles(t.GetGateway()[1], calleeService, calleeVersion, calleeAction, files...)
+	if len(call.Files) > 0 && !deferFiles {
+		t.appendFiles(t.GetGateway()[1], call.Name, call.Version, call.Action, call.Files...)
+	}
+	return true
+}
+
+// RegisterDeferredFiles adds the deferred files of the calls to the transport files.
+//
+// It must be called when the calls are final, so the files of the calls
+// that were removed or never completed are not added to the transport.
+func (t *Transport) RegisterDeferredFiles() {
+	for _, versions := range t.Calls {
+		for _, calls := range versions {
+			for i := range calls {
+				if call := &calls[i]; call.pendingFiles {
+					t.appendFiles(t.GetGateway()[1], call.Name, call.Version, call.Action, call.Files...)
+					call.pendingFiles = false
+				}
+			}
+		}
+	}
+}
+
+// DedupeCalls removes the deferred calls registered more than once with the same idempotency key.
+//
+// The first call registered with a key is kept and the rest are removed.
+func (t *Transport) DedupeCalls() {
+	if t.Calls != nil {
+		t.Calls.dedupe()
+	}
+}
+
+// SeparateWarnings moves the errors with warning severity to the transport warnings.
+//
+// Warnings in the transport errors are handled by the gateway like any other error,
+// while the ones in the transport warnings don't make the request fail.
+func (t *Transport) SeparateWarnings() {
+	if t.Errors == nil {
+		return
+	}
+
+	for address, services := range t.Errors {
+		for service, versions := range services {
+			for version, errors := range versions {
+				var kept []Error
+				for _, err := range errors {
+					if !err.IsWarning() {
+						kept = append(kept, err)
+						continue
+					}
+
+					if t.Warnings == nil {
+						t.Warnings = Errors{}
+					}
+					t.Warnings.append(address, service, version, err)
+				}
+
+				if len(kept) > 0 {
+					versions[version] = kept
+				} else {
+					delete(versions, version)
+				}
+			}
+
+			if len(versions) == 0 {
+				delete(services, service)
+			}
+		}
+
+		if len(services) == 0 {
+			delete(t.Errors, address)
+		}
+	}
+
+	if len(t.Errors) == 0 {
+		t.Errors = nil
 	}
 }
 
+// DedupeErrors collapses the duplicated errors and warnings of each service.
+//
+// Errors are duplicated when they have the same message, code and severity.
+// The first error is kept and its count is incremented for each duplicate.
+func (t *Transport) DedupeErrors() {
+	t.Errors.dedupe()
+	t.Warnings.dedupe()
+}
+
+// LimitErrors sets the maximum number of errors and warnings for each service.
+//
+// The errors that exceed the limit are removed and an overflow
+// error is added with the number of errors that were omitted.
+//
+// max: The maximum number of errors for each service.
+func (t *Transport) LimitErrors(max int) {
+	if max < 1 {
+		return
+	}
+
+	t.Errors.limit(max)
+	t.Warnings.limit(max)
+}
+
 // SetRemoteCall adds a run-time call.
 //
 // Current transport payload is used when the optional transport is not given.
@@ -576,19 +977,28 @@ func (t *Transport) SetRemoteCall(
 // code: The error code.
 // status: The status message for the protocol.
 func (t *Transport) SetError(service, version, message string, code int, status string) {
+	t.AppendError(service, version, Error{
+		Message: message,
+		Code:    code,
+		Status:  status,
+	})
+}
+
+// AppendError adds a service error payload.
+//
+// service: The name of the Service.
+// version: The version of the Service.
+// err: The error payload.
+func (t *Transport) AppendError(service, version string, err Error) {
 	if t.reply != nil {
-		t.reply.Command.Result.Transport.SetError(service, version, message, code, status)
+		t.reply.Command.Result.Transport.AppendError(service, version, err)
 	}
 
 	if t.Errors == nil {
 		t.Errors = Errors{}
 	}
 
-	t.Errors.append(t.GetGateway()[1], service, version, Error{
-		Message: message,
-		Code:    code,
-		Status:  status,
-	})
+	t.Errors.append(t.GetGateway()[1], service, version, err)
 }
 
 // HasCalls checks if there are any type of calls registered for a Service.
@@ -601,9 +1011,7 @@ func (t *Transport) HasCalls(service, version string) bool {
 	}
 
 	for _, call := range t.Calls.get(service, version) {
-		// When duration is zero it means the call was not executed
-		// so is safe to assume a call that has to be executed was found.
-		if call.Duration == 0 {
+		if call.IsPending() {
 			return true
 		}
 	}
@@ -623,18 +1031,52 @@ type TransportMeta struct {
 	Level      uint              `json:"l"`
 	Properties map[string]string `json:"p,omitempty"`
 	Fallbacks  []Fallback        `json:"F,omitempty"`
+	Events     []LogEvent        `json:"L,omitempty"`
+}
+
+// PropertyNamespaceSeparator separates the namespace from the name in namespaced property names.
+const PropertyNamespaceSeparator = "::"
+
+// Copy the meta values so the copy doesn't share the properties or the lists.
+func (t TransportMeta) clone() TransportMeta {
+	clone := t
+	if t.Gateway != nil {
+		clone.Gateway = append([]string{}, t.Gateway...)
+	}
+
+	if t.Origin != nil {
+		clone.Origin = append([]string{}, t.Origin...)
+	}
+
+	if t.Properties != nil {
+		clone.Properties = make(map[string]string, len(t.Properties))
+		for name, value := range t.Properties {
+			clone.Properties[name] = value
+		}
+	}
+
+	if t.Fallbacks != nil {
+		clone.Fallbacks = append([]Fallback{}, t.Fallbacks...)
+	}
+
+	if t.Events != nil {
+		clone.Events = append([]LogEvent{}, t.Events...)
+	}
+
+	return clone
 }
 
 func (t *TransportMeta) merge(meta TransportMeta) {
-	// TODO: See how to merge fallbacks
-	// t.Fallbacks.merge(meta.Fallbacks)
+	t.Fallbacks = mergeFallbacks(t.Fallbacks, meta.Fallbacks)
+	t.Events = mergeLogEvents(t.Events, meta.Events)
 
 	// When there are properties to merge make sure the target meta is initialized
 	if t.Properties == nil && meta.Properties != nil {
 		t.Properties = make(map[string]string)
 	}
 
-	// Assign the properties to the target
+	// Assign the properties to the target. Namespaced properties are merged
+	// per key, so the missing keys of a namespace are added to the target.
 	for name, value := range meta.Properties {
 		// Don't overwrite existing properties
 		if _, ok := t.Properties[name]; !ok {
@@ -674,7 +1116,7 @@ func (f Fallback) GetActionNames() (actions []string) {
 
 	if names, ok := f[2].([]interface{}); ok {
 		for _, v := range names {
-			if action := v.(string); action != "" {
+			if action, _ := v.(string); action != "" {
 				actions = append(actions, action)
 			}
 		}
@@ -682,6 +1124,64 @@ func (f Fallback) GetActionNames() (actions []string) {
 	return actions
 }
 
+// Merge the triggered fallbacks into a list of fallbacks.
+//
+// The action names are merged when a fallback for the same service and version already exists.
+func mergeFallbacks(target, fallbacks []Fallback) []Fallback {
+	for _, fallback := range fallbacks {
+		merged := false
+		for i, current := range target {
+			if current.GetName() != fallback.GetName() || current.GetVersion() != fallback.GetVersion() {
+				continue
+			}
+
+			actions := current.GetActionNames()
+			for _, action := range fallback.GetActionNames() {
+				if !containsString(actions, action) {
+					actions = append(actions, action)
+				}
+			}
+
+			target[i] = newFallback(current.GetName(), current.GetVersion(), actions)
+			merged = true
+			break
+		}
+
+		if !merged {
+			target = append(target, newFallback(fallback.GetName(), fallback.GetVersion(), fallback.GetActionNames()))
+		}
+	}
+	return target
+}
+
+// Create a new fallback value.
+func newFallback(name, version string, actions []string) Fallback {
+	names := make([]interface{}, len(actions))
+	for i, action := range actions {
+		names[i] = action
+	}
+	return Fallback{name, version, names}
+}
+
+// AddFallback registers a fallback triggered for an action.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+func (t *Transport) AddFallback(service, version, action string) {
+	t.Meta.Fallbacks = mergeFallbacks(t.Meta.Fallbacks, []Fallback{newFallback(service, version, []string{action})})
+}
+
+// Check if a list of strings contains a value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // Files contains the transport files.
 type Files map[string]map[string]map[string]map[string][]File
 
@@ -923,6 +1423,77 @@ func (r Relations) merge(source Relations) {
 	}
 }
 
+// Remove a relation, or some of the foreign keys of a "one-to-many" relation.
+//
+// The whole relation is removed when no foreign keys are given or when all the foreign
+// keys of the relation are removed. A "one-to-one" relation is only removed when its
+// foreign key is one of the given keys. Empty entries are pruned after the removal.
+// The result is false when the relation doesn't exist or when nothing is removed.
+func (r Relations) remove(address, service, pk, remoteAddress, remoteService string, foreignKeys []string) bool {
+	remotes := r[address][service][pk][remoteAddress]
+	current, exists := remotes[remoteService]
+	if !exists {
+		return false
+	}
+
+	if len(foreignKeys) > 0 {
+		if keys, ok := relationForeignKeys(current); ok {
+			var kept []string
+			for _, fk := range keys {
+				if !containsString(foreignKeys, fk) {
+					kept = append(kept, fk)
+				}
+			}
+
+			if len(kept) == len(keys) {
+				return false
+			} else if len(kept) > 0 {
+				remotes[remoteService] = kept
+				return true
+			}
+		} else if !containsString(foreignKeys, fmt.Sprint(current)) {
+			// A "one-to-one" relation is only removed when its foreign key is given
+			return false
+		}
+	}
+
+	delete(remotes, remoteService)
+	if len(remotes) == 0 {
+		delete(r[address][service][pk], remoteAddress)
+	}
+	if len(r[address][service][pk]) == 0 {
+		delete(r[address][service], pk)
+	}
+	if len(r[address][service]) == 0 {
+		delete(r[address], service)
+	}
+	if len(r[address]) == 0 {
+		delete(r, address)
+	}
+	return true
+}
+
+// Get the foreign keys of a "one-to-many" relation.
+//
+// The result is false when the value is not a list of foreign keys.
+func relationForeignKeys(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		keys := make([]string, 0, len(v))
+		for _, item := range v {
+			fk, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			keys = append(keys, fk)
+		}
+		return keys, true
+	}
+	return nil, false
+}
+
 // Links contains the transport links.
 type Links map[string]map[string]map[string]string
 
@@ -1035,14 +1606,44 @@ func (c Calls) get(service, version string) []Call {
 	return nil
 }
 
+// Check if a call with an idempotency key exists for a service.
+func (c Calls) hasKey(service, version, key string) bool {
+	for _, call := range c.get(service, version) {
+		if call.key != "" && call.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove the calls that share an idempotency key with a previous call.
+func (c Calls) dedupe() {
+	for _, versions := range c {
+		for version, calls := range versions {
+			keys := make(map[string]bool)
+			unique := calls[:0]
+			for _, call := range calls {
+				if call.key != "" {
+					if keys[call.key] {
+						continue
+					}
+					keys[call.key] = true
+				}
+				unique = append(unique, call)
+			}
+			versions[version] = unique
+		}
+	}
+}
+
 func (c Calls) clone() Calls {
 	clone := Calls{}
 
 	for service, versions := range c {
-		clone[service] = versions
+		clone[service] = make(map[string][]Call)
 
 		for version, calls := range versions {
-			clone[service][version] = append(clone[service][version], calls...)
+			clone[service][version] = append([]Call{}, calls...)
 		}
 	}
 
@@ -1086,6 +1687,39 @@ type Call struct {
 	Timeout  uint    `json:"x,omitempty"`
 	Params   []Param `json:"p,omitempty"`
 	Files    []File  `json:"f,omitempty"`
+	Retry    *Retry  `json:"r,omitempty"`
+	Group    string  `json:"G,omitempty"`
+	Priority int     `json:"P,omitempty"`
+	Attempts []uint  `json:"A,omitempty"`
+
+	// Idempotency key for deferred calls.
+	// The key is only used by the SDK and it is not sent to the framework.
+	key string
+	// The files of the call are not added to the transport files yet
+	pendingFiles bool
+}
+
+// Retry contains the retry policy for a deferred call.
+//
+// The backoff values are in milliseconds.
+type Retry struct {
+	Attempts   uint    `json:"a"`
+	Backoff    uint    `json:"b,omitempty"`
+	Multiplier float64 `json:"m,omitempty"`
+	MaxBackoff uint    `json:"M,omitempty"`
+}
+
+// GetKey returns the idempotency key of the call.
+func (c Call) GetKey() string {
+	return c.key
+}
+
+// IsPending checks if the call is not executed yet.
+//
+// Run-time calls are registered with the duration of the call, so when
+// the duration is zero it means the call has to be executed.
+func (c Call) IsPending() bool {
+	return c.Duration == 0
 }
 
 // Errors contains the transport errors.
@@ -1095,13 +1729,13 @@ func (e Errors) clone() Errors {
 	clone := Errors{}
 
 	for address, services := range e {
-		clone[address] = services
+		clone[address] = make(map[string]map[string][]Error)
 
 		for service, versions := range services {
-			clone[address][service] = versions
+			clone[address][service] = make(map[string][]Error)
 
 			for version, errors := range versions {
-				clone[address][service][version] = append(clone[address][service][version], errors...)
+				clone[address][service][version] = append([]Error{}, errors...)
 			}
 		}
 	}
@@ -1109,6 +1743,60 @@ func (e Errors) clone() Errors {
 	return clone
 }
 
+// Collapse the duplicated errors of each service into a single error with a count.
+func (e Errors) dedupe() {
+	for _, services := range e {
+		for _, versions := range services {
+			for version, errors := range versions {
+				var unique []Error
+			next:
+				for _, err := range errors {
+					for i := range unique {
+						if unique[i].isDuplicate(err) {
+							unique[i].Count = unique[i].GetCount() + err.GetCount()
+							continue next
+						}
+					}
+					unique = append(unique, err)
+				}
+				versions[version] = unique
+			}
+		}
+	}
+}
+
+// Limit the number of errors for each service.
+//
+// The errors that exceed the limit are replaced by an overflow error with the number of omitted errors.
+func (e Errors) limit(max int) {
+	for _, services := range e {
+		for _, versions := range services {
+			for version, errors := range versions {
+				if len(errors) <= max {
+					continue
+				}
+
+				omitted := 0
+				for _, err := range errors[max:] {
+					if err.IsOverflow() {
+						omitted += err.GetOmitted()
+					} else {
+						omitted += err.GetCount()
+					}
+				}
+
+				kept := append([]Error{}, errors[:max]...)
+				versions[version] = append(kept, Error{
+					Message:  ErrorOverflowMessage,
+					Status:   DefaultErrorStatus,
+					Severity: errors[max].Severity,
+					Omitted:  omitted,
+				})
+			}
+		}
+	}
+}
+
 func (e Errors) append(address, service, version string, errors ...Error) {
 	if v := e[address]; v == nil {
 		e[address] = make(map[string]map[string][]Error)
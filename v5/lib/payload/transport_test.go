@@ -0,0 +1,310 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package payload
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/json"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+)
+
+func TestTransportDedupeAndLimitErrors(t *testing.T) {
+	tr := Transport{}
+	for i := 0; i < 5; i++ {
+		tr.AppendError("users", "1.0.0", Error{Message: "Timeout", Code: 1})
+	}
+	tr.AppendError("users", "1.0.0", Error{Message: "Not found", Code: 2})
+	tr.AppendError("users", "1.0.0", Error{Message: "Invalid", Code: 3})
+
+	tr.DedupeErrors()
+
+	errors := tr.Errors[""]["users"]["1.0.0"]
+	if len(errors) != 3 {
+		t.Fatalf("expected 3 errors, got %d", len(errors))
+	}
+
+	if c := errors[0].GetCount(); c != 5 {
+		t.Errorf("expected a count of 5, got %d", c)
+	}
+
+	tr.LimitErrors(1)
+
+	errors = tr.Errors[""]["users"]["1.0.0"]
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 errors, got %d", len(errors))
+	}
+
+	if e := errors[1]; !e.IsOverflow() || e.GetOmitted() != 2 {
+		t.Errorf("expected an overflow error with 2 omitted errors, got %v", e)
+	}
+}
+
+func TestTransportLogEvents(t *testing.T) {
+	caller := Transport{}
+	caller.AddLogEvent(LogEvent{Service: "posts", Message: "listing", Datetime: "1"}, 2)
+
+	callee := Transport{Meta: caller.Meta}
+	callee.AddLogEvent(LogEvent{Service: "users", Message: "reading", Datetime: "2"}, 2)
+	if callee.AddLogEvent(LogEvent{Service: "users", Message: "discarded", Datetime: "3"}, 2) {
+		t.Error("expected the event to be discarded")
+	}
+
+	mergeRuntimeCallTransport(&callee, &caller)
+	if n := len(caller.Meta.Events); n != 2 {
+		t.Fatalf("expected 2 events, got %d: %v", n, caller.Meta.Events)
+	}
+
+	if e := caller.Meta.Events[1]; e.Service != "users" {
+		t.Errorf("unexpected merged event: %v", e)
+	}
+}
+
+func TestRelationsRemove(t *testing.T) {
+	r := Relations{}
+	r.add("gw", "posts", "1", "gw", "users", "10")
+	r.add("gw", "posts", "1", "gw", "tags", []string{"a", "b"})
+
+	if !r.remove("gw", "posts", "1", "gw", "tags", []string{"a"}) {
+		t.Fatal("expected the foreign key to be removed")
+	}
+
+	if fks := r["gw"]["posts"]["1"]["gw"]["tags"].([]string); len(fks) != 1 || fks[0] != "b" {
+		t.Errorf("unexpected foreign keys: %v", fks)
+	}
+
+	r.remove("gw", "posts", "1", "gw", "tags", []string{"b"})
+	r.remove("gw", "posts", "1", "gw", "users", nil)
+	if len(r) != 0 {
+		t.Errorf("expected the empty relations to be pruned, got %v", r)
+	}
+
+	if r.remove("gw", "posts", "1", "gw", "users", nil) {
+		t.Error("expected a missing relation not to be removed")
+	}
+}
+
+func TestRelationsRemoveOneToOne(t *testing.T) {
+	r := Relations{}
+	r.add("gw", "posts", "1", "gw", "users", "10")
+	r.add("gw", "posts", "1", "gw", "tags", []string{"a", "b"})
+
+	// The relation is kept when its foreign key is not given
+	if r.remove("gw", "posts", "1", "gw", "users", []string{"11"}) {
+		t.Error("expected the relation with a different foreign key not to be removed")
+	}
+	if fk := r["gw"]["posts"]["1"]["gw"]["users"]; fk != "10" {
+		t.Errorf("expected the relation to be kept, got %v", fk)
+	}
+
+	if r.remove("gw", "posts", "1", "gw", "tags", []string{"c"}) {
+		t.Error("expected the relation without the foreign keys not to be changed")
+	}
+
+	if !r.remove("gw", "posts", "1", "gw", "users", []string{"11", "10"}) {
+		t.Fatal("expected the relation to be removed")
+	}
+	if _, exists := r["gw"]["posts"]["1"]["gw"]["users"]; exists {
+		t.Error("expected the relation to be removed")
+	}
+}
+
+func TestTransportLazySections(t *testing.T) {
+	source := Transport{Meta: TransportMeta{ID: "abc", Gateway: []string{"", "gw"}}}
+	source.SetData("users", "1.0.0", "read", map[string]interface{}{"id": "1"})
+	source.appendFiles("gw", "users", "1.0.0", "read", File{Name: "avatar", Path: "file:///avatar.png"})
+
+	for name, format := range map[string]struct {
+		encode func(interface{}) ([]byte, error)
+		decode func([]byte, interface{}) error
+	}{
+		"msgpack": {msgpack.Encode, msgpack.Decode},
+		"json":    {json.Encode, json.Decode},
+	} {
+		data, err := format.encode(&source)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var tr Transport
+		if err := format.decode(data, &tr); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if tr.Meta.ID != "abc" || tr.Data != nil || tr.Files != nil {
+			t.Fatalf("%s: expected only the meta to be decoded, got %v", name, tr)
+		}
+
+		// Copies decode the sections of the original transport
+		clone := tr.Clone()
+		if len(clone.Data["gw"]["users"]["1.0.0"]["read"]) != 1 {
+			t.Errorf("%s: unexpected data: %v", name, clone.Data)
+		}
+
+		if files := clone.Files.Get("gw", "users", "1.0.0", "read"); len(files) != 1 || files[0].Name != "avatar" {
+			t.Errorf("%s: unexpected files: %v", name, clone.Files)
+		}
+	}
+}
+
+func TestTransportLazySectionsInValueCopies(t *testing.T) {
+	source := Transport{Meta: TransportMeta{ID: "abc", Gateway: []string{"", "gw"}}}
+	source.SetData("users", "1.0.0", "read", map[string]interface{}{"id": "1"})
+	source.appendFiles("gw", "users", "1.0.0", "read", File{Name: "avatar", Path: "file:///avatar.png"})
+
+	data, err := msgpack.Encode(&source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tr Transport
+	if err := msgpack.Decode(data, &tr); err != nil {
+		t.Fatal(err)
+	}
+
+	// The hash encodes a copy of the transport value, which decodes the shared sections
+	hash, err := tr.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tr.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tr.Data["gw"]["users"]["1.0.0"]["read"]) != 1 {
+		t.Errorf("expected the data after the hash, got %v", tr.Data)
+	}
+
+	if files := tr.Files.Get("gw", "users", "1.0.0", "read"); len(files) != 1 {
+		t.Errorf("expected the files after the hash, got %v", tr.Files)
+	}
+
+	if expected, _ := source.Hash(); hash != expected {
+		t.Errorf("expected the hash of the source transport, got %s", hash)
+	}
+}
+
+func TestTransportLazySectionsEncodeError(t *testing.T) {
+	for name, encode := range map[string]func(interface{}) ([]byte, error){
+		"msgpack": msgpack.Encode,
+		"json":    json.Encode,
+	} {
+		tr := Transport{lazy: &lazySections{rawData: []byte(`{"gw":`)}}
+
+		if _, err := encode(&tr); err == nil || !strings.Contains(err.Error(), "transport data") {
+			t.Errorf("%s: expected the decoding error, got %v", name, err)
+		}
+
+		// The error is kept for the next calls
+		if err := tr.Load(); err == nil {
+			t.Errorf("%s: expected the decoding error from load", name)
+		}
+	}
+}
+
+func TestTransportMetaMergeNamespaces(t *testing.T) {
+	meta := TransportMeta{Properties: map[string]string{
+		"name":       "target",
+		"cache::ttl": "60",
+	}}
+	meta.merge(TransportMeta{Properties: map[string]string{
+		"name":       "source",
+		"other":      "source",
+		"cache::ttl": "10",
+		"cache::tag": "users",
+		"auth::user": "jane",
+	}})
+
+	// Namespaces are merged per key and existing properties are not overwritten
+	expected := map[string]string{
+		"name":       "target",
+		"other":      "source",
+		"cache::ttl": "60",
+		"cache::tag": "users",
+		"auth::user": "jane",
+	}
+	if len(meta.Properties) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, meta.Properties)
+	}
+	for name, value := range expected {
+		if meta.Properties[name] != value {
+			t.Errorf("%s: expected %q, got %q", name, value, meta.Properties[name])
+		}
+	}
+}
+
+func TestTransportMetaMergeNamespaceKeys(t *testing.T) {
+	// The caller transport has an "auth" namespace and the callee adds a scope to it
+	meta := TransportMeta{Properties: map[string]string{"auth::user": "jane"}}
+	meta.merge(TransportMeta{Properties: map[string]string{
+		"auth::user":  "john",
+		"auth::scope": "admin",
+	}})
+
+	if v := meta.Properties["auth::user"]; v != "jane" {
+		t.Errorf("expected the existing user to be kept, got %q", v)
+	}
+	if v := meta.Properties["auth::scope"]; v != "admin" {
+		t.Errorf("expected the scope of the callee to be added, got %q", v)
+	}
+}
+
+func TestTransportMetaMergeFallbacks(t *testing.T) {
+	meta := TransportMeta{Fallbacks: []Fallback{
+		{"users", "1.0.0", []interface{}{"read"}},
+	}}
+	meta.merge(TransportMeta{Fallbacks: []Fallback{
+		{"users", "1.0.0", []interface{}{"read", "list"}},
+		{"users", "2.0.0", []interface{}{"read"}},
+		{"posts", "1.0.0", []interface{}{"list"}},
+	}})
+
+	// The actions are merged for the same service and version
+	expected := map[string][]string{
+		"users 1.0.0": {"read", "list"},
+		"users 2.0.0": {"read"},
+		"posts 1.0.0": {"list"},
+	}
+	if len(meta.Fallbacks) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, meta.Fallbacks)
+	}
+	for _, f := range meta.Fallbacks {
+		key := f.GetName() + " " + f.GetVersion()
+		if actions := f.GetActionNames(); strings.Join(actions, ",") != strings.Join(expected[key], ",") {
+			t.Errorf("%s: expected %v, got %v", key, expected[key], actions)
+		}
+	}
+}
+
+func TestTransportDedupeCalls(t *testing.T) {
+	transport := Transport{}
+	transport.appendCalls("users", "1.0.0",
+		Call{Name: "mails", Action: "send", key: "welcome"},
+		Call{Name: "logs", Action: "write"},
+		Call{Name: "mails", Action: "resend", key: "welcome"},
+		Call{Name: "logs", Action: "write"},
+	)
+
+	if !transport.Calls.hasKey("users", "1.0.0", "welcome") || transport.Calls.hasKey("users", "1.0.0", "other") {
+		t.Error("unexpected idempotency key check")
+	}
+
+	// The first call with a key is kept and calls without key are not removed
+	transport.DedupeCalls()
+	calls := transport.Calls.get("users", "1.0.0")
+	var actions []string
+	for _, c := range calls {
+		actions = append(actions, c.Action)
+	}
+	if strings.Join(actions, ",") != "send,write,write" {
+		t.Errorf("unexpected calls after the dedupe: %v", actions)
+	}
+}
@@ -0,0 +1,37 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTimeout is returned when a runtime call doesn't receive a reply before the timeout.
+var ErrTimeout = errors.New("Runtime call timed out")
+
+// ConnectionError is returned when a runtime call fails to send the message or to read the reply.
+type ConnectionError struct {
+	message string
+}
+
+func (e ConnectionError) Error() string {
+	return e.message
+}
+
+// Create a new connection error.
+func connectionErrorf(format string, args ...interface{}) error {
+	return ConnectionError{fmt.Sprintf(format, args...)}
+}
+
+// IsRetryable checks if a runtime call error is caused by a timeout or a connection failure.
+func IsRetryable(err error) bool {
+	var ce ConnectionError
+	return errors.Is(err, ErrTimeout) || errors.As(err, &ce)
+}
@@ -0,0 +1,35 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"timeout", ErrTimeout, true},
+		{"wrapped timeout", fmt.Errorf("call failed: %w", ErrTimeout), true},
+		{"connection", connectionErrorf("Failed to send the message: %s", "closed"), true},
+		{"other", errors.New("Action failed"), false},
+		{"nil", nil, false},
+	}
+
+	for _, c := range cases {
+		if retryable := IsRetryable(c.err); retryable != c.retryable {
+			t.Errorf("%s: expected %v, got %v", c.name, c.retryable, retryable)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/codec"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+	"github.com/pebbe/zmq4"
+)
+
+// Interval used to check if a runtime call must be stopped while waiting for the reply.
+const stopCheckInterval = 100 * time.Millisecond
+
+// ErrPoolClosed is returned when a call is made using a closed pool.
+var ErrPoolClosed = errors.New("The runtime call socket pool is closed")
+
+// NewPool creates a new pool of sockets for runtime calls.
+//
+// maxSockets: The maximum number of open sockets for each address.
+// idleTimeout: The time after which idle sockets are closed.
+func NewPool(maxSockets int, idleTimeout time.Duration) (*Pool, error) {
+	if maxSockets < 1 {
+		return nil, fmt.Errorf("The maximum number of sockets must be greater than zero: %d", maxSockets)
+	}
+
+	zctx, err := zmq4.NewContext()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pool{
+		context:     zctx,
+		maxSockets:  maxSockets,
+		idleTimeout: idleTimeout,
+		addresses:   make(map[string]*addressPool),
+	}, nil
+}
+
+// Pool keeps the sockets used for runtime calls open to reuse them between calls.
+//
+// The sockets are grouped by address, and calls wait until a socket is available
+// when the maximum number of sockets for an address is in use.
+type Pool struct {
+	mutex       sync.Mutex
+	context     *zmq4.Context
+	maxSockets  int
+	idleTimeout time.Duration
+	addresses   map[string]*addressPool
+	inUse       int
+	closed      bool
+}
+
+// Sockets for a single address.
+type addressPool struct {
+	slots chan struct{}
+	idle  []*pooledSocket
+}
+
+// A socket with the time it was returned to the pool.
+type pooledSocket struct {
+	socket   *zmq4.Socket
+	released time.Time
+}
+
+// Get the sockets for an address.
+func (p *Pool) getAddressPool(address string) *addressPool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	ap, exists := p.addresses[address]
+	if !exists {
+		ap = &addressPool{slots: make(chan struct{}, p.maxSockets)}
+		p.addresses[address] = ap
+	}
+	return ap
+}
+
+// Get a socket for an address.
+//
+// An idle socket is reused when available, otherwise a new socket is created.
+func (p *Pool) acquire(stop <-chan struct{}, address string) (*zmq4.Socket, error) {
+	ap := p.getAddressPool(address)
+
+	// Wait until the number of sockets in use is below the limit
+	select {
+	case ap.slots <- struct{}{}:
+	case <-stop:
+		return nil, errors.New("Runtime call stopped while waiting for a socket")
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		<-ap.slots
+		return nil, ErrPoolClosed
+	}
+
+	p.closeExpired()
+
+	// Reuse the most recent idle socket
+	if last := len(ap.idle) - 1; last >= 0 {
+		socket := ap.idle[last].socket
+		ap.idle = ap.idle[:last]
+		p.inUse++
+		return socket, nil
+	}
+
+	socket, err := p.newSocket(address)
+	if err != nil {
+		<-ap.slots
+		return nil, err
+	}
+
+	p.inUse++
+	return socket, nil
+}
+
+// Create a new socket connected to an address.
+func (p *Pool) newSocket(address string) (*zmq4.Socket, error) {
+	socket, err := p.context.NewSocket(zmq4.REQ)
+	if err != nil {
+		return nil, connectionErrorf("Failed to create internal socket for runtime call: %v", err)
+	}
+
+	if err := socket.SetLinger(0); err != nil {
+		socket.Close()
+		return nil, fmt.Errorf("Failed to set socket's linger option: %v", err)
+	}
+
+	if err := socket.Connect(address); err != nil {
+		socket.Close()
+		return nil, connectionErrorf("Failed to connect to the forwarder socket: %v", err)
+	}
+
+	return socket, nil
+}
+
+// Preconnect opens sockets for an address and keeps them in the pool to be used by the calls.
+//
+// The number of sockets is limited by the maximum number of sockets per address.
+//
+// address: The address to connect the sockets to.
+// count: The number of sockets to have open for the address.
+func (p *Pool) Preconnect(address string, count int) error {
+	ap := p.getAddressPool(address)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	if count > p.maxSockets {
+		count = p.maxSockets
+	}
+
+	// The sockets in use are counted to avoid exceeding the limit
+	for len(ap.idle)+len(ap.slots) < count {
+		socket, err := p.newSocket(address)
+		if err != nil {
+			return err
+		}
+		ap.idle = append(ap.idle, &pooledSocket{socket, time.Now()})
+	}
+	return nil
+}
+
+// Close the idle sockets that exceeded the idle timeout.
+//
+// The pool mutex must be locked before calling this method.
+func (p *Pool) closeExpired() {
+	if p.idleTimeout <= 0 {
+		return
+	}
+
+	for _, ap := range p.addresses {
+		idle := ap.idle[:0]
+		for _, ps := range ap.idle {
+			if time.Since(ps.released) > p.idleTimeout {
+				ps.socket.Close()
+			} else {
+				idle = append(idle, ps)
+			}
+		}
+		ap.idle = idle
+	}
+}
+
+// Return a socket to the pool.
+//
+// Sockets that can't be reused are closed, which is the case when the call
+// failed before the reply was received, because the socket is left in an invalid state.
+func (p *Pool) release(address string, socket *zmq4.Socket, reusable bool) {
+	ap := p.getAddressPool(address)
+
+	p.mutex.Lock()
+	p.inUse--
+	if reusable && !p.closed {
+		ap.idle = append(ap.idle, &pooledSocket{socket, time.Now()})
+	} else {
+		socket.Close()
+		// The context is terminated when the last socket in use is closed after the pool is closed
+		if p.closed && p.inUse == 0 {
+			p.terminate()
+		}
+	}
+	p.mutex.Unlock()
+
+	<-ap.slots
+}
+
+// Call makes a runtime call to a service using a socket from the pool.
+//
+// The codec is used to read the reply, so it must be the same used to serialize the message.
+func (p *Pool) Call(stop <-chan struct{}, address string, message []byte, timeout uint, c codec.Codec) (*payload.Reply, time.Duration, error) {
+	var duration time.Duration
+
+	socket, err := p.acquire(stop, address)
+	if err != nil {
+		return nil, duration, err
+	}
+
+	// Create a poller to be able to stop read on timeout
+	poller := zmq4.NewPoller()
+	poller.Add(socket, zmq4.POLLIN)
+
+	// Send the payload
+	start := time.Now()
+	if _, err := socket.SendMessage([]byte("\x01"), message); err != nil {
+		p.release(address, socket, false)
+		return nil, duration, connectionErrorf("Failed to send runtime call message: %v", err)
+	}
+
+	// Wait for the response checking periodically if the call must be stopped
+	deadline := start.Add(time.Duration(timeout) * time.Millisecond)
+	for {
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			p.release(address, socket, false)
+			duration = time.Since(start)
+			return nil, duration, ErrTimeout
+		} else if wait > stopCheckInterval {
+			wait = stopCheckInterval
+		}
+
+		polled, err := poller.Poll(wait)
+		if err != nil {
+			p.release(address, socket, false)
+			duration = time.Since(start)
+			return nil, duration, connectionErrorf("Failed to poll runtime call reply: %v", err)
+		} else if len(polled) > 0 {
+			break
+		}
+
+		select {
+		case <-stop:
+			p.release(address, socket, false)
+			duration = time.Since(start)
+			return nil, duration, errors.New("Runtime call stopped")
+		default:
+		}
+	}
+
+	// Read response
+	response, err := socket.RecvBytes(0)
+	if err != nil {
+		p.release(address, socket, false)
+		duration = time.Since(start)
+		return nil, duration, connectionErrorf("Failed to read runtime call response: %v", err)
+	}
+
+	// Set call duration when the response is received
+	duration = time.Since(start)
+
+	// The socket can be reused once the reply is received
+	p.release(address, socket, true)
+
+	// Replies with large payloads can be compressed
+	if response, err = codec.Decompress(response); err != nil {
+		return nil, duration, fmt.Errorf("Failed to read runtime call response: %v", err)
+	}
+
+	var reply *payload.Reply
+	if err := c.Decode(response, &reply); err != nil {
+		return nil, duration, fmt.Errorf("Failed to parse runtime call response: %v", err)
+	}
+	return reply, duration, nil
+}
+
+// Close closes the idle sockets of the pool and terminates its ZMQ context.
+//
+// Sockets in use are closed when they are returned to the pool, and the context
+// is terminated once the last of them is closed.
+func (p *Pool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	p.closed = true
+	for _, ap := range p.addresses {
+		for _, ps := range ap.idle {
+			ps.socket.Close()
+		}
+		ap.idle = nil
+	}
+
+	if p.inUse == 0 {
+		p.terminate()
+	}
+}
+
+// Terminate the ZMQ context of the pool.
+//
+// The pool mutex must be locked and all the sockets closed before calling this method.
+func (p *Pool) terminate() {
+	if err := p.context.Term(); err != nil {
+		log.RuntimeCall.Errorf("Failed to terminate the runtime call socket pool context: %v", err)
+	}
+}
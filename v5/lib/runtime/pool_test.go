@@ -0,0 +1,52 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package runtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoolClose(t *testing.T) {
+	pool, err := NewPool(2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := "ipc://@kusanagi-test"
+	if err := pool.Preconnect(address, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	socket, err := pool.acquire(nil, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The context is terminated only after the socket in use is returned
+	pool.Close()
+	if pool.inUse != 1 {
+		t.Errorf("expected one socket in use, got %d", pool.inUse)
+	}
+
+	pool.release(address, socket, true)
+	if pool.inUse != 0 {
+		t.Errorf("expected no sockets in use, got %d", pool.inUse)
+	}
+	if idle := pool.addresses[address].idle; len(idle) != 0 {
+		t.Errorf("expected the released socket to be closed, got %d idle sockets", len(idle))
+	}
+
+	// Closing the pool again is a no-op
+	pool.Close()
+
+	if _, err := pool.acquire(nil, address); err != ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+}
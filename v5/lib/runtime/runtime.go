@@ -12,14 +12,16 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/codec"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
-	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
 	"github.com/pebbe/zmq4"
 )
 
 // Call makes a runtime call to a service.
-func Call(stop <-chan struct{}, address string, message []byte, timeout uint) (*payload.Reply, time.Duration, error) {
+//
+// The codec is used to read the reply, so it must be the same used to serialize the message.
+func Call(stop <-chan struct{}, address string, message []byte, timeout uint, c codec.Codec) (*payload.Reply, time.Duration, error) {
 	var duration time.Duration
 
 	// Define a custom ZMQ context
@@ -37,7 +39,7 @@ func Call(stop <-chan struct{}, address string, message []byte, timeout uint) (*
 		select {
 		case <-stop:
 			if err := zctx.Term(); err != nil {
-				log.Errorf("Failed to terminate runtime call context: %v", err)
+				log.RuntimeCall.Errorf("Failed to terminate runtime call context: %v", err)
 			}
 		case <-quit:
 		}
@@ -46,7 +48,7 @@ func Call(stop <-chan struct{}, address string, message []byte, timeout uint) (*
 	// Create a socket to call the remote service
 	socket, err := zctx.NewSocket(zmq4.REQ)
 	if err != nil {
-		return nil, duration, fmt.Errorf("Failed to create internal socket for runtime call: %v", err)
+		return nil, duration, connectionErrorf("Failed to create internal socket for runtime call: %v", err)
 	}
 	defer socket.Close()
 
@@ -56,33 +58,41 @@ func Call(stop <-chan struct{}, address string, message []byte, timeout uint) (*
 
 	// Connect to the local forwarder socket
 	if err := socket.Connect(address); err != nil {
-		return nil, duration, fmt.Errorf("Failed to connect to the forwarder socket: %v", err)
+		return nil, duration, connectionErrorf("Failed to connect to the forwarder socket: %v", err)
 	}
 
 	// Send the payload
 	start := time.Now()
 	if _, err := socket.SendMessage([]byte("\x01"), message); err != nil {
-		return nil, duration, fmt.Errorf("Failed to send runtime call message: %v", err)
+		return nil, duration, connectionErrorf("Failed to send runtime call message: %v", err)
 	}
 
 	// Wait for the response
-	if _, err := poller.PollAll(time.Duration(timeout) * time.Millisecond); err != nil {
-		duration = time.Since(start) * time.Millisecond
-		return nil, duration, fmt.Errorf("Failed to poll runtime call reply: %v", err)
+	if polled, err := poller.Poll(time.Duration(timeout) * time.Millisecond); err != nil {
+		duration = time.Since(start)
+		return nil, duration, connectionErrorf("Failed to poll runtime call reply: %v", err)
+	} else if len(polled) == 0 {
+		duration = time.Since(start)
+		return nil, duration, ErrTimeout
 	}
 
 	// Read response
 	response, err := socket.RecvBytes(0)
 	if err != nil {
-		duration = time.Since(start) * time.Millisecond
-		return nil, duration, fmt.Errorf("Failed to read runtime call response: %v", err)
+		duration = time.Since(start)
+		return nil, duration, connectionErrorf("Failed to read runtime call response: %v", err)
 	}
 
 	// Set call duration when the response is received
-	duration = time.Since(start) * time.Millisecond
+	duration = time.Since(start)
+
+	// Replies with large payloads can be compressed
+	if response, err = codec.Decompress(response); err != nil {
+		return nil, duration, fmt.Errorf("Failed to read runtime call response: %v", err)
+	}
 
 	var reply *payload.Reply
-	if err := msgpack.Decode(response, &reply); err != nil {
+	if err := c.Decode(response, &reply); err != nil {
 		return nil, duration, fmt.Errorf("Failed to parse runtime call response: %v", err)
 	}
 	return reply, duration, nil
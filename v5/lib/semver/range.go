@@ -0,0 +1,228 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Regexp to check if a version pattern contains range operators
+var reRangeOperators = regexp.MustCompile(`[\^~<>=|\s]`)
+
+// Regexp to parse the constraints of a version range
+var reConstraint = regexp.MustCompile(`(\^|~|>=|<=|>|<|=)?\s*([a-zA-Z0-9*._-]+)`)
+
+// Regexp to check that a version range only contains valid chars
+var reInvalidRangeChars = regexp.MustCompile(`[^a-zA-Z0-9*.,_\-\^~<>=|\s]`)
+
+// Checks if a version pattern is a version range.
+func isRange(pattern string) bool {
+	return reRangeOperators.MatchString(strings.TrimSpace(pattern))
+}
+
+// A constraint that a version must satisfy to be in a range.
+type constraint struct {
+	operator string
+	version  string
+	wildcard *Version
+}
+
+// Check if a version satisfies the constraint.
+func (c constraint) check(version string) bool {
+	if c.wildcard != nil {
+		return c.wildcard.Match(version)
+	}
+
+	result := compareSemver(version, c.version)
+	switch c.operator {
+	case ">=":
+		return result >= 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case "<":
+		return result < 0
+	}
+	return result == 0
+}
+
+// Parse a version range.
+//
+// Ranges contain one or more groups of constraints separated by "||", where each group
+// contains constraints separated by spaces or commas. A version is in the range when it satisfies
+// all the constraints of any of the groups.
+//
+// Supported constraint operators are "=", ">", ">=", "<", "<=", "^" and "~".
+func parseRange(pattern string) ([][]constraint, error) {
+	if reInvalidRangeChars.MatchString(pattern) {
+		return nil, fmt.Errorf(`invalid version range: "%s"`, pattern)
+	}
+
+	var groups [][]constraint
+	for _, group := range strings.Split(pattern, "||") {
+		var constraints []constraint
+
+		matches := reConstraint.FindAllStringSubmatch(group, -1)
+		if len(matches) == 0 {
+			return nil, fmt.Errorf(`invalid version range: "%s"`, pattern)
+		}
+
+		for _, m := range matches {
+			operator, version := m[1], m[2]
+			switch operator {
+			case "^":
+				constraints = append(constraints, constraint{">=", version, nil}, constraint{"<", caretLimit(version), nil})
+			case "~":
+				constraints = append(constraints, constraint{">=", version, nil}, constraint{"<", tildeLimit(version), nil})
+			case "", "=":
+				if strings.Contains(version, "*") {
+					wildcard := New(version)
+					constraints = append(constraints, constraint{"", version, &wildcard})
+				} else {
+					constraints = append(constraints, constraint{"=", version, nil})
+				}
+			default:
+				constraints = append(constraints, constraint{operator, version, nil})
+			}
+		}
+
+		groups = append(groups, constraints)
+	}
+	return groups, nil
+}
+
+// Get the exclusive upper limit for a caret constraint.
+//
+// The limit increments the first non zero part of the version, so "^1.2.3" allows
+// versions lower than "2.0.0" and "^0.2.3" allows versions lower than "0.3.0".
+func caretLimit(version string) string {
+	parts := versionCoreParts(version)
+	for i, p := range parts {
+		if n, err := strconv.Atoi(p); err == nil && (n != 0 || i == len(parts)-1) {
+			return incrementPart(parts, i)
+		}
+	}
+	return incrementPart(parts, 0)
+}
+
+// Get the exclusive upper limit for a tilde constraint.
+//
+// The limit increments the minor version when it is given, so "~1.2.3" allows
+// versions lower than "1.3.0" and "~1" allows versions lower than "2.0.0".
+func tildeLimit(version string) string {
+	parts := versionCoreParts(version)
+	if len(parts) > 1 {
+		return incrementPart(parts, 1)
+	}
+	return incrementPart(parts, 0)
+}
+
+// Get the version parts without the pre-release suffix.
+func versionCoreParts(version string) []string {
+	return strings.Split(strings.SplitN(version, "-", 2)[0], ".")
+}
+
+// Increment a version part and set the following parts to zero.
+func incrementPart(parts []string, index int) string {
+	limit := make([]string, len(parts))
+	for i, p := range parts {
+		switch {
+		case i < index:
+			limit[i] = p
+		case i == index:
+			n, _ := strconv.Atoi(p)
+			limit[i] = strconv.Itoa(n + 1)
+		default:
+			limit[i] = "0"
+		}
+	}
+	return strings.Join(limit, ".")
+}
+
+// Compare two versions using semantic versioning precedence.
+//
+// Missing version parts are considered to be zero, and pre-release versions
+// have a lower precedence than the same version without pre-release.
+//
+// The result is -1 when v1 is lower than v2, 1 when it is greater or 0 when they are equal.
+func compareSemver(v1, v2 string) int {
+	core := [2]string{v1, v2}
+	pre := [2]string{"", ""}
+	for i, v := range core {
+		if parts := strings.SplitN(v, "-", 2); len(parts) == 2 {
+			core[i], pre[i] = parts[0], parts[1]
+		}
+	}
+
+	// Compare the version cores padding the missing parts with zeros
+	for _, parts := range zipVersionParts(strings.Split(core[0], "."), strings.Split(core[1], ".")) {
+		for i := range parts {
+			if parts[i] == "" {
+				parts[i] = "0"
+			}
+		}
+
+		if result := comparePart(parts[0], parts[1]); result != 0 {
+			return result
+		}
+	}
+
+	// A version without pre-release has higher precedence
+	if pre[0] == "" || pre[1] == "" {
+		switch {
+		case pre[0] == pre[1]:
+			return 0
+		case pre[0] == "":
+			return 1
+		}
+		return -1
+	}
+
+	// Compare the pre-release identifiers, where a larger set has higher precedence
+	for _, parts := range zipVersionParts(strings.Split(pre[0], "."), strings.Split(pre[1], ".")) {
+		if parts[0] == "" {
+			return -1
+		} else if parts[1] == "" {
+			return 1
+		}
+
+		if result := comparePart(parts[0], parts[1]); result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// Compare two version parts.
+//
+// Numeric parts are compared numerically and have a higher precedence than non numeric parts.
+func comparePart(p1, p2 string) int {
+	n1, err1 := strconv.Atoi(p1)
+	n2, err2 := strconv.Atoi(p2)
+	if err1 == nil && err2 == nil {
+		switch {
+		case n1 < n2:
+			return -1
+		case n1 > n2:
+			return 1
+		}
+		return 0
+	}
+
+	if compareSubParts(p1, p2) {
+		return -1
+	} else if compareSubParts(p2, p1) {
+		return 1
+	}
+	return 0
+}
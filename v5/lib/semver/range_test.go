@@ -0,0 +1,105 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package semver
+
+import "testing"
+
+func TestCompareSemver(t *testing.T) {
+	tt := []struct {
+		v1       string
+		v2       string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.9.0", "1.10.0", -1},
+		{"2.0.0", "1.10.0", 1},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0", "1.0.0-alpha", 1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha", 1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+	}
+
+	for _, tc := range tt {
+		if result := compareSemver(tc.v1, tc.v2); result != tc.expected {
+			t.Errorf(`comparing "%s" with "%s" expected %d, got %d`, tc.v1, tc.v2, tc.expected, result)
+		}
+	}
+}
+
+func TestMatchRange(t *testing.T) {
+	tt := []struct {
+		pattern string
+		version string
+		matches bool
+	}{
+		{"^1.2", "1.2.0", true},
+		{"^1.2", "1.9.9", true},
+		{"^1.2", "2.0.0", false},
+		{"^1.2", "1.1.9", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1", "1.9.0", true},
+		{">=1.0 <2.0", "1.5.0", true},
+		{">=1.0 <2.0", "2.0.0", false},
+		{">= 1.0, < 2.0", "1.0.0", true},
+		{">1.0", "1.0.0", false},
+		{"<=1.0", "1.0.0", true},
+		{"=1.0.0", "1.0.0", true},
+		{"^1.0 || ^3.0", "3.1.0", true},
+		{"^1.0 || ^3.0", "2.1.0", false},
+		{">=1.0.0-alpha <1.0.0", "1.0.0-beta", true},
+		{">=1.0 1.*", "1.4.0", true},
+	}
+
+	for _, tc := range tt {
+		version := New(tc.pattern)
+		if !version.IsRange() {
+			t.Fatalf(`expected "%s" to be a version range`, tc.pattern)
+		}
+
+		if matches := version.Match(tc.version); matches != tc.matches {
+			t.Errorf(`range "%s" match with "%s" expected %v, got %v`, tc.pattern, tc.version, tc.matches, matches)
+		}
+	}
+}
+
+func TestResolveRange(t *testing.T) {
+	tt := []struct {
+		pattern  string
+		versions []string
+		expected string
+	}{
+		{"^1.2", []string{"1.1.0", "1.2.0", "1.10.1", "1.9.3", "2.0.0"}, "1.10.1"},
+		{"~1.2", []string{"1.2.0", "1.2.5", "1.3.0"}, "1.2.5"},
+		{"<2.0", []string{"2.0.0-beta", "1.9.0"}, "2.0.0-beta"},
+		{">=3.0", []string{"1.0.0", "2.0.0"}, ""},
+	}
+
+	for i, tc := range tt {
+		version := New(tc.pattern)
+		if v := version.Resolve(tc.versions); v != tc.expected {
+			t.Errorf(`case %d expected "%s", got "%s"`, i+1, tc.expected, v)
+		}
+	}
+}
+
+func TestInvalidRange(t *testing.T) {
+	version := New(">=1.0 <@2.0")
+	if version.IsRange() {
+		t.Error("invalid version range should not be parsed")
+	}
+
+	if version.Match("1.5.0") {
+		t.Error("invalid version range should not match")
+	}
+}
@@ -132,11 +132,22 @@ func compareVersions(versions []string) func(i, j int) bool {
 }
 
 // New creates a new semantic version.
+//
+// The pattern can be a fixed version, a version with "*" wildcards or a version range.
+// Ranges support the "=", ">", ">=", "<", "<=", "^" and "~" operators, where constraints
+// are separated by spaces and groups of constraints by "||", for example ">=1.0 <2.0".
 func New(pattern string) Version {
 	// Remove duplicated '*' from the version pattern
 	v := Version{value: reWildcards.ReplaceAllString(pattern, "*")}
 
-	if strings.Contains(v.value, "*") {
+	if isRange(v.value) {
+		ranges, err := parseRange(v.value)
+		if err != nil {
+			log.Errorf(`failed to parse version range "%s": %v`, v.value, err)
+		} else {
+			v.ranges = ranges
+		}
+	} else if strings.Contains(v.value, "*") {
 		// Create an expression to use for version pattern comparison (${1} is the extra matched char)
 		expr := reVersionWildcards.ReplaceAllString(v.value, `[^*.]+${1}`)
 		// Escape dots to work with the regular expression (${1} adds the matched prefix char)
@@ -162,6 +173,7 @@ func New(pattern string) Version {
 // Version is used to resolve component versions.
 type Version struct {
 	pattern *regexp.Regexp
+	ranges  [][]constraint
 	value   string
 }
 
@@ -170,8 +182,22 @@ func (v Version) AllowWildcards() bool {
 	return v.pattern != nil
 }
 
+// IsRange checks if the version is a version range.
+func (v Version) IsRange() bool {
+	return v.ranges != nil
+}
+
 // Match checks if a version matches the current version pattern.
 func (v Version) Match(version string) bool {
+	if v.ranges != nil {
+		for _, constraints := range v.ranges {
+			if matchConstraints(constraints, version) {
+				return true
+			}
+		}
+		return false
+	}
+
 	// Check that the version pattern is valid
 	if reInvalidVersionChars.MatchString(v.value) {
 		return false
@@ -199,7 +225,28 @@ func (v Version) Resolve(versions []string) string {
 		return ""
 	}
 
+	// Version ranges use semantic versioning precedence to resolve the highest version
+	if v.ranges != nil {
+		highest := compatible[0]
+		for _, version := range compatible[1:] {
+			if compareSemver(version, highest) > 0 {
+				highest = version
+			}
+		}
+		return highest
+	}
+
 	// Sort the compatible versions and return the higher one
 	sort.Slice(compatible, compareVersions(compatible))
 	return compatible[len(compatible)-1]
 }
+
+// Check if a version satisfies all the constraints.
+func matchConstraints(constraints []constraint, version string) bool {
+	for _, c := range constraints {
+		if !c.check(version) {
+			return false
+		}
+	}
+	return true
+}
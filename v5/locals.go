@@ -0,0 +1,128 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+// LocalsTTLVariable is the name of the component variable that sets the time in
+// milliseconds that the request local values are kept when the request doesn't finish.
+//
+// Middleware values are kept from the request until the response callback, and are
+// removed after the TTL when the component doesn't receive the response for a request.
+const LocalsTTLVariable = "locals-ttl"
+
+// Default time in milliseconds to keep the request local values.
+const defaultLocalsTTL = 300000
+
+// Creates the request local values store configured with the component variables.
+func newLocalStoreFromInput(input cli.Input) *localStore {
+	ttl := getIntVariable(input, LocalsTTLVariable, defaultLocalsTTL)
+	return newLocalStore(time.Duration(ttl) * time.Millisecond)
+}
+
+// Creates a new request local values store.
+//
+// ttl: The time the values of a request are kept since the last change.
+func newLocalStore(ttl time.Duration) *localStore {
+	return &localStore{
+		ttl:      ttl,
+		requests: make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Store for the values shared by the callbacks that handle the same request in a component.
+//
+// The values are never sent to the framework, so they are not visible to other components.
+// The requests are kept in the order they expire, so the expired values are removed from
+// the front of the list without checking the values of all the requests.
+type localStore struct {
+	mutex    sync.Mutex
+	ttl      time.Duration
+	requests map[string]*list.Element
+	order    *list.List
+}
+
+// Local values of a request.
+type localValues struct {
+	rid     string
+	values  map[string]interface{}
+	expires time.Time
+}
+
+// Get a local value of a request.
+//
+// rid: The ID of the request.
+// name: The name of the value.
+func (s *localStore) get(rid, name string) (interface{}, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, exists := s.requests[rid]
+	if !exists {
+		return nil, false
+	}
+
+	value, exists := element.Value.(*localValues).values[name]
+	return value, exists
+}
+
+// Set a local value for a request.
+//
+// rid: The ID of the request.
+// name: The name of the value.
+// value: The value.
+func (s *localStore) set(rid, name string, value interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+
+	// Remove the values of the requests that didn't finish
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		request := element.Value.(*localValues)
+		if !now.After(request.expires) {
+			break
+		}
+		s.order.Remove(element)
+		delete(s.requests, request.rid)
+	}
+
+	// The TTL is the same for all the requests, so the changed request expires last
+	element, exists := s.requests[rid]
+	if exists {
+		s.order.MoveToBack(element)
+	} else {
+		element = s.order.PushBack(&localValues{rid: rid, values: make(map[string]interface{})})
+		s.requests[rid] = element
+	}
+
+	request := element.Value.(*localValues)
+	request.values[name] = value
+	request.expires = now.Add(s.ttl)
+}
+
+// Remove the local values of a request.
+//
+// rid: The ID of the request.
+func (s *localStore) clear(rid string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, exists := s.requests[rid]; exists {
+		s.order.Remove(element)
+		delete(s.requests, rid)
+	}
+}
@@ -0,0 +1,117 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestLocalStore(t *testing.T) {
+	s := newLocalStore(time.Minute)
+	s.set("rid-1", "user", "jane")
+	s.set("rid-2", "user", "john")
+
+	// The values are isolated by request
+	if value, exists := s.get("rid-1", "user"); !exists || value != "jane" {
+		t.Errorf("unexpected value for the first request: %v", value)
+	}
+	if value, exists := s.get("rid-2", "user"); !exists || value != "john" {
+		t.Errorf("unexpected value for the second request: %v", value)
+	}
+	if _, exists := s.get("rid-1", "missing"); exists {
+		t.Error("expected the value not to exist")
+	}
+
+	s.clear("rid-1")
+	if _, exists := s.get("rid-1", "user"); exists {
+		t.Error("expected the values of the request to be removed")
+	}
+	if _, exists := s.get("rid-2", "user"); !exists {
+		t.Error("expected the values of other requests to be kept")
+	}
+}
+
+func TestLocalStoreExpiration(t *testing.T) {
+	s := newLocalStore(time.Minute)
+	s.set("rid-1", "user", "jane")
+	s.requests["rid-1"].Value.(*localValues).expires = time.Now().Add(-time.Second)
+
+	// The expired values are removed when other values are set
+	s.set("rid-2", "user", "john")
+	if _, exists := s.get("rid-1", "user"); exists {
+		t.Error("expected the expired values to be removed")
+	}
+	if len(s.requests) != 1 {
+		t.Errorf("expected only the values of the second request, got %d", len(s.requests))
+	}
+}
+
+func TestLocalStoreExpirationOrder(t *testing.T) {
+	s := newLocalStore(time.Minute)
+	s.set("rid-1", "user", "jane")
+	s.set("rid-2", "user", "john")
+
+	// Changing the values of a request moves it to the end of the expiration order
+	s.set("rid-1", "role", "admin")
+	if rid := s.order.Front().Value.(*localValues).rid; rid != "rid-2" {
+		t.Errorf("expected the second request to expire first, got %s", rid)
+	}
+
+	s.clear("rid-2")
+	if s.order.Len() != 1 || len(s.requests) != 1 {
+		t.Errorf("expected only the values of the first request, got %d", s.order.Len())
+	}
+}
+
+func TestLocalStoreTTLVariable(t *testing.T) {
+	if s := newLocalStoreFromInput(cli.Input{}); s.ttl != defaultLocalsTTL*time.Millisecond {
+		t.Errorf("expected the default TTL, got %s", s.ttl)
+	}
+
+	setTestVariable(t, LocalsTTLVariable, "1500")
+	if s := newLocalStoreFromInput(cli.Input{}); s.ttl != 1500*time.Millisecond {
+		t.Errorf("expected the TTL of the variable, got %s", s.ttl)
+	}
+}
+
+func TestApiLocals(t *testing.T) {
+	locals := newLocalStore(time.Minute)
+	newLocalsTestAction := func() *Action {
+		transport := &payload.Transport{}
+		transport.Meta.ID = "rid"
+		s := newTestState("users", "1.0.0", "read", transport)
+		s.locals = locals
+		return newAction(NewService(), s)
+	}
+
+	a := newLocalsTestAction()
+	a.SetLocal("user", "jane")
+
+	// Callbacks that handle the same request share the values
+	other := newLocalsTestAction()
+	if !other.HasLocal("user") || other.GetLocal("user") != "jane" {
+		t.Errorf("expected the local value, got %v", other.GetLocal("user"))
+	}
+
+	other.state.clearLocals()
+	if a.HasLocal("user") {
+		t.Error("expected the local values to be removed")
+	}
+
+	// Without store the values are ignored
+	a = newTestAction("users", "1.0.0", "read", nil)
+	a.SetLocal("user", "jane")
+	if a.HasLocal("user") || a.GetLocal("user") != nil {
+		t.Error("expected no local values without store")
+	}
+}
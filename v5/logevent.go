@@ -0,0 +1,111 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/format"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// LogEventsVariable is the name of the component variable that enables the
+// recording of the action log events in the transport.
+//
+// By default the log events are only written to the component log.
+const LogEventsVariable = "log-events"
+
+// MaxLogEventsVariable is the name of the component variable that sets the
+// maximum number of log events that can be recorded in the transport.
+const MaxLogEventsVariable = "max-log-events"
+
+// Default maximum number of log events recorded in the transport.
+const defaultMaxLogEvents = 100
+
+// LogEvent writes a log message with structured fields to the component log.
+//
+// When the "log-events" component variable is enabled the event is also recorded
+// in the transport, so the event is available to the response middlewares. The
+// events are discarded once the transport contains the maximum number of events.
+//
+// level: The log level for the message.
+// message: The log message.
+// fields: Optional fields to add to the log message.
+func (a *Action) LogEvent(level int, message string, fields map[string]interface{}) *Action {
+	a.GetLogger().WithFields(fields).Log(level, message)
+
+	if enabled, _ := strconv.ParseBool(a.input.GetVariable(LogEventsVariable)); !enabled {
+		return a
+	}
+
+	event := payload.LogEvent{
+		Service:  a.GetName(),
+		Version:  a.GetVersion(),
+		Action:   a.GetActionName(),
+		Level:    level,
+		Message:  message,
+		Fields:   fields,
+		Datetime: format.TimeToString(time.Now()),
+	}
+
+	limit := getIntVariable(a.input, MaxLogEventsVariable, defaultMaxLogEvents)
+	if !a.reply.Command.Result.Transport.AddLogEvent(event, limit) {
+		a.logger.Debugf(`Log event discarded, the transport already contains %d events: "%s"`, limit, message)
+	}
+	return a
+}
+
+// LogEvent contains a log event recorded in the transport.
+type LogEvent struct {
+	payload payload.LogEvent
+}
+
+// GetName returns the name of the service that recorded the event.
+func (e LogEvent) GetName() string {
+	return e.payload.Service
+}
+
+// GetVersion returns the version of the service that recorded the event.
+func (e LogEvent) GetVersion() string {
+	return e.payload.Version
+}
+
+// GetAction returns the name of the action that recorded the event.
+func (e LogEvent) GetAction() string {
+	return e.payload.Action
+}
+
+// GetLevel returns the log level of the event.
+func (e LogEvent) GetLevel() int {
+	return e.payload.Level
+}
+
+// GetMessage returns the log message.
+func (e LogEvent) GetMessage() string {
+	return e.payload.Message
+}
+
+// GetFields returns the structured fields of the event.
+func (e LogEvent) GetFields() map[string]interface{} {
+	return e.payload.Fields
+}
+
+// GetTimestamp returns the date and time when the event was recorded.
+func (e LogEvent) GetTimestamp() string {
+	return e.payload.Datetime
+}
+
+// GetLogEvents returns the log events recorded in the transport.
+func (t Transport) GetLogEvents() (events []LogEvent) {
+	for _, e := range t.payload.Meta.Events {
+		events = append(events, LogEvent{e})
+	}
+	return events
+}
@@ -0,0 +1,82 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+)
+
+// LogLevelsVariable is the name of the component variable with the log levels of the
+// SDK subsystems, for example "server=debug,payload=warning".
+//
+// The subsystems are "server", "worker", "payload", "runtime-call" and "userland".
+// Subsystems without level use the level of the component.
+const LogLevelsVariable = "log-levels"
+
+// Setup the log level and output before the server is created.
+//
+// The result is a function to call before the component exits, to write the buffered log lines.
+func setupLogging(input cli.Input) (func(), error) {
+	log.SetLevel(input.GetLogLevel())
+	if err := setupSubsystemLogLevels(input); err != nil {
+		return nil, err
+	}
+	return setupLogOutput(input)
+}
+
+// Setup the log levels of the subsystems.
+func setupSubsystemLogLevels(input cli.Input) error {
+	value := input.GetVariable(LogLevelsVariable)
+	if value == "" {
+		return nil
+	}
+
+	levels, err := log.ParseSubsystemLevels(value)
+	if err != nil {
+		return err
+	}
+
+	for subsystem, level := range levels {
+		log.SetSubsystemLevel(subsystem, level)
+	}
+	return nil
+}
+
+// Setup the output of the log messages.
+//
+// The result is a function to call before the component exits, to write the buffered log lines.
+// The log lines are buffered when the "log-buffer" option is given, even when the output is stdout.
+func setupLogOutput(input cli.Input) (func(), error) {
+	if input.GetLogOutput() == "" && input.GetLogBuffer() == 0 {
+		return func() {}, nil
+	}
+
+	tag := input.GetName()
+	if tag == "" {
+		tag = "kusanagi"
+	}
+
+	output, err := log.NewOutput(input.GetLogOutput(), tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if size := input.GetLogBuffer(); size > 0 {
+		buffered := log.NewBufferedWriter(output, int(size))
+		log.SetOutput(buffered)
+		return func() {
+			buffered.Close()
+		}, nil
+	}
+
+	// The output is closed when the process exits
+	log.SetOutput(output)
+	return func() {}, nil
+}
@@ -8,7 +8,10 @@
 
 package kusanagi
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+)
 
 // Empty frame defines an empty frame for a multipart response.
 var emptyFrame = []byte("\x00")
@@ -22,6 +25,8 @@ const (
 	msgActionPart
 	msgSchemasPart
 	msgPayloadPart
+	msgFormatPart
+	msgSignaturePart
 )
 
 // Response message contains the frames for a ZMQ multipart response.
@@ -31,14 +36,36 @@ type responseMsg [][]byte
 type requestMsg [][]byte
 
 // Validates that the multipart message has the right format.
+//
+// The serialization format and signature frames are optional, so the message can have
+// up to two parts less. The format frame is required when the message has a signature.
 func (m requestMsg) check() error {
-	if length := len(m); length != 7 {
+	if length := len(m); length < msgFormatPart || length > msgSignaturePart+1 {
 		return fmt.Errorf("Invalid multipart request length: %d", length)
 	}
 
+	if len(m) > msgFormatPart {
+		if f := m[msgFormatPart]; !bytes.Equal(f, msgpackFormatFlag) && !bytes.Equal(f, jsonFormatFlag) {
+			return fmt.Errorf("Invalid multipart request format flag: %q", f)
+		}
+	}
+
 	return nil
 }
 
+// Get the serialization format for the request payloads.
+//
+// The binary format is used by default when the request doesn't have a format frame.
+//
+// binary: The binary serialization format of the component.
+func (m requestMsg) getFormat(binary wireFormat) wireFormat {
+	if len(m) > msgFormatPart && bytes.Equal(m[msgFormatPart], jsonFormatFlag) {
+		return formatJSON
+	}
+
+	return binary
+}
+
 // Get the ID for the current request.
 func (m requestMsg) getRequestID() string {
 	return string(m[msgRequestIDPart])
@@ -58,6 +85,17 @@ func (m requestMsg) getSchemas() []byte {
 	return nil
 }
 
+// Get the signature of the command payload.
+//
+// The result is nil when the request has no signature.
+func (m requestMsg) getSignature() []byte {
+	if len(m) > msgSignaturePart && len(m[msgSignaturePart]) > 0 {
+		return m[msgSignaturePart]
+	}
+
+	return nil
+}
+
 // Get the command payload stream.
 func (m requestMsg) getPayload() []byte {
 	return m[msgPayloadPart]
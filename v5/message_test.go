@@ -0,0 +1,103 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestRequestMsgCheck(t *testing.T) {
+	frames := func(extra ...[]byte) requestMsg {
+		return append(requestMsg{{}, {}, {}, []byte("rid"), []byte("read"), nil, []byte("payload")}, extra...)
+	}
+
+	cases := []struct {
+		name  string
+		msg   requestMsg
+		valid bool
+	}{
+		{"without format", frames(), true},
+		{"msgpack", frames(msgpackFormatFlag), true},
+		{"JSON", frames(jsonFormatFlag), true},
+		{"signature", frames(jsonFormatFlag, []byte("signature")), true},
+		{"invalid format", frames([]byte("\x02")), false},
+		{"too short", frames()[:msgPayloadPart], false},
+		{"too long", frames(msgpackFormatFlag, []byte("signature"), []byte("extra")), false},
+	}
+
+	for _, c := range cases {
+		if err := c.msg.check(); (err == nil) != c.valid {
+			t.Errorf("%s: unexpected check result: %v", c.name, err)
+		}
+	}
+}
+
+func TestRequestMsgGetFormat(t *testing.T) {
+	msg := requestMsg{{}, {}, {}, []byte("rid"), []byte("read"), nil, []byte("payload")}
+	if format := msg.getFormat(formatMsgpack); format != formatMsgpack {
+		t.Errorf("expected the binary format without format frame, got %s", format)
+	}
+	if format := append(msg, msgpackFormatFlag).getFormat(formatMsgpack); format != formatMsgpack {
+		t.Errorf("expected the binary format, got %s", format)
+	}
+	if format := append(msg, jsonFormatFlag).getFormat(formatMsgpack); !format.isJSON() {
+		t.Errorf("expected the JSON format, got %s", format)
+	}
+}
+
+func TestCreateErrorResponseJSON(t *testing.T) {
+	response, err := createErrorResponse(formatJSON, errors.New("Failed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reply payload.Reply
+	if err := formatJSON.decode(response[1], &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Error == nil || reply.Error.Message != "Failed" {
+		t.Errorf("unexpected error reply: %s", response[1])
+	}
+}
+
+func TestServerProcessJSONRequest(t *testing.T) {
+	service := NewService()
+	service.SetSchemaPolicy(SchemaPolicyCLIOnlyPermissive)
+	service.Action("read", func(a *Action) (*Action, error) {
+		return a.SetProperty("format", "json"), nil
+	})
+
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	s := newServer(input, service.base(), service.processor)
+
+	command := payload.NewCommand("read", "service")
+	command.Command.Arguments = &payload.CommandArguments{Transport: &payload.Transport{}}
+	message, err := formatJSON.encode(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := s.process(requestMsg{{}, {}, {}, []byte("rid"), []byte("read"), nil, message, jsonFormatFlag})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result payload.Reply
+	b, _ := formatJSON.encode(reply)
+	if err := formatJSON.decode(b, &result); err != nil {
+		t.Fatal(err)
+	}
+	if value := result.GetTransport().Meta.Properties["format"]; value != "json" {
+		t.Errorf("expected the property set by the action, got %q", value)
+	}
+}
@@ -0,0 +1,219 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A value of an "Accept" header with its quality.
+type acceptRange struct {
+	value   string
+	quality float64
+}
+
+// Function to check if a value matches an accept range.
+//
+// The result is the specificity of the match, where more specific ranges have a higher value.
+type acceptMatcher func(rng, value string) (specificity int, ok bool)
+
+// Parse the values of an "Accept" header.
+//
+// The header values are lists of comma separated ranges, where each
+// range can have an optional quality value, for example "text/html;q=0.8".
+func parseAcceptRanges(values []string) (ranges []acceptRange) {
+	for _, header := range values {
+		for _, value := range strings.Split(header, ",") {
+			parts := strings.Split(value, ";")
+			rng := strings.ToLower(strings.TrimSpace(parts[0]))
+			if rng == "" {
+				continue
+			}
+
+			// Get the quality of the range when present
+			quality := 1.0
+			for _, param := range parts[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+						quality = q
+					}
+				}
+			}
+
+			ranges = append(ranges, acceptRange{rng, quality})
+		}
+	}
+	return ranges
+}
+
+// Get the quality of a value for a list of accept ranges.
+//
+// The quality is taken from the most specific range that matches the value,
+// and it is zero when the value doesn't match any range.
+func getAcceptQuality(ranges []acceptRange, value string, match acceptMatcher) float64 {
+	value = strings.ToLower(strings.TrimSpace(value))
+	quality := 0.0
+	best := -1
+	for _, r := range ranges {
+		if specificity, ok := match(r.value, value); ok && specificity > best {
+			best = specificity
+			quality = r.quality
+		}
+	}
+	return quality
+}
+
+// Get the offered value with the highest quality for a list of accept ranges.
+//
+// The order of the offers is used as preference when more than one offer has the same quality.
+// When there are no ranges the first offer is returned, and when no offer is acceptable
+// the result is an empty string.
+func negotiate(ranges []acceptRange, offers []string, match acceptMatcher) string {
+	if len(ranges) == 0 {
+		if len(offers) > 0 {
+			return offers[0]
+		}
+		return ""
+	}
+
+	preferred := ""
+	best := 0.0
+	for _, offer := range offers {
+		if quality := getAcceptQuality(ranges, offer, match); quality > best {
+			preferred = offer
+			best = quality
+		}
+	}
+	return preferred
+}
+
+// Check if a media type matches a media range like "text/html", "text/*" or "*/*".
+func matchMediaRange(rng, mime string) (int, bool) {
+	// Media type parameters are not considered during the match
+	rng = strings.TrimSpace(strings.SplitN(rng, ";", 2)[0])
+	mime = strings.TrimSpace(strings.SplitN(mime, ";", 2)[0])
+
+	if rng == "*/*" || rng == "*" {
+		return 0, true
+	}
+
+	rngParts := strings.SplitN(rng, "/", 2)
+	mimeParts := strings.SplitN(mime, "/", 2)
+	if len(rngParts) != 2 || len(mimeParts) != 2 || rngParts[0] != mimeParts[0] {
+		return 0, false
+	} else if rngParts[1] == "*" {
+		return 1, true
+	} else if rngParts[1] == mimeParts[1] {
+		return 2, true
+	}
+	return 0, false
+}
+
+// Check if a language tag matches a language range like "en", "en-us" or "*".
+func matchLanguageRange(rng, language string) (int, bool) {
+	if rng == "*" {
+		return 0, true
+	} else if rng == language || strings.HasPrefix(language, rng+"-") {
+		return len(rng), true
+	}
+	return 0, false
+}
+
+// Check if a token like a charset or an encoding matches a range.
+func matchTokenRange(rng, token string) (int, bool) {
+	if rng == "*" {
+		return 0, true
+	} else if rng == token {
+		return 1, true
+	}
+	return 0, false
+}
+
+// Accepts checks if the request accepts a content type.
+//
+// The "Accept" header is used to check the content type, and all
+// content types are accepted when the request doesn't have the header.
+//
+// mime: The content type, for example "application/json".
+func (r HTTPRequest) Accepts(mime string) bool {
+	if !r.HasHeader("Accept") {
+		return true
+	}
+
+	ranges := parseAcceptRanges(r.GetHeaderArray("Accept", nil))
+	return getAcceptQuality(ranges, mime, matchMediaRange) > 0
+}
+
+// PreferredContentType returns the offered content type that the request prefers.
+//
+// The content types are negotiated using the "Accept" header. When more than one of
+// the offered types have the same quality the first one in the list is returned.
+// An empty string is returned when none of the offered types is accepted.
+//
+// offer: The list of content types that can be used for the response.
+func (r HTTPRequest) PreferredContentType(offer []string) string {
+	ranges := parseAcceptRanges(r.GetHeaderArray("Accept", nil))
+	return negotiate(ranges, offer, matchMediaRange)
+}
+
+// AcceptsLanguage checks if the request accepts a language.
+//
+// The "Accept-Language" header is used to check the language, and all
+// languages are accepted when the request doesn't have the header.
+//
+// language: The language tag, for example "en-US".
+func (r HTTPRequest) AcceptsLanguage(language string) bool {
+	if !r.HasHeader("Accept-Language") {
+		return true
+	}
+
+	ranges := parseAcceptRanges(r.GetHeaderArray("Accept-Language", nil))
+	return getAcceptQuality(ranges, language, matchLanguageRange) > 0
+}
+
+// PreferredLanguage returns the offered language that the request prefers.
+//
+// The languages are negotiated using the "Accept-Language" header. When more than one of
+// the offered languages have the same quality the first one in the list is returned.
+// An empty string is returned when none of the offered languages is accepted.
+//
+// offer: The list of language tags that can be used for the response.
+func (r HTTPRequest) PreferredLanguage(offer []string) string {
+	ranges := parseAcceptRanges(r.GetHeaderArray("Accept-Language", nil))
+	return negotiate(ranges, offer, matchLanguageRange)
+}
+
+// AcceptsCharset checks if the request accepts a charset.
+//
+// The "Accept-Charset" header is used to check the charset, and all
+// charsets are accepted when the request doesn't have the header.
+//
+// charset: The charset name, for example "utf-8".
+func (r HTTPRequest) AcceptsCharset(charset string) bool {
+	if !r.HasHeader("Accept-Charset") {
+		return true
+	}
+
+	ranges := parseAcceptRanges(r.GetHeaderArray("Accept-Charset", nil))
+	return getAcceptQuality(ranges, charset, matchTokenRange) > 0
+}
+
+// PreferredCharset returns the offered charset that the request prefers.
+//
+// The charsets are negotiated using the "Accept-Charset" header. When more than one of
+// the offered charsets have the same quality the first one in the list is returned.
+// An empty string is returned when none of the offered charsets is accepted.
+//
+// offer: The list of charsets that can be used for the response.
+func (r HTTPRequest) PreferredCharset(offer []string) string {
+	ranges := parseAcceptRanges(r.GetHeaderArray("Accept-Charset", nil))
+	return negotiate(ranges, offer, matchTokenRange)
+}
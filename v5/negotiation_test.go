@@ -0,0 +1,136 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create an HTTP request with a header for the negotiation tests.
+func newNegotiationTestRequest(name string, values ...string) *HTTPRequest {
+	headers := http.Header{}
+	for _, v := range values {
+		headers.Add(name, v)
+	}
+	return newHTTPRequest(&payload.HTTPRequest{Method: "GET", Headers: headers})
+}
+
+func TestParseAcceptRanges(t *testing.T) {
+	ranges := parseAcceptRanges([]string{"text/HTML, application/json;q=0.5", "*/*; q=0.1,, text/plain;q=x"})
+	expected := []acceptRange{
+		{"text/html", 1},
+		{"application/json", 0.5},
+		{"*/*", 0.1},
+		{"text/plain", 1},
+	}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("expected %v, got %v", expected, ranges)
+	}
+}
+
+func TestMatchMediaRange(t *testing.T) {
+	cases := []struct {
+		rng         string
+		mime        string
+		specificity int
+		ok          bool
+	}{
+		{"*/*", "text/html", 0, true},
+		{"text/*", "text/html", 1, true},
+		{"text/html", "text/html; charset=utf-8", 2, true},
+		{"text/*", "application/json", 0, false},
+		{"text/html", "text/plain", 0, false},
+		{"text", "text/html", 0, false},
+	}
+
+	for _, c := range cases {
+		if specificity, ok := matchMediaRange(c.rng, c.mime); specificity != c.specificity || ok != c.ok {
+			t.Errorf("%s with %s: expected %d %v, got %d %v", c.rng, c.mime, c.specificity, c.ok, specificity, ok)
+		}
+	}
+}
+
+func TestHTTPRequestAccepts(t *testing.T) {
+	if r := newNegotiationTestRequest("Accept"); !r.Accepts("application/json") {
+		t.Error("expected all the types to be accepted without the header")
+	}
+
+	r := newNegotiationTestRequest("Accept", "text/*;q=0.5, application/json, image/png;q=0")
+	cases := map[string]bool{
+		"application/json": true,
+		"text/html":        true,
+		"image/png":        false,
+		"image/jpeg":       false,
+	}
+	for mime, expected := range cases {
+		if accepted := r.Accepts(mime); accepted != expected {
+			t.Errorf("%s: expected %v, got %v", mime, expected, accepted)
+		}
+	}
+}
+
+func TestHTTPRequestPreferredContentType(t *testing.T) {
+	cases := []struct {
+		accept   []string
+		offer    []string
+		expected string
+	}{
+		{nil, []string{"application/json", "text/html"}, "application/json"},
+		{nil, nil, ""},
+		{[]string{"text/html;q=0.9, application/json"}, []string{"text/html", "application/json"}, "application/json"},
+		{[]string{"text/*, application/json"}, []string{"text/html", "application/json"}, "text/html"},
+		// The most specific range sets the quality
+		{[]string{"text/*;q=0.1, text/html"}, []string{"text/plain", "text/html"}, "text/html"},
+		{[]string{"application/xml"}, []string{"text/html", "application/json"}, ""},
+	}
+
+	for _, c := range cases {
+		r := newNegotiationTestRequest("Accept", c.accept...)
+		if preferred := r.PreferredContentType(c.offer); preferred != c.expected {
+			t.Errorf("%v with %v: expected %q, got %q", c.accept, c.offer, c.expected, preferred)
+		}
+	}
+}
+
+func TestHTTPRequestLanguageNegotiation(t *testing.T) {
+	r := newNegotiationTestRequest("Accept-Language", "en;q=0.5, es-AR, fr;q=0")
+	if !r.AcceptsLanguage("en-US") || !r.AcceptsLanguage("es-ar") {
+		t.Error("expected the languages to be accepted")
+	}
+	if r.AcceptsLanguage("fr") || r.AcceptsLanguage("es") || r.AcceptsLanguage("eng") {
+		t.Error("expected the languages not to be accepted")
+	}
+
+	if preferred := r.PreferredLanguage([]string{"en-GB", "es-AR", "fr"}); preferred != "es-AR" {
+		t.Errorf("unexpected preferred language: %s", preferred)
+	}
+	if !newNegotiationTestRequest("Accept-Language").AcceptsLanguage("de") {
+		t.Error("expected all the languages to be accepted without the header")
+	}
+}
+
+func TestHTTPRequestCharsetNegotiation(t *testing.T) {
+	r := newNegotiationTestRequest("Accept-Charset", "utf-8, iso-8859-1;q=0.5")
+	if !r.AcceptsCharset("UTF-8") || r.AcceptsCharset("utf-16") {
+		t.Error("unexpected accepted charsets")
+	}
+
+	if preferred := r.PreferredCharset([]string{"iso-8859-1", "utf-8"}); preferred != "utf-8" {
+		t.Errorf("unexpected preferred charset: %s", preferred)
+	}
+
+	r = newNegotiationTestRequest("Accept-Charset", "*;q=0.1, utf-8")
+	if preferred := r.PreferredCharset([]string{"utf-16", "ascii"}); preferred != "utf-16" {
+		t.Errorf("expected the first charset accepted by the wildcard, got %s", preferred)
+	}
+}
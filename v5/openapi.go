@@ -0,0 +1,421 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification used for the generated documents.
+const OpenAPIVersion = "3.0.3"
+
+// MIME types used for the HTTP request bodies with form data.
+const (
+	mimeMultipartForm = "multipart/form-data"
+	mimeURLEncoded    = "application/x-www-form-urlencoded"
+)
+
+// Object of an OpenAPI document.
+type openAPIObject map[string]interface{}
+
+// GenerateOpenAPI generates an OpenAPI 3 document in JSON format for a mapping.
+//
+// The document describes the actions of all the services that are accesible through the gateway,
+// including their parameters, files and the HTTP request body types defined in the schemas.
+//
+// mapping: The mapping with the service schemas.
+func GenerateOpenAPI(mapping payload.Mapping) ([]byte, error) {
+	paths := openAPIObject{}
+
+	for _, name := range sortedKeys(mapping) {
+		versions := mapping[name]
+		for _, version := range sortedKeys(versions) {
+			schema := versions[version]
+			if !schema.HTTP.GetGateway() {
+				continue
+			}
+
+			for _, action := range sortedKeys(schema.Actions) {
+				actionSchema := schema.Actions[action]
+				if actionSchema.HTTP.Gateway != nil && !*actionSchema.HTTP.Gateway {
+					continue
+				}
+
+				path := getOpenAPIPath(schema.HTTP.BasePath, actionSchema.HTTP.Path)
+				method := strings.ToLower(actionSchema.HTTP.Method)
+				if method == "" {
+					method = "get"
+				}
+
+				item, _ := paths[path].(openAPIObject)
+				if item == nil {
+					item = openAPIObject{}
+					paths[path] = item
+				}
+
+				if _, exists := item[method]; exists {
+					return nil, fmt.Errorf(
+						`Duplicated OpenAPI operation for "%s" (%s) action "%s": %s %s`,
+						name,
+						version,
+						action,
+						strings.ToUpper(method),
+						path,
+					)
+				}
+
+				operation, err := newOpenAPIOperation(name, version, action, method, actionSchema)
+				if err != nil {
+					return nil, err
+				}
+				item[method] = operation
+			}
+		}
+	}
+
+	document := openAPIObject{
+		"openapi": OpenAPIVersion,
+		"info": openAPIObject{
+			"title":   "KUSANAGI API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+	}
+	return json.Marshal(document)
+}
+
+// Get the sorted keys of a map to generate the documents in a deterministic order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Get the full HTTP path of an action.
+func getOpenAPIPath(basePath, path string) string {
+	full := strings.TrimRight(basePath, "/") + path
+	if full == "" {
+		return "/"
+	} else if full[0] != '/' {
+		full = "/" + full
+	}
+	return full
+}
+
+// Create the OpenAPI operation for an action.
+func newOpenAPIOperation(service, version, action, method string, schema payload.ActionSchema) (openAPIObject, error) {
+	operation := openAPIObject{
+		"operationId": fmt.Sprintf("%s.%s.%s", service, version, action),
+		"summary":     fmt.Sprintf(`"%s" (%s) action "%s"`, service, version, action),
+		"tags":        append([]string{service}, schema.Tags...),
+		"responses": openAPIObject{
+			"default": openAPIObject{"description": "Response from the action"},
+		},
+	}
+
+	if schema.Deprecated != nil && *schema.Deprecated {
+		operation["deprecated"] = true
+	}
+
+	parameters := []openAPIObject{}
+	formProperties := openAPIObject{}
+	formRequired := []string{}
+	var bodyParam openAPIObject
+
+	for _, name := range sortedKeys(schema.Params) {
+		param := schema.Params[name]
+		if param.HTTP.Gateway != nil && !*param.HTTP.Gateway {
+			continue
+		}
+
+		paramSchema, err := newOpenAPIParamSchema(param)
+		if err != nil {
+			return nil, fmt.Errorf(`Failed to generate the OpenAPI schema for "%s" (%s) action "%s" param "%s": %v`, service, version, action, name, err)
+		}
+
+		httpName := param.HTTP.Param
+		if httpName == "" {
+			httpName = name
+		}
+
+		input := resolveParamLocation(schema.HTTP.Input, param.HTTP.Input)
+		switch input {
+		case ParamLocationForm:
+			formProperties[httpName] = paramSchema
+			if param.Required {
+				formRequired = append(formRequired, httpName)
+			}
+		case ParamLocationBody:
+			bodyParam = paramSchema
+		default:
+			parameter := openAPIObject{
+				"name":   httpName,
+				"in":     string(input),
+				"schema": paramSchema,
+			}
+			// Path parameters are always required in OpenAPI
+			if param.Required || input == ParamLocationPath {
+				parameter["required"] = true
+			}
+			if param.AllowEmpty && input == ParamLocationQuery {
+				parameter["allowEmptyValue"] = true
+			}
+			if param.Type == payload.TypeArray {
+				setOpenAPIArrayStyle(parameter, param.ArrayFormat)
+			}
+			parameters = append(parameters, parameter)
+		}
+	}
+
+	for _, name := range sortedKeys(schema.Files) {
+		file := schema.Files[name]
+		if file.HTTP.Gateway != nil && !*file.HTTP.Gateway {
+			continue
+		}
+
+		httpName := file.HTTP.Param
+		if httpName == "" {
+			httpName = name
+		}
+
+		formProperties[httpName] = openAPIObject{"type": "string", "format": "binary"}
+		if file.Required {
+			formRequired = append(formRequired, httpName)
+		}
+	}
+
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if body := newOpenAPIRequestBody(method, schema, bodyParam, formProperties, formRequired); body != nil {
+		operation["requestBody"] = body
+	}
+	return operation, nil
+}
+
+// Create the OpenAPI request body for an action.
+//
+// The result is nil when the action doesn't expect a request body.
+func newOpenAPIRequestBody(
+	method string,
+	schema payload.ActionSchema,
+	bodyParam openAPIObject,
+	formProperties openAPIObject,
+	formRequired []string,
+) openAPIObject {
+	hasForm := len(formProperties) > 0
+	hasFiles := len(schema.Files) > 0
+
+	// Actions without form values nor body parameters only expect a body for the methods that allow it
+	if !hasForm && bodyParam == nil && (method == "get" || method == "head" || method == "options") {
+		return nil
+	}
+
+	mimeTypes := append([]string{}, schema.HTTP.Body...)
+	if len(mimeTypes) == 0 && !hasForm {
+		mimeTypes = []string{"text/plain"}
+	}
+
+	// Form values are always described, using multipart bodies when there are files
+	if hasForm {
+		formType := mimeURLEncoded
+		if hasFiles {
+			formType = mimeMultipartForm
+		}
+		if !(HTTPActionSchema{schema.HTTP}).HasBodyType(formType) {
+			mimeTypes = append(mimeTypes, formType)
+		}
+	}
+
+	content := openAPIObject{}
+	for _, mimeType := range mimeTypes {
+		mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+		if mediaType == "" {
+			continue
+		}
+
+		var bodySchema openAPIObject
+		if (mediaType == mimeMultipartForm || mediaType == mimeURLEncoded) && hasForm {
+			bodySchema = openAPIObject{"type": "object", "properties": formProperties}
+			if len(formRequired) > 0 {
+				bodySchema["required"] = formRequired
+			}
+		} else if bodyParam != nil {
+			bodySchema = bodyParam
+		} else {
+			bodySchema = openAPIObject{}
+		}
+		content[mediaType] = openAPIObject{"schema": bodySchema}
+	}
+
+	if len(content) == 0 {
+		return nil
+	}
+
+	body := openAPIObject{"content": content}
+	if len(formRequired) > 0 {
+		body["required"] = true
+	}
+	return body
+}
+
+// Create the OpenAPI schema for an action parameter.
+func newOpenAPIParamSchema(param payload.ParamSchema) (openAPIObject, error) {
+	schema := openAPIObject{}
+
+	switch param.Type {
+	case payload.TypeInteger:
+		schema["type"] = "integer"
+	case payload.TypeFloat:
+		schema["type"] = "number"
+	case payload.TypeBoolean:
+		schema["type"] = "boolean"
+	case payload.TypeBinary:
+		schema["type"] = "string"
+		schema["format"] = "binary"
+	case payload.TypeObject:
+		schema["type"] = "object"
+	case payload.TypeArray:
+		schema["type"] = "array"
+		items := openAPIObject{}
+		if param.Items != "" {
+			if err := json.Unmarshal([]byte(param.Items), &items); err != nil {
+				return nil, fmt.Errorf("Invalid JSON schema for the array items: %v", err)
+			}
+		}
+		schema["items"] = items
+		if param.MaxItems > 0 {
+			schema["maxItems"] = param.MaxItems
+		}
+		if param.MinItems != nil {
+			schema["minItems"] = *param.MinItems
+		}
+		if param.UniqueItems {
+			schema["uniqueItems"] = true
+		}
+	default:
+		schema["type"] = "string"
+	}
+
+	if param.Format != "" {
+		schema["format"] = param.Format
+	}
+	if param.Pattern != "" {
+		schema["pattern"] = param.Pattern
+	}
+	if len(param.Enum) > 0 {
+		schema["enum"] = param.Enum
+	}
+	if param.DefaultValue != nil {
+		schema["default"] = param.DefaultValue
+	}
+	if param.Max != nil {
+		schema["maximum"] = *param.Max
+		if param.ExclusiveMax {
+			schema["exclusiveMaximum"] = true
+		}
+	}
+	if param.Min != nil {
+		schema["minimum"] = *param.Min
+		if param.ExclusiveMin {
+			schema["exclusiveMinimum"] = true
+		}
+	}
+	if param.MultipleOf > 0 {
+		schema["multipleOf"] = param.MultipleOf
+	}
+	return schema, nil
+}
+
+// Set the serialization style of an array parameter using the array format of the schema.
+func setOpenAPIArrayStyle(parameter openAPIObject, format string) {
+	switch format {
+	case ArrayFormatMulti:
+		parameter["style"] = "form"
+		parameter["explode"] = true
+	case ArrayFormatSSV:
+		parameter["style"] = "spaceDelimited"
+		parameter["explode"] = false
+	case ArrayFormatPipe:
+		parameter["style"] = "pipeDelimited"
+		parameter["explode"] = false
+	default:
+		// CSV is the default array format. There is no OpenAPI style for TSV.
+		parameter["style"] = "form"
+		parameter["explode"] = false
+	}
+}
+
+// Create a new HTTP server for the OpenAPI document.
+func newOpenAPIServer(address string) *openAPIServer {
+	return &openAPIServer{address: address}
+}
+
+// HTTP server that serves the OpenAPI document generated from the last mapping received.
+type openAPIServer struct {
+	mutex    sync.RWMutex
+	address  string
+	document []byte
+}
+
+// Generate the OpenAPI document for a new mapping.
+func (s *openAPIServer) update(mapping payload.Mapping) {
+	document, err := GenerateOpenAPI(mapping)
+	if err != nil {
+		log.Errorf("Failed to generate the OpenAPI document: %v", err)
+		return
+	}
+
+	s.mutex.Lock()
+	s.document = document
+	s.mutex.Unlock()
+}
+
+func (s *openAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mutex.RLock()
+	document := s.document
+	s.mutex.RUnlock()
+
+	// The document is available after the first mapping is received
+	if document == nil {
+		http.Error(w, "The mappings are not available yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(document)
+}
+
+// Start serving the OpenAPI document.
+func (s *openAPIServer) start() {
+	log.Debugf(`Serving OpenAPI document at address: "%s"`, s.address)
+	go func() {
+		if err := http.ListenAndServe(s.address, s); err != nil {
+			log.Errorf("OpenAPI document server failed: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,150 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a mapping for the OpenAPI tests.
+func newOpenAPITestMapping() payload.Mapping {
+	hidden := false
+	return payload.Mapping{
+		"users": {"1.0.0": {
+			HTTP: payload.HTTPSchema{BasePath: "/users/"},
+			Actions: map[string]payload.ActionSchema{
+				"read": {
+					HTTP: payload.HTTPActionSchema{Path: "/{id}", Method: "GET"},
+					Params: map[string]payload.ParamSchema{
+						"id":     {Name: "id", Type: payload.TypeInteger, HTTP: payload.HTTPParamSchema{Input: "path"}},
+						"fields": {Name: "fields", Type: payload.TypeString, Enum: []interface{}{"name", "email"}},
+					},
+				},
+				"create": {
+					HTTP: payload.HTTPActionSchema{Path: "", Method: "POST", Input: "form-data"},
+					Params: map[string]payload.ParamSchema{
+						"name": {Name: "name", Type: payload.TypeString, Pattern: "^[a-z]+$", Required: true},
+					},
+					Files: map[string]payload.FileSchema{"avatar": {}},
+				},
+				"internal": {HTTP: payload.HTTPActionSchema{Gateway: &hidden}},
+			},
+		}},
+		"posts": {"1.0.0": {
+			HTTP:    payload.HTTPSchema{Gateway: &hidden},
+			Actions: map[string]payload.ActionSchema{"list": {}},
+		}},
+	}
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	data, err := GenerateOpenAPI(newOpenAPITestMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var document struct {
+		OpenAPI string                                       `json:"openapi"`
+		Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+
+	if document.OpenAPI != OpenAPIVersion {
+		t.Errorf("unexpected OpenAPI version: %s", document.OpenAPI)
+	}
+
+	// Actions and services that are not accesible through the gateway are not included
+	var paths []string
+	for path, item := range document.Paths {
+		for method := range item {
+			paths = append(paths, method+" "+path)
+		}
+	}
+	if len(paths) != 2 || document.Paths["/users/{id}"]["get"] == nil || document.Paths["/users"]["post"] == nil {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+
+	read := document.Paths["/users/{id}"]["get"]
+	expected := []interface{}{
+		map[string]interface{}{
+			"name":   "fields",
+			"in":     "query",
+			"schema": map[string]interface{}{"type": "string", "enum": []interface{}{"name", "email"}},
+		},
+		map[string]interface{}{
+			"name":     "id",
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "integer"},
+		},
+	}
+	if !reflect.DeepEqual(read["parameters"], expected) {
+		t.Errorf("unexpected parameters: %v", read["parameters"])
+	}
+	if read["requestBody"] != nil {
+		t.Errorf("expected no request body, got %v", read["requestBody"])
+	}
+
+	// Form parameters and files are described as a multipart body
+	body, _ := document.Paths["/users"]["post"]["requestBody"].(map[string]interface{})
+	content, _ := body["content"].(map[string]interface{})
+	form, _ := content[mimeMultipartForm].(map[string]interface{})
+	expectedForm := map[string]interface{}{"schema": map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"avatar": map[string]interface{}{"type": "string", "format": "binary"},
+			"name":   map[string]interface{}{"type": "string", "pattern": "^[a-z]+$"},
+		},
+		"required": []interface{}{"name"},
+	}}
+	if len(content) != 1 || !reflect.DeepEqual(form, expectedForm) || body["required"] != true {
+		t.Errorf("unexpected request body: %v", body)
+	}
+}
+
+func TestGenerateOpenAPIDuplicatedOperation(t *testing.T) {
+	mapping := payload.Mapping{"users": {"1.0.0": {Actions: map[string]payload.ActionSchema{
+		"list":   {HTTP: payload.HTTPActionSchema{Path: "/users"}},
+		"search": {HTTP: payload.HTTPActionSchema{Path: "/users", Method: "get"}},
+	}}}}
+
+	if _, err := GenerateOpenAPI(mapping); err == nil {
+		t.Error("expected an error for the duplicated operation")
+	}
+}
+
+func TestOpenAPIServer(t *testing.T) {
+	server := newOpenAPIServer("127.0.0.1:0")
+	get := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(method, "/", nil))
+		return w
+	}
+
+	// The document is not available before the first mapping
+	if code := get(http.MethodGet).Code; code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	server.update(newOpenAPITestMapping())
+	if w := get(http.MethodGet); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if code := get(http.MethodPost).Code; code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, code)
+	}
+}
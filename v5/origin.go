@@ -0,0 +1,75 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import "fmt"
+
+// Origin contains the service action that was the origin of a request.
+type Origin struct {
+	Service string
+	Version string
+	Action  string
+}
+
+// Create an origin from the origin values of the transport meta.
+//
+// The values that are missing are left empty.
+func newOrigin(values []string) (o Origin) {
+	if len(values) > 0 {
+		o.Service = values[0]
+	}
+	if len(values) > 1 {
+		o.Version = values[1]
+	}
+	if len(values) > 2 {
+		o.Action = values[2]
+	}
+	return o
+}
+
+// IsEmpty checks if the origin is not known.
+func (o Origin) IsEmpty() bool {
+	return o.Service == ""
+}
+
+// Is checks if the origin is an action of a service.
+//
+// service: The name of the service.
+func (o Origin) Is(service string) bool {
+	return !o.IsEmpty() && o.Service == service
+}
+
+// Equals checks if the origin is the same as another origin.
+//
+// other: The origin to compare.
+func (o Origin) Equals(other Origin) bool {
+	return o == other
+}
+
+// String returns a description of the origin.
+func (o Origin) String() string {
+	if o.IsEmpty() {
+		return "an unknown origin"
+	}
+	return fmt.Sprintf(`"%s" (%s) action "%s"`, o.Service, o.Version, o.Action)
+}
+
+// GetOrigin returns the service action that was the origin of the request.
+//
+// The fields of the result are empty when the origin is not known.
+func (a *Action) GetOrigin() Origin {
+	return newOrigin(a.transport.Meta.Origin)
+}
+
+// GetOrigin returns the service action that was the origin of the request.
+//
+// The fields of the result are empty when the origin is not known.
+func (t Transport) GetOrigin() Origin {
+	return newOrigin(t.payload.Meta.Origin)
+}
@@ -0,0 +1,82 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestNewOrigin(t *testing.T) {
+	cases := []struct {
+		values   []string
+		expected Origin
+	}{
+		{nil, Origin{}},
+		{[]string{"users"}, Origin{Service: "users"}},
+		{[]string{"users", "1.0.0"}, Origin{Service: "users", Version: "1.0.0"}},
+		{[]string{"users", "1.0.0", "read"}, Origin{"users", "1.0.0", "read"}},
+	}
+
+	for _, c := range cases {
+		if o := newOrigin(c.values); o != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.values, c.expected, o)
+		}
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	o := Origin{"users", "1.0.0", "read"}
+	if o.IsEmpty() || !o.Is("users") || o.Is("posts") {
+		t.Errorf("unexpected origin checks for %v", o)
+	}
+
+	if !o.Equals(Origin{"users", "1.0.0", "read"}) || o.Equals(Origin{"users", "1.0.0", "write"}) {
+		t.Errorf("unexpected origin comparison for %v", o)
+	}
+
+	if s := o.String(); s != `"users" (1.0.0) action "read"` {
+		t.Errorf("unexpected origin description: %s", s)
+	}
+
+	// The service must be known to check the origin
+	empty := Origin{}
+	if !empty.IsEmpty() || empty.Is("") || empty.String() != "an unknown origin" {
+		t.Errorf("unexpected checks for an empty origin: %v", empty)
+	}
+}
+
+func TestActionGetOrigin(t *testing.T) {
+	transport := &payload.Transport{}
+	transport.Meta.Origin = []string{"users", "1.0.0", "read"}
+
+	a := newTestAction("users", "1.0.0", "read", transport)
+	if o := a.GetOrigin(); o != (Origin{"users", "1.0.0", "read"}) {
+		t.Errorf("unexpected action origin: %v", o)
+	}
+	if o := (Transport{transport}).GetOrigin(); o != a.GetOrigin() {
+		t.Errorf("unexpected transport origin: %v", o)
+	}
+	if !a.IsOrigin() {
+		t.Error("expected the action to be the origin")
+	}
+
+	if newTestAction("users", "1.0.0", "write", transport).IsOrigin() {
+		t.Error("expected the action not to be the origin")
+	}
+
+	// Short origins don't panic
+	transport = &payload.Transport{}
+	transport.Meta.Origin = []string{"users"}
+	a = newTestAction("users", "1.0.0", "read", transport)
+	if a.IsOrigin() || a.GetOrigin().Action != "" {
+		t.Errorf("unexpected origin: %v", a.GetOrigin())
+	}
+}
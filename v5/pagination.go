@@ -0,0 +1,76 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Prefix for the transport properties that contain pagination metadata.
+const paginationPropertyPrefix = "pagination"
+
+// Get the name of the transport property with the pagination metadata of an action.
+func getPaginationProperty(service, version, action string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", paginationPropertyPrefix, service, version, action)
+}
+
+// Pagination contains the paging information of a collection returned by an action.
+type Pagination struct {
+	total  uint
+	offset uint
+	limit  uint
+}
+
+// Pagination metadata saved in the transport properties.
+type paginationProperty struct {
+	Total  uint `json:"total"`
+	Offset uint `json:"offset"`
+	Limit  uint `json:"limit"`
+}
+
+// GetTotal returns the total number of items available.
+func (p Pagination) GetTotal() uint {
+	return p.total
+}
+
+// GetOffset returns the position of the first item in the page.
+func (p Pagination) GetOffset() uint {
+	return p.offset
+}
+
+// GetLimit returns the maximum number of items in the page.
+func (p Pagination) GetLimit() uint {
+	return p.limit
+}
+
+// HasNext checks if there are more items after the page.
+func (p Pagination) HasNext() bool {
+	return p.offset+p.limit < p.total
+}
+
+// HasPrevious checks if there are items before the page.
+func (p Pagination) HasPrevious() bool {
+	return p.offset > 0
+}
+
+// Serialize the pagination to be saved as a transport property.
+func (p Pagination) encode() string {
+	v, _ := json.Marshal(paginationProperty{p.total, p.offset, p.limit})
+	return string(v)
+}
+
+// Deserialize the pagination from a transport property value.
+func decodePagination(value string) (*Pagination, error) {
+	var p paginationProperty
+	if err := json.Unmarshal([]byte(value), &p); err != nil {
+		return nil, fmt.Errorf("Invalid pagination metadata: %v", err)
+	}
+	return &Pagination{p.Total, p.Offset, p.Limit}, nil
+}
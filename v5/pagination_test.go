@@ -0,0 +1,80 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import "testing"
+
+func TestPagination(t *testing.T) {
+	cases := []struct {
+		pagination Pagination
+		next       bool
+		previous   bool
+	}{
+		{Pagination{total: 25, offset: 0, limit: 10}, true, false},
+		{Pagination{total: 25, offset: 10, limit: 10}, true, true},
+		{Pagination{total: 25, offset: 20, limit: 10}, false, true},
+		{Pagination{total: 20, offset: 10, limit: 10}, false, true},
+		{Pagination{total: 0, offset: 0, limit: 10}, false, false},
+	}
+
+	for _, c := range cases {
+		p := c.pagination
+		if p.HasNext() != c.next || p.HasPrevious() != c.previous {
+			t.Errorf("%+v: expected %v %v, got %v %v", p, c.next, c.previous, p.HasNext(), p.HasPrevious())
+		}
+
+		decoded, err := decodePagination(p.encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *decoded != p {
+			t.Errorf("expected %+v, got %+v", p, *decoded)
+		}
+	}
+
+	if _, err := decodePagination("{"); err == nil {
+		t.Error("expected an error for invalid metadata")
+	}
+}
+
+func TestActionSetCollectionPage(t *testing.T) {
+	a := newTestAction("users", "1.0.0", "list", nil)
+	page := []map[string]interface{}{{"id": 11}, {"id": 12}}
+	if _, err := a.SetCollectionPage(page, 25, 10, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := Transport{a.reply.Command.Result.Transport}
+	p, err := transport.GetPagination("users", "1.0.0", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.GetTotal() != 25 || p.GetOffset() != 10 || p.GetLimit() != 10 {
+		t.Errorf("unexpected pagination: %+v", p)
+	}
+
+	if data := transport.GetData(); len(data) != 1 {
+		t.Errorf("expected the page as collection data, got %v", data)
+	}
+
+	// Actions without pages have no pagination
+	if p, err := transport.GetPagination("users", "1.0.0", "read"); p != nil || err != nil {
+		t.Errorf("expected no pagination, got %v %v", p, err)
+	}
+}
+
+func TestActionSetCollectionPageErrors(t *testing.T) {
+	a := newTestAction("users", "1.0.0", "list", nil)
+	if _, err := a.SetCollectionPage([]map[string]interface{}{}, 0, 0, 0); err == nil {
+		t.Error("expected an error for a zero limit")
+	}
+	if _, err := a.SetCollectionPage([]int{1}, 1, 0, 10); err == nil {
+		t.Error("expected an error for an invalid collection")
+	}
+}
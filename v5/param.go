@@ -9,12 +9,46 @@
 package kusanagi
 
 import (
+	"encoding/base64"
 	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/format"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
 )
 
+// ParamFormatDate defines the parameter format for dates like "2006-01-02".
+const ParamFormatDate = "date"
+
+// ParamFormatDateTime defines the parameter format for RFC 3339 date and times.
+const ParamFormatDateTime = "date-time"
+
+// ParamFormatUUID defines the parameter format for UUIDs.
+const ParamFormatUUID = "uuid"
+
+// ParamFormatDuration defines the parameter format for durations like "1h30m" or "250ms".
+//
+// Integer values are durations in milliseconds.
+const ParamFormatDuration = "duration"
+
+// Layouts used to parse date and time parameter values.
+const paramDateLayout = "2006-01-02"
+const paramDateTimeLayout = time.RFC3339Nano
+
+// Time layouts by parameter format.
+var paramTimeLayouts = map[string][]string{
+	ParamFormatDate:     {paramDateLayout},
+	ParamFormatDateTime: {paramDateTimeLayout},
+}
+
+// Regexp to validate UUID values
+var reUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
 // Cast a value from one supported type to another
 // TODO: Cast from type to type using strconv
 func cast(value interface{}, valueType string) (v interface{}, ok bool) {
@@ -51,11 +85,26 @@ func newParam(name string, value interface{}, valueType string, exists bool) (*P
 		return nil, fmt.Errorf(`Invalid parameter type: "%s"`, valueType)
 	}
 
+	// Binary values can be given as strings
+	if v, ok := value.(string); ok && valueType == datatypes.Binary {
+		value = []byte(v)
+	}
+
+	// Times and durations are given as strings using the framework formats
+	if valueType == datatypes.String {
+		switch v := value.(type) {
+		case time.Time:
+			value = format.TimeToString(v)
+		case time.Duration:
+			value = format.DurationToString(v)
+		}
+	}
+
 	if t := datatypes.ResolveType(value); t != valueType {
 		return nil, fmt.Errorf("Value must be %s", valueType)
 	}
 
-	return &Param{name, value, valueType, exists}, nil
+	return &Param{name, value, valueType, exists, ""}, nil
 }
 
 // Creates a new empty parameter.
@@ -72,6 +121,7 @@ type Param struct {
 	value     interface{}
 	valueType string
 	exists    bool
+	format    string
 }
 
 // GetName reads the name of the parameter.
@@ -94,18 +144,258 @@ func (p *Param) Exists() bool {
 	return p.exists
 }
 
+// GetFormat reads the format of the parameter value.
+//
+// The format is defined in the parameter schema, for example "date", "date-time" or "uuid".
+// An empty string is returned when the parameter has no format.
+func (p *Param) GetFormat() string {
+	return p.format
+}
+
+// Create an error for a parameter value that can't be converted to a type.
+func (p *Param) newConversionError(target string) error {
+	return fmt.Errorf(`Param "%s" value of type "%s" cannot be converted to %s`, p.name, p.valueType, target)
+}
+
+// GetBytes returns the parameter value as bytes.
+//
+// Binary and string values are converted.
+func (p *Param) GetBytes() ([]byte, error) {
+	switch v := p.value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, p.newConversionError("binary")
+}
+
+// GetInt64 returns the parameter value as an integer.
+//
+// Integer and float values without decimals, and strings with an integer are converted.
+func (p *Param) GetInt64() (int64, error) {
+	rv := reflect.ValueOf(p.value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v := rv.Uint(); v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if v := rv.Float(); v == math.Trunc(v) && v >= math.MinInt64 && v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case reflect.String:
+		if v, err := strconv.ParseInt(rv.String(), 10, 64); err == nil {
+			return v, nil
+		}
+	}
+	return 0, p.newConversionError("integer")
+}
+
+// GetFloat64 returns the parameter value as a float.
+//
+// Integer and float values, and strings with a number are converted.
+func (p *Param) GetFloat64() (float64, error) {
+	rv := reflect.ValueOf(p.value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		if v, err := strconv.ParseFloat(rv.String(), 64); err == nil {
+			return v, nil
+		}
+	}
+	return 0, p.newConversionError("float")
+}
+
+// GetBool returns the parameter value as a boolean.
+//
+// Boolean values, the integers 0 and 1, and strings like "true" or "false" are converted.
+func (p *Param) GetBool() (bool, error) {
+	rv := reflect.ValueOf(p.value)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		if v, err := strconv.ParseBool(rv.String()); err == nil {
+			return v, nil
+		}
+	default:
+		if v, err := p.GetInt64(); err == nil && (v == 0 || v == 1) {
+			return v == 1, nil
+		}
+	}
+	return false, p.newConversionError("boolean")
+}
+
+// GetTime returns the parameter value as a time.
+//
+// String values are parsed using the parameter format, where "date" values use the
+// "2006-01-02" layout and "date-time" values use the RFC 3339 layout. When the parameter
+// has no format both layouts are accepted.
+func (p *Param) GetTime() (time.Time, error) {
+	if v, ok := p.value.(time.Time); ok {
+		return v, nil
+	}
+
+	if v, ok := p.value.(string); ok {
+		layouts := paramTimeLayouts[p.format]
+		if layouts == nil {
+			layouts = []string{time.RFC3339Nano, paramDateLayout}
+		}
+
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, p.newConversionError("time")
+}
+
+// GetDuration returns the parameter value as a duration.
+//
+// Strings like "1h30m" or "250ms" are parsed, and integer values and strings with
+// an integer are converted using the value as milliseconds.
+func (p *Param) GetDuration() (time.Duration, error) {
+	switch v := p.value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		if d, err := format.StringToDuration(v); err == nil {
+			return d, nil
+		}
+	default:
+		if ms, err := p.GetInt64(); err == nil {
+			return time.Duration(ms) * time.Millisecond, nil
+		}
+	}
+	return 0, p.newConversionError("duration")
+}
+
+// As creates a copy of the parameter with the value converted to a different type.
+//
+// Unlike CopyWithType, the value is converted between compatible types, so for example
+// a string with a number can be converted to an integer. Values converted to string
+// must be valid for the parameter format when it is "date", "date-time", "uuid" or "duration".
+// Times are converted to strings using the framework format for "date-time" values.
+//
+// dataType: The type for the new parameter.
+func (p *Param) As(dataType string) (*Param, error) {
+	if !payload.IsValidType(dataType) {
+		return nil, fmt.Errorf(`Invalid parameter type: "%s"`, dataType)
+	}
+
+	var (
+		value interface{}
+		err   error
+	)
+
+	switch dataType {
+	case datatypes.Null:
+		value = nil
+	case datatypes.Integer:
+		var v int64
+		if v, err = p.GetInt64(); err == nil {
+			if v < int64(datatypes.MinInt) || v > int64(datatypes.MaxInt) {
+				err = p.newConversionError(dataType)
+			}
+			value = int(v)
+		}
+	case datatypes.Float:
+		value, err = p.GetFloat64()
+	case datatypes.Boolean:
+		value, err = p.GetBool()
+	case datatypes.String:
+		value, err = p.getString()
+	case datatypes.Binary:
+		switch v := p.value.(type) {
+		case []byte:
+			value = v
+		case string:
+			value = []byte(v)
+		default:
+			err = p.newConversionError(dataType)
+		}
+	default:
+		// Arrays and objects can't be converted from other types
+		if datatypes.ResolveType(p.value) != dataType {
+			err = p.newConversionError(dataType)
+		}
+		value = p.value
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &Param{p.name, value, dataType, p.exists, p.format}, nil
+}
+
+// Get the parameter value as a string that is valid for the parameter format.
+func (p *Param) getString() (string, error) {
+	var value string
+	switch v := p.value.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	case bool:
+		value = strconv.FormatBool(v)
+	case time.Time:
+		if p.format == ParamFormatDate {
+			value = v.Format(paramDateLayout)
+		} else {
+			value = format.TimeToString(v)
+		}
+	case time.Duration:
+		value = format.DurationToString(v)
+	case float32, float64:
+		f, _ := p.GetFloat64()
+		value = strconv.FormatFloat(f, 'f', -1, 64)
+	default:
+		i, err := p.GetInt64()
+		if err != nil {
+			return "", p.newConversionError(datatypes.String)
+		}
+		value = strconv.FormatInt(i, 10)
+	}
+
+	// Check that the value is valid for the parameter format
+	switch p.format {
+	case ParamFormatDate, ParamFormatDateTime:
+		if _, err := (&Param{p.name, value, datatypes.String, p.exists, p.format}).GetTime(); err != nil {
+			return "", fmt.Errorf(`Param "%s" value is not a valid %s: "%s"`, p.name, p.format, value)
+		}
+	case ParamFormatUUID:
+		if !reUUID.MatchString(value) {
+			return "", fmt.Errorf(`Param "%s" value is not a valid %s: "%s"`, p.name, p.format, value)
+		}
+	case ParamFormatDuration:
+		if _, err := format.StringToDuration(value); err != nil {
+			return "", fmt.Errorf(`Param "%s" value is not a valid %s: "%s"`, p.name, p.format, value)
+		}
+	}
+	return value, nil
+}
+
 // CopyWithName creates a copy of the parameter with a different name.
 //
 // name: Name of the new parameter.
 func (p *Param) CopyWithName(name string) *Param {
-	return &Param{name, p.GetValue(), p.GetType(), p.Exists()}
+	return &Param{name, p.GetValue(), p.GetType(), p.Exists(), p.GetFormat()}
 }
 
 // CopyWithValue creates a copy of the parameter with a different value.
 //
 // value: Value for the new parameter.
 func (p *Param) CopyWithValue(value interface{}) *Param {
-	return &Param{p.GetName(), value, p.GetType(), p.Exists()}
+	return &Param{p.GetName(), value, p.GetType(), p.Exists(), p.GetFormat()}
 }
 
 // CopyWithType creates a copy of the parameter with a different type.
@@ -139,7 +429,7 @@ func (p *Param) CopyWithType(valueType string) (*Param, error) {
 			)
 		}
 	}
-	return &Param{p.GetName(), value, valueType, p.Exists()}, nil
+	return &Param{p.GetName(), value, valueType, p.Exists(), p.GetFormat()}, nil
 }
 
 // Converts a param to a param payload.
@@ -155,12 +445,37 @@ func paramToPayload(p *Param) payload.Param {
 func payloadToParam(p payload.Param) *Param {
 	return &Param{
 		name:      p.Name,
-		value:     p.Value,
+		value:     datatypes.Normalize(decodeBinaryValue(p.Value, p.Type), p.Type),
 		valueType: p.Type,
 		exists:    true,
 	}
 }
 
+// Get the value of a binary parameter as bytes.
+//
+// Msgpack binary values are decoded as strings, so they are converted to bytes.
+func decodeBinaryValue(value interface{}, valueType string) interface{} {
+	if v, ok := value.(string); ok && valueType == datatypes.Binary {
+		return []byte(v)
+	}
+	return value
+}
+
+// Decode the binary values of parameters received in a JSON payload.
+//
+// Binary values are serialized as base64 strings when the payloads use JSON,
+// so they are decoded to get the original bytes. Strings that are not valid
+// base64 are used without changes.
+func decodeJSONBinaryParams(params []payload.Param) {
+	for i, p := range params {
+		if v, ok := p.Value.(string); ok && p.Type == datatypes.Binary {
+			if b, err := base64.StdEncoding.DecodeString(v); err == nil {
+				params[i].Value = b
+			}
+		}
+	}
+}
+
 // Converts a list params to a list of param payloads.
 func paramsToPayload(ps []*Param) (params []payload.Param) {
 	for _, p := range ps {
@@ -176,3 +491,14 @@ func payloadToParams(ps []payload.Param) (params []*Param) {
 	}
 	return params
 }
+
+// Index parameter payloads by name.
+//
+// Repeated parameters keep their values in the same order they have in the payload.
+func indexParams(params []payload.Param) map[string][]payload.Param {
+	index := make(map[string][]payload.Param)
+	for _, p := range params {
+		index[p.Name] = append(index[p.Name], p)
+	}
+	return index
+}
@@ -0,0 +1,232 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a parameter with a format for the parameter tests.
+func newFormatTestParam(value interface{}, valueType, format string) *Param {
+	return &Param{"test", value, valueType, true, format}
+}
+
+func TestParamGetInt64(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected int64
+		fails    bool
+	}{
+		{int64(42), 42, false},
+		{uint(42), 42, false},
+		{42.0, 42, false},
+		{"42", 42, false},
+		{42.5, 0, true},
+		{uint64(1 << 63), 0, true},
+		{"4x", 0, true},
+		{true, 0, true},
+	}
+
+	for _, c := range cases {
+		value, err := newFormatTestParam(c.value, payload.TypeString, "").GetInt64()
+		if (err != nil) != c.fails || value != c.expected {
+			t.Errorf("%v: expected %d, got %d %v", c.value, c.expected, value, err)
+		}
+	}
+}
+
+func TestParamGetFloat64AndBool(t *testing.T) {
+	if value, err := newFormatTestParam("1.5", payload.TypeString, "").GetFloat64(); err != nil || value != 1.5 {
+		t.Errorf("unexpected float value: %v %v", value, err)
+	}
+	if _, err := newFormatTestParam([]interface{}{}, payload.TypeArray, "").GetFloat64(); err == nil {
+		t.Error("expected an error for the array value")
+	}
+
+	cases := []struct {
+		value    interface{}
+		expected bool
+		fails    bool
+	}{
+		{true, true, false},
+		{"false", false, false},
+		{int64(1), true, false},
+		{0.0, false, false},
+		{int64(2), false, true},
+		{"yes", false, true},
+	}
+
+	for _, c := range cases {
+		value, err := newFormatTestParam(c.value, payload.TypeString, "").GetBool()
+		if (err != nil) != c.fails || value != c.expected {
+			t.Errorf("%v: expected %v, got %v %v", c.value, c.expected, value, err)
+		}
+	}
+}
+
+func TestParamGetTime(t *testing.T) {
+	expected := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value  string
+		format string
+		fails  bool
+	}{
+		{"2020-01-02", ParamFormatDate, false},
+		{"2020-01-02T00:00:00Z", ParamFormatDateTime, false},
+		{"2020-01-02", "", false},
+		{"2020-01-02T00:00:00Z", "", false},
+		{"2020-01-02", ParamFormatDateTime, true},
+		{"2020-01-02T00:00:00Z", ParamFormatDate, true},
+		{"yesterday", "", true},
+	}
+
+	for _, c := range cases {
+		value, err := newFormatTestParam(c.value, payload.TypeString, c.format).GetTime()
+		if (err != nil) != c.fails {
+			t.Errorf("%s (%s): unexpected error: %v", c.value, c.format, err)
+		} else if !c.fails && !value.Equal(expected) {
+			t.Errorf("%s (%s): expected %s, got %s", c.value, c.format, expected, value)
+		}
+	}
+}
+
+func TestParamAs(t *testing.T) {
+	cases := []struct {
+		param    *Param
+		dataType string
+		expected interface{}
+		fails    bool
+	}{
+		{newFormatTestParam("42", payload.TypeString, ""), payload.TypeInteger, 42, false},
+		{newFormatTestParam(int64(1), payload.TypeInteger, ""), payload.TypeBoolean, true, false},
+		{newFormatTestParam(1.5, payload.TypeFloat, ""), payload.TypeString, "1.5", false},
+		{newFormatTestParam(time.Date(2020, 1, 2, 3, 0, 0, 0, time.UTC), payload.TypeString, ParamFormatDate), payload.TypeString, "2020-01-02", false},
+		{newFormatTestParam("01234567-89ab-cdef-0123-456789abcdef", payload.TypeString, ParamFormatUUID), payload.TypeString, "01234567-89ab-cdef-0123-456789abcdef", false},
+		{newFormatTestParam("invalid", payload.TypeString, ParamFormatUUID), payload.TypeString, nil, true},
+		{newFormatTestParam("4.2", payload.TypeString, ""), payload.TypeInteger, nil, true},
+		{newFormatTestParam("value", payload.TypeString, ""), payload.TypeArray, nil, true},
+		{newFormatTestParam("value", payload.TypeString, ""), "invalid", nil, true},
+	}
+
+	for _, c := range cases {
+		p, err := c.param.As(c.dataType)
+		if (err != nil) != c.fails {
+			t.Errorf("%v as %s: unexpected error: %v", c.param.GetValue(), c.dataType, err)
+			continue
+		} else if c.fails {
+			continue
+		}
+
+		if p.GetValue() != c.expected || p.GetType() != c.dataType || p.GetFormat() != c.param.GetFormat() {
+			t.Errorf("%v as %s: expected %v, got %v (%s)", c.param.GetValue(), c.dataType, c.expected, p.GetValue(), p.GetType())
+		}
+	}
+}
+
+func TestActionGetParamArray(t *testing.T) {
+	s := newTestState("users", "1.0.0", "list", nil)
+	s.command.Command.Arguments.Params = payload.ActionParams{
+		{Name: "tag", Value: "a", Type: payload.TypeString},
+		{Name: "id", Value: "1", Type: payload.TypeString},
+		{Name: "tag", Value: "b", Type: payload.TypeString},
+	}
+	a := newAction(NewService(), s)
+
+	var values []interface{}
+	for _, p := range a.GetParamArray("tag") {
+		values = append(values, p.GetValue())
+	}
+	if len(values) != 2 || values[0] != "a" || values[1] != "b" {
+		t.Errorf("expected the values in order, got %v", values)
+	}
+
+	// The last value is used for single params
+	if value := a.GetParam("tag").GetValue(); value != "b" {
+		t.Errorf("expected the last value, got %v", value)
+	}
+	if params := a.GetParamArray("missing"); len(params) != 0 {
+		t.Errorf("expected no params, got %v", params)
+	}
+}
+
+func TestParamBinary(t *testing.T) {
+	// Binary values can be given as strings
+	p, err := newParam("test", "data", payload.TypeBinary, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := p.GetBytes(); err != nil || !bytes.Equal(v, []byte("data")) {
+		t.Errorf("unexpected binary value: %v %v", v, err)
+	}
+
+	// Msgpack binary values are decoded as strings
+	p = payloadToParam(payload.Param{Name: "test", Value: "data", Type: payload.TypeBinary})
+	if v, ok := p.GetValue().([]byte); !ok || !bytes.Equal(v, []byte("data")) {
+		t.Errorf("expected a binary value, got %v", p.GetValue())
+	}
+
+	if _, err := newFormatTestParam(42, payload.TypeInteger, "").GetBytes(); err == nil {
+		t.Error("expected an error for the integer value")
+	}
+}
+
+func TestDecodeJSONBinaryParams(t *testing.T) {
+	params := []payload.Param{
+		{Name: "data", Value: "ZGF0YQ==", Type: payload.TypeBinary},
+		{Name: "invalid", Value: "not base64!", Type: payload.TypeBinary},
+		{Name: "text", Value: "ZGF0YQ==", Type: payload.TypeString},
+	}
+	decodeJSONBinaryParams(params)
+
+	if v, ok := params[0].Value.([]byte); !ok || !bytes.Equal(v, []byte("data")) {
+		t.Errorf("expected the decoded value, got %v", params[0].Value)
+	}
+	// Invalid base64 values and other types don't change
+	if params[1].Value != "not base64!" || params[2].Value != "ZGF0YQ==" {
+		t.Errorf("unexpected values: %v %v", params[1].Value, params[2].Value)
+	}
+}
+
+func TestParamSchemaValidateSize(t *testing.T) {
+	min, max := 2.0, 4.0
+	schema := ParamSchema{payload.ParamSchema{Name: "data", Type: payload.TypeBinary, Min: &min, Max: &max}}
+	exclusive := ParamSchema{payload.ParamSchema{
+		Name:         "data",
+		Type:         payload.TypeBinary,
+		Min:          &min,
+		Max:          &max,
+		ExclusiveMin: true,
+		ExclusiveMax: true,
+	}}
+
+	cases := []struct {
+		name   string
+		schema ParamSchema
+		value  string
+		valid  bool
+	}{
+		{"min", schema, "ab", true},
+		{"max", schema, "abcd", true},
+		{"below min", schema, "a", false},
+		{"above max", schema, "abcde", false},
+		{"exclusive min", exclusive, "ab", false},
+		{"exclusive max", exclusive, "abcd", false},
+		{"exclusive", exclusive, "abc", true},
+	}
+
+	for _, c := range cases {
+		if err := c.schema.ValidateSize([]byte(c.value)); (err == nil) != c.valid {
+			t.Errorf("%s: unexpected validation result: %v", c.name, err)
+		}
+	}
+}
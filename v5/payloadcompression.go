@@ -0,0 +1,72 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/codec"
+)
+
+// Flag used in the response flags frame when the reply payload is compressed.
+//
+// The flag is not defined by the framework protocol, so the compression is experimental
+// and it must only be enabled when the framework decompresses the flagged payloads.
+var compressedFlag = []byte("\x05")
+
+// Default minimum size in bytes of the reply payloads that are compressed.
+const defaultPayloadCompressionMinSize = 64 * 1024
+
+// Compression of the reply payloads.
+type payloadCompression struct {
+	compressor codec.Compressor
+	minSize    int
+}
+
+// Compress the payload of a response when it is larger than the minimum size.
+//
+// The compressed flag is added to the flags frame so the framework knows that the payload
+// must be decompressed. The response is not changed when the compressed payload is not smaller,
+// and when the compression fails the error is returned with the uncompressed response.
+func (p *payloadCompression) apply(response responseMsg) (responseMsg, error) {
+	if len(response) < 2 || len(response[1]) < p.minSize {
+		return response, nil
+	}
+
+	data, err := codec.Compress(p.compressor, response[1])
+	if err != nil {
+		return response, err
+	}
+
+	if len(data) >= len(response[1]) {
+		return response, nil
+	}
+
+	flags := compressedFlag
+	if !bytes.Equal(response[0], emptyFrame) {
+		flags = append(append([]byte{}, response[0]...), compressedFlag...)
+	}
+	return responseMsg{flags, data}, nil
+}
+
+func (c *component) SetPayloadCompression(compressor codec.Compressor, minSize int) Component {
+	if compressor == nil {
+		c.compression = nil
+		return c
+	}
+
+	if minSize <= 0 {
+		minSize = defaultPayloadCompressionMinSize
+	}
+
+	// The compressor is registered to be able to decompress the replies of the run-time calls
+	codec.RegisterCompressor(compressor)
+	c.compression = &payloadCompression{compressor, minSize}
+	return c
+}
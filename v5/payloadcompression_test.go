@@ -0,0 +1,98 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/codec"
+)
+
+// Compressor that always fails.
+type failingCompressor struct{}
+
+func (failingCompressor) Name() string {
+	return "failing"
+}
+
+func (failingCompressor) Compress([]byte) ([]byte, error) {
+	return nil, errors.New("compression failed")
+}
+
+func (failingCompressor) Decompress([]byte) ([]byte, error) {
+	return nil, errors.New("decompression failed")
+}
+
+func TestPayloadCompressionApply(t *testing.T) {
+	p := &payloadCompression{codec.Deflate{}, 100}
+	payload := bytes.Repeat([]byte("kusanagi"), 100)
+
+	// Small payloads are not compressed
+	response := responseMsg{emptyFrame, []byte("small")}
+	if result, err := p.apply(response); err != nil || !bytes.Equal(result[1], response[1]) {
+		t.Errorf("expected the small payload without changes, got %v %v", result, err)
+	}
+
+	result, err := p.apply(responseMsg{emptyFrame, payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(result[0], compressedFlag) {
+		t.Errorf("expected the compressed flag, got %v", result[0])
+	}
+
+	if decompressed, err := codec.Decompress(result[1]); err != nil || !bytes.Equal(decompressed, payload) {
+		t.Errorf("expected the compressed payload: %v", err)
+	}
+
+	// The compressed flag is added to the existing flags
+	result, err = p.apply(responseMsg{serviceCallFlag, payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := append(append([]byte{}, serviceCallFlag...), compressedFlag...); !bytes.Equal(result[0], expected) {
+		t.Errorf("expected the flags %v, got %v", expected, result[0])
+	}
+	if !bytes.Equal(serviceCallFlag, []byte("\x01")) {
+		t.Error("expected the existing flag to be unchanged")
+	}
+}
+
+func TestPayloadCompressionKeepsLargerPayloads(t *testing.T) {
+	p := &payloadCompression{codec.Deflate{}, 1}
+
+	// Random looking payloads are larger when they are compressed
+	payload := make([]byte, 256)
+	for i := range payload {
+		payload[i] = byte(i * 7919 % 251)
+	}
+
+	response := responseMsg{emptyFrame, payload}
+	if result, err := p.apply(response); err != nil || !bytes.Equal(result[0], emptyFrame) || !bytes.Equal(result[1], payload) {
+		t.Errorf("expected the response without changes, got %v", err)
+	}
+}
+
+func TestPayloadCompressionFailure(t *testing.T) {
+	p := &payloadCompression{failingCompressor{}, 1}
+
+	response := responseMsg{emptyFrame, []byte("payload")}
+	result, err := p.apply(response)
+	if err == nil {
+		t.Fatal("expected the compression error")
+	}
+
+	// The uncompressed response is returned with the error
+	if len(result) != 2 || !bytes.Equal(result[0], emptyFrame) || !bytes.Equal(result[1], response[1]) {
+		t.Errorf("expected the uncompressed response, got %v", result)
+	}
+}
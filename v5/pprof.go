@@ -0,0 +1,84 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+)
+
+// PprofVariable is the name of the component variable that enables the profiling endpoints.
+//
+// The endpoints are only served when the variable is true and the "pprof-port" CLI option
+// is given. They listen on the loopback interface, so they are not reachable from other hosts.
+const PprofVariable = "pprof"
+
+// Creates a new server for the profiling endpoints.
+//
+// The result is nil when the profiling endpoints are not enabled.
+func newPprofServer(input cli.Input, stats *serverStats) *http.Server {
+	port := input.GetPprofPort()
+	if port == 0 {
+		return nil
+	} else if enabled, _ := strconv.ParseBool(input.GetVariable(PprofVariable)); !enabled {
+		log.Warningf(`Profiling endpoints are disabled, the "%s" variable must be true`, PprofVariable)
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		writeRuntimeStats(w, stats.snapshot())
+	})
+
+	return &http.Server{Addr: fmt.Sprintf("127.0.0.1:%d", port), Handler: mux}
+}
+
+// Write the component statistics as JSON.
+func writeRuntimeStats(w http.ResponseWriter, stats *RuntimeStats) {
+	memory := stats.GetMemStats()
+	data, err := json.Marshal(map[string]interface{}{
+		"goroutines": stats.GetGoroutines(),
+		"queued":     stats.GetQueueDepth(),
+		"in_flight":  stats.GetInFlight(),
+		"processed":  stats.GetProcessed(),
+		"failed":     stats.GetFailed(),
+		"uptime":     stats.GetUptime().String(),
+		"heap_alloc": memory.HeapAlloc,
+		"heap_inuse": memory.HeapInuse,
+		"num_gc":     memory.NumGC,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// Start serving the profiling endpoints.
+func startPprofServer(server *http.Server) {
+	log.Warningf(`Serving profiling endpoints at address: "%s"`, server.Addr)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("Profiling endpoints server failed: %v", err)
+		}
+	}()
+}
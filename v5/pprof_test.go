@@ -0,0 +1,74 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+func TestPprofServerIsOptIn(t *testing.T) {
+	if server := newPprofServer(cli.Input{}, newServerStats()); server != nil {
+		t.Error("expected no server without port")
+	}
+
+	if err := flag.Set("pprof-port", "6060"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		flag.Set("pprof-port", "0")
+	})
+
+	// The variable must be enabled to serve the endpoints
+	if server := newPprofServer(cli.Input{}, newServerStats()); server != nil {
+		t.Error("expected no server without the variable")
+	}
+
+	setTestVariable(t, PprofVariable, "true")
+	server := newPprofServer(cli.Input{}, newServerStats())
+	if server == nil {
+		t.Fatal("expected the profiling server")
+	}
+	if server.Addr != "127.0.0.1:6060" {
+		t.Errorf("expected the loopback address, got %s", server.Addr)
+	}
+}
+
+func TestPprofRuntimeStatsEndpoint(t *testing.T) {
+	if err := flag.Set("pprof-port", "6060"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		flag.Set("pprof-port", "0")
+	})
+	setTestVariable(t, PprofVariable, "true")
+
+	stats := newServerStats()
+	stats.done(true)
+	server := newPprofServer(cli.Input{}, stats)
+
+	w := httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/runtime", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &values); err != nil {
+		t.Fatal(err)
+	}
+	if values["processed"] != 1.0 || values["failed"] != 1.0 {
+		t.Errorf("unexpected statistics: %s", w.Body.String())
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"fmt"
 	"runtime/debug"
 
-	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
 )
 
@@ -77,6 +76,9 @@ func middlewareRequestProcessor(c Component, state *state, out chan<- requestOut
 		}
 	}()
 
+	// Resources are created for each request
+	defer state.closeResources()
+
 	var result interface{}
 
 	// Execute the userland callback
@@ -85,6 +87,9 @@ func middlewareRequestProcessor(c Component, state *state, out chan<- requestOut
 		result = executeRequestMiddleware(m, state)
 	} else {
 		result = executeResponseMiddleware(m, state)
+
+		// The request finishes with the response so the local values are not needed anymore
+		state.clearLocals()
 	}
 
 	var reply payload.Reply
@@ -93,12 +98,19 @@ func middlewareRequestProcessor(c Component, state *state, out chan<- requestOut
 	if _, ok := result.(*Request); ok {
 		reply = state.reply.ForRequest()
 	} else {
+		// Compress the response body when the client accepts it
+		if r, ok := result.(*Response); ok {
+			if err := compressResponse(r, getCompressionMinSize(state.input)); err != nil {
+				state.logger.Warningf("Failed to compress the response body: %v", err)
+			}
+		}
+
 		reply = state.reply.ForResponse()
 	}
 
 	// Serialize the payload
 	output := requestOutput{state: state}
-	message, err := msgpack.Encode(reply)
+	message, err := state.encode(reply)
 	if err != nil {
 		output.err = fmt.Errorf("Failed to serialize the response: %v", err)
 	} else {
@@ -123,26 +135,79 @@ func serviceRequestProcessor(c Component, state *state, out chan<- requestOutput
 
 	// Execute the userland callback
 	service := c.(*Service)
-	callback := service.callbacks[state.action].(ActionCallback)
+	resolved, _ := service.getCallback(state.action)
+	callback := resolved.(ActionCallback)
+
+	// Services use the whole transport so the sections that are decoded on use are decoded now
+	if t := state.command.GetTransport(); t != nil {
+		if err := t.Load(); err != nil {
+			out <- requestOutput{state: state, err: err}
+			return
+		}
+	}
+
 	state.reply = payload.NewActionReply(&state.command)
 
-	action, err := callback(newAction(service, state))
-	if action == nil {
-		panic(fmt.Sprintf("callback returned a nil action: %s", state.action))
-	} else if err != nil {
-		state.logger.Errorf("Callback error: %v", err)
+	action := newAction(service, state)
+
+	// Local values are only shared by the hooks and callback of the action
+	defer state.clearLocals()
+	defer state.closeResources()
+
+	// Clean the parameter values before they are validated
+	action.sanitizeParams(service)
+
+	// Check the deprecation and the strict params before calling the action.
+	// The parameters with custom formats are validated after the before hooks.
+	if err := checkDeprecation(action); err != nil {
+		state.logger.Errorf("Deprecation error: %v", err)
+
+		action.ErrorFrom(err)
+	} else if err := checkStrictParams(action); err != nil {
+		state.logger.Errorf("Validation error: %v", err)
+
+		action.ErrorFrom(err)
+	} else {
+		action, err = service.execute(action, callback)
+		if err != nil {
+			state.logger.Errorf("Callback error: %v", err)
 
-		// Call the userland error handler
-		service.events.error(err)
+			// Call the userland error handler
+			service.events.error(err)
 
-		// Add the error to the action to it is saved in the transport
-		action.Error(err.Error(), 0, "500 Internal Server Error")
+			// Add the error to the action to it is saved in the transport
+			action.ErrorFrom(err)
+		}
 	}
 
 	var flags []byte
 
 	// Inspect the transport to set the flags for the response
 	if t := state.reply.GetTransport(); t != nil {
+		// Make sure deferred calls registered with the same key run only once
+		t.DedupeCalls()
+
+		// The calls are final so the deferred call files can be added
+		t.RegisterDeferredFiles()
+
+		// Keep the warnings out of the errors so the request doesn't fail
+		if !isFailOnWarnings(state.input) {
+			t.SeparateWarnings()
+		}
+
+		if isErrorDedupe(state.input) {
+			t.DedupeErrors()
+		}
+
+		if max := getIntVariable(state.input, MaxErrorsVariable, 0); max > 0 {
+			t.LimitErrors(max)
+		}
+
+		// Remove the sensitive values before the transport leaves the service
+		if service.redaction != nil {
+			service.redaction.apply(t)
+		}
+
 		if t.HasCalls(action.GetName(), action.GetVersion()) {
 			flags = append(flags, serviceCallFlag...)
 		}
@@ -167,7 +232,7 @@ func serviceRequestProcessor(c Component, state *state, out chan<- requestOutput
 	output := requestOutput{state: state}
 
 	// Serialize the payload
-	message, err := msgpack.Encode(state.reply)
+	message, err := state.encode(state.reply)
 	if err != nil {
 		output.err = fmt.Errorf("Failed to serialize the response: %v", err)
 	} else {
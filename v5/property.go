@@ -0,0 +1,46 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"strings"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// PropertyName returns the name of a property in a namespace.
+//
+// Namespaced properties are merged per key when the transports of the run-time
+// calls are merged, so existing properties are kept and the missing properties
+// of the namespace are added to the transport.
+//
+// namespace: The property namespace.
+// name: The property name.
+func PropertyName(namespace, name string) string {
+	return namespace + payload.PropertyNamespaceSeparator + name
+}
+
+// Get the prefix of the property names in a namespace.
+func getPropertyNamespacePrefix(namespace string) string {
+	return namespace + payload.PropertyNamespaceSeparator
+}
+
+// Get the property names and values that belong to a namespace.
+//
+// The names in the result don't include the namespace.
+func getNamespaceProperties(properties map[string]string, namespace string) map[string]string {
+	prefix := getPropertyNamespacePrefix(namespace)
+	values := make(map[string]string)
+	for name, value := range properties {
+		if strings.HasPrefix(name, prefix) {
+			values[name[len(prefix):]] = value
+		}
+	}
+	return values
+}
@@ -0,0 +1,77 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestActionTypedProperties(t *testing.T) {
+	a := newTestAction("users", "1.0.0", "read", nil)
+	a.SetPropertyInt("count", 42)
+	a.SetPropertyBool("active", true)
+	if _, err := a.SetPropertyJSON("user", map[string]interface{}{"name": "jane"}); err != nil {
+		t.Fatal(err)
+	}
+	a.SetProperty("invalid", "yes")
+
+	transport := Transport{a.reply.Command.Result.Transport}
+	if value, err := transport.GetPropertyInt("count", 0); err != nil || value != 42 {
+		t.Errorf("expected the integer property, got %v %v", value, err)
+	}
+	if value, err := transport.GetPropertyBool("active", false); err != nil || !value {
+		t.Errorf("expected the boolean property, got %v %v", value, err)
+	}
+
+	var user map[string]interface{}
+	if err := transport.GetPropertyJSON("user", &user); err != nil || user["name"] != "jane" {
+		t.Errorf("expected the JSON property, got %v %v", user, err)
+	}
+
+	// Missing properties return the default values
+	if value, err := transport.GetPropertyInt("missing", 7); err != nil || value != 7 {
+		t.Errorf("expected the default integer, got %v %v", value, err)
+	}
+	if !transport.HasProperty("count") || transport.HasProperty("missing") {
+		t.Error("unexpected property checks")
+	}
+
+	// Invalid values return the default values with an error
+	if value, err := transport.GetPropertyInt("invalid", 7); err == nil || value != 7 {
+		t.Errorf("expected an error for an invalid integer, got %v %v", value, err)
+	}
+	if value, err := transport.GetPropertyBool("invalid", true); err == nil || !value {
+		t.Errorf("expected an error for an invalid boolean, got %v %v", value, err)
+	}
+	if err := transport.GetPropertyJSON("invalid", &user); err == nil {
+		t.Error("expected an error for an invalid JSON value")
+	}
+}
+
+func TestNamespaceProperties(t *testing.T) {
+	if name := PropertyName("cache", "ttl"); name != "cache::ttl" {
+		t.Errorf("unexpected property name: %s", name)
+	}
+
+	a := newTestAction("users", "1.0.0", "read", nil)
+	a.SetProperty(PropertyName("cache", "ttl"), "60")
+	a.SetProperty(PropertyName("cache", "tags"), "users")
+	a.SetProperty(PropertyName("cached", "ttl"), "10")
+	a.SetProperty("ttl", "5")
+
+	expected := map[string]string{"ttl": "60", "tags": "users"}
+	transport := Transport{a.reply.Command.Result.Transport}
+	if properties := transport.GetNamespaceProperties("cache"); !reflect.DeepEqual(properties, expected) {
+		t.Errorf("expected %v, got %v", expected, properties)
+	}
+	if properties := transport.GetNamespaceProperties("missing"); len(properties) != 0 {
+		t.Errorf("expected no properties, got %v", properties)
+	}
+}
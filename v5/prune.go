@@ -0,0 +1,43 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+// TransportFilter decides if the values of a service are kept in the transport.
+//
+// The address is the public address of the gateway where the service values were
+// registered. The result is false when the values of the service must be removed.
+type TransportFilter func(address, service string) bool
+
+// Prune returns a copy of the transport without the values of the services that don't match a filter.
+//
+// The data, links, relations, files, errors and warnings of the services are removed.
+// Relations are also removed when the related service doesn't match the filter.
+//
+// filter: The filter for the services to keep.
+func (t Transport) Prune(filter TransportFilter) *Transport {
+	transport := t.payload.Clone()
+	transport.Prune(func(address, service string) bool {
+		return filter(address, service)
+	})
+	return &Transport{transport}
+}
+
+// PruneTransport removes from the transport the values of the services that don't match a filter.
+//
+// The pruned transport is sent to the framework in the middleware reply, so the values
+// are not included in the response. Calling GetTransport after the transport is pruned
+// returns the pruned transport.
+//
+// filter: The filter for the services to keep.
+func (r *Response) PruneTransport(filter TransportFilter) *Response {
+	if transport := r.GetTransport(); transport != nil {
+		r.reply.Command.Result.Transport = transport.Prune(filter).payload
+	}
+	return r
+}
@@ -0,0 +1,113 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Unit of the HTTP ranges supported for downloads.
+const rangeUnit = "bytes"
+
+// Parse the value of an HTTP "Range" header for contents of a given size.
+//
+// Only requests with a single range are supported, and the result is false for
+// any other range so the full contents are sent. An error is returned when the
+// range can't be satisfied.
+func parseByteRange(header string, size int64) (start, end int64, ok bool, err error) {
+	spec := strings.TrimSpace(header)
+	if !strings.HasPrefix(spec, rangeUnit+"=") {
+		return 0, 0, false, nil
+	}
+
+	spec = strings.TrimSpace(strings.TrimPrefix(spec, rangeUnit+"="))
+	if strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, false, nil
+	}
+
+	first, last := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if first == "" {
+		// Suffix range with the last N bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false, fmt.Errorf(`Invalid range: "%s"`, header)
+		} else if n > size {
+			n = size
+		}
+		return size - n, size - 1, size > 0, nil
+	}
+
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, fmt.Errorf(`Invalid range: "%s"`, header)
+	} else if start >= size {
+		return 0, 0, false, fmt.Errorf(`Range not satisfiable: "%s"`, header)
+	}
+
+	end = size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false, fmt.Errorf(`Invalid range: "%s"`, header)
+		} else if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, true, nil
+}
+
+// SetDownloadBody sets the contents of a download as the HTTP response body, honoring the request range.
+//
+// When the file supports ranged downloads the "Accept-Ranges" and "ETag" headers are set, and
+// a request with a single range in the "Range" header gets a "206 Partial Content" response
+// with the requested part of the contents. Ranges that can't be satisfied get a "416 Range Not
+// Satisfiable" response. The range is ignored when the "If-Range" header doesn't match the
+// file entity tag, and the full contents are sent.
+//
+// request: The HTTP request.
+// file: The download file, for example the result of Transport.GetDownload().
+// content: The full contents of the file.
+func (r *HTTPResponse) SetDownloadBody(request *HTTPRequest, file File, content []byte) *HTTPResponse {
+	if !file.AcceptsRanges() {
+		return r.SetBody(content)
+	}
+
+	r.SetHeader("Accept-Ranges", rangeUnit, true)
+	if etag := file.GetETag(); etag != "" {
+		r.SetHeader("ETag", etag, true)
+	}
+
+	header := request.GetHeader("Range", "")
+	if header == "" {
+		return r.SetBody(content)
+	} else if ifRange := request.GetHeader("If-Range", ""); ifRange != "" && ifRange != file.GetETag() {
+		return r.SetBody(content)
+	}
+
+	size := int64(len(content))
+	start, end, ok, err := parseByteRange(header, size)
+	if err != nil {
+		r.SetStatus(416, "Range Not Satisfiable")
+		r.SetHeader("Content-Range", fmt.Sprintf("%s */%d", rangeUnit, size), true)
+		return r.SetBody([]byte{})
+	} else if !ok {
+		return r.SetBody(content)
+	}
+
+	r.SetStatus(206, "Partial Content")
+	r.SetHeader("Content-Range", fmt.Sprintf("%s %d-%d/%d", rangeUnit, start, end, size), true)
+	r.SetHeader("Content-Length", strconv.FormatInt(end-start+1, 10), true)
+	return r.SetBody(content[start : end+1])
+}
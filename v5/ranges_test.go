@@ -0,0 +1,112 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestParseByteRange(t *testing.T) {
+	cases := []struct {
+		header string
+		start  int64
+		end    int64
+		ok     bool
+		fails  bool
+	}{
+		{"bytes=0-4", 0, 4, true, false},
+		{"bytes=5-", 5, 9, true, false},
+		{"bytes=5-100", 5, 9, true, false},
+		{"bytes=-3", 7, 9, true, false},
+		{"bytes=-100", 0, 9, true, false},
+		{" bytes= 2-3 ", 2, 3, true, false},
+		// Unsupported ranges send the full contents
+		{"items=0-4", 0, 0, false, false},
+		{"bytes=0-1,4-5", 0, 0, false, false},
+		{"bytes=5", 0, 0, false, false},
+		// Invalid ranges
+		{"bytes=10-", 0, 0, false, true},
+		{"bytes=4-2", 0, 0, false, true},
+		{"bytes=a-2", 0, 0, false, true},
+		{"bytes=-0", 0, 0, false, true},
+	}
+
+	for _, c := range cases {
+		start, end, ok, err := parseByteRange(c.header, 10)
+		if (err != nil) != c.fails {
+			t.Errorf("%q: unexpected error: %v", c.header, err)
+			continue
+		}
+
+		if ok != c.ok || start != c.start || end != c.end {
+			t.Errorf("%q: expected %d-%d %v, got %d-%d %v", c.header, c.start, c.end, c.ok, start, end, ok)
+		}
+	}
+}
+
+func TestHTTPResponseSetDownloadBody(t *testing.T) {
+	content := []byte("0123456789")
+	file, err := NewFile("download", "http://127.0.0.1:8000/files/a", "text/plain", "a.txt", 10, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rangedFile := file.WithRangeSupport(`"v1"`)
+
+	cases := []struct {
+		name    string
+		file    *File
+		headers http.Header
+		status  int
+		body    string
+		rng     string
+	}{
+		{"no range support", file, http.Header{"Range": {"bytes=0-4"}}, 200, "0123456789", ""},
+		{"no range", rangedFile, http.Header{}, 200, "0123456789", ""},
+		{"range", rangedFile, http.Header{"Range": {"bytes=2-4"}}, 206, "234", "bytes 2-4/10"},
+		{"matching tag", rangedFile, http.Header{"Range": {"bytes=-2"}, "If-Range": {`"v1"`}}, 206, "89", "bytes 8-9/10"},
+		{"changed tag", rangedFile, http.Header{"Range": {"bytes=2-4"}, "If-Range": {`"v2"`}}, 200, "0123456789", ""},
+		{"multiple ranges", rangedFile, http.Header{"Range": {"bytes=0-1,4-5"}}, 200, "0123456789", ""},
+		{"not satisfiable", rangedFile, http.Header{"Range": {"bytes=20-"}}, 416, "", "bytes */10"},
+	}
+
+	for _, c := range cases {
+		request := newHTTPRequest(&payload.HTTPRequest{Method: "GET", Headers: c.headers})
+		r := newHTTPResponse(&payload.HTTPResponse{Status: "200 OK", Headers: http.Header{}})
+		r.SetDownloadBody(request, *c.file, content)
+
+		if r.GetStatusCode() != c.status || string(r.GetBody()) != c.body {
+			t.Errorf("%s: expected %d %q, got %d %q", c.name, c.status, c.body, r.GetStatusCode(), r.GetBody())
+		}
+		if value := r.GetHeader("Content-Range", ""); value != c.rng {
+			t.Errorf("%s: expected the content range %q, got %q", c.name, c.rng, value)
+		}
+		if c.file.AcceptsRanges() && (r.GetHeader("Accept-Ranges", "") != "bytes" || r.GetHeader("ETag", "") != `"v1"`) {
+			t.Errorf("%s: expected the range headers, got %v", c.name, r.GetHeaders())
+		}
+	}
+}
+
+func TestFileRangeSupportPayload(t *testing.T) {
+	file, err := NewFile("download", "http://127.0.0.1:8000/files/a", "text/plain", "a.txt", 10, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := fileToPayload(*file.WithRangeSupport(`"v1"`))
+	if !p.Ranges || p.ETag != `"v1"` {
+		t.Errorf("expected the range support in the payload, got %+v", p)
+	}
+
+	if f := payloadToFile(&p); !f.AcceptsRanges() || f.GetETag() != `"v1"` {
+		t.Error("expected the range support from the payload")
+	}
+}
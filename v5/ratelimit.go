@@ -0,0 +1,118 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+)
+
+// RateLimitVariable is the name of the component variable that sets the maximum
+// number of requests per second that the component accepts.
+const RateLimitVariable = "rate-limit"
+
+// RateLimitBurstVariable is the name of the component variable that sets the maximum
+// number of requests that the component accepts in a burst.
+const RateLimitBurstVariable = "rate-limit-burst"
+
+// Rate limiter that uses a token bucket to limit the number of requests.
+//
+// The limiter is disabled when the rate is zero.
+type rateLimiter struct {
+	mutex    sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	last     time.Time
+	rejected uint64
+}
+
+// Creates a new rate limiter configured with the component variables.
+func newRateLimiter(input cli.Input) *rateLimiter {
+	l := rateLimiter{}
+	l.configure(input)
+	return &l
+}
+
+// Configure the limiter using the values of the component variables.
+func (l *rateLimiter) configure(input cli.Input) {
+	var rate, burst float64
+
+	if v := input.GetVariable(RateLimitVariable); v != "" {
+		if r, err := strconv.ParseFloat(v, 64); err == nil && r > 0 {
+			rate = r
+		} else {
+			log.Warningf(`Invalid value for variable "%s", rate limit disabled: "%s"`, RateLimitVariable, v)
+		}
+	}
+
+	// By default allow a burst of one second of requests
+	burst = rate
+	if v := input.GetVariable(RateLimitBurstVariable); v != "" && rate > 0 {
+		if b, err := strconv.ParseFloat(v, 64); err == nil && b >= 1 {
+			burst = b
+		} else {
+			log.Warningf(`Invalid value for variable "%s", using rate as burst: "%s"`, RateLimitBurstVariable, v)
+		}
+	}
+
+	if burst < 1 && rate > 0 {
+		burst = 1
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.rate = rate
+	l.burst = burst
+	l.tokens = burst
+	l.last = time.Now()
+
+	if rate > 0 {
+		log.Debugf("Rate limit enabled: %v requests per second with a burst of %v", rate, burst)
+	}
+}
+
+// Check if a request is allowed and consume a token when it is.
+func (l *rateLimiter) allow() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.rate == 0 {
+		return true
+	}
+
+	// Refill the bucket with the tokens for the elapsed time
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		l.rejected++
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// Get the number of requests rejected by the limiter.
+func (l *rateLimiter) getRejected() uint64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.rejected
+}
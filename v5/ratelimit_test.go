@@ -0,0 +1,77 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+func TestRateLimiterIsDisabledByDefault(t *testing.T) {
+	l := newRateLimiter(cli.Input{})
+	for i := 0; i < 100; i++ {
+		if !l.allow() {
+			t.Fatal("expected the requests to be allowed")
+		}
+	}
+}
+
+func TestRateLimiterConfigure(t *testing.T) {
+	cases := []struct {
+		rate  string
+		burst string
+		// Expected values
+		expectedRate  float64
+		expectedBurst float64
+	}{
+		{"10", "", 10, 10},
+		{"10", "25", 10, 25},
+		{"0.5", "", 0.5, 1},
+		{"10", "0.5", 10, 10},
+		{"10", "invalid", 10, 10},
+		{"-1", "5", 0, 0},
+		{"invalid", "", 0, 0},
+	}
+
+	for _, c := range cases {
+		setTestVariable(t, RateLimitVariable, c.rate)
+		setTestVariable(t, RateLimitBurstVariable, c.burst)
+
+		l := newRateLimiter(cli.Input{})
+		if l.rate != c.expectedRate || l.burst != c.expectedBurst {
+			t.Errorf("%s/%s: expected %v/%v, got %v/%v", c.rate, c.burst, c.expectedRate, c.expectedBurst, l.rate, l.burst)
+		}
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := &rateLimiter{rate: 10, burst: 2, tokens: 2, last: time.Now()}
+
+	// The burst is allowed and the next requests are rejected until the bucket is refilled
+	if !l.allow() || !l.allow() {
+		t.Fatal("expected the burst to be allowed")
+	}
+	if l.allow() || l.allow() {
+		t.Fatal("expected the requests over the burst to be rejected")
+	}
+	if rejected := l.getRejected(); rejected != 2 {
+		t.Errorf("expected 2 rejected requests, got %d", rejected)
+	}
+
+	// The tokens are refilled with the elapsed time, up to the burst
+	l.last = l.last.Add(-time.Hour)
+	if !l.allow() {
+		t.Fatal("expected the request to be allowed after the refill")
+	}
+	if l.tokens != 1 {
+		t.Errorf("expected the tokens to be limited by the burst, got %v", l.tokens)
+	}
+}
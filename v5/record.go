@@ -0,0 +1,265 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+)
+
+// File extensions of the recorded requests.
+const (
+	recordMsgpackExt = ".msgpack"
+	recordJSONExt    = ".json"
+)
+
+// Time to wait for the replayed request in addition to the process execution timeout.
+const replayTimeoutMargin = time.Second
+
+// Request recorded to be replayed.
+//
+// The msgpack recordings contain the original frames of the request, so they can be replayed
+// exactly as they were received. The JSON recordings contain the decoded schemas and command
+// payload to make them readable, and they are replayed using the JSON serialization format.
+type recordedRequest struct {
+	RequestID string          `json:"request_id"`
+	Action    string          `json:"action"`
+	Format    string          `json:"format"`
+	Schemas   json.RawMessage `json:"schemas,omitempty"`
+	Command   json.RawMessage `json:"command"`
+}
+
+// Creates a new request recorder.
+//
+// directory: The directory where the requests are saved.
+func newRecorder(directory string) *recorder {
+	return &recorder{directory}
+}
+
+// Recorder saves the incoming requests to files.
+//
+// Each request is saved in msgpack and JSON, using the time and the request ID as file name.
+type recorder struct {
+	directory string
+}
+
+// Save a request message.
+//
+// msg: The request message.
+// format: The serialization format of the request payloads.
+func (r *recorder) record(msg requestMsg, format wireFormat) error {
+	rr := recordedRequest{
+		RequestID: msg.getRequestID(),
+		Action:    msg.getAction(),
+		Format:    format.String(),
+	}
+
+	name := filepath.Join(r.directory, fmt.Sprintf("%d-%s", time.Now().UnixNano(), rr.RequestID))
+
+	// Save the original frames to be able to replay the request exactly as it was received
+	data, err := encodeRecordedRequest(msg, msg.getSchemas())
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(name+recordMsgpackExt, data, 0o644); err != nil {
+		return fmt.Errorf("Failed to save the recorded request: %v", err)
+	}
+
+	// Decode the payloads to save a readable version of the request
+	var command, schemas interface{}
+	if err := format.decode(msg.getPayload(), &command); err != nil {
+		return fmt.Errorf("Failed to read the recorded request payload: %v", err)
+	}
+	if v := msg.getSchemas(); v != nil {
+		if err := format.decode(v, &schemas); err != nil {
+			return fmt.Errorf("Failed to read the recorded request schemas: %v", err)
+		}
+	}
+
+	if rr.Command, err = json.Marshal(command); err != nil {
+		return fmt.Errorf("Failed to serialize the recorded request payload: %v", err)
+	}
+	if schemas != nil {
+		if rr.Schemas, err = json.Marshal(schemas); err != nil {
+			return fmt.Errorf("Failed to serialize the recorded request schemas: %v", err)
+		}
+	}
+
+	data, err = json.MarshalIndent(rr, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to serialize the recorded request: %v", err)
+	}
+
+	if err := os.WriteFile(name+recordJSONExt, data, 0o644); err != nil {
+		return fmt.Errorf("Failed to save the recorded request: %v", err)
+	}
+	return nil
+}
+
+// Serialize the frames of a request message as a msgpack recorded request.
+//
+// msg: The request message.
+// schemas: The serialized schemas to save with the request.
+func encodeRecordedRequest(msg requestMsg, schemas []byte) ([]byte, error) {
+	var formatFrame []byte
+	if len(msg) > msgFormatPart {
+		formatFrame = msg[msgFormatPart]
+	}
+
+	data, err := msgpack.Encode(map[string]interface{}{
+		"request_id": msg.getRequestID(),
+		"action":     msg.getAction(),
+		"schemas":    schemas,
+		"payload":    msg.getPayload(),
+		"format":     formatFrame,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to serialize the recorded request: %v", err)
+	}
+	return data, nil
+}
+
+// Read a recorded request and create the request message to replay it.
+func readRecordedRequest(path string) (requestMsg, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the recorded request: %v", err)
+	}
+
+	msg, err := parseRecordedRequest(data, strings.HasSuffix(path, recordJSONExt))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, path)
+	}
+	return msg, nil
+}
+
+// Create the request message for a recorded request.
+//
+// data: The contents of the recorded request.
+// isJSON: True when the request was recorded in JSON, otherwise msgpack is used.
+func parseRecordedRequest(data []byte, isJSON bool) (requestMsg, error) {
+	var rid, action string
+	var schemas, command, format []byte
+
+	if isJSON {
+		var rr recordedRequest
+		if err := json.Unmarshal(data, &rr); err != nil {
+			return nil, fmt.Errorf("Failed to parse the recorded request: %v", err)
+		}
+
+		rid, action, schemas, command, format = rr.RequestID, rr.Action, rr.Schemas, rr.Command, jsonFormatFlag
+	} else {
+		var rr map[string]interface{}
+		if err := msgpack.Decode(data, &rr); err != nil {
+			return nil, fmt.Errorf("Failed to parse the recorded request: %v", err)
+		}
+
+		// Binary values are decoded as strings
+		rid, _ = rr["request_id"].(string)
+		action, _ = rr["action"].(string)
+		if v, ok := rr["schemas"].(string); ok {
+			schemas = []byte(v)
+		}
+		if v, ok := rr["payload"].(string); ok {
+			command = []byte(v)
+		}
+		if v, ok := rr["format"].(string); ok && v != "" {
+			format = []byte(v)
+		}
+	}
+
+	if action == "" || len(command) == 0 {
+		return nil, errors.New("The recorded request is not valid")
+	}
+
+	msg := requestMsg{[]byte{}, []byte{}, []byte{}, []byte(rid), []byte(action), schemas, command}
+	if format != nil {
+		msg = append(msg, format)
+	}
+	return msg, nil
+}
+
+// Process a recorded request with the component callbacks and write the reply as JSON.
+//
+// The request is processed by the same listener that processes the requests
+// received by the server, but without opening any socket.
+func (s *server) replay(path string, out io.Writer) error {
+	msg, err := readRecordedRequest(path)
+	if err != nil {
+		return err
+	}
+
+	log.Infof(`Replaying recorded request "%s" for action: "%s"`, msg.getRequestID(), msg.getAction())
+
+	// Recorded requests don't keep the signatures
+	s.verifier = nil
+
+	reply, err := s.process(msg)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(reply)
+}
+
+// Process a single request message with the component callbacks and get the decoded reply.
+//
+// The request is processed by a new message listener that is stopped when the reply is received.
+func (s *server) process(msg requestMsg) (interface{}, error) {
+	msgc := make(chan requestMsg, 1)
+	defer close(msgc)
+
+	resc := s.startMessageListener(msgc)
+	msgc <- msg
+
+	timeout := s.timeout + replayTimeoutMargin
+
+	var output requestOutput
+	select {
+	case output = <-resc:
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("The request timed out after %s", timeout)
+	}
+
+	response := output.response
+	if output.err != nil {
+		var err error
+		if response, err = createErrorResponse(output.state.format, output.err); err != nil {
+			return nil, fmt.Errorf("Failed to create error response: %v", err)
+		}
+	}
+
+	message := response[len(response)-1]
+
+	// The exported reply must not contain the call parameters removed by the redaction policy
+	if policy := s.component.(*component).redaction; policy != nil && output.err == nil {
+		var err error
+		if message, err = redactExportedReply(policy, output.state); err != nil {
+			return nil, fmt.Errorf("Failed to redact the reply: %v", err)
+		}
+	}
+
+	var reply interface{}
+	if err := output.state.format.decode(message, &reply); err != nil {
+		return nil, fmt.Errorf("Failed to read the reply: %v", err)
+	}
+	return reply, nil
+}
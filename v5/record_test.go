@@ -0,0 +1,90 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	command, err := formatMsgpack.encode(map[string]interface{}{"c": map[string]interface{}{"n": "read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := t.TempDir()
+	msg := requestMsg{[]byte{}, []byte{}, []byte{}, []byte("rid"), []byte("read"), []byte{}, command}
+	if err := newRecorder(directory).record(msg, formatMsgpack); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(directory, "*-rid.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if len(files) != 2 || !strings.HasSuffix(files[0], recordJSONExt) || !strings.HasSuffix(files[1], recordMsgpackExt) {
+		t.Fatalf("expected the JSON and msgpack recordings, got %v", files)
+	}
+
+	// The msgpack recording contains the original payload
+	replayed, err := readRecordedRequest(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.getRequestID() != "rid" || replayed.getAction() != "read" || !bytes.Equal(replayed.getPayload(), command) {
+		t.Errorf("unexpected msgpack recording: %q", replayed)
+	}
+	if replayed.getFormat(formatMsgpack) != formatMsgpack {
+		t.Error("expected the msgpack format")
+	}
+
+	// The JSON recording is replayed using the JSON format
+	replayed, err = readRecordedRequest(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(replayed.getPayload(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if replayed.getAction() != "read" || payload["c"] == nil {
+		t.Errorf("unexpected JSON recording: %q", replayed)
+	}
+	if !replayed.getFormat(formatMsgpack).isJSON() {
+		t.Error("expected the JSON format")
+	}
+}
+
+func TestParseRecordedRequestInvalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		data   string
+		isJSON bool
+	}{
+		{"invalid JSON", "invalid", true},
+		{"missing command", `{"request_id": "rid", "action": "read"}`, true},
+		{"invalid msgpack", "\xc1", false},
+	}
+
+	for _, c := range cases {
+		if _, err := parseRecordedRequest([]byte(c.data), c.isJSON); err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+
+	if _, err := readRecordedRequest(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for the missing file")
+	}
+}
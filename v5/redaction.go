@@ -0,0 +1,264 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"strings"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// RedactedValue is the default value used to replace the redacted values.
+const RedactedValue = "[REDACTED]"
+
+// Separator for the redaction path names.
+const redactionPathSeparator = "."
+
+// Wildcard that matches any name in a redaction path.
+const redactionPathWildcard = "*"
+
+// RedactionCallback is called for each value that is checked by a redaction policy.
+//
+// The path contains the names of the object fields separated by ".", starting
+// from the root of the entity, the parameter or the error metadata. The result
+// is the new value and true when the value must be replaced.
+type RedactionCallback func(path string, value interface{}) (interface{}, bool)
+
+// NewRedactionPolicy creates a new redaction policy.
+func NewRedactionPolicy() *RedactionPolicy {
+	return &RedactionPolicy{fields: make(map[string]bool), replacement: RedactedValue}
+}
+
+// RedactionPolicy defines the values to redact from the transport before the reply is sent.
+//
+// The policy is applied to the transport data and the metadata of the errors and warnings.
+// The parameters of the run-time and deferred calls and the transactions are only redacted
+// in the copies of the reply that the component exports, like the replayed requests or the
+// debug console calls, because the framework needs the original values to run the calls.
+// Arrays don't add names to the paths, so for example the path "cards.number" matches the
+// "number" field of every element in the "cards" array.
+type RedactionPolicy struct {
+	fields      map[string]bool
+	paths       [][]string
+	callbacks   []RedactionCallback
+	replacement interface{}
+}
+
+// WithFields adds field names to redact at any depth.
+//
+// The names are case-insensitive, and they also match the parameter names.
+//
+// names: The field names.
+func (p *RedactionPolicy) WithFields(names ...string) *RedactionPolicy {
+	for _, name := range names {
+		p.fields[strings.ToLower(name)] = true
+	}
+	return p
+}
+
+// WithPaths adds field paths to redact.
+//
+// Paths are field names separated by ".", where "*" matches any name,
+// for example "user.password" or "accounts.*.iban".
+//
+// paths: The field paths.
+func (p *RedactionPolicy) WithPaths(paths ...string) *RedactionPolicy {
+	for _, path := range paths {
+		p.paths = append(p.paths, strings.Split(path, redactionPathSeparator))
+	}
+	return p
+}
+
+// WithCallback adds a callback to decide which values to redact.
+//
+// callback: The redaction callback.
+func (p *RedactionPolicy) WithCallback(callback RedactionCallback) *RedactionPolicy {
+	p.callbacks = append(p.callbacks, callback)
+	return p
+}
+
+// WithReplacement sets the value used to replace the redacted values.
+//
+// value: The replacement value.
+func (p *RedactionPolicy) WithReplacement(value interface{}) *RedactionPolicy {
+	p.replacement = value
+	return p
+}
+
+// Check if a path matches one of the policy paths.
+func (p *RedactionPolicy) matchPath(path []string) bool {
+next:
+	for _, pattern := range p.paths {
+		if len(pattern) != len(path) {
+			continue
+		}
+
+		for i, name := range pattern {
+			if name != redactionPathWildcard && name != path[i] {
+				continue next
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Get the redacted value for a field.
+//
+// The result is false when the value must not be redacted.
+func (p *RedactionPolicy) redactField(path []string, value interface{}) (interface{}, bool) {
+	if p.fields[strings.ToLower(path[len(path)-1])] || p.matchPath(path) {
+		return p.replacement, true
+	}
+
+	for _, callback := range p.callbacks {
+		if redacted, ok := callback(strings.Join(path, redactionPathSeparator), value); ok {
+			return redacted, true
+		}
+	}
+	return nil, false
+}
+
+// Redact a value.
+//
+// Objects and arrays are copied when they are redacted so the userland values are not modified.
+func (p *RedactionPolicy) redact(path []string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			// Copy the path to avoid sharing the backing array between fields
+			fieldPath := append(append([]string{}, path...), name)
+			if redacted, ok := p.redactField(fieldPath, item); ok {
+				result[name] = redacted
+			} else {
+				result[name] = p.redact(fieldPath, item)
+			}
+		}
+		return result
+	case []map[string]interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = p.redact(path, item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = p.redact(path, item)
+		}
+		return result
+	}
+	return value
+}
+
+// Redact the values of a list of parameters.
+func (p *RedactionPolicy) redactParams(params []payload.Param) []payload.Param {
+	if len(params) == 0 {
+		return params
+	}
+
+	result := make([]payload.Param, len(params))
+	for i, param := range params {
+		path := []string{param.Name}
+		if redacted, ok := p.redactField(path, param.Value); ok {
+			param.Value = redacted
+			param.Type = datatypes.ResolveType(redacted)
+		} else {
+			param.Value = p.redact(path, param.Value)
+		}
+		result[i] = param
+	}
+	return result
+}
+
+// Redact the metadata of the errors.
+func (p *RedactionPolicy) redactErrors(errors payload.Errors) {
+	for _, services := range errors {
+		for _, versions := range services {
+			for version, list := range versions {
+				result := make([]payload.Error, len(list))
+				for i, err := range list {
+					if err.Metadata != nil {
+						err.Metadata = p.redact(nil, err.Metadata).(map[string]interface{})
+					}
+					result[i] = err
+				}
+				versions[version] = result
+			}
+		}
+	}
+}
+
+// Apply the policy to the transport of a reply.
+//
+// The parameters of the calls and the transactions are kept, because the framework uses them.
+func (p *RedactionPolicy) apply(t *payload.Transport) {
+	// The data is changed in place
+	t.Unshare()
+
+	for _, services := range t.Data {
+		for _, versions := range services {
+			for _, actions := range versions {
+				for action, values := range actions {
+					actions[action] = p.redact(nil, values).([]interface{})
+				}
+			}
+		}
+	}
+
+	p.redactErrors(t.Errors)
+	p.redactErrors(t.Warnings)
+}
+
+// Apply the policy to the parameters of the calls and the transactions of a transport.
+//
+// It must only be used with the copies of the transports that are exported by the component.
+func (p *RedactionPolicy) applyToCalls(t *payload.Transport) {
+	t.Unshare()
+
+	for _, services := range t.Calls {
+		for version, calls := range services {
+			result := make([]payload.Call, len(calls))
+			for i, call := range calls {
+				call.Params = p.redactParams(call.Params)
+				result[i] = call
+			}
+			services[version] = result
+		}
+	}
+
+	for kind, transactions := range t.Transactions {
+		result := make([]payload.Transaction, len(transactions))
+		for i, transaction := range transactions {
+			transaction.Params = p.redactParams(transaction.Params)
+			result[i] = transaction
+		}
+		t.Transactions[kind] = result
+	}
+}
+
+// Serialize a copy of the reply of a request with the call and transaction parameters redacted.
+func redactExportedReply(policy *RedactionPolicy, state *state) ([]byte, error) {
+	reply, err := state.reply.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := reply.GetTransport(); t != nil {
+		policy.applyToCalls(t)
+	}
+	return state.format.encode(reply)
+}
+
+func (c *component) SetRedactionPolicy(policy *RedactionPolicy) Component {
+	c.redaction = policy
+	return c
+}
@@ -0,0 +1,106 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a transport with sensitive values in the data and in the call parameters.
+func newRedactionTestTransport() *payload.Transport {
+	t := &payload.Transport{Meta: payload.TransportMeta{Gateway: []string{"ktp://internal", "http://public"}}}
+	t.SetData("users", "1.0.0", "read", map[string]interface{}{"name": "jane", "password": "secret"})
+	t.Calls = payload.Calls{"users": {"1.0.0": []payload.Call{
+		{Name: "auth", Version: "1.0.0", Action: "login", Params: []payload.Param{{Name: "password", Value: "secret", Type: payload.TypeString}}},
+	}}}
+	return t
+}
+
+func TestRedactionPolicyKeepsCallParams(t *testing.T) {
+	policy := NewRedactionPolicy().WithFields("password")
+
+	transport := newRedactionTestTransport()
+	policy.apply(transport)
+
+	data := transport.Data["http://public"]["users"]["1.0.0"]["read"][0].(map[string]interface{})
+	if data["password"] != RedactedValue || data["name"] != "jane" {
+		t.Errorf("expected the password to be redacted from the data, got %v", data)
+	}
+
+	// The framework uses the call parameters, so they are sent unchanged
+	params := transport.Calls["users"]["1.0.0"][0].Params
+	if params[0].Value != "secret" {
+		t.Errorf("expected the call parameter to be kept, got %v", params[0].Value)
+	}
+
+	policy.applyToCalls(transport)
+	if params := transport.Calls["users"]["1.0.0"][0].Params; params[0].Value != RedactedValue {
+		t.Errorf("expected the call parameter to be redacted, got %v", params[0].Value)
+	}
+}
+
+func TestServerProcessRedactsExportedReply(t *testing.T) {
+	service := NewService()
+	service.SetRedactionPolicy(NewRedactionPolicy().WithFields("password"))
+	service.SetSchemaPolicy(SchemaPolicyCLIOnlyPermissive)
+
+	var sent *payload.Transport
+	service.Action("read", func(a *Action) (*Action, error) {
+		p, _ := a.NewParam("password", "secret", payload.TypeString)
+		if _, err := a.DeferCall("auth", "1.0.0", "login", []*Param{p}, nil); err != nil {
+			return nil, err
+		}
+		sent = a.transport
+		return a, nil
+	})
+
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	s := newServer(input, service.base(), service.processor)
+
+	command := payload.NewCommand("read", "service")
+	command.Command.Arguments = &payload.CommandArguments{Transport: newRedactionTestTransport()}
+	message, err := formatMsgpack.encode(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := s.process(requestMsg{{}, {}, {}, []byte("rid"), []byte("read"), nil, message, msgpackFormatFlag})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exported payload.Reply
+	b, _ := formatMsgpack.encode(reply)
+	if err := formatMsgpack.decode(b, &exported); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := exported.GetTransport().Calls["users"]["1.0.0"]
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %v", calls)
+	}
+	for _, call := range calls {
+		if call.Params[0].Value != RedactedValue {
+			t.Errorf("expected the exported call parameter to be redacted, got %v", call.Params[0].Value)
+		}
+	}
+
+	// The reply sent to the framework keeps the call parameters
+	if sent == nil {
+		t.Fatal("expected the action to run")
+	}
+	for _, call := range sent.Calls["users"]["1.0.0"] {
+		if call.Params[0].Value != "secret" {
+			t.Errorf("expected the call parameter of the reply to be kept, got %v", call.Params[0].Value)
+		}
+	}
+}
@@ -9,6 +9,7 @@
 package kusanagi
 
 import (
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -19,20 +20,24 @@ import (
 func newRequest(c Component, s *state) *Request {
 	api := newApi(c, s)
 
-	// Index parameters by name
-	params := make(map[string]payload.Param)
-	for _, p := range api.reply.Command.Result.Call.Params {
-		params[p.Name] = p
+	// Binary parameter values are base64 strings in JSON payloads
+	if s.format.isJSON() {
+		decodeJSONBinaryParams(api.reply.Command.Result.Call.Params)
 	}
 
-	return &Request{api, params}
+	// Index parameters by name
+	params := indexParams(api.reply.Command.Result.Call.Params)
+
+	request := &Request{api, params}
+	api.parent = request
+	return request
 }
 
 // Request API type for the middleware component.
 type Request struct {
 	*Api
 
-	params map[string]payload.Param
+	params map[string][]payload.Param
 }
 
 // GetID returns the request UUID.
@@ -70,6 +75,49 @@ func (r *Request) SetAttribute(name, value string) *Request {
 	return r
 }
 
+// SetAttributeIn registers a request attribute within a namespace.
+//
+// Namespaces avoid collisions between attributes with the same name set by different middlewares.
+//
+// namespace: The attribute namespace.
+// name: The attribute name.
+// value: The attribute value.
+func (r *Request) SetAttributeIn(namespace, name, value string) *Request {
+	return r.SetAttribute(payload.AttributeKey(namespace, name), value)
+}
+
+// GetAttributesIn returns the request attributes registered within a namespace.
+//
+// The names of the attributes in the result don't include the namespace.
+//
+// namespace: The attribute namespace.
+func (r *Request) GetAttributesIn(namespace string) map[string]string {
+	return payload.GetAttributesIn(r.reply.Command.Result.Attributes, namespace)
+}
+
+// MigrateAttributes copies flat request attributes into a namespace.
+//
+// This allows middlewares that read the attributes from a namespace to coexist with
+// middlewares that still register flat attributes. The flat attributes are kept, and
+// attributes that already exist in the namespace are not overwritten.
+//
+// namespace: The attribute namespace.
+// names: The names of the flat attributes to copy.
+func (r *Request) MigrateAttributes(namespace string, names ...string) *Request {
+	attributes := r.reply.Command.Result.Attributes
+	for _, name := range names {
+		value, exists := attributes[name]
+		if !exists {
+			continue
+		}
+
+		if _, exists := attributes[payload.AttributeKey(namespace, name)]; !exists {
+			r.SetAttributeIn(namespace, name, value)
+		}
+	}
+	return r
+}
+
 // GetServiceName returns the name of the service.
 func (r *Request) GetServiceName() string {
 	return r.reply.Command.Result.Call.Service
@@ -121,26 +169,43 @@ func (r *Request) HasParam(name string) bool {
 //
 // name: The name of the parameter.
 func (r *Request) GetParam(name string) *Param {
-	if p, exists := r.params[name]; exists {
-		return payloadToParam(p)
+	if values, exists := r.params[name]; exists {
+		return payloadToParam(values[len(values)-1])
 	}
 	return newEmptyParam(name)
 }
 
+// GetParamArray returns all the request parameters with the same name.
+//
+// Parameters are repeated when the request contains more than one value for a parameter.
+// The result is empty when the parameter doesn't exist.
+//
+// name: The name of the parameter.
+func (r *Request) GetParamArray(name string) (params []*Param) {
+	for _, p := range r.params[name] {
+		params = append(params, payloadToParam(p))
+	}
+	return params
+}
+
 // GetParams returns all the request's parameters.
+//
+// When a parameter is repeated only its last value is returned.
 func (r *Request) GetParams() (params []*Param) {
-	for _, p := range r.params {
-		params = append(params, payloadToParam(p))
+	for _, values := range r.params {
+		params = append(params, payloadToParam(values[len(values)-1]))
 	}
 	return params
 }
 
 // SetParam adds a new param for the current request.
 //
+// The param is added as a new value when a param with the same name exists.
+//
 // param: The parameter.
 func (r *Request) SetParam(p *Param) *Request {
 	payload := paramToPayload(p)
-	r.params[p.GetName()] = payload
+	r.params[p.GetName()] = append(r.params[p.GetName()], payload)
 	r.reply.Command.Result.Call.Params = append(r.reply.Command.Result.Call.Params, payload)
 	return r
 }
@@ -172,8 +237,14 @@ func (r *Request) NewResponse(code int, text string) *Response {
 }
 
 // GetHTTPRequest returns the HTTP request semantics for the current request.
+//
+// Changes made to the HTTP request are sent to the gateway with the request reply.
 func (r *Request) GetHTTPRequest() *HTTPRequest {
-	return newHTTPRequest(r.command.Command.Arguments.Request)
+	hr := newHTTPRequest(r.command.Command.Arguments.Request)
+	hr.changed = func(p *payload.HTTPRequest) {
+		r.reply.Command.Result.Request = p
+	}
+	return hr
 }
 
 func newHTTPRequest(p *payload.HTTPRequest) *HTTPRequest {
@@ -184,8 +255,11 @@ func newHTTPRequest(p *payload.HTTPRequest) *HTTPRequest {
 	}
 
 	// Parse the URL and assign it to the request
-	url, _ := url.Parse(p.URL)
-	r.url = url
+	if u, err := url.Parse(p.URL); err == nil {
+		r.url = u
+	} else {
+		r.url = &url.URL{}
+	}
 
 	// Index the headers using their upper case names
 	for name, values := range p.Headers {
@@ -207,6 +281,8 @@ type HTTPRequest struct {
 	url     *url.URL
 	// TODO: Change this to make each file a list to support multiple files with same name
 	files map[string]File
+	// Called when the HTTP request is changed
+	changed func(*payload.HTTPRequest)
 }
 
 // IsMethod checks if the request used the given HTTP method.
@@ -476,3 +552,116 @@ func (r HTTPRequest) GetFiles() (files []File) {
 	}
 	return files
 }
+
+// SetURLPath changes the path of the HTTP request URL.
+//
+// The change is only sent to the gateway by request middlewares.
+//
+// path: The new URL path.
+func (r *HTTPRequest) SetURLPath(path string) *HTTPRequest {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	r.url.Path = path
+	r.url.RawPath = ""
+	r.update()
+	return r
+}
+
+// SetQueryParam sets the value of a query parameter.
+//
+// Existing values for the parameter are replaced.
+// The change is only sent to the gateway by request middlewares.
+//
+// name: The parameter name.
+// value: The parameter value.
+func (r *HTTPRequest) SetQueryParam(name, value string) *HTTPRequest {
+	return r.SetQueryParamArray(name, []string{value})
+}
+
+// SetQueryParamArray sets the values of a query parameter.
+//
+// Existing values for the parameter are replaced.
+// The change is only sent to the gateway by request middlewares.
+//
+// name: The parameter name.
+// values: The parameter values.
+func (r *HTTPRequest) SetQueryParamArray(name string, values []string) *HTTPRequest {
+	if r.payload.Query == nil {
+		r.payload.Query = make(payload.HTTPRequestData)
+	}
+
+	r.payload.Query[name] = append([]string{}, values...)
+	r.url.RawQuery = url.Values(r.payload.Query).Encode()
+	r.update()
+	return r
+}
+
+// RemoveQueryParam removes a query parameter.
+//
+// The change is only sent to the gateway by request middlewares.
+//
+// name: The parameter name.
+func (r *HTTPRequest) RemoveQueryParam(name string) *HTTPRequest {
+	if _, exists := r.payload.Query[name]; !exists {
+		return r
+	}
+
+	delete(r.payload.Query, name)
+	r.url.RawQuery = url.Values(r.payload.Query).Encode()
+	r.update()
+	return r
+}
+
+// SetHeader sets the value of an HTTP header.
+//
+// The header name is case insensitive and existing values for the header are replaced.
+// The change is only sent to the gateway by request middlewares.
+//
+// name: The HTTP header name.
+// value: The header value.
+func (r *HTTPRequest) SetHeader(name, value string) *HTTPRequest {
+	r.removeHeader(name)
+	if r.payload.Headers == nil {
+		r.payload.Headers = make(http.Header)
+	}
+
+	r.payload.Headers[name] = []string{value}
+	r.headers[strings.ToUpper(name)] = r.payload.Headers[name]
+	r.update()
+	return r
+}
+
+// RemoveHeader removes an HTTP header.
+//
+// The header name is case insensitive.
+// The change is only sent to the gateway by request middlewares.
+//
+// name: The HTTP header name.
+func (r *HTTPRequest) RemoveHeader(name string) *HTTPRequest {
+	if r.removeHeader(name) {
+		r.update()
+	}
+	return r
+}
+
+// Remove a header from the payload using a case insensitive name.
+func (r *HTTPRequest) removeHeader(name string) (removed bool) {
+	delete(r.headers, strings.ToUpper(name))
+	for n := range r.payload.Headers {
+		if strings.EqualFold(n, name) {
+			delete(r.payload.Headers, n)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// Update the HTTP request payload after a change.
+func (r *HTTPRequest) update() {
+	r.payload.URL = r.url.String()
+	if r.changed != nil {
+		r.changed(r.payload)
+	}
+}
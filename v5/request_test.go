@@ -0,0 +1,67 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a middleware request for the HTTP request rewriting tests.
+func newRewriteTestRequest() *Request {
+	s := newTestState("gateway", "1.0.0", "request", nil)
+	s.command = payload.NewCommand("request", "middleware")
+	s.command.Command.Arguments = &payload.CommandArguments{
+		Request: &payload.HTTPRequest{
+			Method:  "GET",
+			URL:     "http://example.com/users?debug=1&page=1",
+			Query:   payload.HTTPRequestData{"page": {"1"}, "debug": {"1"}},
+			Headers: http.Header{"X-Token": {"secret"}},
+		},
+	}
+	s.reply = &payload.Reply{Command: &payload.CommandReply{
+		Name:   "request",
+		Result: payload.CommandResult{Call: &payload.CallInfo{Service: "users", Version: "1.0.0", Action: "list"}},
+	}}
+	return newRequest(NewMiddleware(), s)
+}
+
+func TestHTTPRequestRewrite(t *testing.T) {
+	r := newRewriteTestRequest()
+	if r.reply.Command.Result.Request != nil {
+		t.Fatal("expected no HTTP request changes in the reply")
+	}
+
+	hr := r.GetHTTPRequest()
+	hr.SetURLPath("v2/users").
+		SetQueryParamArray("tag", []string{"a", "b"}).
+		SetQueryParam("page", "2").
+		RemoveQueryParam("debug").
+		SetHeader("x-token", "other").
+		SetHeader("X-Version", "2")
+
+	// The changes are sent in the reply
+	p := r.reply.Command.Result.Request
+	if p == nil {
+		t.Fatal("expected the HTTP request changes in the reply")
+	}
+	if expected := "http://example.com/v2/users?page=2&tag=a&tag=b"; p.URL != expected {
+		t.Errorf("expected the URL %s, got %s", expected, p.URL)
+	}
+
+	// Headers are replaced without case sensitivity using the new header name
+	if len(p.Headers) != 2 || p.Headers["x-token"][0] != "other" || p.Headers["X-Version"][0] != "2" {
+		t.Errorf("unexpected headers: %v", p.Headers)
+	}
+	if hr.GetHeader("X-TOKEN", "") != "other" || hr.GetURLPath() != "/v2/users" || hr.HasQueryParam("debug") {
+		t.Error("expected the changes in the HTTP request")
+	}
+}
@@ -0,0 +1,94 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// RequestLimits contains the limits of the request payloads.
+//
+// A zero value disables the limit.
+type RequestLimits struct {
+	// Maximum size in bytes of the HTTP request body
+	MaxBodySize uint
+	// Maximum size in bytes of each file.
+	//
+	// The limit is checked using the file sizes declared in the request payloads, which
+	// are the sizes reported by the client, so it doesn't protect the components that read
+	// the file contents from files that are larger than declared.
+	MaxFileSize uint
+	// Maximum number of parameters, including the HTTP query and post parameters
+	MaxParams uint
+}
+
+// Create the error for a request that exceeds the size limits.
+func newPayloadTooLargeError(format string, args ...interface{}) replyError {
+	return replyError{
+		message: fmt.Sprintf(format, args...),
+		code:    413,
+		status:  "413 Payload Too Large",
+	}
+}
+
+// Check that a request payload doesn't exceed the limits.
+//
+// The body and file sizes are rejected with a 413 error, and the number of
+// parameters is rejected with a 422 error. The parameters of the action or the
+// call are counted together with the HTTP query and post parameters.
+func (l RequestLimits) check(command payload.Command) error {
+	args := command.Command.Arguments
+	if args == nil {
+		return nil
+	}
+
+	files := args.Files
+	params := uint(len(args.Params))
+	if call := args.GetCall(); call != nil {
+		params += uint(len(call.Params))
+	}
+
+	if r := args.Request; r != nil {
+		if l.MaxBodySize > 0 && uint(len(r.Body)) > l.MaxBodySize {
+			return newPayloadTooLargeError("The request body exceeds the maximum size of %d bytes", l.MaxBodySize)
+		}
+
+		files = append(files[:len(files):len(files)], r.Files...)
+		for _, values := range r.Query {
+			params += uint(len(values))
+		}
+		for _, values := range r.PostData {
+			params += uint(len(values))
+		}
+	}
+
+	if l.MaxFileSize > 0 {
+		for _, f := range files {
+			if f.Size > l.MaxFileSize {
+				return newPayloadTooLargeError(`The file "%s" exceeds the maximum size of %d bytes`, f.Name, l.MaxFileSize)
+			}
+		}
+	}
+
+	if l.MaxParams > 0 && params > l.MaxParams {
+		return replyError{
+			message: fmt.Sprintf("The request exceeds the maximum number of parameters: %d", l.MaxParams),
+			code:    422,
+			status:  "422 Unprocessable Entity",
+		}
+	}
+	return nil
+}
+
+func (c *component) SetRequestLimits(limits RequestLimits) Component {
+	c.limits = limits
+	return c
+}
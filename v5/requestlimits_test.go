@@ -0,0 +1,109 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a command with the given request arguments for the limit tests.
+func newLimitsTestCommand(args *payload.CommandArguments) payload.Command {
+	command := payload.NewCommand("request", "middleware")
+	command.Command.Arguments = args
+	return command
+}
+
+func TestRequestLimitsCheck(t *testing.T) {
+	request := &payload.HTTPRequest{
+		Body:     []byte("0123456789"),
+		Query:    payload.HTTPRequestData{"a": {"1", "2"}, "b": {"3"}},
+		PostData: payload.HTTPRequestData{"c": {"4"}},
+		Files:    []payload.File{{Name: "avatar", Size: 100}},
+	}
+	call := map[string]interface{}{
+		"s": "users",
+		"v": "1.0.0",
+		"a": "read",
+		"p": []interface{}{
+			map[string]interface{}{"n": "id", "v": "1", "t": "string"},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		limits RequestLimits
+		args   *payload.CommandArguments
+		code   int
+	}{
+		{"no arguments", RequestLimits{MaxBodySize: 1, MaxFileSize: 1, MaxParams: 1}, nil, 0},
+		{"no limits", RequestLimits{}, &payload.CommandArguments{Request: request, C: call}, 0},
+		{"body within limit", RequestLimits{MaxBodySize: 10}, &payload.CommandArguments{Request: request}, 0},
+		{"body too large", RequestLimits{MaxBodySize: 9}, &payload.CommandArguments{Request: request}, 413},
+		{"request file within limit", RequestLimits{MaxFileSize: 100}, &payload.CommandArguments{Request: request}, 0},
+		{"request file too large", RequestLimits{MaxFileSize: 99}, &payload.CommandArguments{Request: request}, 413},
+		{
+			"action file too large",
+			RequestLimits{MaxFileSize: 99},
+			&payload.CommandArguments{Files: payload.ActionFiles{{Name: "doc", Size: 200}}},
+			413,
+		},
+		// The query, post and call params are counted together
+		{"params within limit", RequestLimits{MaxParams: 5}, &payload.CommandArguments{Request: request, C: call}, 0},
+		{"too many params", RequestLimits{MaxParams: 4}, &payload.CommandArguments{Request: request, C: call}, 422},
+		{"only HTTP params", RequestLimits{MaxParams: 4}, &payload.CommandArguments{Request: request}, 0},
+		{
+			"action params",
+			RequestLimits{MaxParams: 1},
+			&payload.CommandArguments{Params: payload.ActionParams{{Name: "a"}, {Name: "b"}}},
+			422,
+		},
+		// The sizes are checked before the params
+		{"size before params", RequestLimits{MaxBodySize: 1, MaxParams: 1}, &payload.CommandArguments{Request: request, C: call}, 413},
+	}
+
+	for _, c := range cases {
+		err := c.limits.check(newLimitsTestCommand(c.args))
+		if c.code == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", c.name, err)
+			}
+			continue
+		}
+
+		replyErr, ok := err.(replyError)
+		if !ok {
+			t.Errorf("%s: expected a reply error, got %v", c.name, err)
+			continue
+		}
+
+		if replyErr.code != c.code {
+			t.Errorf("%s: expected the code %d, got %d: %v", c.name, c.code, replyErr.code, err)
+		}
+	}
+}
+
+func TestRequestLimitsCheckKeepsFiles(t *testing.T) {
+	files := make(payload.ActionFiles, 1, 2)
+	files[0] = payload.File{Name: "doc", Size: 1}
+	args := &payload.CommandArguments{
+		Files:   files,
+		Request: &payload.HTTPRequest{Files: []payload.File{{Name: "avatar", Size: 1}}},
+	}
+
+	if err := (RequestLimits{MaxFileSize: 10}).check(newLimitsTestCommand(args)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The request files are not appended to the action files
+	if len(args.Files) != 1 || args.Files[:2][1].Name != "" {
+		t.Errorf("expected the action files without changes, got %v", args.Files[:2])
+	}
+}
@@ -0,0 +1,298 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+)
+
+// RequestResourceFactory functions create resources for a single request.
+//
+// The factory argument is the API of the request, which can be casted to the
+// specific API type, for example:
+//
+//	action := api.(*Action)
+type RequestResourceFactory func(interface{}) (interface{}, error)
+
+// GetResourceAs returns a component resource of a specific type.
+//
+// c: The component.
+// name: Name of the resource.
+func GetResourceAs[T any](c Component, name string) (T, error) {
+	resource, err := c.GetResource(name)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return resourceAs[T](name, resource)
+}
+
+// GetRequestResourceAs returns a request resource of a specific type.
+//
+// api: The API of the request.
+// name: Name of the resource.
+func GetRequestResourceAs[T any](api *Api, name string) (T, error) {
+	resource, err := api.GetRequestResource(name)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return resourceAs[T](name, resource)
+}
+
+// Convert a resource to a specific type.
+func resourceAs[T any](name string, resource interface{}) (T, error) {
+	value, ok := resource.(T)
+	if !ok {
+		return value, fmt.Errorf(`invalid type for resource "%s": %T`, name, resource)
+	}
+	return value, nil
+}
+
+// Create a new resource registry.
+func newResourceRegistry() *resourceRegistry {
+	return &resourceRegistry{
+		resources: make(map[string]*resource),
+		factories: make(map[string]RequestResourceFactory),
+	}
+}
+
+// Registry for the resources of a component.
+//
+// Resources are created when they are registered, or the first time they are used
+// when they are lazy. Resources that implement io.Closer are closed when the component
+// shuts down, in the reverse order they were registered.
+type resourceRegistry struct {
+	mutex     sync.RWMutex
+	resources map[string]*resource
+	names     []string
+	factories map[string]RequestResourceFactory
+}
+
+// Component resource.
+type resource struct {
+	mutex   sync.Mutex
+	factory ResourceFactory
+	value   interface{}
+}
+
+// Get the resource value, creating it when it is lazy and it was not created yet.
+func (r *resource) get(name string, c Component) (interface{}, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.value == nil {
+		value, err := createResource(name, func() (interface{}, error) {
+			return r.factory(c)
+		})
+		if err != nil {
+			return nil, err
+		}
+		r.value = value
+	}
+	return r.value, nil
+}
+
+// Create a resource value using a factory.
+func createResource(name string, factory func() (interface{}, error)) (interface{}, error) {
+	value, err := factory()
+	if err != nil {
+		return nil, err
+	} else if value == nil {
+		return nil, fmt.Errorf(`invalid result value for resource: "%s"`, name)
+	}
+	return value, nil
+}
+
+// Check if a resource is registered.
+func (r *resourceRegistry) has(name string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.resources[name]
+	return exists
+}
+
+// Register a resource.
+//
+// When the resource is not lazy the factory is called during the registration.
+func (r *resourceRegistry) set(name string, factory ResourceFactory, lazy bool, c Component) error {
+	res := &resource{factory: factory}
+	if !lazy {
+		if _, err := res.get(name, c); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.resources[name]; !exists {
+		r.names = append(r.names, name)
+	}
+	r.resources[name] = res
+	return nil
+}
+
+// Get the value of a resource.
+func (r *resourceRegistry) get(name string, c Component) (interface{}, error) {
+	r.mutex.RLock()
+	res, exists := r.resources[name]
+	r.mutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf(`resource not found: "%s"`, name)
+	}
+	return res.get(name, c)
+}
+
+// Get the names of the registered resources and whether they are already created.
+func (r *resourceRegistry) states() map[string]bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	states := make(map[string]bool, len(r.resources))
+	for name, res := range r.resources {
+		res.mutex.Lock()
+		states[name] = res.value != nil
+		res.mutex.Unlock()
+	}
+	return states
+}
+
+// Register a factory for request resources.
+func (r *resourceRegistry) setRequestFactory(name string, factory RequestResourceFactory) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.factories[name] = factory
+}
+
+// Get the factory of a request resource.
+func (r *resourceRegistry) getRequestFactory(name string) (RequestResourceFactory, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	factory, exists := r.factories[name]
+	return factory, exists
+}
+
+// Add the resources and request resource factories of another registry.
+//
+// The resources that are already registered are not changed.
+func (r *resourceRegistry) merge(source *resourceRegistry) {
+	source.mutex.RLock()
+	defer source.mutex.RUnlock()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, name := range source.names {
+		if _, exists := r.resources[name]; !exists {
+			r.names = append(r.names, name)
+			r.resources[name] = source.resources[name]
+		}
+	}
+
+	for name, factory := range source.factories {
+		if _, exists := r.factories[name]; !exists {
+			r.factories[name] = factory
+		}
+	}
+}
+
+// Close the resources that implement io.Closer.
+//
+// Lazy resources that were never used are not created to be closed.
+func (r *resourceRegistry) close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := len(r.names) - 1; i >= 0; i-- {
+		name := r.names[i]
+		res := r.resources[name]
+
+		res.mutex.Lock()
+		closeResource(name, res.value)
+		res.value = nil
+		res.mutex.Unlock()
+	}
+}
+
+// Close a resource when it implements io.Closer.
+func closeResource(name string, value interface{}) {
+	if closer, ok := value.(io.Closer); ok {
+		log.Debugf(`Closing resource: "%s"`, name)
+		if err := closer.Close(); err != nil {
+			log.Errorf(`Failed to close resource "%s": %v`, name, err)
+		}
+	}
+}
+
+// Create the resources for a request.
+//
+// registry: The registry with the request resource factories.
+func newRequestResources(registry *resourceRegistry) *requestResources {
+	return &requestResources{registry: registry, values: make(map[string]interface{})}
+}
+
+// Resources created for a single request.
+//
+// Resources are created the first time they are used during the request, and the
+// ones that implement io.Closer are closed when the request finishes.
+type requestResources struct {
+	mutex    sync.Mutex
+	registry *resourceRegistry
+	values   map[string]interface{}
+	names    []string
+}
+
+// Get a request resource, creating it when it was not used before during the request.
+//
+// api: The API of the request to pass to the resource factory.
+func (r *requestResources) get(name string, api interface{}) (interface{}, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if value, exists := r.values[name]; exists {
+		return value, nil
+	}
+
+	factory, exists := r.registry.getRequestFactory(name)
+	if !exists {
+		return nil, fmt.Errorf(`request resource not found: "%s"`, name)
+	}
+
+	value, err := createResource(name, func() (interface{}, error) {
+		return factory(api)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.values[name] = value
+	r.names = append(r.names, name)
+	return value, nil
+}
+
+// Close the request resources that implement io.Closer.
+func (r *requestResources) close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := len(r.names) - 1; i >= 0; i-- {
+		closeResource(r.names[i], r.values[r.names[i]])
+	}
+	r.values = make(map[string]interface{})
+	r.names = nil
+}
@@ -0,0 +1,97 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Resource that records when it is closed for the resource tests.
+type testResource struct {
+	name   string
+	closed *[]string
+}
+
+func (r *testResource) Close() error {
+	*r.closed = append(*r.closed, r.name)
+	return nil
+}
+
+func TestResourceRegistry(t *testing.T) {
+	var closed []string
+	created := 0
+	newFactory := func(name string) ResourceFactory {
+		return func(Component) (interface{}, error) {
+			created++
+			return &testResource{name, &closed}, nil
+		}
+	}
+
+	service := NewService()
+	if err := service.SetResource("db", newFactory("db")); err != nil {
+		t.Fatal(err)
+	}
+	service.SetLazyResource("cache", newFactory("cache"))
+	service.SetLazyResource("unused", newFactory("unused"))
+
+	// Lazy resources are created the first time they are used
+	if created != 1 || !service.HasResource("cache") {
+		t.Errorf("expected only the eager resource to be created, got %d", created)
+	}
+	cache, err := GetResourceAs[*testResource](service, "cache")
+	if err != nil || cache.name != "cache" {
+		t.Fatalf("unexpected resource: %v %v", cache, err)
+	}
+	if _, err := service.GetResource("cache"); err != nil || created != 2 {
+		t.Errorf("expected the resource to be created once, got %d", created)
+	}
+
+	if _, err := GetResourceAs[string](service, "db"); err == nil {
+		t.Error("expected an error for the invalid resource type")
+	}
+	if _, err := service.GetResource("missing"); err == nil {
+		t.Error("expected an error for the missing resource")
+	}
+
+	// Resources are closed in the reverse order, without creating the unused ones
+	service.resources.close()
+	if !reflect.DeepEqual(closed, []string{"cache", "db"}) {
+		t.Errorf("unexpected closed resources: %v", closed)
+	}
+}
+
+func TestRequestResources(t *testing.T) {
+	var closed []string
+	service := NewService()
+	service.SetRequestResource("tx", func(api interface{}) (interface{}, error) {
+		return &testResource{api.(*Action).GetActionName(), &closed}, nil
+	})
+
+	s := newTestState("users", "1.0.0", "read", nil)
+	s.resources = newRequestResources(service.resources)
+	a := newAction(service, s)
+
+	// The factories receive the action and the resources are created once per request
+	tx, err := GetRequestResourceAs[*testResource](a.Api, "tx")
+	if err != nil || tx.name != "read" {
+		t.Fatalf("unexpected request resource: %v %v", tx, err)
+	}
+	if other, _ := a.GetRequestResource("tx"); other != tx {
+		t.Error("expected the same resource during the request")
+	}
+	if _, err := a.GetRequestResource("missing"); err == nil {
+		t.Error("expected an error for the missing resource")
+	}
+
+	s.closeResources()
+	if !reflect.DeepEqual(closed, []string{"read"}) {
+		t.Errorf("expected the request resource to be closed, got %v", closed)
+	}
+}
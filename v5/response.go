@@ -13,11 +13,14 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
 )
 
 func newResponse(c Component, s *state) *Response {
-	return &Response{newApi(c, s)}
+	response := &Response{newApi(c, s)}
+	response.parent = response
+	return response
 }
 
 // Response API type for the middleware component.
@@ -51,6 +54,24 @@ func (r *Response) GetRequestAttributes() map[string]string {
 	return r.command.Command.Arguments.Meta.Attributes
 }
 
+// GetRequestAttributeIn returns a request attribute value registered within a namespace.
+//
+// namespace: The attribute namespace.
+// name: The attribute name.
+// preset: A default value to use when the attribute doesn't exist.
+func (r *Response) GetRequestAttributeIn(namespace, name, preset string) string {
+	return r.GetRequestAttribute(payload.AttributeKey(namespace, name), preset)
+}
+
+// GetRequestAttributesIn returns the request attributes registered within a namespace.
+//
+// The names of the attributes in the result don't include the namespace.
+//
+// namespace: The attribute namespace.
+func (r *Response) GetRequestAttributesIn(namespace string) map[string]string {
+	return payload.GetAttributesIn(r.command.Command.Arguments.Meta.Attributes, namespace)
+}
+
 // GetHTTPRequest returns the HTTP request semantics for the current response.
 func (r *Response) GetHTTPRequest() *HTTPRequest {
 	return newHTTPRequest(r.command.Command.Arguments.Request)
@@ -78,18 +99,21 @@ func (r *Response) HasReturn() bool {
 // GetReturn returns the value returned by the called service.
 func (r *Response) GetReturn() (interface{}, error) {
 	if !r.HasReturn() {
-		origin := r.command.Command.Arguments.Transport.Meta.Origin
-		service := origin[0]
-		version := origin[1]
-		action := origin[2]
-		err := fmt.Errorf(`No return value defined on "%s" (%s) for action: "%s"`, service, version, action)
+		origin := newOrigin(r.command.Command.Arguments.Transport.Meta.Origin)
+		err := fmt.Errorf(`No return value defined on "%s" (%s) for action: "%s"`, origin.Service, origin.Version, origin.Action)
 		return nil, err
 	}
-	return r.command.Command.Arguments.Return, nil
+	return datatypes.Normalize(r.command.Command.Arguments.Return, ""), nil
 }
 
 // GetTransport returns the transport.
+//
+// The result is the pruned transport when the transport was pruned by the middleware.
 func (r *Response) GetTransport() *Transport {
+	if r.reply.Command.Result.Transport != nil {
+		return &Transport{r.reply.Command.Result.Transport}
+	}
+
 	if r.command.Command.Arguments.Transport != nil {
 		return &Transport{r.command.Command.Arguments.Transport}
 	}
@@ -309,3 +333,25 @@ func (r *HTTPResponse) SetBody(content []byte) *HTTPResponse {
 	}
 	return r
 }
+
+// SetBodyCompressed compresses the contents and sets them as the HTTP response body.
+//
+// The "Content-Encoding" header is set to the given encoding, and the
+// supported encodings are "gzip" and "deflate".
+// An error is returned when the encoding is not supported.
+//
+// content: The uncompressed body contents.
+// encoding: The content encoding to use for the compression.
+func (r *HTTPResponse) SetBodyCompressed(content []byte, encoding string) (*HTTPResponse, error) {
+	body, err := compressBody(content, encoding)
+	if err != nil {
+		return r, err
+	}
+
+	r.SetHeader("Content-Encoding", strings.ToLower(encoding), true)
+	// Keep the content length in sync with the compressed body
+	if r.HasHeader("Content-Length") {
+		r.SetHeader("Content-Length", strconv.Itoa(len(body)), true)
+	}
+	return r.SetBody(body), nil
+}
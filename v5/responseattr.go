@@ -0,0 +1,60 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import "github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+
+// ResponseAttributeNamespace is the attribute namespace reserved for the response attributes.
+//
+// Response attributes are saved in the reply attributes within this namespace, so they are
+// received by the response middlewares that run after the one that set them, and they are
+// kept separated from the attributes set by the request middlewares.
+const ResponseAttributeNamespace = "response"
+
+// SetAttribute registers a response attribute.
+//
+// Response attributes allow the response middlewares to communicate with the
+// response middlewares that run after them.
+//
+// name: The attribute name.
+// value: The attribute value.
+func (r *Response) SetAttribute(name, value string) *Response {
+	if r.reply.Command.Result.Attributes == nil {
+		r.reply.Command.Result.Attributes = make(map[string]string)
+	}
+
+	r.reply.Command.Result.Attributes[payload.AttributeKey(ResponseAttributeNamespace, name)] = value
+	return r
+}
+
+// HasAttribute checks if a response attribute exists.
+//
+// name: The attribute name.
+func (r *Response) HasAttribute(name string) bool {
+	_, exists := r.reply.Command.Result.Attributes[payload.AttributeKey(ResponseAttributeNamespace, name)]
+	return exists
+}
+
+// GetAttribute returns a response attribute value.
+//
+// The value can be set by the current middleware or by a previous response middleware.
+//
+// name: The attribute name.
+// preset: A default value to use when the attribute doesn't exist.
+func (r *Response) GetAttribute(name, preset string) string {
+	if value, exists := r.reply.Command.Result.Attributes[payload.AttributeKey(ResponseAttributeNamespace, name)]; exists {
+		return value
+	}
+	return preset
+}
+
+// GetAttributes returns all the response attributes.
+func (r *Response) GetAttributes() map[string]string {
+	return payload.GetAttributesIn(r.reply.Command.Result.Attributes, ResponseAttributeNamespace)
+}
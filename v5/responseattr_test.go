@@ -0,0 +1,68 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a middleware response with attributes for the response attribute tests.
+func newAttributeTestResponse(attributes map[string]string) *Response {
+	s := newTestState("gateway", "1.0.0", "response", nil)
+	s.command = payload.NewCommand("response", "middleware")
+	s.command.Command.Arguments = &payload.CommandArguments{
+		A:         attributes,
+		Request:   &payload.HTTPRequest{Method: "GET", URL: "http://example.com/users/1"},
+		Response:  &payload.HTTPResponse{Status: "200 OK", Headers: http.Header{}},
+		Transport: &payload.Transport{},
+	}
+	s.reply = payload.NewResponseReply(&s.command)
+	return newResponse(NewMiddleware(), s)
+}
+
+func TestResponseAttributes(t *testing.T) {
+	attributes := map[string]string{
+		"token": "request",
+		payload.AttributeKey(ResponseAttributeNamespace, "theme"): "dark",
+	}
+	r := newAttributeTestResponse(attributes)
+
+	// The attributes of previous response middlewares are available
+	if !r.HasAttribute("theme") || r.GetAttribute("theme", "") != "dark" {
+		t.Error("expected the attribute of a previous middleware")
+	}
+	if r.HasAttribute("token") || r.GetAttribute("token", "none") != "none" {
+		t.Error("expected the request attributes not to be response attributes")
+	}
+
+	r.SetAttribute("lang", "en")
+	expected := map[string]string{"theme": "dark", "lang": "en"}
+	if values := r.GetAttributes(); !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	// The command attributes don't change
+	if len(attributes) != 2 {
+		t.Errorf("expected the command attributes not to change, got %v", attributes)
+	}
+}
+
+func TestResponseSetAttributeWithoutAttributes(t *testing.T) {
+	r := newAttributeTestResponse(nil)
+	r.SetAttribute("lang", "en")
+
+	key := payload.AttributeKey(ResponseAttributeNamespace, "lang")
+	if value := r.reply.Command.Result.Attributes[key]; value != "en" {
+		t.Errorf("expected the attribute in the reply, got %q", value)
+	}
+}
@@ -0,0 +1,134 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Path of the root return value used in the validation errors.
+const returnValueRootPath = "$"
+
+// ReturnValueError is the error returned when a return value doesn't match the action schema.
+type ReturnValueError struct {
+	// Path is the location of the invalid value, where "$" is the return value.
+	Path string
+	// Message describes the error.
+	Message string
+}
+
+func (e ReturnValueError) Error() string {
+	return fmt.Sprintf(`Invalid return value at "%s": %s`, e.Path, e.Message)
+}
+
+// ValidateReturn checks that a return value matches the return schema of the action.
+//
+// Object values are checked against the fields defined for the return value,
+// and the items of array values are checked against the items schema.
+// The error is a ReturnValueError with the path to the first invalid value.
+//
+// value: The return value.
+func (s ActionSchema) ValidateReturn(value interface{}) error {
+	if s.payload.Return == nil {
+		return fmt.Errorf("Return value not defined for action: %s", s.GetName())
+	}
+	return validateReturnValue(returnValueRootPath, value, s.payload.Return)
+}
+
+// Validate a value against a return value schema.
+func validateReturnValue(path string, value interface{}, schema *payload.ReturnSchema) error {
+	if err := checkReturnValueType(path, value, schema.Type); err != nil {
+		return err
+	}
+
+	switch schema.Type {
+	case datatypes.Object:
+		return validateReturnObject(path, reflect.ValueOf(value), schema.Field, schema.Fields)
+	case datatypes.Array:
+		if schema.Items == nil {
+			return nil
+		}
+
+		items := reflect.ValueOf(value)
+		for i := 0; i < items.Len(); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := validateReturnValue(itemPath, items.Index(i).Interface(), schema.Items); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate the fields of an object value.
+func validateReturnObject(path string, object reflect.Value, fields []payload.FieldSchema, objectFields []payload.ObjectFieldSchema) error {
+	for _, f := range fields {
+		fieldPath := path + "." + f.Name
+		value, exists := getReturnObjectField(object, f.Name)
+		if !exists || value == nil {
+			if f.Optional {
+				continue
+			}
+			return ReturnValueError{fieldPath, "the field is required"}
+		}
+
+		if f.Type != "" {
+			if err := checkReturnValueType(fieldPath, value, f.Type); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, f := range objectFields {
+		fieldPath := path + "." + f.Name
+		value, exists := getReturnObjectField(object, f.Name)
+		if !exists || value == nil {
+			if f.Optional {
+				continue
+			}
+			return ReturnValueError{fieldPath, "the field is required"}
+		}
+
+		if err := checkReturnValueType(fieldPath, value, datatypes.Object); err != nil {
+			return err
+		}
+
+		if err := validateReturnObject(fieldPath, reflect.ValueOf(value), f.Field, f.Fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the value of an object field.
+//
+// Objects are maps with string keys.
+func getReturnObjectField(object reflect.Value, name string) (interface{}, bool) {
+	if object.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	value := object.MapIndex(reflect.ValueOf(name).Convert(object.Type().Key()))
+	if !value.IsValid() {
+		return nil, false
+	}
+	return value.Interface(), true
+}
+
+// Check that a value has a specific type.
+func checkReturnValueType(path string, value interface{}, expected string) error {
+	if t := datatypes.ResolveType(value); t != expected {
+		return ReturnValueError{path, fmt.Sprintf(`expected type "%s", got "%s"`, expected, t)}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create an action schema with a return value for the return value tests.
+func newReturnValueTestSchema() ActionSchema {
+	return ActionSchema{"read", payload.ActionSchema{Return: &payload.ReturnSchema{
+		Type: payload.TypeArray,
+		Items: &payload.ReturnSchema{
+			Type: payload.TypeObject,
+			Field: []payload.FieldSchema{
+				{Name: "id", Type: payload.TypeInteger},
+				{Name: "name", Type: payload.TypeString, Optional: true},
+			},
+			Fields: []payload.ObjectFieldSchema{
+				{Name: "address", Field: []payload.FieldSchema{{Name: "city", Type: payload.TypeString}}},
+			},
+		},
+	}}}
+}
+
+func TestActionSchemaValidateReturn(t *testing.T) {
+	address := map[string]interface{}{"city": "Barcelona"}
+	cases := []struct {
+		name  string
+		value interface{}
+		path  string
+	}{
+		{"valid", []interface{}{map[string]interface{}{"id": 1, "address": address}}, ""},
+		{"empty", []interface{}{}, ""},
+		{"root type", map[string]interface{}{}, "$"},
+		{"item type", []interface{}{"invalid"}, "$[0]"},
+		{"required field", []interface{}{map[string]interface{}{"address": address}}, "$[0].id"},
+		{"field type", []interface{}{map[string]interface{}{"id": "1", "address": address}}, "$[0].id"},
+		{"optional field type", []interface{}{map[string]interface{}{"id": 1, "name": 2, "address": address}}, "$[0].name"},
+		{"required object", []interface{}{map[string]interface{}{"id": 1}}, "$[0].address"},
+		{"nested field", []interface{}{
+			map[string]interface{}{"id": 1, "address": address},
+			map[string]interface{}{"id": 2, "address": map[string]interface{}{}},
+		}, "$[1].address.city"},
+	}
+
+	schema := newReturnValueTestSchema()
+	for _, c := range cases {
+		err := schema.ValidateReturn(c.value)
+		if c.path == "" {
+			if err != nil {
+				t.Errorf("%s: expected no error, got %v", c.name, err)
+			}
+			continue
+		}
+
+		rerr, ok := err.(ReturnValueError)
+		if !ok {
+			t.Errorf("%s: expected a return value error, got %v", c.name, err)
+		} else if rerr.Path != c.path {
+			t.Errorf("%s: expected the path %q, got %q", c.name, c.path, rerr.Path)
+		}
+	}
+
+	if err := (ActionSchema{"read", payload.ActionSchema{}}).ValidateReturn(nil); err == nil {
+		t.Error("expected an error without return value")
+	}
+}
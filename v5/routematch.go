@@ -0,0 +1,130 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Expression that matches the parameters in the HTTP path patterns, like "{id}".
+var pathParamRe = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// Compiled HTTP path patterns by pattern.
+var pathPatterns sync.Map
+
+// Compiled HTTP path pattern.
+type pathPattern struct {
+	re    *regexp.Regexp
+	names []string
+}
+
+// Compile an HTTP path pattern into an expression that extracts the parameter values.
+//
+// Each parameter matches a single path segment, or a part of a segment when the
+// segment contains other characters, like "{name}.{ext}".
+func compilePathPattern(pattern string) *pathPattern {
+	if p, ok := pathPatterns.Load(pattern); ok {
+		return p.(*pathPattern)
+	}
+
+	var (
+		expr  strings.Builder
+		names []string
+		last  int
+	)
+
+	expr.WriteString("^")
+	for _, m := range pathParamRe.FindAllStringSubmatchIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[last:m[0]]))
+		expr.WriteString("([^/]+?)")
+		names = append(names, pattern[m[2]:m[3]])
+		last = m[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+
+	p := &pathPattern{regexp.MustCompile(expr.String()), names}
+	pathPatterns.Store(pattern, p)
+	return p
+}
+
+// Remove the trailing slashes of a path, keeping the root path.
+func trimPathSlash(path string) string {
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+	return "/"
+}
+
+// MatchPathPattern matches a URL path with an HTTP path pattern.
+//
+// Parameters in the pattern are defined between curly braces, like "/users/{id}".
+// The result contains the values of the path parameters by name, and it is false
+// when the path doesn't match the pattern. Trailing slashes are ignored.
+//
+// pattern: The HTTP path pattern.
+// path: The URL path.
+func MatchPathPattern(pattern, path string) (map[string]string, bool) {
+	p := compilePathPattern(trimPathSlash(pattern))
+	match := p.re.FindStringSubmatch(trimPathSlash(path))
+	if match == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string, len(p.names))
+	for i, name := range p.names {
+		params[name] = match[i+1]
+	}
+	return params, true
+}
+
+// GetPathPattern returns the HTTP path pattern of the action that handles the request.
+//
+// The pattern is the base path of the service followed by the path of the action, as they are
+// defined in the service schema. The result is empty when the schema of the action is not available.
+func (r *Request) GetPathPattern() string {
+	service, err := r.GetServiceSchema(r.GetServiceName(), r.GetServiceVersion())
+	if err != nil {
+		return ""
+	}
+
+	action, err := service.GetActionSchema(r.GetActionName())
+	if err != nil {
+		return ""
+	}
+	return getOpenAPIPath(service.GetBasePath(), action.GetHTTPSchema().GetPath())
+}
+
+// GetPathParams returns the values of the path parameters of the request by name.
+//
+// The values are extracted by matching the URL path of the request with the path pattern of the
+// action, so middlewares don't have to parse the URL again. The escaped URL path is matched, so
+// escaped slashes are part of the values, and the values are unescaped. The result is empty when
+// the schema of the action is not available or when the URL path doesn't match the pattern.
+func (r *Request) GetPathParams() map[string]string {
+	pattern := r.GetPathPattern()
+	if pattern == "" || r.command.Command.Arguments.Request == nil {
+		return map[string]string{}
+	}
+
+	params, ok := MatchPathPattern(pattern, r.GetHTTPRequest().url.EscapedPath())
+	if !ok {
+		return map[string]string{}
+	}
+
+	for name, value := range params {
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			params[name] = unescaped
+		}
+	}
+	return params
+}
@@ -0,0 +1,142 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a middleware request for the path parameter tests.
+//
+// The action schema is not added to the mapping when the action path is empty.
+func newRouteTestRequest(basePath, actionPath, url string) *Request {
+	s := newTestState("gateway", "1.0.0", "request", nil)
+	s.command = payload.NewCommand("request", "middleware")
+	s.command.Command.Arguments = &payload.CommandArguments{
+		Request: &payload.HTTPRequest{Method: "GET", URL: url},
+	}
+	s.reply = &payload.Reply{Command: &payload.CommandReply{
+		Name:   "request",
+		Result: payload.CommandResult{Call: &payload.CallInfo{Service: "files", Version: "1.0.0", Action: "read"}},
+	}}
+
+	schema := payload.Schema{HTTP: payload.HTTPSchema{BasePath: basePath}, Actions: map[string]payload.ActionSchema{}}
+	if actionPath != "" {
+		schema.Actions["read"] = payload.ActionSchema{HTTP: payload.HTTPActionSchema{Path: actionPath}}
+	}
+	s.schemas = payload.Mapping{"files": {"1.0.0": schema}}
+	return newRequest(NewMiddleware(), s)
+}
+
+func TestMatchPathPattern(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		path     string
+		expected map[string]string
+	}{
+		{"/users/{id}", "/users/1", map[string]string{"id": "1"}},
+		{"/users/{id}", "/users/1/", map[string]string{"id": "1"}},
+		{"/users/{id}/", "/users/1", map[string]string{"id": "1"}},
+		{"/users/{id}", "/users/1/posts", nil},
+		{"/users/{id}", "/users/", nil},
+		{"/files/{name}.{ext}", "/files/report.final.pdf", map[string]string{"name": "report", "ext": "final.pdf"}},
+		{"/files/{name}.{ext}", "/files/report", nil},
+		{"/{a}/{b}", "/x/y", map[string]string{"a": "x", "b": "y"}},
+		{"/", "/", map[string]string{}},
+		{"/", "", map[string]string{}},
+		{"/v1.0/{id}", "/v1x0/1", nil},
+	}
+
+	for _, c := range cases {
+		params, ok := MatchPathPattern(c.pattern, c.path)
+		if ok != (c.expected != nil) {
+			t.Errorf("%s with %s: expected a match %v, got %v", c.pattern, c.path, c.expected != nil, ok)
+			continue
+		}
+
+		if ok && !reflect.DeepEqual(params, c.expected) {
+			t.Errorf("%s with %s: expected %v, got %v", c.pattern, c.path, c.expected, params)
+		}
+	}
+}
+
+func TestRequestGetPathParams(t *testing.T) {
+	cases := []struct {
+		name       string
+		basePath   string
+		actionPath string
+		url        string
+		pattern    string
+		expected   map[string]string
+	}{
+		{
+			"name and extension",
+			"", "/files/{name}.{ext}",
+			"http://example.com/files/report.pdf",
+			"/files/{name}.{ext}",
+			map[string]string{"name": "report", "ext": "pdf"},
+		},
+		{
+			"base path",
+			"/api/v1/", "/files/{id}",
+			"http://example.com/api/v1/files/42?download=1",
+			"/api/v1/files/{id}",
+			map[string]string{"id": "42"},
+		},
+		{
+			"trailing slash",
+			"/api", "/files/{id}",
+			"http://example.com/api/files/42/",
+			"/api/files/{id}",
+			map[string]string{"id": "42"},
+		},
+		{
+			"escaped values",
+			"", "/files/{name}",
+			"http://example.com/files/a%20b%2Fc",
+			"/files/{name}",
+			map[string]string{"name": "a b/c"},
+		},
+		{
+			"no match",
+			"/api", "/files/{id}",
+			"http://example.com/files/42",
+			"/api/files/{id}",
+			map[string]string{},
+		},
+		{
+			"missing schema",
+			"", "",
+			"http://example.com/files/42",
+			"",
+			map[string]string{},
+		},
+	}
+
+	for _, c := range cases {
+		r := newRouteTestRequest(c.basePath, c.actionPath, c.url)
+		if pattern := r.GetPathPattern(); pattern != c.pattern {
+			t.Errorf("%s: expected the pattern %q, got %q", c.name, c.pattern, pattern)
+		}
+
+		if params := r.GetPathParams(); !reflect.DeepEqual(params, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, params)
+		}
+	}
+
+	// The params are empty when the mapping is not available
+	r := newRouteTestRequest("", "/files/{id}", "http://example.com/files/42")
+	r.schemas = nil
+	if params := r.GetPathParams(); len(params) != 0 {
+		t.Errorf("expected no params without mapping, got %v", params)
+	}
+}
@@ -0,0 +1,189 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ActionSeparator separates the prefix of a mounted handler from the action names it handles.
+const ActionSeparator = "."
+
+// ActionHandler handles a family of service actions.
+type ActionHandler interface {
+	// HasAction checks if the handler can process an action.
+	//
+	// name: The action name without the mount prefix.
+	HasAction(name string) bool
+
+	// HandleAction processes an action.
+	//
+	// name: The action name without the mount prefix.
+	// action: The action API.
+	HandleAction(name string, action *Action) (*Action, error)
+}
+
+// NewActionRouter creates a new action router.
+func NewActionRouter() *ActionRouter {
+	return &ActionRouter{}
+}
+
+// ActionRouter is an action handler that dispatches actions to callbacks by name pattern.
+//
+// Patterns use the syntax of path.Match, so for example the pattern "*" matches all
+// the actions, and the pattern "read-*" matches actions like "read-one" or "read-many".
+// When more than one pattern matches an action the first one added is used.
+type ActionRouter struct {
+	hooks  []ActionCallback
+	routes []actionRoute
+}
+
+// Action route with the pattern and the callback to execute for the matching actions.
+type actionRoute struct {
+	pattern  string
+	callback ActionCallback
+}
+
+// Use adds a hook to execute before the callback of every action handled by the router.
+//
+// Hooks are executed in the order they are added, after the before hooks of the service.
+// When a hook returns an error the remaining hooks and the action callback are not executed.
+//
+// callback: The hook to execute before the action callbacks.
+func (r *ActionRouter) Use(callback ActionCallback) *ActionRouter {
+	r.hooks = append(r.hooks, callback)
+
+	return r
+}
+
+// Action assigns a callback to execute for the actions that match a pattern.
+//
+// pattern: The pattern to match the action names.
+// callback: The callback to execute.
+func (r *ActionRouter) Action(pattern string, callback ActionCallback) *ActionRouter {
+	r.routes = append(r.routes, actionRoute{pattern, callback})
+
+	return r
+}
+
+// Get the callback of the first route that matches an action name.
+func (r *ActionRouter) match(name string) (ActionCallback, bool) {
+	for _, route := range r.routes {
+		if matchActionPattern(route.pattern, name) {
+			return route.callback, true
+		}
+	}
+	return nil, false
+}
+
+// HasAction checks if the router has a route for an action.
+//
+// name: The action name.
+func (r *ActionRouter) HasAction(name string) bool {
+	_, ok := r.match(name)
+	return ok
+}
+
+// HandleAction runs the router hooks and the callback that matches an action.
+//
+// name: The action name.
+// action: The action API.
+func (r *ActionRouter) HandleAction(name string, action *Action) (*Action, error) {
+	callback, ok := r.match(name)
+	if !ok {
+		return action, fmt.Errorf(`The action "%s" has no route`, name)
+	}
+
+	callbacks := make([]ActionCallback, 0, len(r.hooks)+1)
+	callbacks = append(callbacks, r.hooks...)
+	return runActionCallbacks(action, append(callbacks, callback))
+}
+
+// Check if an action name matches a pattern.
+//
+// Invalid patterns never match.
+func matchActionPattern(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// Mounted action handler.
+type actionMount struct {
+	prefix  string
+	handler ActionHandler
+}
+
+// Mount assigns a handler for the actions with a name prefix.
+//
+// The handler processes the actions named as the prefix followed by a "." and
+// the action name, so for example when a handler is mounted with the prefix
+// "users" the action "users.read" is handled with the name "read".
+// Callbacks assigned with Action() have precedence over mounted handlers.
+//
+// prefix: The prefix of the action names.
+// handler: The handler for the actions.
+func (s *Service) Mount(prefix string, handler ActionHandler) *Service {
+	s.mounts = append(s.mounts, actionMount{prefix, handler})
+
+	return s
+}
+
+// ActionPattern assigns a callback to execute for the actions that match a pattern.
+//
+// Patterns use the syntax of path.Match, so for example "users.*" matches all the
+// actions with the "users." prefix. Callbacks assigned with Action() and mounted
+// handlers have precedence over patterns, and when more than one pattern matches
+// an action the first one added is used.
+//
+// pattern: The pattern to match the action names.
+// callback: The callback to execute.
+func (s *Service) ActionPattern(pattern string, callback ActionCallback) *Service {
+	s.patterns = append(s.patterns, actionRoute{pattern, callback})
+
+	return s
+}
+
+// Resolve the callback for an action that has no callback assigned by name.
+func (s *Service) resolveCallback(name string) (interface{}, bool) {
+	for _, m := range s.mounts {
+		prefix := m.prefix + ActionSeparator
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		handler := m.handler
+		subname := strings.TrimPrefix(name, prefix)
+		if handler.HasAction(subname) {
+			return ActionCallback(func(action *Action) (*Action, error) {
+				return handler.HandleAction(subname, action)
+			}), true
+		}
+	}
+
+	for _, route := range s.patterns {
+		if matchActionPattern(route.pattern, name) {
+			return route.callback, true
+		}
+	}
+	return nil, false
+}
+
+// Get the patterns of the actions handled by the mounted handlers and the action patterns.
+func (s *Service) getCallbackPatterns() []string {
+	patterns := []string{}
+	for _, m := range s.mounts {
+		patterns = append(patterns, m.prefix+ActionSeparator+"*")
+	}
+	for _, route := range s.patterns {
+		patterns = append(patterns, route.pattern)
+	}
+	return patterns
+}
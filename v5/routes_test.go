@@ -0,0 +1,141 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Process an action with a service server and return the names of the callbacks that were run.
+//
+// The callbacks created with newRouteTestCallback() add their name to the calls.
+func processRouteTestAction(t *testing.T, service *Service, action string, calls *[]string) []string {
+	t.Helper()
+
+	*calls = nil
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	s := newServer(input, service.base(), service.processor)
+
+	command := payload.NewCommand(action, "service")
+	command.Command.Arguments = &payload.CommandArguments{Transport: &payload.Transport{
+		Meta: payload.TransportMeta{Gateway: []string{"ktp://internal", "http://public"}},
+	}}
+	message, err := formatMsgpack.encode(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.process(requestMsg{{}, {}, {}, []byte("rid"), []byte(action), nil, message, msgpackFormatFlag})
+	return *calls
+}
+
+// Create an action callback that records its name when it runs.
+func newRouteTestCallback(name string, calls *[]string) ActionCallback {
+	return func(a *Action) (*Action, error) {
+		*calls = append(*calls, name)
+		return a, nil
+	}
+}
+
+func TestMatchActionPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*", "read", true},
+		{"read-*", "read-one", true},
+		{"read-*", "write-one", false},
+		{"users.*", "users.read", true},
+		{"users.*", "posts.read", false},
+		{"[", "[", false},
+	}
+
+	for _, c := range cases {
+		if match := matchActionPattern(c.pattern, c.name); match != c.match {
+			t.Errorf("expected %v for %s and %s, got %v", c.match, c.pattern, c.name, match)
+		}
+	}
+}
+
+func TestActionRouter(t *testing.T) {
+	var calls []string
+	router := NewActionRouter().
+		Use(newRouteTestCallback("hook", &calls)).
+		Action("read-*", newRouteTestCallback("read", &calls)).
+		Action("*", newRouteTestCallback("default", &calls))
+
+	if !router.HasAction("read-one") || !router.HasAction("write") {
+		t.Error("expected the router to have the actions")
+	}
+
+	// The first matching route is used after the hooks
+	if _, err := router.HandleAction("read-one", newTestAction("users", "1.0.0", "read-one", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(calls, []string{"hook", "read"}) {
+		t.Errorf("unexpected callbacks: %v", calls)
+	}
+
+	// The action callback is not run when a hook fails
+	calls = nil
+	router = NewActionRouter().
+		Use(func(a *Action) (*Action, error) { return a, errors.New("denied") }).
+		Action("*", newRouteTestCallback("default", &calls))
+	if _, err := router.HandleAction("read", newTestAction("users", "1.0.0", "read", nil)); err == nil || len(calls) != 0 {
+		t.Errorf("expected the hook error without callbacks, got %v %v", err, calls)
+	}
+
+	if _, err := NewActionRouter().HandleAction("read", newTestAction("users", "1.0.0", "read", nil)); err == nil {
+		t.Error("expected an error for an action without route")
+	}
+}
+
+func TestServiceActionRouting(t *testing.T) {
+	var calls []string
+	service := NewService()
+	service.Action("users.read", newRouteTestCallback("action", &calls))
+	service.Mount("users", NewActionRouter().Action("*", newRouteTestCallback("mount", &calls)))
+	service.ActionPattern("users.*", newRouteTestCallback("pattern", &calls))
+	service.ActionPattern("*", newRouteTestCallback("fallback", &calls))
+
+	cases := []struct {
+		action   string
+		expected []string
+	}{
+		// Callbacks assigned by name have precedence
+		{"users.read", []string{"action"}},
+		{"users.write", []string{"mount"}},
+		{"posts.read", []string{"fallback"}},
+	}
+
+	for _, c := range cases {
+		if result := processRouteTestAction(t, service, c.action, &calls); !reflect.DeepEqual(result, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.action, c.expected, result)
+		}
+	}
+
+	// Patterns are used when the mounted handler doesn't have the action
+	service = NewService()
+	service.Mount("users", NewActionRouter().Action("read", newRouteTestCallback("mount", &calls)))
+	service.ActionPattern("users.*", newRouteTestCallback("pattern", &calls))
+	if result := processRouteTestAction(t, service, "users.write", &calls); !reflect.DeepEqual(result, []string{"pattern"}) {
+		t.Errorf("expected the pattern callback, got %v", result)
+	}
+
+	expected := []string{"users.*", "users.*"}
+	if patterns := service.getCallbackPatterns(); !reflect.DeepEqual(patterns, expected) {
+		t.Errorf("expected %v, got %v", expected, patterns)
+	}
+}
@@ -11,14 +11,80 @@ package kusanagi
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/codec"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/protocol"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/runtime"
 )
 
+// RuntimeCallPoolSizeVariable is the name of the component variable that sets the maximum
+// number of sockets kept open for the runtime calls to each address.
+//
+// The pool is disabled by default, and the sockets are not reused between runtime
+// calls when the value is zero.
+const RuntimeCallPoolSizeVariable = "runtime-call-pool-size"
+
+// RuntimeCallIdleTimeoutVariable is the name of the component variable that sets the time
+// in milliseconds after which the idle runtime call sockets are closed.
+const RuntimeCallIdleTimeoutVariable = "runtime-call-idle-timeout"
+
+// Default values for the runtime call socket pool.
+const defaultRuntimeCallPoolSize = 0
+const defaultRuntimeCallIdleTimeout = 60000
+
+// Get the value of an integer component variable.
+func getIntVariable(input cli.Input, name string, preset int) int {
+	v := input.GetVariable(name)
+	if v == "" {
+		return preset
+	}
+
+	value, err := strconv.Atoi(v)
+	if err != nil || value < 0 {
+		log.Warningf(`Invalid value for variable "%s", using default value %d: "%s"`, name, preset, v)
+		return preset
+	}
+	return value
+}
+
+// Creates the socket pool for the runtime calls configured with the component variables.
+//
+// The result is nil when the pool is disabled.
+func newCallPool(input cli.Input) *runtime.Pool {
+	size := getIntVariable(input, RuntimeCallPoolSizeVariable, defaultRuntimeCallPoolSize)
+	if size == 0 {
+		return nil
+	}
+
+	idleTimeout := getIntVariable(input, RuntimeCallIdleTimeoutVariable, defaultRuntimeCallIdleTimeout)
+	pool, err := runtime.NewPool(size, time.Duration(idleTimeout)*time.Millisecond)
+	if err != nil {
+		log.RuntimeCall.Errorf("Failed to create the runtime call socket pool: %v", err)
+		return nil
+	}
+
+	log.RuntimeCall.Debugf("Runtime call socket pool enabled with %d sockets per address", size)
+	return pool
+}
+
+// Convert a duration to milliseconds.
+//
+// Durations under one millisecond are rounded up so they are not considered missing.
+func durationToMilliseconds(d time.Duration) uint {
+	if d <= 0 {
+		return 0
+	} else if d < time.Millisecond {
+		return 1
+	}
+	return uint(d.Milliseconds())
+}
+
 type callResult struct {
 	ReturnValue interface{}
 	Transport   *payload.Transport
@@ -27,20 +93,25 @@ type callResult struct {
 }
 
 func call(
+	pool *runtime.Pool,
 	stop <-chan struct{},
 	address string,
 	action string,
 	callee []string,
+	gateway string,
+	callID string,
 	transport *payload.Transport,
 	params []*Param,
 	files []File,
 	tcp bool,
 	timeout uint,
+	codec codec.Codec,
 ) (<-chan callResult, error) {
 	// Create the command payload arguments
 	args := payload.CommandArguments{Transport: transport}
 	args.SetAction(action)
 	args.SetCallee(callee)
+	args.Gateway = gateway
 
 	if params != nil {
 		args.Params = paramsToPayload(params)
@@ -53,8 +124,9 @@ func call(
 	// Create the command payload for the call
 	command := payload.NewCommand("runtime-call", "service")
 	command.Command.Arguments = &args
+	command.Meta.CallID = callID
 
-	message, err := msgpack.Encode(command)
+	message, err := codec.Encode(command)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to serialize the runtime call payload: %v", err)
 	}
@@ -65,7 +137,19 @@ func call(
 		// NOTE: Run-time calls are made to the server address where the caller is runnning
 		//       and NOT directly to the service we wish to call. The KUSANAGI framework
 		//       takes care of the call logic for us to keep consistency between all the SDKs.
-		reply, duration, err := runtime.Call(stop, protocol.SocketAddress(address, tcp), message, timeout)
+		var (
+			reply    *payload.Reply
+			duration time.Duration
+			err      error
+		)
+
+		// Reuse the sockets from the pool when it is enabled
+		if pool != nil {
+			reply, duration, err = pool.Call(stop, protocol.SocketAddress(address, tcp), message, timeout, codec)
+		} else {
+			reply, duration, err = runtime.Call(stop, protocol.SocketAddress(address, tcp), message, timeout, codec)
+		}
+
 		if err != nil {
 			c <- callResult{Duration: duration, Error: err}
 		} else if err := reply.Error; err != nil {
@@ -76,7 +160,7 @@ func call(
 		} else {
 			c <- callResult{
 				Duration:    duration,
-				ReturnValue: reply.GetReturnValue(),
+				ReturnValue: datatypes.Normalize(reply.GetReturnValue(), ""),
 				Transport:   reply.GetTransport(),
 			}
 		}
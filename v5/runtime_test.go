@@ -0,0 +1,22 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+func TestRuntimeCallPoolIsDisabledByDefault(t *testing.T) {
+	if pool := newCallPool(cli.Input{}); pool != nil {
+		pool.Close()
+		t.Error("expected the runtime call socket pool to be disabled by default")
+	}
+}
@@ -0,0 +1,115 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
+)
+
+// SagaStepParam is the name of the parameter added to the transactions of a saga
+// step that contains the step name, so the commit and rollback actions can be correlated.
+const SagaStepParam = "saga_step"
+
+// Saga creates a builder to register the commit and rollback transactions of a saga step.
+//
+// The transactions are registered together when SagaStep.Register() is called,
+// and only when both the commit and the rollback actions are defined.
+//
+// step: The name of the saga step.
+func (a *Action) Saga(step string) *SagaStep {
+	return &SagaStep{action: a, step: step}
+}
+
+// SagaStep registers the paired commit and rollback transactions for a step of a saga.
+type SagaStep struct {
+	action         *Action
+	step           string
+	params         []*Param
+	commit         string
+	commitParams   []*Param
+	rollback       string
+	rollbackParams []*Param
+}
+
+// WithParams adds parameters to both the commit and the rollback transactions.
+//
+// params: The parameters to share.
+func (s *SagaStep) WithParams(params ...*Param) *SagaStep {
+	s.params = append(s.params, params...)
+	return s
+}
+
+// OnCommit sets the action to call when the request succeeds.
+//
+// action: The action name.
+// params: Optional list of parameters.
+func (s *SagaStep) OnCommit(action string, params []*Param) *SagaStep {
+	s.commit = action
+	s.commitParams = params
+	return s
+}
+
+// OnRollback sets the action to call to compensate the step when the request fails.
+//
+// action: The action name.
+// params: Optional list of parameters.
+func (s *SagaStep) OnRollback(action string, params []*Param) *SagaStep {
+	s.rollback = action
+	s.rollbackParams = params
+	return s
+}
+
+// Register adds the commit and rollback transactions of the step to the transport.
+//
+// The transactions include the shared parameters and a parameter with the step name.
+func (s *SagaStep) Register() (*Action, error) {
+	if s.step == "" {
+		return nil, fmt.Errorf("The saga step name is empty")
+	} else if s.commit == "" {
+		return nil, fmt.Errorf(`The commit action is not defined for saga step: "%s"`, s.step)
+	} else if s.rollback == "" {
+		return nil, fmt.Errorf(`The rollback action is not defined for saga step: "%s"`, s.step)
+	}
+
+	stepParam, err := newParam(SagaStepParam, s.step, datatypes.String, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.action.Commit(s.commit, s.getParams(stepParam, s.commitParams)); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.action.Rollback(s.rollback, s.getParams(stepParam, s.rollbackParams)); err != nil {
+		return nil, err
+	}
+
+	return s.action, nil
+}
+
+// Get the parameters for one of the transactions of the step.
+//
+// The transaction parameters have priority over the shared parameters with the same name.
+func (s *SagaStep) getParams(stepParam *Param, params []*Param) []*Param {
+	names := make(map[string]bool, len(params))
+	for _, p := range params {
+		names[p.GetName()] = true
+	}
+
+	result := append([]*Param{}, params...)
+	for _, p := range append(s.params, stepParam) {
+		if !names[p.GetName()] {
+			names[p.GetName()] = true
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Get the parameter values of a transaction.
+func getSagaTestParams(trx payload.Transaction) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, p := range trx.Params {
+		values[p.Name] = p.Value
+	}
+	return values
+}
+
+func TestSagaRegister(t *testing.T) {
+	a := newTestAction("orders", "1.0.0", "create", nil)
+	shared, _ := a.NewParam("order_id", "1", payload.TypeString)
+	reason, _ := a.NewParam("reason", "failed", payload.TypeString)
+	override, _ := a.NewParam("order_id", "2", payload.TypeString)
+
+	_, err := a.Saga("reserve").
+		WithParams(shared).
+		OnCommit("confirm", nil).
+		OnRollback("cancel", []*Param{reason, override}).
+		Register()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions := a.reply.Command.Result.Transport.Transactions
+	commits := transactions.Get(payload.TransactionCommit)
+	rollbacks := transactions.Get(payload.TransactionRollback)
+	if len(commits) != 1 || len(rollbacks) != 1 {
+		t.Fatalf("expected one commit and one rollback, got %v", transactions)
+	}
+
+	if commits[0].Action != "confirm" || rollbacks[0].Action != "cancel" {
+		t.Errorf("unexpected transaction actions: %s, %s", commits[0].Action, rollbacks[0].Action)
+	}
+
+	params := getSagaTestParams(commits[0])
+	if params[SagaStepParam] != "reserve" || params["order_id"] != "1" {
+		t.Errorf("unexpected commit params: %v", params)
+	}
+
+	// The transaction params have priority over the shared params
+	params = getSagaTestParams(rollbacks[0])
+	if params[SagaStepParam] != "reserve" || params["order_id"] != "2" || params["reason"] != "failed" {
+		t.Errorf("unexpected rollback params: %v", params)
+	}
+}
+
+func TestSagaRegisterErrors(t *testing.T) {
+	a := newTestAction("orders", "1.0.0", "create", nil)
+	steps := map[string]*SagaStep{
+		"no name":     a.Saga("").OnCommit("confirm", nil).OnRollback("cancel", nil),
+		"no commit":   a.Saga("reserve").OnRollback("cancel", nil),
+		"no rollback": a.Saga("reserve").OnCommit("confirm", nil),
+	}
+
+	for name, step := range steps {
+		if _, err := step.Register(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if transactions := a.reply.Command.Result.Transport.Transactions; len(transactions) != 0 {
+		t.Errorf("expected no transactions, got %v", transactions)
+	}
+}
@@ -0,0 +1,99 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// SamplingRateVariable is the name of the component variable that sets the percentage
+// of the requests that are sampled, as a number between 0 and 100.
+//
+// Sampled requests write the log messages of all levels, record the transport audit
+// and log a timing breakdown. The decision is made using the request ID, so all the
+// components that handle a request take the same decision for the same rate.
+const SamplingRateVariable = "sampling-rate"
+
+// SamplingPropertyVariable is the name of the component variable with the name of a
+// transport property that samples the requests where the property value is true.
+const SamplingPropertyVariable = "sampling-property"
+
+// Precision of the sampling rate, to support rates with two decimals.
+const samplingScale = 10000
+
+// Get the sampling rate of a component scaled to an integer.
+func getSamplingRate(input cli.Input) uint32 {
+	value := strings.TrimSpace(input.GetVariable(SamplingRateVariable))
+	if value == "" {
+		return 0
+	}
+
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate <= 0 {
+		return 0
+	} else if rate >= 100 {
+		return samplingScale
+	}
+	return uint32(rate * samplingScale / 100)
+}
+
+// Check if a request must be sampled.
+//
+// input: The CLI input with the sampling variables.
+// rid: The request ID.
+// command: The command payload of the request.
+func isRequestSampled(input cli.Input, rid string, command payload.Command) bool {
+	if name := input.GetVariable(SamplingPropertyVariable); name != "" {
+		if t := command.GetTransport(); t != nil {
+			if enabled, _ := strconv.ParseBool(t.Meta.Properties[name]); enabled {
+				return true
+			}
+		}
+	}
+
+	rate := getSamplingRate(input)
+	if rate == 0 {
+		return false
+	} else if rate >= samplingScale {
+		return true
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(rid))
+	return h.Sum32()%samplingScale < rate
+}
+
+// Log the timing breakdown of a sampled request.
+//
+// logger: The request logger.
+// received: The time when the request was received.
+// started: The time when the request processing started.
+func logSampledTiming(logger log.RequestLogger, received, started time.Time) {
+	finished := time.Now()
+	logger.Debugf(
+		"Sampled request timing: preparation=%s processing=%s total=%s",
+		started.Sub(received),
+		finished.Sub(started),
+		finished.Sub(received),
+	)
+}
+
+// IsSampled checks if the current request is sampled.
+//
+// Sampled requests write the log messages of all levels and record the transport audit.
+func (a *Api) IsSampled() bool {
+	return a.state.sampled
+}
@@ -0,0 +1,93 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create a command with transport properties for the sampling tests.
+func newSamplingTestCommand(properties map[string]string) payload.Command {
+	command := payload.NewCommand("read", "service")
+	command.Command.Arguments = &payload.CommandArguments{Transport: &payload.Transport{
+		Meta: payload.TransportMeta{Properties: properties},
+	}}
+	return command
+}
+
+func TestGetSamplingRate(t *testing.T) {
+	cases := map[string]uint32{
+		"":        0,
+		"invalid": 0,
+		"-5":      0,
+		"0":       0,
+		"12.5":    1250,
+		"0.01":    1,
+		"100":     samplingScale,
+		"150":     samplingScale,
+	}
+
+	for value, expected := range cases {
+		setTestVariable(t, SamplingRateVariable, value)
+		if rate := getSamplingRate(cli.Input{}); rate != expected {
+			t.Errorf("%q: expected %d, got %d", value, expected, rate)
+		}
+	}
+}
+
+func TestIsRequestSampled(t *testing.T) {
+	command := newSamplingTestCommand(nil)
+	if isRequestSampled(cli.Input{}, "rid", command) {
+		t.Error("expected the sampling to be disabled by default")
+	}
+
+	setTestVariable(t, SamplingRateVariable, "100")
+	if !isRequestSampled(cli.Input{}, "rid", command) {
+		t.Error("expected all the requests to be sampled")
+	}
+
+	// The decision depends only on the request ID
+	setTestVariable(t, SamplingRateVariable, "50")
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		rid := fmt.Sprintf("rid-%d", i)
+		decision := isRequestSampled(cli.Input{}, rid, command)
+		if decision != isRequestSampled(cli.Input{}, rid, command) {
+			t.Fatalf("expected the same decision for the request %s", rid)
+		}
+		if decision {
+			sampled++
+		}
+	}
+	if sampled < 400 || sampled > 600 {
+		t.Errorf("expected about half of the requests to be sampled, got %d", sampled)
+	}
+}
+
+func TestIsRequestSampledByProperty(t *testing.T) {
+	setTestVariable(t, SamplingPropertyVariable, "debug")
+
+	if !isRequestSampled(cli.Input{}, "rid", newSamplingTestCommand(map[string]string{"debug": "true"})) {
+		t.Error("expected the request with the property to be sampled")
+	}
+	if isRequestSampled(cli.Input{}, "rid", newSamplingTestCommand(map[string]string{"debug": "no"})) {
+		t.Error("expected the request with a false property not to be sampled")
+	}
+
+	// Middleware requests don't have a transport
+	command := payload.NewCommand("request", "middleware")
+	command.Command.Arguments = &payload.CommandArguments{Request: &payload.HTTPRequest{Method: "GET"}}
+	if isRequestSampled(cli.Input{}, "rid", command) {
+		t.Error("expected the request without transport not to be sampled")
+	}
+}
@@ -0,0 +1,147 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Sanitizer cleans a parameter value before the action callback is called.
+//
+// The result is the value to use for the parameter.
+type Sanitizer func(value interface{}) interface{}
+
+// StringSanitizer creates a sanitizer that cleans the string values.
+//
+// The function is applied to the string values and to the strings
+// in array values. Values of other types are not changed.
+//
+// clean: The function to clean the strings.
+func StringSanitizer(clean func(string) string) Sanitizer {
+	var sanitizer Sanitizer
+	sanitizer = func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case string:
+			return clean(v)
+		case []interface{}:
+			result := make([]interface{}, len(v))
+			for i, item := range v {
+				result[i] = sanitizer(item)
+			}
+			return result
+		}
+		return value
+	}
+	return sanitizer
+}
+
+// TrimSanitizer removes the leading and trailing white space from the string values.
+var TrimSanitizer = StringSanitizer(strings.TrimSpace)
+
+// LowercaseSanitizer changes the string values to lower case.
+var LowercaseSanitizer = StringSanitizer(strings.ToLower)
+
+// StripControlCharsSanitizer removes the control characters from the string values.
+var StripControlCharsSanitizer = StringSanitizer(func(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+})
+
+// Mutex to guard the registered format sanitizers.
+var sanitizersMutex sync.RWMutex
+
+// Sanitizers by parameter format name.
+var formatSanitizers = make(map[string][]Sanitizer)
+
+// RegisterFormatSanitizers registers the sanitizers for a parameter format.
+//
+// The sanitizers are applied in order to the values of the parameters that have the
+// format defined in their schema, before the format is validated. The sanitizers
+// previously registered for the same format are replaced.
+//
+// name: The format name, for example "email".
+// sanitizers: The sanitizers for the format.
+func RegisterFormatSanitizers(name string, sanitizers ...Sanitizer) {
+	sanitizersMutex.Lock()
+	defer sanitizersMutex.Unlock()
+
+	if len(sanitizers) == 0 {
+		delete(formatSanitizers, name)
+	} else {
+		formatSanitizers[name] = sanitizers
+	}
+}
+
+// Get the sanitizers for a parameter format.
+func getFormatSanitizers(name string) []Sanitizer {
+	sanitizersMutex.RLock()
+	defer sanitizersMutex.RUnlock()
+
+	return formatSanitizers[name]
+}
+
+// SanitizeParam adds sanitizers for a parameter of an action.
+//
+// The sanitizers are applied in order before the action callback is called,
+// after the sanitizers registered for the format of the parameter.
+//
+// action: The action name.
+// param: The parameter name.
+// sanitizers: The sanitizers to apply.
+func (s *Service) SanitizeParam(action, param string, sanitizers ...Sanitizer) *Service {
+	if s.sanitizers == nil {
+		s.sanitizers = make(map[string]map[string][]Sanitizer)
+	}
+	if s.sanitizers[action] == nil {
+		s.sanitizers[action] = make(map[string][]Sanitizer)
+	}
+
+	s.sanitizers[action][param] = append(s.sanitizers[action][param], sanitizers...)
+	return s
+}
+
+// Get the sanitizers for a parameter.
+//
+// The format sanitizers are applied before the sanitizers registered in the service.
+func (a *Action) getParamSanitizers(service *Service, schema *ActionSchema, name string) []Sanitizer {
+	var sanitizers []Sanitizer
+	if schema != nil {
+		if paramSchema, err := schema.GetParamSchema(name); err == nil && paramSchema.GetFormat() != "" {
+			sanitizers = append(sanitizers, getFormatSanitizers(paramSchema.GetFormat())...)
+		}
+	}
+	return append(sanitizers, service.sanitizers[a.GetActionName()][name]...)
+}
+
+// Apply the sanitizers to the action parameters.
+func (a *Action) sanitizeParams(service *Service) {
+	var actionSchema *ActionSchema
+	if schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion()); err == nil {
+		actionSchema, _ = schema.GetActionSchema(a.GetActionName())
+	}
+
+	for name, values := range a.params {
+		sanitizers := a.getParamSanitizers(service, actionSchema, name)
+		if len(sanitizers) == 0 {
+			continue
+		}
+
+		for i := range values {
+			for _, sanitize := range sanitizers {
+				values[i].Value = sanitize(values[i].Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestStringSanitizers(t *testing.T) {
+	cases := []struct {
+		name      string
+		sanitizer Sanitizer
+		value     interface{}
+		expected  interface{}
+	}{
+		{"trim", TrimSanitizer, "  jane \n", "jane"},
+		{"lowercase", LowercaseSanitizer, "Jane@Example.COM", "jane@example.com"},
+		{"control chars", StripControlCharsSanitizer, "ja\x00ne\t\r\n", "jane"},
+		{"array", TrimSanitizer, []interface{}{" a ", []interface{}{" b "}, 1}, []interface{}{"a", []interface{}{"b"}, 1}},
+		{"other types", TrimSanitizer, 42, 42},
+		{"objects", TrimSanitizer, map[string]interface{}{"a": " b "}, map[string]interface{}{"a": " b "}},
+	}
+
+	for _, c := range cases {
+		if value := c.sanitizer(c.value); !reflect.DeepEqual(value, c.expected) {
+			t.Errorf("%s: expected %#v, got %#v", c.name, c.expected, value)
+		}
+	}
+}
+
+func TestRegisterFormatSanitizers(t *testing.T) {
+	RegisterFormatSanitizers("test-email", TrimSanitizer, LowercaseSanitizer)
+	if sanitizers := getFormatSanitizers("test-email"); len(sanitizers) != 2 {
+		t.Errorf("expected the format sanitizers, got %d", len(sanitizers))
+	}
+
+	// Registering the format again replaces the sanitizers
+	RegisterFormatSanitizers("test-email", TrimSanitizer)
+	if sanitizers := getFormatSanitizers("test-email"); len(sanitizers) != 1 {
+		t.Errorf("expected the sanitizers to be replaced, got %d", len(sanitizers))
+	}
+
+	RegisterFormatSanitizers("test-email")
+	if sanitizers := getFormatSanitizers("test-email"); sanitizers != nil {
+		t.Errorf("expected the sanitizers to be removed, got %d", len(sanitizers))
+	}
+}
+
+func TestActionSanitizeParams(t *testing.T) {
+	RegisterFormatSanitizers("test-email", TrimSanitizer, LowercaseSanitizer)
+	t.Cleanup(func() {
+		RegisterFormatSanitizers("test-email")
+	})
+
+	service := NewService()
+	service.SanitizeParam("create", "email", StringSanitizer(func(s string) string {
+		return strings.TrimSuffix(s, ".invalid")
+	}))
+	service.SanitizeParam("create", "name", TrimSanitizer)
+	service.SanitizeParam("update", "code", LowercaseSanitizer)
+
+	s := newTestState("users", "1.0.0", "create", nil)
+	s.command.Command.Arguments.Params = payload.ActionParams{
+		{Name: "email", Value: " Jane@Example.COM.INVALID ", Type: payload.TypeString},
+		{Name: "name", Value: " Jane ", Type: payload.TypeString},
+		{Name: "code", Value: " ABC ", Type: payload.TypeString},
+	}
+	s.schemas = payload.Mapping{"users": {"1.0.0": payload.Schema{Actions: map[string]payload.ActionSchema{
+		"create": {Params: map[string]payload.ParamSchema{"email": {Format: "test-email"}}},
+	}}}}
+
+	action := newAction(service, s)
+	action.sanitizeParams(service)
+
+	// The format sanitizers run before the sanitizers of the service
+	expected := map[string]interface{}{
+		"email": "jane@example.com",
+		"name":  "Jane",
+		"code":  " ABC ",
+	}
+	for name, value := range expected {
+		if p := action.GetParam(name); p.GetValue() != value {
+			t.Errorf("%s: expected %q, got %q", name, value, p.GetValue())
+		}
+	}
+
+	// The command parameters are not changed
+	if value := s.command.Command.Arguments.Params[1].Value; value != " Jane " {
+		t.Errorf("expected the command parameter without changes, got %q", value)
+	}
+}
@@ -11,6 +11,7 @@ package kusanagi
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/json"
@@ -21,6 +22,12 @@ import (
 // ExecutionTimeout defines the number of milliseconds to wait by default when an action is executed.
 const ExecutionTimeout = 30000
 
+// SchemaNotFoundError is returned when the schema for a service version is not available.
+//
+// The error contains the requested name and version, and the versions of the service that
+// exist in the mapping, so it can be inspected using errors.As() to pick an alternative.
+type SchemaNotFoundError = payload.SchemaNotFoundError
+
 // ServiceSchema contains the schema definition for a service of a specific version.
 type ServiceSchema struct {
 	name    string
@@ -72,6 +79,21 @@ func (s ServiceSchema) GetActionSchema(name string) (*ActionSchema, error) {
 	return nil, fmt.Errorf(`Cannot resolve schema for "%s" (%s) action: %s`, s.GetName(), s.GetVersion(), name)
 }
 
+// HasFallback checks if a fallback is defined for the action.
+func (s ActionSchema) HasFallback() bool {
+	return s.payload.Fallback != nil
+}
+
+// GetFallback returns the fallback defined for the action.
+//
+// The result is nil when the action has no fallback.
+func (s ActionSchema) GetFallback() *FallbackSchema {
+	if s.payload.Fallback == nil {
+		return nil
+	}
+	return &FallbackSchema{*s.payload.Fallback}
+}
+
 // GetHTTPSchema returns the HTTP schema.
 func (s ServiceSchema) GetHTTPSchema() *HTTPServiceSchema {
 	return &HTTPServiceSchema{s.payload.HTTP}
@@ -117,6 +139,35 @@ func (s ActionSchema) IsDeprecated() bool {
 	return *s.payload.Deprecated
 }
 
+// GetSunset returns the date after which a deprecated action is no longer available.
+//
+// The date is read from the action "sunset" schema value, or from an action tag with
+// the "sunset:" prefix. The date can be either a date like "2006-01-02" or an RFC3339
+// date-time. The result is nil when the action has no sunset date.
+func (s ActionSchema) GetSunset() (*time.Time, error) {
+	value := s.payload.Sunset
+	if value == "" {
+		for _, tag := range s.payload.Tags {
+			if strings.HasPrefix(tag, sunsetTagPrefix) {
+				value = strings.TrimPrefix(tag, sunsetTagPrefix)
+				break
+			}
+		}
+	}
+
+	if value == "" {
+		return nil, nil
+	}
+
+	sunset, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if sunset, err = time.Parse(sunsetDateLayout, value); err != nil {
+			return nil, fmt.Errorf(`Invalid sunset date for action "%s": %s`, s.GetName(), value)
+		}
+	}
+	return &sunset, nil
+}
+
 // IsCollection checks if the action returns a collection of entities.
 func (s ActionSchema) IsCollection() bool {
 	if s.payload.Collection == nil {
@@ -181,6 +232,10 @@ func (s ActionSchema) GetEntity() *Entity {
 		entity.Validate = schema.Validate
 		entity.Field = copyFields(schema.Field)
 		entity.Fields = copyObjectFields(schema.Fields)
+		entity.Name = schema.Name
+		if schema.Primarykey != "" {
+			entity.Primarykey = schema.Primarykey
+		}
 	}
 	return entity
 }
@@ -206,18 +261,67 @@ func (s ActionSchema) GetRelations() (relations [][]string) {
 	return relations
 }
 
-// GetCalls returns the run-time service calls.
+// Check if a version matches the version of a call defined in the schema.
 //
-// Each call item is a list containing the service name, the service version and the action name.
-func (s ActionSchema) GetCalls() (calls [][]string) {
-	for _, c := range s.payload.Calls {
-		call := make([]string, len(c))
-		copy(c, call)
-		calls = append(calls, call)
+// The call version can be a fixed version, a wildcard pattern or a version range.
+// The version can also be a wildcard pattern that matches the call version.
+func matchCallVersion(callVersion, version string) bool {
+	return callVersion == version || semver.New(callVersion).Match(version) || semver.New(version).Match(callVersion)
+}
+
+// CallTarget describes a service action that can be called from an action.
+type CallTarget struct {
+	// Gateway contains the public address of the gateway for remote calls.
+	Gateway string
+	Service string
+	Version string
+	Action  string
+}
+
+// Converts the service, version and action values of a schema call to a call target.
+func schemaCallToTarget(call []string) CallTarget {
+	var t CallTarget
+	if len(call) > 0 {
+		t.Service = call[0]
+	}
+	if len(call) > 1 {
+		t.Version = call[1]
+	}
+	if len(call) > 2 {
+		t.Action = call[2]
+	}
+	return t
+}
+
+// Converts a list of call targets to a list of string slices.
+func callTargetsToSlices(targets []CallTarget, remote bool) (calls [][]string) {
+	for _, t := range targets {
+		if remote {
+			calls = append(calls, []string{t.Gateway, t.Service, t.Version, t.Action})
+		} else {
+			calls = append(calls, []string{t.Service, t.Version, t.Action})
+		}
 	}
 	return calls
 }
 
+// GetCallTargets returns the run-time service calls.
+func (s ActionSchema) GetCallTargets() (targets []CallTarget) {
+	for _, c := range s.payload.Calls {
+		targets = append(targets, schemaCallToTarget(c))
+	}
+	return targets
+}
+
+// GetCalls returns the run-time service calls.
+//
+// Each call item is a list containing the service name, the service version and the action name.
+//
+// Deprecated: Use GetCallTargets instead.
+func (s ActionSchema) GetCalls() [][]string {
+	return callTargetsToSlices(s.GetCallTargets(), false)
+}
+
 // HasCall checks if a run-time call exists for a service.
 //
 // name: The service name.
@@ -233,7 +337,7 @@ func (s ActionSchema) HasCall(name, version, action string) bool {
 			continue
 		}
 
-		if version != "" && call[1] != "*" && call[1] != version && !semver.New(version).Match(call[1]) {
+		if version != "" && call[1] != "*" && !matchCallVersion(call[1], version) {
 			continue
 		}
 
@@ -251,16 +355,21 @@ func (s ActionSchema) HasCalls() bool {
 	return len(s.payload.Calls) > 0
 }
 
+// GetDeferCallTargets returns the deferred service calls.
+func (s ActionSchema) GetDeferCallTargets() (targets []CallTarget) {
+	for _, c := range s.payload.DeferredCalls {
+		targets = append(targets, schemaCallToTarget(c))
+	}
+	return targets
+}
+
 // GetDeferCalls returns the deferred service calls.
 //
 // Each call item is a list containing the service name, the service version and the action name.
-func (s ActionSchema) GetDeferCalls() (calls [][]string) {
-	for _, c := range s.payload.DeferredCalls {
-		call := make([]string, len(c))
-		copy(c, call)
-		calls = append(calls, call)
-	}
-	return calls
+//
+// Deprecated: Use GetDeferCallTargets instead.
+func (s ActionSchema) GetDeferCalls() [][]string {
+	return callTargetsToSlices(s.GetDeferCallTargets(), false)
 }
 
 // HasDeferCall checks if a deferred call exists for a service.
@@ -278,7 +387,7 @@ func (s ActionSchema) HasDeferCall(name, version, action string) bool {
 			continue
 		}
 
-		if version != "" && call[1] != "*" && call[1] != version && !semver.New(version).Match(call[1]) {
+		if version != "" && call[1] != "*" && !matchCallVersion(call[1], version) {
 			continue
 		}
 
@@ -296,17 +405,29 @@ func (s ActionSchema) HasDeferCalls() bool {
 	return len(s.payload.DeferredCalls) > 0
 }
 
+// GetRemoteCallTargets returns the remote service calls.
+//
+// The gateway of each call target contains the public address of the gateway.
+func (s ActionSchema) GetRemoteCallTargets() (targets []CallTarget) {
+	for _, c := range s.payload.RemoteCalls {
+		var t CallTarget
+		if len(c) > 0 {
+			t = schemaCallToTarget(c[1:])
+			t.Gateway = c[0]
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
 // GetRemoteCalls returns the remote service calls.
 //
 // Each call item is a list containing the public address of the gateway,
 // the service name, the service version and the action name.
-func (s ActionSchema) GetRemoteCalls() (calls [][]string) {
-	for _, c := range s.payload.RemoteCalls {
-		call := make([]string, len(c))
-		copy(c, call)
-		calls = append(calls, call)
-	}
-	return calls
+//
+// Deprecated: Use GetRemoteCallTargets instead.
+func (s ActionSchema) GetRemoteCalls() [][]string {
+	return callTargetsToSlices(s.GetRemoteCallTargets(), true)
 }
 
 // HasRemoteCall checks if a remote call exists for a service.
@@ -329,7 +450,7 @@ func (s ActionSchema) HasRemoteCall(address, name, version, action string) bool
 			continue
 		}
 
-		if version != "" && call[2] != "*" && call[2] != version && !semver.New(version).Match(call[2]) {
+		if version != "" && call[2] != "*" && !matchCallVersion(call[2], version) {
 			continue
 		}
 
@@ -387,6 +508,20 @@ func (s ActionSchema) GetParamSchema(name string) (*ParamSchema, error) {
 	return &ParamSchema{schema}, nil
 }
 
+// ResolveParamLocation returns the location of a parameter in the HTTP requests to the gateway.
+//
+// The location defined for the parameter is used when available, otherwise
+// the default location of the action is used.
+//
+// name: The parameter name.
+func (s ActionSchema) ResolveParamLocation(name string) (ParamLocation, error) {
+	schema, ok := s.payload.Params[name]
+	if !ok {
+		return "", fmt.Errorf(`Cannot resolve schema for parameter: "%s"`, name)
+	}
+	return resolveParamLocation(s.payload.HTTP.Input, schema.HTTP.Input), nil
+}
+
 // GetFiles returns the file parameter names defined for the action.
 func (s ActionSchema) GetFiles() (files []string) {
 	for name := range s.payload.Files {
@@ -441,6 +576,31 @@ func (s ActionSchema) GetHTTPSchema() *HTTPActionSchema {
 	return &HTTPActionSchema{s.payload.HTTP}
 }
 
+// ParamLocation is the location of a parameter in the HTTP requests.
+type ParamLocation string
+
+// Locations of the parameters in the HTTP requests.
+const (
+	ParamLocationQuery  ParamLocation = "query"
+	ParamLocationPath   ParamLocation = "path"
+	ParamLocationHeader ParamLocation = "header"
+	ParamLocationBody   ParamLocation = "body"
+	ParamLocationForm   ParamLocation = "form-data"
+)
+
+// Get the location of a parameter from the action and parameter inputs.
+//
+// actionInput: The default location for the action parameters.
+// paramInput: The location defined for the parameter.
+func resolveParamLocation(actionInput, paramInput string) ParamLocation {
+	if paramInput != "" {
+		return ParamLocation(paramInput)
+	} else if actionInput != "" {
+		return ParamLocation(actionInput)
+	}
+	return ParamLocationQuery
+}
+
 // HTTPActionSchema contains the HTTP schema definition for the action.
 type HTTPActionSchema struct {
 	payload payload.HTTPActionSchema
@@ -472,10 +632,10 @@ func (s HTTPActionSchema) GetMethod() string {
 
 // GetInput returns the default HTTP parameter location.
 func (s HTTPActionSchema) GetInput() string {
-	if s.payload.Method == "" {
-		return "query"
+	if s.payload.Input == "" {
+		return string(ParamLocationQuery)
 	}
-	return s.payload.Method
+	return s.payload.Input
 }
 
 // GetBody returns the expected MIME type of the HTTP request body
@@ -487,6 +647,29 @@ func (s HTTPActionSchema) GetBody() string {
 	return strings.Join(s.payload.Body, ",")
 }
 
+// GetBodyTypes returns the list of expected MIME types of the HTTP request body.
+func (s HTTPActionSchema) GetBodyTypes() []string {
+	if len(s.payload.Body) == 0 {
+		return []string{"text/plain"}
+	}
+	return append([]string{}, s.payload.Body...)
+}
+
+// HasBodyType checks if a MIME type is expected for the HTTP request body.
+//
+// Media type parameters are ignored during the check.
+//
+// mimeType: The MIME type, for example "application/json".
+func (s HTTPActionSchema) HasBodyType(mimeType string) bool {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+	for _, t := range s.GetBodyTypes() {
+		if strings.ToLower(strings.TrimSpace(strings.SplitN(t, ";", 2)[0])) == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
 func copyFields(schemas []payload.FieldSchema) (fields []Field) {
 	for _, schema := range schemas {
 		fields = append(fields, Field{
@@ -524,6 +707,47 @@ func (e Entity) IsEmpty() bool {
 	return len(e.Field) == 0 && len(e.Fields) == 0
 }
 
+// Get a copy of the entity data that only contains the fields of the entity definition.
+//
+// The primary key is always kept. The data is returned as is when the definition has no fields.
+//
+// data: The entity data.
+func (e Entity) filter(data map[string]interface{}) map[string]interface{} {
+	if data == nil || e.IsEmpty() {
+		return data
+	}
+
+	entity := filterEntityFields(data, e.Field, e.Fields)
+	if v, ok := data[e.Primarykey]; ok {
+		entity[e.Primarykey] = v
+	}
+	return entity
+}
+
+// Copy the values of the data that are defined as fields or object fields.
+func filterEntityFields(data map[string]interface{}, fields []Field, objectFields []ObjectField) map[string]interface{} {
+	entity := make(map[string]interface{})
+	for _, f := range fields {
+		if v, ok := data[f.Name]; ok {
+			entity[f.Name] = v
+		}
+	}
+
+	for _, f := range objectFields {
+		v, ok := data[f.Name]
+		if !ok {
+			continue
+		}
+
+		// Object values that are not maps are kept as they are
+		if object, ok := v.(map[string]interface{}); ok && (len(f.Field) > 0 || len(f.Fields) > 0) {
+			v = filterEntityFields(object, f.Field, f.Fields)
+		}
+		entity[f.Name] = v
+	}
+	return entity
+}
+
 // Field defines an entity field.
 type Field struct {
 	Name     string
@@ -578,6 +802,48 @@ func (s ParamSchema) GetFormat() string {
 	return s.payload.Format
 }
 
+// HasFormatValidator checks if a validator is registered for the parameter format.
+func (s ParamSchema) HasFormatValidator() bool {
+	return s.payload.Format != "" && getFormatValidator(s.payload.Format) != nil
+}
+
+// ValidateFormat validates a value using the validator registered for the parameter format.
+//
+// Values are considered valid when there is no validator registered for the format.
+//
+// value: The parameter value.
+func (s ParamSchema) ValidateFormat(value interface{}) error {
+	if !s.HasFormatValidator() {
+		return nil
+	}
+
+	if err := getFormatValidator(s.payload.Format)(value); err != nil {
+		return fmt.Errorf(`Invalid "%s" value: %v`, s.payload.Format, err)
+	}
+	return nil
+}
+
+// ValidateSize validates the size of a binary parameter value.
+//
+// The minimum and maximum values of the schema are used as the limits of the size in bytes.
+//
+// value: The binary value.
+func (s ParamSchema) ValidateSize(value []byte) error {
+	size := float64(len(value))
+	if max := s.payload.Max; max != nil {
+		if size > *max || (s.payload.ExclusiveMax && size == *max) {
+			return fmt.Errorf("The binary value size exceeds the maximum: %d bytes", len(value))
+		}
+	}
+
+	if min := s.payload.Min; min != nil {
+		if size < *min || (s.payload.ExclusiveMin && size == *min) {
+			return fmt.Errorf("The binary value size is below the minimum: %d bytes", len(value))
+		}
+	}
+	return nil
+}
+
 // GetArrayFormat returns the format for the parameter if the type property is set to "array".
 //
 // Formats:
@@ -772,6 +1038,41 @@ func (s FileSchema) IsExclusiveMin() bool {
 	return s.payload.ExclusiveMin
 }
 
+// IsChecksumRequired checks if the file must have a checksum.
+func (s FileSchema) IsChecksumRequired() bool {
+	if s.payload.Checksum != nil {
+		return *s.payload.Checksum
+	}
+	return false
+}
+
+// GetChecksumAlgorithm returns the algorithm that the file checksum must use.
+//
+// Any supported algorithm can be used when the result is empty.
+func (s FileSchema) GetChecksumAlgorithm() string {
+	return s.payload.Algorithm
+}
+
+// ValidateChecksum checks that a file has the checksum expected by the schema.
+//
+// Only the checksum metadata is validated, the file contents are not read.
+// To check the file contents use File.VerifyChecksum().
+//
+// f: The file to validate.
+func (s FileSchema) ValidateChecksum(f File) error {
+	if !s.IsChecksumRequired() {
+		return nil
+	}
+
+	algorithm, digest := f.GetChecksum()
+	if digest == "" {
+		return fmt.Errorf("The file checksum is required")
+	} else if expected := s.GetChecksumAlgorithm(); expected != "" && !strings.EqualFold(algorithm, expected) {
+		return fmt.Errorf(`The file checksum must use the "%s" algorithm, got "%s"`, expected, algorithm)
+	}
+	return nil
+}
+
 // GetHTTPSchema returns the HTTP schema.
 func (s FileSchema) GetHTTPSchema() *HTTPFileSchema {
 	return &HTTPFileSchema{s.payload.HTTP}
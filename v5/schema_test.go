@@ -0,0 +1,108 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestActionSchemaCallTargets(t *testing.T) {
+	schema := ActionSchema{"read", payload.ActionSchema{
+		Calls:         [][]string{{"posts", "1.0.0", "list"}},
+		DeferredCalls: [][]string{{"mails", "*", "send"}},
+		RemoteCalls:   [][]string{{"ktp://remote:8080", "users", "2.0.0", "read"}},
+	}}
+
+	cases := []struct {
+		name     string
+		targets  []CallTarget
+		expected CallTarget
+	}{
+		{"calls", schema.GetCallTargets(), CallTarget{"", "posts", "1.0.0", "list"}},
+		{"deferred calls", schema.GetDeferCallTargets(), CallTarget{"", "mails", "*", "send"}},
+		{"remote calls", schema.GetRemoteCallTargets(), CallTarget{"ktp://remote:8080", "users", "2.0.0", "read"}},
+	}
+
+	for _, c := range cases {
+		if len(c.targets) != 1 || c.targets[0] != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, c.targets)
+		}
+	}
+
+	// The deprecated getters return the values of the schema
+	if calls := schema.GetRemoteCalls(); !reflect.DeepEqual(calls, schema.payload.RemoteCalls) {
+		t.Errorf("unexpected remote calls: %v", calls)
+	}
+	if calls := schema.GetDeferCalls(); !reflect.DeepEqual(calls, schema.payload.DeferredCalls) {
+		t.Errorf("unexpected deferred calls: %v", calls)
+	}
+}
+
+func TestActionSchemaHasCall(t *testing.T) {
+	schema := ActionSchema{"read", payload.ActionSchema{
+		Calls:         [][]string{{"posts", "1.0.0", "list"}, {"invalid"}},
+		DeferredCalls: [][]string{{"mails", "*", "send"}},
+	}}
+
+	cases := []struct {
+		name     string
+		exists   bool
+		expected bool
+	}{
+		{"call", schema.HasCall("posts", "1.0.0", "list"), true},
+		{"call without version", schema.HasCall("posts", "", "list"), true},
+		{"call with other version", schema.HasCall("posts", "2.0.0", "list"), false},
+		{"call with other action", schema.HasCall("posts", "1.0.0", "read"), false},
+		{"deferred call", schema.HasDeferCall("mails", "3.1.0", "send"), true},
+		{"missing deferred call", schema.HasDeferCall("posts", "1.0.0", "list"), false},
+	}
+
+	for _, c := range cases {
+		if c.exists != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, c.exists)
+		}
+	}
+}
+
+func TestHTTPActionSchemaGetInput(t *testing.T) {
+	schema := HTTPActionSchema{payload.HTTPActionSchema{Method: "POST", Input: "form-data"}}
+	if input := schema.GetInput(); input != "form-data" {
+		t.Errorf("expected the input location, got %s", input)
+	}
+	if input := (HTTPActionSchema{payload.HTTPActionSchema{Method: "POST"}}).GetInput(); input != "query" {
+		t.Errorf("expected the default location, got %s", input)
+	}
+}
+
+func TestActionSchemaResolveParamLocation(t *testing.T) {
+	schema := ActionSchema{"create", payload.ActionSchema{
+		HTTP: payload.HTTPActionSchema{Input: "form-data"},
+		Params: map[string]payload.ParamSchema{
+			"id":   {Name: "id", HTTP: payload.HTTPParamSchema{Input: "path"}},
+			"name": {Name: "name"},
+		},
+	}}
+
+	cases := map[string]ParamLocation{"id": ParamLocationPath, "name": ParamLocationForm}
+	for name, expected := range cases {
+		if location, err := schema.ResolveParamLocation(name); err != nil || location != expected {
+			t.Errorf("%s: expected %s, got %s %v", name, expected, location, err)
+		}
+	}
+
+	if _, err := schema.ResolveParamLocation("missing"); err == nil {
+		t.Error("expected an error for the missing param")
+	}
+	if location := resolveParamLocation("", ""); location != ParamLocationQuery {
+		t.Errorf("expected the query location by default, got %s", location)
+	}
+}
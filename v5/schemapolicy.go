@@ -0,0 +1,75 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+// SchemaPolicy defines how the actions behave when the schemas required to validate
+// calls, deferred calls, remote calls and return values are missing.
+type SchemaPolicy int
+
+const (
+	// SchemaPolicyStrict fails when the schemas are missing. This is the default policy.
+	SchemaPolicyStrict SchemaPolicy = iota
+	// SchemaPolicyCLIOnlyPermissive skips the validations only when the discovery mapping
+	// is not available, for example when the action runs from the CLI or during the
+	// startup, and fails when the schema of the service or the action is missing from
+	// the mapping.
+	SchemaPolicyCLIOnlyPermissive
+	// SchemaPolicyPermissive writes a warning and skips the validations when the schemas are missing.
+	SchemaPolicyPermissive
+)
+
+// String returns the policy name.
+func (p SchemaPolicy) String() string {
+	switch p {
+	case SchemaPolicyCLIOnlyPermissive:
+		return "cli-only-permissive"
+	case SchemaPolicyPermissive:
+		return "permissive"
+	}
+	return "strict"
+}
+
+func (c *component) SetSchemaPolicy(policy SchemaPolicy) Component {
+	c.schemaPolicy = policy
+	return c
+}
+
+// Get the schemas for the current service and action.
+func (a *Action) getCurrentSchemas() (*ServiceSchema, *ActionSchema, error) {
+	schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actionSchema, err := schema.GetActionSchema(a.GetActionName())
+	if err != nil {
+		return nil, nil, err
+	}
+	return schema, actionSchema, nil
+}
+
+// Check if the validations that require a missing schema can be skipped.
+//
+// A warning is written when the validations are skipped.
+//
+// err: The error returned when the schema was read.
+func (a *Action) skipSchemaValidation(err error) bool {
+	switch a.state.schemaPolicy {
+	case SchemaPolicyPermissive:
+	case SchemaPolicyCLIOnlyPermissive:
+		if a.schemas != nil {
+			return false
+		}
+	default:
+		return false
+	}
+
+	a.logger.Warningf("Schema validation skipped: %v", err)
+	return true
+}
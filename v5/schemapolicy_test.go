@@ -0,0 +1,70 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestSchemaPolicyIsStrictByDefault(t *testing.T) {
+	var policy SchemaPolicy
+	if policy != SchemaPolicyStrict || policy.String() != "strict" {
+		t.Errorf("expected the strict policy, got %s", policy)
+	}
+
+	if c := NewService().base(); c.schemaPolicy != SchemaPolicyStrict {
+		t.Errorf("expected the strict policy for new components, got %s", c.schemaPolicy)
+	}
+}
+
+func TestSchemaPolicySkipsValidation(t *testing.T) {
+	cases := []struct {
+		policy  SchemaPolicy
+		mapping payload.Mapping
+		skip    bool
+	}{
+		{SchemaPolicyStrict, nil, false},
+		{SchemaPolicyStrict, payload.Mapping{}, false},
+		{SchemaPolicyCLIOnlyPermissive, nil, true},
+		{SchemaPolicyCLIOnlyPermissive, payload.Mapping{}, false},
+		{SchemaPolicyPermissive, nil, true},
+		{SchemaPolicyPermissive, payload.Mapping{}, true},
+	}
+
+	for _, c := range cases {
+		s := newTestState("posts", "1.0.0", "list", nil)
+		s.schemas = c.mapping
+		s.schemaPolicy = c.policy
+		action := newAction(NewService(), s)
+
+		_, _, err := action.getCurrentSchemas()
+		if err == nil {
+			t.Fatal("expected an error for the missing schemas")
+		}
+
+		if skip := action.skipSchemaValidation(err); skip != c.skip {
+			t.Errorf("expected %v for the %s policy with mapping %v, got %v", c.skip, c.policy, c.mapping, skip)
+		}
+	}
+}
+
+func TestActionSetReturnWithoutSchemas(t *testing.T) {
+	action := newTestAction("posts", "1.0.0", "list", nil)
+	if _, err := action.SetReturn(true); err == nil {
+		t.Error("expected an error for the strict policy")
+	}
+
+	action = newTestAction("posts", "1.0.0", "list", nil)
+	action.state.schemaPolicy = SchemaPolicyCLIOnlyPermissive
+	if _, err := action.SetReturn(true); err != nil {
+		t.Errorf("expected the return value without schemas, got %v", err)
+	}
+}
@@ -10,6 +10,7 @@ package kusanagi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -18,24 +19,62 @@ import (
 
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
-	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/msgpack"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/protocol"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/runtime"
 	"github.com/pebbe/zmq4"
 )
 
 // State contains the context data for a multipart request of the framework.
 type state struct {
-	id      string
-	action  string
-	schemas payload.Mapping
-	command payload.Command
-	reply   *payload.Reply
-	payload []byte
-	input   cli.Input
-	ctx     context.Context
-	logger  log.RequestLogger
-	request requestMsg
+	id        string
+	action    string
+	schemas   payload.Mapping
+	command   payload.Command
+	reply     *payload.Reply
+	payload   []byte
+	format    wireFormat
+	binary    wireFormat
+	canonical bool
+	signer    Signer
+	input     cli.Input
+	pool      *runtime.Pool
+	cache     *callCache
+	locals    *localStore
+	resources *requestResources
+	stats     *serverStats
+	ctx       context.Context
+	logger    log.RequestLogger
+	request   requestMsg
+	sampled   bool
+	// Behavior of the validations when the schemas are missing
+	schemaPolicy SchemaPolicy
+	// Optional compression for the large reply payloads
+	compression *payloadCompression
+}
+
+// Remove the local values of the request.
+func (s *state) clearLocals() {
+	if s.locals != nil {
+		s.locals.clear(s.command.GetRequestID())
+	}
+}
+
+// Close the resources created for the request.
+func (s *state) closeResources() {
+	if s.resources != nil {
+		s.resources.close()
+	}
+}
+
+// Serialize a value using the format negotiated for the request.
+//
+// The map keys are sorted when canonical serialization is enabled.
+func (s *state) encode(v interface{}) ([]byte, error) {
+	if s.canonical {
+		return s.format.encodeCanonical(v)
+	}
+	return s.format.encode(v)
 }
 
 // Output for a request
@@ -48,12 +87,29 @@ type requestOutput struct {
 // Request processor processes ZMQ request messages for a component.
 type requestProcessor func(*state, chan<- requestOutput)
 
+// Error to be returned as reply payload with a specific code and status.
+type replyError struct {
+	message string
+	code    int
+	status  string
+}
+
+func (e replyError) Error() string {
+	return e.message
+}
+
 // Create a response that contains an error as payload.
-func createErrorResponse(message string) (responseMsg, error) {
+func createErrorResponse(f wireFormat, cause error) (responseMsg, error) {
 	p := payload.NewErrorReply()
-	p.Error.Message = message
+	p.Error.Message = cause.Error()
 
-	data, err := msgpack.Encode(p)
+	var e replyError
+	if errors.As(cause, &e) {
+		p.Error.Code = e.code
+		p.Error.Status = e.status
+	}
+
+	data, err := f.encode(p)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +119,7 @@ func createErrorResponse(message string) (responseMsg, error) {
 }
 
 // Pipe responses from a channel to a ZMQ internal socket
-func pipeOutput(zctx *zmq4.Context, c <-chan requestOutput) error {
+func pipeOutput(zctx *zmq4.Context, c <-chan requestOutput, stats *serverStats) error {
 	errorc := make(chan error)
 
 	go func() {
@@ -93,10 +149,11 @@ func pipeOutput(zctx *zmq4.Context, c <-chan requestOutput) error {
 		for output := range c {
 			logger := output.state.logger
 			response := output.response
+			stats.done(output.err != nil)
 
 			if output.err != nil {
 				// Create an error response
-				response, err = createErrorResponse(output.err.Error())
+				response, err = createErrorResponse(output.state.format, output.err)
 				if err != nil {
 					// When the error response creation fails log the issue
 					// and stop processing the response.
@@ -107,13 +164,32 @@ func pipeOutput(zctx *zmq4.Context, c <-chan requestOutput) error {
 				}
 			}
 
+			// Compress the large payloads before they are signed
+			if output.state.compression != nil {
+				if compressed, err := output.state.compression.apply(response); err != nil {
+					// The uncompressed payload is sent when the compression fails
+					logger.Warningf("Failed to compress the response: %v", err)
+				} else {
+					response = compressed
+				}
+			}
+
+			// Sign the response payload when there is a signer
+			if output.state.signer != nil {
+				if response, err = signResponse(output.state.signer, response); err != nil {
+					logger.Errorf("Failed to sign the response: %v", err)
+
+					continue
+				}
+			}
+
 			// Create the response message for the original request and send it to the forwarder
 			msg := output.state.request.makeResponseMessage(response...)
 			if _, err := socket.SendMessage([][]byte(msg)); err != nil {
 				if zmq4.AsErrno(err) == zmq4.ETERM {
 					break
 				} else {
-					log.Errorf("Failed to send internal response: %v", err)
+					log.Server.Errorf("Failed to send internal response: %v", err)
 
 					continue
 				}
@@ -127,7 +203,28 @@ func pipeOutput(zctx *zmq4.Context, c <-chan requestOutput) error {
 
 // Creates a new component server.
 func newServer(input cli.Input, c Component, p requestProcessor) *server {
-	return &server{c, input, p}
+	signer, verifier := getSigning(input, c.(*component))
+	s := &server{
+		component: c,
+		input:     input,
+		processor: p,
+		limiter:   newRateLimiter(input),
+		signer:    signer,
+		verifier:  verifier,
+		cache:     newCallCacheFromInput(input),
+		locals:    newLocalStoreFromInput(input),
+		binary:    getBinaryFormat(input, c.(*component).codec),
+		stats:     newServerStats(),
+		timeout:   time.Duration(input.GetTimeout()) * time.Millisecond,
+	}
+	if input.IsRecordEnabled() {
+		s.recorder = newRecorder(input.GetRecordDirectory())
+	}
+	if input.IsOpenAPIEnabled() {
+		s.openapi = newOpenAPIServer(input.GetOpenAPIAddress())
+	}
+	s.console = newConsole(s)
+	return s
 }
 
 // SDK component server.
@@ -135,18 +232,40 @@ type server struct {
 	component Component
 	input     cli.Input
 	processor requestProcessor
+	limiter   *rateLimiter
+	pool      *runtime.Pool
+	openapi   *openAPIServer
+	console   *console
+	signer    Signer
+	verifier  Verifier
+	cache     *callCache
+	recorder  *recorder
+	journal   *requestJournal
+	mappings  *sharedMapping
+	locals    *localStore
+	binary    wireFormat
+	stats     *serverStats
+	// Channel closed to stop the server without a termination signal
+	stop chan struct{}
+	// Process execution timeout
+	timeout time.Duration
 }
 
 // Get the ZMQ channel address to use for listening incoming requests.
-func (s *server) getAddress() (address string) {
-	if s.input.IsTCPEnabled() {
-		address = fmt.Sprintf("tcp://127.0.0.1:%d", s.input.GetTCP())
-	} else if name := s.input.GetSocket(); name != "" {
+func (s *server) getAddress() string {
+	return getListenAddress(s.input)
+}
+
+// Get the ZMQ channel address where the component listens for incoming requests.
+func getListenAddress(input cli.Input) (address string) {
+	if input.IsTCPEnabled() {
+		address = formatTCPAddress(input.GetBindHost(), input.GetTCP())
+	} else if name := input.GetSocket(); name != "" {
 		address = fmt.Sprintf("ipc://%s", name)
 	} else {
 		// Create a default name for the socket when no name is available.
 		// The 'ipc://' prefix is removed from the string to get the socket name.
-		address = protocol.IPC(s.input.GetComponent(), s.input.GetName(), s.input.GetVersion())
+		address = protocol.IPC(input.GetComponent(), input.GetName(), input.GetVersion())
 	}
 
 	return address
@@ -164,14 +283,21 @@ func (s *server) startMessageListener(msgc <-chan requestMsg) <-chan requestOutp
 
 	// Handle messages until the messages channel is closed
 	go func() {
-		// TODO: See how to avoid race conditions when mapping are updated here (and read by userland)
+		// Mapping used by the requests. Each request keeps the mapping that was current when the
+		// request was received, so new mappings are decoded into a new value instead of updating it.
 		var schemas payload.Mapping
 
+		// The component is warmed up with the first mapping
+		warm := false
+
+		// The mapping change callbacks run in the background
+		mappingChanges := &mappingDispatcher{events: s.component.(*component).events}
+
 		// Get the title to use for the component
 		title := s.input.GetComponentTitle()
 
 		// Process execution timeout
-		timeout := time.Duration(s.input.GetTimeout()) * time.Millisecond
+		timeout := s.timeout
 
 		// Define a parent context for each request
 		ctx, cancel := context.WithCancel(context.Background())
@@ -188,16 +314,45 @@ func (s *server) startMessageListener(msgc <-chan requestMsg) <-chan requestOutp
 
 			// Check that the multipart message is valid
 			if err := msg.check(); err != nil {
-				log.Critical(err)
+				log.Server.Critical(err)
 
 				// Log the error and continue listening for incoming requests
 				continue
 			}
 
+			// Get the serialization format negotiated for the request
+			format := msg.getFormat(s.binary)
+
 			// Try to read the new schemas when present
 			if v := msg.getSchemas(); v != nil {
-				if err := msgpack.Decode(v, &schemas); err != nil {
-					log.Errorf("Failed to read schemas: %v", err)
+				var mapping payload.Mapping
+				if err := format.decode(v, &mapping); err != nil {
+					log.Server.Errorf("Failed to read schemas: %v", err)
+				} else {
+					mappingChanges.dispatch(schemas, mapping)
+
+					schemas = mapping
+					if s.mappings != nil {
+						s.mappings.set(schemas)
+					}
+					if s.openapi != nil {
+						s.openapi.update(schemas)
+					}
+					if s.console != nil {
+						s.console.update(schemas)
+					}
+
+					if !warm {
+						warm = true
+						s.warmup(schemas)
+					}
+				}
+			}
+
+			// Components that run in the same process use the latest mapping received by any of them
+			if s.mappings != nil {
+				if mapping := s.mappings.get(); mapping != nil {
+					schemas = mapping
 				}
 			}
 
@@ -209,24 +364,61 @@ func (s *server) startMessageListener(msgc <-chan requestMsg) <-chan requestOutp
 
 				defer cancel()
 
+				s.stats.begin()
+				defer s.stats.end()
+
+				received := time.Now()
+
 				rid := msg.getRequestID()
 				action := msg.getAction()
-				logger := log.NewRequestLogger(rid)
+				logger := log.NewRequestLogger(rid).WithSubsystem(log.ServerSubsystem)
+
+				// Save the request to be able to replay it
+				if s.recorder != nil {
+					if err := s.recorder.record(msg, format); err != nil {
+						logger.Errorf("Failed to record the request: %v", err)
+					}
+				}
 
 				// State for the request
 				state := state{
-					id:      rid,
-					action:  action,
-					schemas: schemas,
-					input:   s.input,
-					ctx:     ctx,
-					logger:  logger,
-					request: msg,
+					id:        rid,
+					action:    action,
+					schemas:   schemas,
+					format:    format,
+					binary:    s.binary,
+					canonical: isCanonicalSerialization(s.input),
+					signer:    s.signer,
+					input:     s.input,
+					pool:      s.pool,
+					cache:     s.cache,
+					locals:    s.locals,
+					resources: newRequestResources(s.component.(*component).resources),
+					stats:     s.stats,
+					ctx:       ctx,
+					logger:    logger,
+					request:   msg,
+
+					schemaPolicy: s.component.(*component).schemaPolicy,
+					compression:  s.component.(*component).compression,
 				}
 
 				// Prepare defaults for the request output
 				output := requestOutput{state: &state}
 
+				// Reject the request when the rate limit is exceeded
+				if !s.limiter.allow() {
+					logger.Warningf("Rate limit exceeded, request rejected. Total rejected: %d", s.limiter.getRejected())
+					output.err = replyError{
+						message: fmt.Sprintf(`Rate limit exceeded for component %s: "%s"`, title, action),
+						code:    429,
+						status:  "429 Too Many Requests",
+					}
+					resc <- output
+
+					return
+				}
+
 				// Check that the request action is defined
 				if !s.hasComponentCallback(msg.getAction()) {
 					output.err = fmt.Errorf(`Invalid action for component %s: "%s"`, title, action)
@@ -235,18 +427,38 @@ func (s *server) startMessageListener(msgc <-chan requestMsg) <-chan requestOutp
 					return
 				}
 
+				// Reject the request when the payload signature is not valid
+				if s.verifier != nil {
+					if err := s.verifier.Verify(msg.getPayload(), msg.getSignature()); err != nil {
+						logger.Warningf("Request signature verification failed: %v", err)
+						output.err = replyError{
+							message: fmt.Sprintf(`Invalid request signature for component %s: "%s"`, title, action),
+							code:    401,
+							status:  "401 Unauthorized",
+						}
+						resc <- output
+
+						return
+					}
+				}
+
 				// Try to read the new schemas when present
 				if v := msg.getPayload(); v != nil {
-					if err := msgpack.Decode(v, &state.command); err != nil {
-						log.Criticalf("Failed to read payload: %v", err)
+					if err := format.decode(v, &state.command); err != nil {
+						log.Payload.Criticalf("Failed to read payload: %v", err)
 
 						output.err = fmt.Errorf(`Invalid payload for component %s: "%s"`, title, action)
 						resc <- output
 
 						return
 					}
+
+					// Binary parameter values are base64 strings in JSON payloads
+					if format.isJSON() && state.command.Command.Arguments != nil {
+						decodeJSONBinaryParams(state.command.Command.Arguments.Params)
+					}
 				} else {
-					log.Critical("Empty command payload received")
+					log.Payload.Critical("Empty command payload received")
 
 					output.err = fmt.Errorf(`Empty command payload for component %s: "%s"`, title, action)
 					resc <- output
@@ -254,18 +466,51 @@ func (s *server) startMessageListener(msgc <-chan requestMsg) <-chan requestOutp
 					return
 				}
 
+				// Reject the request when the payload exceeds the limits of the component
+				if err := s.component.(*component).limits.check(state.command); err != nil {
+					logger.Warningf("Request rejected: %v", err)
+					output.err = err
+					resc <- output
+
+					return
+				}
+
+				// Sampled requests write verbose logs and a timing breakdown
+				if state.sampled = isRequestSampled(s.input, rid, state.command); state.sampled {
+					state.logger = state.logger.WithVerbose(true)
+					logger = state.logger
+				}
+
+				// Save the accepted request until it is processed
+				if s.journal != nil {
+					if path, err := s.journal.add(msg, format, state.schemas); err != nil {
+						logger.Errorf("Failed to save the request in the journal: %v", err)
+					} else {
+						defer func() {
+							if err := s.journal.remove(path); err != nil {
+								logger.Errorf("Failed to remove the request from the journal: %v", err)
+							}
+						}()
+					}
+				}
+
 				// Create a channel to wait for the processor output
 				outc := make(chan requestOutput)
 
 				// Process the request and return the response
+				started := time.Now()
 				go s.processor(&state, outc)
 
 				// Block until the processor finishes or the execution timeout is triggered
 				select {
 				case output := <-outc:
+					if state.sampled {
+						logSampledTiming(logger, received, started)
+					}
 					resc <- output
 				case <-ctx.Done():
 					logger.Warningf("Execution timed out after %s. PID: %d", timeout, os.Getpid())
+					s.stats.done(true)
 				}
 			}()
 		}
@@ -274,6 +519,25 @@ func (s *server) startMessageListener(msgc <-chan requestMsg) <-chan requestOutp
 	return resc
 }
 
+// Reload the component variables each time a SIGHUP signal is received.
+func (s *server) listenReloadSignal() {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGHUP)
+
+	for range sigc {
+		log.Server.Debug("Reload signal received")
+		if err := s.input.ReloadVariables(); err != nil {
+			log.Server.Errorf("Failed to reload variables: %v", err)
+			continue
+		}
+
+		s.limiter.configure(s.input)
+
+		c := s.component.(*component)
+		c.events.reload(c)
+	}
+}
+
 func (s *server) start() error {
 	// Define a custom ZMQ context
 	zctx, err := zmq4.NewContext()
@@ -281,17 +545,29 @@ func (s *server) start() error {
 		return err
 	}
 
+	// SIGHUP reloads the variables when there is a variables file, otherwise it terminates the component
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM}
+	if s.input.HasVariablesFile() {
+		go s.listenReloadSignal()
+	} else {
+		signals = append(signals, syscall.SIGHUP)
+	}
+
 	// Listen for termination signals
 	go func() {
 		// Define a channel to receive system signals
 		sigc := make(chan os.Signal, 1)
-		signal.Notify(sigc, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
-		// Block until a signal is received
-		<-sigc
-		log.Debug("Termination signal received")
+		signal.Notify(sigc, signals...)
+		// Block until a signal is received or the server is stopped
+		select {
+		case <-sigc:
+			log.Server.Debug("Termination signal received")
+		case <-s.stop:
+			log.Server.Debug("Server stopped")
+		}
 		// Terminate the ZMQ context to close sockets gracefully
 		if err := zctx.Term(); err != nil {
-			log.Errorf("Failed to terminate sockets context: %v", err)
+			log.Server.Errorf("Failed to terminate sockets context: %v", err)
 		}
 		// Clear the default ZMQ settings for retrying operations after EINTR.
 		zmq4.SetRetryAfterEINTR(false)
@@ -316,8 +592,15 @@ func (s *server) start() error {
 	}
 	defer responses.Unbind("inproc://responses")
 
-	// Create a socket to receive incoming requests
-	socket, err := zctx.NewSocket(zmq4.ROUTER)
+	// Create a socket to receive incoming requests.
+	// Worker processes receive the requests forwarded by the supervisor
+	// without changes, so they use a dealer socket to keep the envelope.
+	socketType := zmq4.ROUTER
+	if s.input.IsWorker() {
+		socketType = zmq4.DEALER
+	}
+
+	socket, err := zctx.NewSocket(socketType)
 	if err != nil {
 		return fmt.Errorf("Failed to create socket: %v", err)
 	}
@@ -327,29 +610,95 @@ func (s *server) start() error {
 	if err := socket.SetLinger(0); err != nil {
 		return fmt.Errorf("Failed to set socket's linger option: %v", err)
 	}
-	// Change the socket HWM to allow caching any number of incoming request.
+	// By default the socket HWM allows caching any number of incoming request.
 	// ZMQ default value is 1000.
-	if err := socket.SetRcvhwm(0); err != nil {
-		return fmt.Errorf("Failed to set socket's high water mark option: %v", err)
+	if err := setSocketHWM(socket, s.input); err != nil {
+		return err
 	}
 
 	// Start listening for incoming requests
-	address := s.getAddress()
-	log.Debugf(`Listening for request at address: "%s"`, address)
-	if err := socket.Bind(address); err != nil {
-		return fmt.Errorf(`Faled to open socket at address "%s": %v`, address, err)
+	if s.input.IsWorker() {
+		address := s.input.GetWorkerAddress()
+		log.Server.Debugf(`Worker listening for requests from supervisor at address: "%s"`, address)
+		if err := socket.Connect(address); err != nil {
+			return fmt.Errorf(`Failed to connect to supervisor at address "%s": %v`, address, err)
+		}
+	} else {
+		address := s.getAddress()
+		log.Server.Debugf(`Listening for request at address: "%s"`, address)
+		if err := bindSocket(socket, address); err != nil {
+			return fmt.Errorf(`Faled to open socket at address "%s": %v`, address, err)
+		}
+		defer socket.Unbind(address)
+	}
+
+	// The compressed flag is not part of the framework protocol yet
+	if s.component.(*component).compression != nil {
+		log.Server.Warning("Experimental payload compression is enabled: the framework must support compressed replies")
+	}
+
+	// Create the socket pool for the runtime calls
+	if s.pool = newCallPool(s.input); s.pool != nil {
+		defer s.pool.Close()
+	}
+
+	// Process the requests left in the journal before accepting new requests
+	if s.input.IsJournalEnabled() {
+		if s.journal, err = newRequestJournal(s.input.GetJournalDirectory(), isJournalSync(s.input)); err != nil {
+			return err
+		}
+
+		if err := s.recoverJournal(); err != nil {
+			return err
+		}
+	}
+
+	// Serve the OpenAPI document generated from the mappings when enabled
+	if s.openapi != nil {
+		s.openapi.start()
+	}
+
+	// Serve the profiling endpoints when enabled
+	if pprofServer := newPprofServer(s.input, s.stats); pprofServer != nil {
+		startPprofServer(pprofServer)
+		defer pprofServer.Close()
+	}
+
+	// Serve the debug console when enabled
+	if s.console != nil {
+		if err := s.console.start(); err != nil {
+			return err
+		}
+		defer s.console.close()
+	}
+
+	// Publish the component status periodically when enabled
+	if heartbeat := newHeartbeatPublisher(s.input, s.stats); heartbeat != nil {
+		if err := heartbeat.start(zctx); err != nil {
+			return err
+		}
+		defer heartbeat.stop()
 	}
-	defer socket.Unbind(address)
 
 	// Create a buffered channel to send request payloads to the message listener.
 	// The channel is buffered to allow faster request processing by the reactor.
-	msgc := make(chan requestMsg, 1000)
+	// When the request queue is enabled its size limits the buffered requests.
+	queueSize := getRequestQueueSize(s.input)
+	bufferSize := queueSize
+	if bufferSize == 0 {
+		bufferSize = defaultRequestBufferSize
+	}
+	msgc := make(chan requestMsg, bufferSize)
 	// On exit close the channel to avoid worker creation
 	defer close(msgc)
 
+	s.stats.queue = func() int {
+		return len(msgc)
+	}
+
 	// Define a channel to read the responses from the processors.
 	// The output is piped to be able to use send channel responses to the ZMQ socket
-	if err := pipeOutput(zctx, s.startMessageListener(msgc)); err != nil {
+	if err := pipeOutput(zctx, s.startMessageListener(msgc), s.stats); err != nil {
 		return err
 	}
 
@@ -368,7 +717,7 @@ MAIN:
 			if errno == zmq4.ETERM {
 				break MAIN
 			} else if errno != zmq4.Errno(syscall.EINTR) {
-				log.Errorf("Socket poll failed: %v", err)
+				log.Server.Errorf("Socket poll failed: %v", err)
 			}
 			continue
 		}
@@ -383,12 +732,22 @@ MAIN:
 					if zmq4.AsErrno(err) == zmq4.ETERM {
 						break MAIN
 					} else {
-						log.Errorf("Failed to read request: %v", err)
+						log.Server.Errorf("Failed to read request: %v", err)
 						continue
 					}
 				}
-				// Send the request to be processed by the workers
-				msgc <- msg
+				// Send the request to be processed by the workers. When the request queue
+				// is enabled the request is rejected when the queue is full to avoid
+				// blocking the reactor.
+				if queueSize == 0 {
+					msgc <- msg
+				} else {
+					select {
+					case msgc <- msg:
+					default:
+						s.rejectOverloaded(socket, msg)
+					}
+				}
 			case responses:
 				// Read the response from the internal socket
 				msg, err := responses.RecvMessageBytes(0)
@@ -396,17 +755,18 @@ MAIN:
 					if zmq4.AsErrno(err) == zmq4.ETERM {
 						break MAIN
 					} else {
-						log.Errorf("Failed to read internal response: %v", err)
+						log.Server.Errorf("Failed to read internal response: %v", err)
 						continue
 					}
 				}
 
-				// Write response to the client
+				// Write response to the client. The router socket doesn't block when the
+				// outgoing queue of the client is full, it drops the response instead.
 				if _, err := socket.SendMessage(msg); err != nil {
 					if zmq4.AsErrno(err) == zmq4.ETERM {
 						break MAIN
 					} else {
-						log.Errorf("Failed to send response to client: %v", err)
+						log.Server.Errorf("Failed to send response to client: %v", err)
 						continue
 					}
 				}
@@ -414,6 +774,6 @@ MAIN:
 		}
 	}
 
-	log.Info("Component stopped")
+	log.Server.Info("Component stopped")
 	return nil
 }
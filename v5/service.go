@@ -8,6 +8,8 @@
 
 package kusanagi
 
+import "fmt"
+
 // ActionCallback is called when a service request is received.
 type ActionCallback func(*Action) (*Action, error)
 
@@ -18,6 +20,7 @@ func NewService() *Service {
 	service.component = newComponent(func(s *state, c chan<- requestOutput) {
 		serviceRequestProcessor(service, s, c)
 	})
+	service.component.resolver = service
 
 	return service
 }
@@ -25,6 +28,12 @@ func NewService() *Service {
 // Service component.
 type Service struct {
 	component
+
+	beforeHooks []ActionCallback
+	afterHooks  []ActionCallback
+	mounts      []actionMount
+	patterns    []actionRoute
+	sanitizers  map[string]map[string][]Sanitizer
 }
 
 // Action assigns a callback to execute when a service action request is received.
@@ -33,3 +42,72 @@ func (s *Service) Action(name string, callback ActionCallback) *Service {
 
 	return s
 }
+
+// BeforeAction adds a hook to execute before the callback of every action.
+//
+// Hooks are executed in the order they are added. When a hook returns an error
+// the remaining hooks and the action callback are not executed, and the error
+// is added to the transport as if it was returned by the action callback.
+// The parameters are validated after the hooks, so the values changed by the
+// hooks are validated before the action callback is executed.
+//
+// callback: The hook to execute before the action callbacks.
+func (s *Service) BeforeAction(callback ActionCallback) *Service {
+	s.beforeHooks = append(s.beforeHooks, callback)
+
+	return s
+}
+
+// AfterAction adds a hook to execute after the callback of every action.
+//
+// Hooks are executed in the order they are added, even when the action callback
+// or a before hook fails. When a hook returns an error the remaining hooks are
+// not executed, and the error is added to the transport.
+//
+// callback: The hook to execute after the action callbacks.
+func (s *Service) AfterAction(callback ActionCallback) *Service {
+	s.afterHooks = append(s.afterHooks, callback)
+
+	return s
+}
+
+// Execute an action callback and the hooks registered for the actions.
+//
+// The parameters are validated after the before hooks. When the validation fails
+// the error is added to the transport and the action callback is not executed.
+// The result is the action and the first error returned by the hooks or the callback.
+func (s *Service) execute(action *Action, callback ActionCallback) (*Action, error) {
+	action, err := runActionCallbacks(action, s.beforeHooks)
+	if err == nil {
+		// The parameters are validated after the before hooks so the values they change are validated
+		if verr := action.validateParams(); verr != nil {
+			action.logger.Errorf("Validation error: %v", verr)
+			action.ErrorFrom(NewServiceError(verr.Error(), 0, "400 Bad Request"))
+		} else {
+			action, err = runActionCallbacks(action, []ActionCallback{callback})
+		}
+	}
+
+	// After hooks run always but their error is only used when the action succeeded
+	action, afterErr := runActionCallbacks(action, s.afterHooks)
+	if err == nil {
+		err = afterErr
+	}
+	return action, err
+}
+
+// Run a list of action callbacks in order until one of them fails.
+func runActionCallbacks(action *Action, callbacks []ActionCallback) (*Action, error) {
+	for _, callback := range callbacks {
+		result, err := callback(action)
+		if result == nil {
+			panic(fmt.Sprintf("callback returned a nil action: %s", action.GetActionName()))
+		}
+
+		action = result
+		if err != nil {
+			return action, err
+		}
+	}
+	return action, nil
+}
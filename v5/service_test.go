@@ -0,0 +1,114 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Create an action callback that records its name for the action hook tests.
+func newHookTestCallback(calls *[]string, name string, err error) ActionCallback {
+	return func(action *Action) (*Action, error) {
+		*calls = append(*calls, name)
+		return action, err
+	}
+}
+
+func TestServiceActionHooks(t *testing.T) {
+	failure := errors.New("failed")
+	cases := []struct {
+		name     string
+		before   error
+		callback error
+		after    error
+		calls    []string
+		err      error
+	}{
+		{"success", nil, nil, nil, []string{"before", "callback", "after", "after-last"}, nil},
+		// The callback is not executed when a before hook fails
+		{"before error", failure, nil, nil, []string{"before", "after", "after-last"}, failure},
+		{"callback error", nil, failure, nil, []string{"before", "callback", "after", "after-last"}, failure},
+		// The remaining after hooks are not executed when an after hook fails
+		{"after error", nil, nil, failure, []string{"before", "callback", "after"}, failure},
+	}
+
+	for _, c := range cases {
+		var calls []string
+		service := NewService().
+			BeforeAction(newHookTestCallback(&calls, "before", c.before)).
+			AfterAction(newHookTestCallback(&calls, "after", c.after)).
+			AfterAction(newHookTestCallback(&calls, "after-last", nil))
+
+		a := newTestAction("users", "1.0.0", "read", nil)
+		_, err := service.execute(a, newHookTestCallback(&calls, "callback", c.callback))
+		if err != c.err {
+			t.Errorf("%s: expected the error %v, got %v", c.name, c.err, err)
+		}
+		if !reflect.DeepEqual(calls, c.calls) {
+			t.Errorf("%s: expected the calls %v, got %v", c.name, c.calls, calls)
+		}
+	}
+}
+
+func TestServiceActionHooksKeepCallbackError(t *testing.T) {
+	callbackErr := errors.New("callback failed")
+	var calls []string
+	service := NewService().AfterAction(newHookTestCallback(&calls, "after", errors.New("after failed")))
+
+	a := newTestAction("users", "1.0.0", "read", nil)
+	if _, err := service.execute(a, newHookTestCallback(&calls, "callback", callbackErr)); err != callbackErr {
+		t.Errorf("expected the callback error, got %v", err)
+	}
+}
+
+func TestServiceValidatesParamsAfterBeforeHooks(t *testing.T) {
+	RegisterFormat("test-slug", validateTestSlug)
+	t.Cleanup(func() {
+		RegisterFormat("test-slug", nil)
+	})
+
+	failure := errors.New("failed")
+	cases := []struct {
+		name   string
+		before error
+		calls  []string
+		errors int
+	}{
+		// The callback is not executed when the params are not valid
+		{"invalid params", nil, []string{"before", "after"}, 1},
+		// The params are not validated when a before hook fails
+		{"before error", failure, []string{"before", "after"}, 0},
+	}
+
+	for _, c := range cases {
+		a := newTestActionWithParams("posts", "1.0.0", "create",
+			payload.ActionSchema{Params: map[string]payload.ParamSchema{"slug": {Name: "slug", Format: "test-slug"}}},
+			payload.Param{Name: "slug", Value: "First Post", Type: payload.TypeString},
+		)
+
+		var calls []string
+		service := NewService().
+			BeforeAction(newHookTestCallback(&calls, "before", c.before)).
+			AfterAction(newHookTestCallback(&calls, "after", nil))
+
+		if _, err := service.execute(a, newHookTestCallback(&calls, "callback", nil)); err != c.before {
+			t.Errorf("%s: expected the error %v, got %v", c.name, c.before, err)
+		}
+		if !reflect.DeepEqual(calls, c.calls) {
+			t.Errorf("%s: expected the calls %v, got %v", c.name, c.calls, calls)
+		}
+		if errs := a.reply.Command.Result.Transport.Errors; len(errs) != c.errors {
+			t.Errorf("%s: expected %d validation errors, got %v", c.name, c.errors, errs)
+		}
+	}
+}
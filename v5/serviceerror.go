@@ -0,0 +1,55 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+// NewServiceError creates a new service error.
+//
+// message: The error message.
+// code: The error code.
+// status: The HTTP status message.
+func NewServiceError(message string, code int, status string) *ServiceError {
+	return &ServiceError{Message: message, Code: code, Status: status}
+}
+
+// ServiceError is an error with a code, a status and metadata.
+//
+// Service errors keep their machine-readable values when they are added to the transport,
+// either by calling Action.ErrorFrom() or by returning them from an action callback.
+type ServiceError struct {
+	Message  string
+	Code     int
+	Status   string
+	Metadata map[string]interface{}
+	Severity string
+}
+
+func (e *ServiceError) Error() string {
+	return e.Message
+}
+
+// WithMetadata sets a metadata value for the error.
+//
+// name: The metadata name.
+// value: The metadata value.
+func (e *ServiceError) WithMetadata(name string, value interface{}) *ServiceError {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]interface{})
+	}
+
+	e.Metadata[name] = value
+	return e
+}
+
+// WithSeverity sets the severity of the error.
+//
+// severity: The error severity.
+func (e *ServiceError) WithSeverity(severity string) *ServiceError {
+	e.Severity = severity
+	return e
+}
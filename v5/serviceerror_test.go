@@ -0,0 +1,86 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestServiceErrorMetadata(t *testing.T) {
+	serr := NewServiceError("Invalid post", 3, "").WithMetadata("field", "title")
+	action := newTestAction("posts", "1.0.0", "create", nil)
+	action.ErrorFrom(serr)
+
+	// The metadata of the service error is not changed by the transport
+	serr.WithMetadata("other", true)
+
+	errs := getTransportErrors(action.transport.Errors)
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+
+	e := errs[0]
+	if e.GetStatus() != payload.DefaultErrorStatus {
+		t.Errorf("expected the default status, got %s", e.GetStatus())
+	}
+	if metadata := e.GetMetadata(); len(metadata) != 1 || metadata["field"] != "title" {
+		t.Errorf("unexpected error metadata: %v", metadata)
+	}
+}
+
+func TestActionErrorFromPlainError(t *testing.T) {
+	action := newTestAction("posts", "1.0.0", "create", nil)
+	action.ErrorFrom(errors.New("Failed"))
+
+	e := getTransportErrors(action.transport.Errors)[0]
+	if e.GetMessage() != "Failed" || e.GetCode() != 0 || e.GetStatus() != payload.DefaultErrorStatus || e.GetMetadata() != nil {
+		t.Errorf("unexpected error: %q %d %s %v", e.GetMessage(), e.GetCode(), e.GetStatus(), e.GetMetadata())
+	}
+}
+
+func TestActionErrorWithSeverity(t *testing.T) {
+	action := newTestAction("posts", "1.0.0", "create", nil)
+	action.ErrorWithSeverity("Slow storage", 1, "", ErrorSeverityWarning)
+	action.ErrorFrom(NewServiceError("Invalid post", 2, "400 Bad Request").WithSeverity(ErrorSeverityFatal))
+	action.Error("Failed", 3, "500 Internal Server Error")
+
+	severities := make(map[int]string)
+	for _, e := range getTransportErrors(action.transport.Errors) {
+		severities[e.GetCode()] = e.GetSeverity()
+	}
+
+	// Errors without severity use the error severity
+	expected := map[int]string{1: ErrorSeverityWarning, 2: ErrorSeverityFatal, 3: ErrorSeverityError}
+	for code, severity := range expected {
+		if severities[code] != severity {
+			t.Errorf("%d: expected the severity %s, got %s", code, severity, severities[code])
+		}
+	}
+
+	// The warnings are moved to the transport warnings when they don't make the request fail
+	transport := action.transport.Clone()
+	transport.SeparateWarnings()
+	if errs, warnings := getTransportErrors(transport.Errors), getTransportErrors(transport.Warnings); len(errs) != 2 || len(warnings) != 1 {
+		t.Errorf("expected two errors and one warning, got %v and %v", errs, warnings)
+	}
+}
+
+func TestIsFailOnWarnings(t *testing.T) {
+	cases := map[string]bool{"": true, "invalid": true, "true": true, "false": false}
+	for value, expected := range cases {
+		setTestVariable(t, FailOnWarningsVariable, value)
+		if fail := isFailOnWarnings(cli.Input{}); fail != expected {
+			t.Errorf("%q: expected %v, got %v", value, expected, fail)
+		}
+	}
+}
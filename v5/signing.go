@@ -0,0 +1,117 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+// SigningKeyVariable is the name of the component variable with the secret key used
+// to sign the replies and verify the requests using HMAC-SHA256 signatures.
+//
+// The key is only used when no custom signer or verifier is set for the component.
+const SigningKeyVariable = "signing-key"
+
+// ErrInvalidSignature is returned when a payload signature is not valid.
+var ErrInvalidSignature = errors.New("The payload signature is not valid")
+
+// ErrMissingSignature is returned when a payload that must be verified has no signature.
+var ErrMissingSignature = errors.New("The payload signature is missing")
+
+// Signer signs the reply payloads sent by the component.
+//
+// The signature is sent in an extra frame after the reply payload.
+type Signer interface {
+	// Sign returns the signature for a payload.
+	//
+	// payload: The serialized payload.
+	Sign(payload []byte) ([]byte, error)
+}
+
+// Verifier verifies the signatures of the request payloads received by the component.
+//
+// The signature is received in an extra frame after the serialization format frame,
+// and the requests with missing or invalid signatures are rejected.
+type Verifier interface {
+	// Verify checks that a signature is valid for a payload.
+	//
+	// payload: The serialized payload.
+	// signature: The payload signature, or nil when the request has no signature.
+	Verify(payload, signature []byte) error
+}
+
+// NewHMACSigner creates a signer and verifier that uses HMAC-SHA256 signatures.
+//
+// key: The secret key.
+func NewHMACSigner(key []byte) *HMACSigner {
+	return &HMACSigner{key: append([]byte{}, key...)}
+}
+
+// HMACSigner signs and verifies payloads using HMAC-SHA256 signatures.
+type HMACSigner struct {
+	key []byte
+}
+
+// Sign returns the HMAC-SHA256 signature for a payload.
+//
+// payload: The serialized payload.
+func (s *HMACSigner) Sign(payload []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// Verify checks that an HMAC-SHA256 signature is valid for a payload.
+//
+// payload: The serialized payload.
+// signature: The payload signature.
+func (s *HMACSigner) Verify(payload, signature []byte) error {
+	if len(signature) == 0 {
+		return ErrMissingSignature
+	}
+
+	expected, _ := s.Sign(payload)
+	if !hmac.Equal(expected, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Get the signer and verifier for a component.
+//
+// When the component has no custom signer or verifier the HMAC signer
+// is used if there is a signing key defined in the component variables.
+func getSigning(input cli.Input, c *component) (Signer, Verifier) {
+	signer, verifier := c.signer, c.verifier
+	if key := input.GetVariable(SigningKeyVariable); key != "" {
+		hmacSigner := NewHMACSigner([]byte(key))
+		if signer == nil {
+			signer = hmacSigner
+		}
+		if verifier == nil {
+			verifier = hmacSigner
+		}
+	}
+	return signer, verifier
+}
+
+// Sign the payload of a response message.
+//
+// The signature is added to the response as an extra frame.
+func signResponse(signer Signer, response responseMsg) (responseMsg, error) {
+	signature, err := signer.Sign(response[len(response)-1])
+	if err != nil {
+		return nil, err
+	}
+	return append(response, signature), nil
+}
@@ -0,0 +1,115 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+// Signer that returns a fixed signature.
+type fixedSigner []byte
+
+func (s fixedSigner) Sign([]byte) ([]byte, error) {
+	return s, nil
+}
+
+func (s fixedSigner) Verify(_, signature []byte) error {
+	if !bytes.Equal(s, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func TestHMACSigner(t *testing.T) {
+	key := []byte("secret")
+	s := NewHMACSigner(key)
+
+	// The signer keeps a copy of the key
+	key[0] = 'x'
+
+	signature, err := s.Sign([]byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signature) != 32 {
+		t.Errorf("expected an HMAC-SHA256 signature, got %d bytes", len(signature))
+	}
+
+	if err := NewHMACSigner([]byte("secret")).Verify([]byte("payload"), signature); err != nil {
+		t.Errorf("expected the signature to be valid, got %v", err)
+	}
+
+	cases := []struct {
+		name      string
+		signer    *HMACSigner
+		payload   string
+		signature []byte
+		expected  error
+	}{
+		{"missing signature", s, "payload", nil, ErrMissingSignature},
+		{"changed payload", s, "changed", signature, ErrInvalidSignature},
+		{"other key", NewHMACSigner([]byte("other")), "payload", signature, ErrInvalidSignature},
+		{"truncated signature", s, "payload", signature[:16], ErrInvalidSignature},
+	}
+
+	for _, c := range cases {
+		if err := c.signer.Verify([]byte(c.payload), c.signature); !errors.Is(err, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestGetSigning(t *testing.T) {
+	c := NewService().base()
+	if signer, verifier := getSigning(cli.Input{}, c); signer != nil || verifier != nil {
+		t.Error("expected the signing to be disabled by default")
+	}
+
+	setTestVariable(t, SigningKeyVariable, "secret")
+	signer, verifier := getSigning(cli.Input{}, c)
+	if _, ok := signer.(*HMACSigner); !ok {
+		t.Errorf("expected the HMAC signer, got %T", signer)
+	}
+	if _, ok := verifier.(*HMACSigner); !ok {
+		t.Errorf("expected the HMAC verifier, got %T", verifier)
+	}
+
+	// The custom signer and verifier have precedence over the key
+	c.SetSigner(fixedSigner("signature"))
+	c.SetVerifier(fixedSigner("signature"))
+	signer, verifier = getSigning(cli.Input{}, c)
+	if _, ok := signer.(fixedSigner); !ok {
+		t.Errorf("expected the custom signer, got %T", signer)
+	}
+	if _, ok := verifier.(fixedSigner); !ok {
+		t.Errorf("expected the custom verifier, got %T", verifier)
+	}
+}
+
+func TestSignResponse(t *testing.T) {
+	msg := requestMsg{[]byte("id"), []byte("fid"), nil, []byte("rid"), []byte("action")}
+	response := msg.makeResponseMessage([]byte("payload"))
+
+	signed, err := signResponse(fixedSigner("signature"), response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The signature is added after the payload
+	if len(signed) != len(response)+1 || string(signed[len(signed)-1]) != "signature" {
+		t.Errorf("expected the signature frame, got %q", signed)
+	}
+	if string(signed[len(signed)-2]) != "payload" {
+		t.Errorf("expected the payload before the signature, got %q", signed)
+	}
+}
@@ -0,0 +1,116 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Creates a new request counter for the component server.
+func newServerStats() *serverStats {
+	return &serverStats{started: time.Now()}
+}
+
+// Counters for the requests handled by the component server.
+type serverStats struct {
+	started   time.Time
+	processed atomic.Int64
+	failed    atomic.Int64
+	inFlight  atomic.Int64
+	// Returns the number of requests waiting to be processed
+	queue func() int
+}
+
+// Register the start of a request.
+func (s *serverStats) begin() {
+	s.inFlight.Add(1)
+}
+
+// Register the end of a request.
+func (s *serverStats) end() {
+	s.inFlight.Add(-1)
+}
+
+// Register a request that was processed.
+//
+// failed: True when the request failed.
+func (s *serverStats) done(failed bool) {
+	s.processed.Add(1)
+	if failed {
+		s.failed.Add(1)
+	}
+}
+
+// Take a snapshot of the component statistics.
+func (s *serverStats) snapshot() *RuntimeStats {
+	stats := RuntimeStats{
+		goroutines: runtime.NumGoroutine(),
+		processed:  s.processed.Load(),
+		failed:     s.failed.Load(),
+		inFlight:   s.inFlight.Load(),
+		uptime:     time.Since(s.started),
+	}
+
+	if s.queue != nil {
+		stats.queued = s.queue()
+	}
+
+	runtime.ReadMemStats(&stats.memory)
+	return &stats
+}
+
+// RuntimeStats contains the process statistics of the component.
+type RuntimeStats struct {
+	goroutines int
+	memory     runtime.MemStats
+	queued     int
+	inFlight   int64
+	processed  int64
+	failed     int64
+	uptime     time.Duration
+}
+
+// GetGoroutines returns the number of goroutines of the process.
+func (s RuntimeStats) GetGoroutines() int {
+	return s.goroutines
+}
+
+// GetMemStats returns the memory allocator statistics of the process.
+func (s RuntimeStats) GetMemStats() runtime.MemStats {
+	return s.memory
+}
+
+// GetQueueDepth returns the number of requests waiting to be processed.
+func (s RuntimeStats) GetQueueDepth() int {
+	return s.queued
+}
+
+// GetInFlight returns the number of requests being processed.
+func (s RuntimeStats) GetInFlight() int64 {
+	return s.inFlight
+}
+
+// GetProcessed returns the number of requests processed since the component started.
+func (s RuntimeStats) GetProcessed() int64 {
+	return s.processed
+}
+
+// GetFailed returns the number of requests that failed since the component started.
+//
+// Requests fail when the component replies with an error or when they time out.
+func (s RuntimeStats) GetFailed() int64 {
+	return s.failed
+}
+
+// GetUptime returns the time since the component started.
+func (s RuntimeStats) GetUptime() time.Duration {
+	return s.uptime
+}
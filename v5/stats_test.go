@@ -0,0 +1,49 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import "testing"
+
+func TestServerStats(t *testing.T) {
+	stats := newServerStats()
+	stats.queue = func() int { return 3 }
+
+	stats.begin()
+	stats.begin()
+	stats.done(false)
+	stats.end()
+	stats.done(true)
+
+	snapshot := stats.snapshot()
+	if snapshot.GetProcessed() != 2 || snapshot.GetFailed() != 1 {
+		t.Errorf("unexpected request counters: %d processed, %d failed", snapshot.GetProcessed(), snapshot.GetFailed())
+	}
+	if snapshot.GetInFlight() != 1 || snapshot.GetQueueDepth() != 3 {
+		t.Errorf("unexpected pending requests: %d in flight, %d queued", snapshot.GetInFlight(), snapshot.GetQueueDepth())
+	}
+	if snapshot.GetGoroutines() == 0 || snapshot.GetMemStats().HeapAlloc == 0 || snapshot.GetUptime() <= 0 {
+		t.Error("expected the process statistics")
+	}
+}
+
+func TestApiGetRuntimeStats(t *testing.T) {
+	// Without server the counters are empty
+	a := newTestAction("users", "1.0.0", "read", nil)
+	if stats := a.GetRuntimeStats(); stats.GetProcessed() != 0 || stats.GetGoroutines() == 0 {
+		t.Errorf("expected empty counters, got %d processed", stats.GetProcessed())
+	}
+
+	s := newTestState("users", "1.0.0", "read", nil)
+	s.stats = newServerStats()
+	s.stats.done(true)
+	a = newAction(NewService(), s)
+	if stats := a.GetRuntimeStats(); stats.GetProcessed() != 1 || stats.GetFailed() != 1 {
+		t.Errorf("expected the server statistics, got %d processed", stats.GetProcessed())
+	}
+}
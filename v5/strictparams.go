@@ -0,0 +1,82 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StrictParamsVariable is the name of the component variable that enables the strict parameter mode.
+//
+// In strict mode the parameters that are not defined in the action schema are considered unknown.
+// The value "warn" logs a warning for each request with unknown parameters, and the value
+// "reject" also fails the request with an error that contains the unknown parameter names.
+const StrictParamsVariable = "strict-params"
+
+// Modes for the strict parameter variable.
+const (
+	StrictParamsWarn   = "warn"
+	StrictParamsReject = "reject"
+)
+
+// Name of the error metadata that contains the unknown parameter names.
+const UnknownParamsMetadata = "unknown_params"
+
+// Status used for the requests rejected because of unknown parameters.
+const unknownParamsErrorStatus = "400 Bad Request"
+
+// GetUnknownParams returns the names of the parameters that are not defined in the action schema.
+//
+// The result is empty when the schema for the action is not available.
+func (a *Action) GetUnknownParams() []string {
+	names := []string{}
+	schema, err := a.GetServiceSchema(a.GetName(), a.GetVersion())
+	if err != nil {
+		return names
+	}
+
+	actionSchema, err := schema.GetActionSchema(a.GetActionName())
+	if err != nil {
+		return names
+	}
+
+	for name := range a.params {
+		if !actionSchema.HasParam(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check the action parameters when the strict parameter mode is enabled.
+//
+// An error is returned when there are unknown parameters and the mode is "reject".
+func checkStrictParams(a *Action) error {
+	mode := a.GetVariable(StrictParamsVariable)
+	if mode != StrictParamsWarn && mode != StrictParamsReject {
+		return nil
+	}
+
+	unknown := a.GetUnknownParams()
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	names := `"` + strings.Join(unknown, `", "`) + `"`
+	a.logger.Warningf(`Unknown params for action "%s": %s`, a.GetActionName(), names)
+	if mode != StrictParamsReject {
+		return nil
+	}
+
+	message := fmt.Sprintf(`The action "%s" doesn't support the params: %s`, a.GetActionName(), names)
+	return NewServiceError(message, 0, unknownParamsErrorStatus).WithMetadata(UnknownParamsMetadata, unknown)
+}
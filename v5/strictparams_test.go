@@ -0,0 +1,62 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Action schema and parameters with values that are not defined in the schema for the strict params tests.
+var (
+	strictParamsTestSchema = payload.ActionSchema{Params: map[string]payload.ParamSchema{"id": {Name: "id"}}}
+	strictParamsTestParams = []payload.Param{
+		{Name: "id", Value: "1", Type: payload.TypeString},
+		{Name: "zone", Value: "eu", Type: payload.TypeString},
+		{Name: "debug", Value: "1", Type: payload.TypeString},
+	}
+)
+
+func TestActionGetUnknownParams(t *testing.T) {
+	expected := []string{"debug", "zone"}
+	if names := newTestActionWithParams("users", "1.0.0", "read", strictParamsTestSchema, strictParamsTestParams...).GetUnknownParams(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	// The params are not checked without schema
+	if names := newTestAction("users", "1.0.0", "read", nil).GetUnknownParams(); len(names) != 0 {
+		t.Errorf("expected no unknown params, got %v", names)
+	}
+}
+
+func TestCheckStrictParams(t *testing.T) {
+	if err := checkStrictParams(newTestActionWithParams("users", "1.0.0", "read", strictParamsTestSchema, strictParamsTestParams...)); err != nil {
+		t.Errorf("expected the strict mode to be disabled by default, got %v", err)
+	}
+
+	setTestVariable(t, StrictParamsVariable, StrictParamsWarn)
+	if err := checkStrictParams(newTestActionWithParams("users", "1.0.0", "read", strictParamsTestSchema, strictParamsTestParams...)); err != nil {
+		t.Errorf("expected only a warning, got %v", err)
+	}
+
+	setTestVariable(t, StrictParamsVariable, StrictParamsReject)
+	err := checkStrictParams(newTestActionWithParams("users", "1.0.0", "read", strictParamsTestSchema, strictParamsTestParams...))
+	serr, ok := err.(*ServiceError)
+	if !ok {
+		t.Fatalf("expected a service error, got %v", err)
+	}
+	if serr.Status != unknownParamsErrorStatus {
+		t.Errorf("unexpected status: %s", serr.Status)
+	}
+	if names := serr.Metadata[UnknownParamsMetadata]; !reflect.DeepEqual(names, []string{"debug", "zone"}) {
+		t.Errorf("unexpected unknown params metadata: %v", names)
+	}
+}
@@ -0,0 +1,297 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/protocol"
+	"github.com/pebbe/zmq4"
+)
+
+// Time to wait before a worker process that exited is started again.
+const workerRespawnDelay = time.Second
+
+// Time to wait for the worker processes to finish after the termination
+// signal before they are killed.
+const workerStopTimeout = 30 * time.Second
+
+// Creates a new worker process supervisor.
+func newSupervisor(input cli.Input) *supervisor {
+	return &supervisor{input: input}
+}
+
+// Supervisor that runs the component in worker processes.
+//
+// The supervisor listens for incoming requests and forwards them to the worker
+// processes, which are started again when they exit while the component is running.
+type supervisor struct {
+	input    cli.Input
+	mutex    sync.Mutex
+	wg       sync.WaitGroup
+	workers  map[int]*exec.Cmd
+	stopping bool
+}
+
+// Get the internal address used to forward the requests to the workers.
+func (s *supervisor) getWorkerAddress() string {
+	return protocol.IPC(
+		s.input.GetComponent(),
+		s.input.GetName(),
+		s.input.GetVersion(),
+		"workers",
+		strconv.Itoa(os.Getpid()),
+	)
+}
+
+// Start a worker process.
+//
+// id: The worker number.
+// address: The internal address where the worker receives the requests.
+func (s *supervisor) spawn(id int, address string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopping {
+		return
+	}
+
+	// Workers run the same executable with the same options
+	args := append([]string{}, os.Args[1:]...)
+	args = append(args, "--worker-address", address)
+
+	// Each worker uses its own journal so the requests are only recovered by the worker that accepted them
+	if s.input.IsJournalEnabled() {
+		args = append(args, "--journal", filepath.Join(s.input.GetJournalDirectory(), fmt.Sprintf("worker-%d", id)))
+	}
+
+	cmd := exec.Command(s.input.GetPath(), args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		log.Worker.Errorf("Failed to start worker process %d: %v", id, err)
+		time.AfterFunc(workerRespawnDelay, func() { s.spawn(id, address) })
+		return
+	}
+
+	log.Worker.Debugf("Worker process %d started. PID: %d", id, cmd.Process.Pid)
+	s.workers[id] = cmd
+	s.wg.Add(1)
+
+	// Start the worker again when it exits while the component is running
+	go func() {
+		defer s.wg.Done()
+
+		err := cmd.Wait()
+
+		s.mutex.Lock()
+		delete(s.workers, id)
+		stopping := s.stopping
+		s.mutex.Unlock()
+
+		if stopping {
+			log.Worker.Debugf("Worker process %d stopped. PID: %d", id, cmd.Process.Pid)
+			return
+		}
+
+		log.Worker.Warningf("Worker process %d exited unexpectedly, restarting it. PID: %d: %v", id, cmd.Process.Pid, err)
+		time.Sleep(workerRespawnDelay)
+		s.spawn(id, address)
+	}()
+}
+
+// Send a signal to all the worker processes.
+func (s *supervisor) signal(sig os.Signal) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, cmd := range s.workers {
+		if err := cmd.Process.Signal(sig); err != nil {
+			log.Worker.Errorf("Failed to signal worker process %d: %v", id, err)
+		}
+	}
+}
+
+// Stop the worker processes and wait until they finish.
+func (s *supervisor) stop() {
+	s.terminate(workerStopTimeout)
+}
+
+// Stop the worker processes and kill the ones that don't finish in time.
+//
+// timeout: The time to wait for the workers after the termination signal.
+func (s *supervisor) terminate(timeout time.Duration) {
+	s.mutex.Lock()
+	s.stopping = true
+	s.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	s.signal(syscall.SIGTERM)
+	select {
+	case <-done:
+		return
+	case <-time.After(timeout):
+	}
+
+	log.Worker.Warningf("Worker processes didn't stop after %v, killing them", timeout)
+	s.signal(syscall.SIGKILL)
+	<-done
+}
+
+// Forward the SIGHUP signals to the workers so they reload the component variables.
+func (s *supervisor) listenReloadSignal() {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGHUP)
+
+	for range sigc {
+		log.Worker.Debug("Reload signal received, reloading workers")
+		s.signal(syscall.SIGHUP)
+	}
+}
+
+func (s *supervisor) start() error {
+	// Define a custom ZMQ context
+	zctx, err := zmq4.NewContext()
+	if err != nil {
+		return err
+	}
+
+	// SIGHUP is forwarded to the workers when there is a variables file, otherwise it terminates the component
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM}
+	if s.input.HasVariablesFile() {
+		go s.listenReloadSignal()
+	} else {
+		signals = append(signals, syscall.SIGHUP)
+	}
+
+	// Listen for termination signals
+	go func() {
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, signals...)
+		<-sigc
+		log.Worker.Debug("Termination signal received")
+		// Terminate the ZMQ context to close sockets gracefully
+		if err := zctx.Term(); err != nil {
+			log.Worker.Errorf("Failed to terminate sockets context: %v", err)
+		}
+		zmq4.SetRetryAfterEINTR(false)
+		zctx.SetRetryAfterEINTR(false)
+	}()
+
+	// Create a socket to receive incoming requests
+	frontend, err := zctx.NewSocket(zmq4.ROUTER)
+	if err != nil {
+		return fmt.Errorf("Failed to create socket: %v", err)
+	}
+	defer frontend.Close()
+
+	if err := frontend.SetLinger(0); err != nil {
+		return fmt.Errorf("Failed to set socket's linger option: %v", err)
+	}
+	if err := setSocketHWM(frontend, s.input); err != nil {
+		return err
+	}
+
+	// Create a socket to load-balance the requests between the workers
+	backend, err := zctx.NewSocket(zmq4.DEALER)
+	if err != nil {
+		return fmt.Errorf("Failed to create socket: %v", err)
+	}
+	defer backend.Close()
+
+	if err := backend.SetLinger(0); err != nil {
+		return fmt.Errorf("Failed to set socket's linger option: %v", err)
+	}
+	if err := setWorkerSocketHWM(backend, s.input); err != nil {
+		return err
+	}
+
+	workerAddress := s.getWorkerAddress()
+	if err := backend.Bind(workerAddress); err != nil {
+		return fmt.Errorf(`Failed to open workers socket at address "%s": %v`, workerAddress, err)
+	}
+	defer backend.Unbind(workerAddress)
+
+	address := getListenAddress(s.input)
+	log.Worker.Debugf(`Listening for request at address: "%s"`, address)
+	if err := bindSocket(frontend, address); err != nil {
+		return fmt.Errorf(`Failed to open socket at address "%s": %v`, address, err)
+	}
+	defer frontend.Unbind(address)
+
+	// Start the worker processes
+	s.workers = make(map[int]*exec.Cmd)
+	for id := 1; id <= int(s.input.GetWorkers()); id++ {
+		s.spawn(id, workerAddress)
+	}
+	defer s.stop()
+
+	log.Worker.Infof("Supervisor started with %d worker processes", s.input.GetWorkers())
+
+	// Create a poller to forward the requests and the responses
+	poller := zmq4.NewPoller()
+	poller.Add(frontend, zmq4.POLLIN)
+	poller.Add(backend, zmq4.POLLIN)
+
+MAIN:
+	for {
+		polled, err := poller.Poll(-1)
+		if err != nil {
+			errno := zmq4.AsErrno(err)
+			if errno == zmq4.ETERM {
+				break MAIN
+			} else if errno != zmq4.Errno(syscall.EINTR) {
+				log.Worker.Errorf("Socket poll failed: %v", err)
+			}
+			continue
+		}
+
+		for _, p := range polled {
+			// Requests are sent to the workers and responses to the clients
+			target := backend
+			if p.Socket == backend {
+				target = frontend
+			}
+
+			msg, err := p.Socket.RecvMessageBytes(0)
+			if err != nil {
+				if zmq4.AsErrno(err) == zmq4.ETERM {
+					break MAIN
+				}
+				log.Worker.Errorf("Failed to read message: %v", err)
+				continue
+			}
+
+			if _, err := target.SendMessage(msg); err != nil {
+				if zmq4.AsErrno(err) == zmq4.ETERM {
+					break MAIN
+				}
+				log.Worker.Errorf("Failed to forward message: %v", err)
+			}
+		}
+	}
+
+	log.Worker.Info("Supervisor stopped")
+	return nil
+}
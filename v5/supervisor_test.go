@@ -0,0 +1,77 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+)
+
+func TestSupervisorWorkerAddress(t *testing.T) {
+	input := cli.Input{}.WithIdentity(cli.Identity{Component: "service", Name: "users", Version: "1.0.0"})
+	address := newSupervisor(input).getWorkerAddress()
+
+	// The address is unique for each supervisor process
+	if expected := fmt.Sprintf("ipc://@kusanagi-service-users-1-0-0-workers-%d", os.Getpid()); address != expected {
+		t.Errorf("expected %s, got %s", expected, address)
+	}
+}
+
+func TestSupervisorSpawnAfterStop(t *testing.T) {
+	s := newSupervisor(cli.Input{})
+	s.workers = make(map[int]*exec.Cmd)
+	s.stop()
+
+	// Workers are not started again while the supervisor stops
+	s.spawn(1, "ipc://@kusanagi-test-workers")
+	if len(s.workers) != 0 {
+		t.Errorf("expected no workers, got %d", len(s.workers))
+	}
+}
+
+func TestSupervisorKillsWorkersAfterTimeout(t *testing.T) {
+	s := newSupervisor(cli.Input{})
+	s.workers = make(map[int]*exec.Cmd)
+
+	// The worker ignores the termination signal
+	cmd := exec.Command("sh", "-c", `trap "" TERM; echo ready; exec sleep 30`)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("failed to start the worker process: %v", err)
+	}
+
+	// Wait until the worker is ready to ignore the signal
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	s.workers[1] = cmd
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		cmd.Wait()
+	}()
+
+	start := time.Now()
+	s.terminate(100 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > 10*time.Second {
+		t.Errorf("expected the worker to be killed after the timeout, waited %v", elapsed)
+	}
+	if cmd.ProcessState == nil || cmd.ProcessState.Success() {
+		t.Errorf("expected the worker to be killed, got %v", cmd.ProcessState)
+	}
+}
@@ -0,0 +1,106 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Content types inferred for the templated HTTP response bodies.
+const (
+	templateContentTypeHTML = "text/html; charset=utf-8"
+	templateContentTypeXML  = "application/xml; charset=utf-8"
+	templateContentTypeJSON = "application/json; charset=utf-8"
+	templateContentTypeText = "text/plain; charset=utf-8"
+)
+
+// Infer the content type of a template from its contents.
+func inferTemplateContentType(tmpl string) string {
+	content := strings.ToLower(strings.TrimSpace(tmpl))
+	switch {
+	case strings.HasPrefix(content, "<?xml"):
+		return templateContentTypeXML
+	case strings.HasPrefix(content, "<"):
+		return templateContentTypeHTML
+	case strings.HasPrefix(content, "{{"):
+		// Templates that start with an action can't be inferred from the first character
+		return templateContentTypeText
+	case strings.HasPrefix(content, "{") || strings.HasPrefix(content, "["):
+		return templateContentTypeJSON
+	}
+	return templateContentTypeText
+}
+
+// Render a template.
+//
+// HTML templates are rendered with "html/template" so the values are escaped,
+// and any other content type is rendered with "text/template".
+func renderTemplate(tmpl, contentType string, data interface{}) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf(`Invalid template content type "%s": %v`, contentType, err)
+	}
+
+	var buffer bytes.Buffer
+	if mediaType == "text/html" {
+		t, perr := htmltemplate.New("body").Parse(tmpl)
+		if perr != nil {
+			return nil, fmt.Errorf("Failed to parse the body template: %v", perr)
+		}
+		err = t.Execute(&buffer, data)
+	} else {
+		t, perr := texttemplate.New("body").Parse(tmpl)
+		if perr != nil {
+			return nil, fmt.Errorf("Failed to parse the body template: %v", perr)
+		}
+		err = t.Execute(&buffer, data)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to render the body template: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// SetBodyFromTemplate renders a template and sets the result as the HTTP response body.
+//
+// The content type is inferred from the template contents: templates that start with
+// "<?xml" are XML, templates that start with any other tag are HTML, templates that
+// start with "{" or "[" are JSON, and any other template is plain text. HTML templates
+// use "html/template" so the data values are escaped. The "Content-Type" header is
+// set to the inferred content type.
+//
+// tmpl: The template using the Go template syntax.
+// data: The data to render in the template.
+func (r *HTTPResponse) SetBodyFromTemplate(tmpl string, data interface{}) (*HTTPResponse, error) {
+	return r.SetBodyFromTemplateWithType(tmpl, inferTemplateContentType(tmpl), data)
+}
+
+// SetBodyFromTemplateWithType renders a template for a content type and sets the result as the HTTP response body.
+//
+// HTML templates use "html/template" so the data values are escaped.
+// The "Content-Type" header is set to the given content type.
+//
+// tmpl: The template using the Go template syntax.
+// contentType: The content type of the rendered body.
+// data: The data to render in the template.
+func (r *HTTPResponse) SetBodyFromTemplateWithType(tmpl, contentType string, data interface{}) (*HTTPResponse, error) {
+	body, err := renderTemplate(tmpl, contentType, data)
+	if err != nil {
+		return r, err
+	}
+
+	r.SetHeader("Content-Type", contentType, true)
+	return r.SetBody(body), nil
+}
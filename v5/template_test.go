@@ -0,0 +1,79 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestInferTemplateContentType(t *testing.T) {
+	cases := map[string]string{
+		`<?xml version="1.0"?><name>{{.}}</name>`: templateContentTypeXML,
+		" <p>{{.}}</p>":     templateContentTypeHTML,
+		`{"name": "{{.}}"}`: templateContentTypeJSON,
+		`[{{.}}]`:           templateContentTypeJSON,
+		`{{.}}`:             templateContentTypeText,
+		"Hello {{.}}":       templateContentTypeText,
+	}
+
+	for tmpl, expected := range cases {
+		if value := inferTemplateContentType(tmpl); value != expected {
+			t.Errorf("%q: expected %s, got %s", tmpl, expected, value)
+		}
+	}
+}
+
+func TestHTTPResponseSetBodyFromTemplate(t *testing.T) {
+	cases := []struct {
+		tmpl        string
+		contentType string
+		body        string
+	}{
+		// HTML values are escaped
+		{"<p>{{.}}</p>", templateContentTypeHTML, "<p>&lt;b&gt;</p>"},
+		{`<?xml version="1.0"?><v>{{.}}</v>`, templateContentTypeXML, `<?xml version="1.0"?><v><b></v>`},
+		{"value: {{.}}", templateContentTypeText, "value: <b>"},
+	}
+
+	for _, c := range cases {
+		r := newHTTPResponse(&payload.HTTPResponse{Status: "200 OK", Headers: http.Header{}})
+		if _, err := r.SetBodyFromTemplate(c.tmpl, "<b>"); err != nil {
+			t.Errorf("%q: %v", c.tmpl, err)
+			continue
+		}
+
+		if body := string(r.GetBody()); body != c.body {
+			t.Errorf("%q: expected the body %q, got %q", c.tmpl, c.body, body)
+		}
+		if value := r.GetHeader("Content-Type", ""); value != c.contentType {
+			t.Errorf("%q: expected the content type %s, got %s", c.tmpl, c.contentType, value)
+		}
+	}
+}
+
+func TestHTTPResponseSetBodyFromTemplateErrors(t *testing.T) {
+	cases := map[string]string{
+		"{{.Missing": templateContentTypeText,
+		"{{.Name}}":  templateContentTypeText,
+		"{{.}}":      "invalid/",
+	}
+
+	for tmpl, contentType := range cases {
+		r := newHTTPResponse(&payload.HTTPResponse{Status: "200 OK", Headers: http.Header{}, Body: []byte("current")})
+		if _, err := r.SetBodyFromTemplateWithType(tmpl, contentType, 42); err == nil {
+			t.Errorf("%q: expected an error", tmpl)
+		}
+		if body := string(r.GetBody()); body != "current" {
+			t.Errorf("%q: expected the body not to change, got %q", tmpl, body)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/datatypes"
+)
+
+// EntityTemplateName is the name used in the parameter templates to reference the entity.
+const EntityTemplateName = "entity"
+
+// Expression that matches the entity references in the parameter values, like "${entity.id}".
+var entityTemplateRe = regexp.MustCompile(`\$\{entity((?:\.[^.}]+)*)\}`)
+
+// EntityField returns a reference to an entity field to use as a transaction parameter value.
+//
+// The reference is resolved when the transaction is executed, so the transactions can use the
+// entity values without copying them. Nested fields are separated by dots, and the items of a
+// collection are referenced by index, for example "items.0.id". An empty path references
+// the whole entity.
+//
+// path: The path to the entity field.
+func EntityField(path string) string {
+	if path == "" {
+		return "${" + EntityTemplateName + "}"
+	}
+	return "${" + EntityTemplateName + "." + path + "}"
+}
+
+// Get the value of an entity field.
+//
+// entity: The entity.
+// path: The field names separated by dots.
+func getEntityField(entity interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(path, ".")
+
+	value := entity
+	for _, name := range strings.Split(path, ".") {
+		if name == "" {
+			continue
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			field, ok := v[name]
+			if !ok {
+				return nil, fmt.Errorf(`The entity field doesn't exist: "%s"`, path)
+			}
+			value = field
+		case []interface{}:
+			index, err := strconv.Atoi(name)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf(`The entity item doesn't exist: "%s"`, path)
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf(`The entity field is not an object or a collection: "%s"`, path)
+		}
+	}
+
+	// Objects and collections are not normalized to avoid changing the entity
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return value, nil
+	}
+	return datatypes.Normalize(value, ""), nil
+}
+
+// Resolve the entity references of a parameter.
+//
+// When the value is a single reference the parameter gets the value and type of the entity
+// field, otherwise the references are replaced by the field values in the string value.
+func resolveParamTemplate(p *Param, entity interface{}) (*Param, error) {
+	value, ok := p.GetValue().(string)
+	if !ok || !strings.Contains(value, "${"+EntityTemplateName) {
+		return p, nil
+	}
+
+	if match := entityTemplateRe.FindStringSubmatch(value); match != nil && match[0] == value {
+		field, err := getEntityField(entity, match[1])
+		if err != nil {
+			return nil, fmt.Errorf(`Failed to resolve param "%s": %v`, p.GetName(), err)
+		}
+		return &Param{p.GetName(), field, datatypes.ResolveType(field), p.Exists(), p.GetFormat()}, nil
+	}
+
+	var err error
+	resolved := entityTemplateRe.ReplaceAllStringFunc(value, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+
+		var field interface{}
+		if field, err = getEntityField(entity, entityTemplateRe.FindStringSubmatch(ref)[1]); err != nil {
+			return ref
+		}
+
+		// Only scalar values can be part of a string
+		switch reflect.ValueOf(field).Kind() {
+		case reflect.Map, reflect.Slice:
+			err = fmt.Errorf(`The entity field must be a scalar value: "%s"`, ref)
+			return ref
+		}
+		return fmt.Sprint(field)
+	})
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to resolve param "%s": %v`, p.GetName(), err)
+	}
+	return p.CopyWithValue(resolved), nil
+}
+
+// ResolveParamTemplates resolves the entity references in the parameter values.
+//
+// The parameters without references are returned without changes.
+//
+// params: The parameters.
+// entity: The entity used to resolve the references.
+func ResolveParamTemplates(params []*Param, entity interface{}) ([]*Param, error) {
+	resolved := make([]*Param, 0, len(params))
+	for _, p := range params {
+		p, err := resolveParamTemplate(p, entity)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// Check if any of the parameters references the entity.
+func hasParamTemplates(params []*Param) bool {
+	for _, p := range params {
+		if v, ok := p.GetValue().(string); ok && entityTemplateRe.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasParamTemplates checks if any of the transaction parameters references the entity.
+func (t Transaction) HasParamTemplates() bool {
+	return hasParamTemplates(t.params)
+}
+
+// Check if the entity references of the transaction parameters match the values of a set of parameters.
+//
+// params: The parameter values by name.
+func (t Transaction) matchParamTemplates(params map[string]interface{}) bool {
+	for _, p := range t.params {
+		if v, ok := p.GetValue().(string); ok && entityTemplateRe.MatchString(v) && params[p.GetName()] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveParams returns the transaction parameters with the entity references resolved.
+//
+// The TransactionRunner resolves the references before the transactions are called.
+//
+// entity: The entity used to resolve the references.
+func (t Transaction) ResolveParams(entity interface{}) ([]*Param, error) {
+	return ResolveParamTemplates(t.GetParams(), entity)
+}
+
+// Get the last entity or collection saved in the transport data by a service action.
+func (t Transport) getActionEntity(service, version, action string) (entity interface{}, ok bool) {
+	for _, data := range t.GetData() {
+		if data.GetName() != service || data.GetVersion() != version {
+			continue
+		}
+
+		if values := data.actions[action]; len(values) > 0 {
+			entity, ok = values[len(values)-1], true
+		}
+	}
+	return entity, ok
+}
+
+// GetResolvedParams returns the action parameters with the entity references resolved.
+//
+// The framework calls the actions of the transactions with the parameters as they were
+// registered, without resolving the entity references like "${entity.id}", so the actions
+// that run as transactions must use it instead of GetParams. The references are resolved
+// using the last entity saved in the transport data by the action that registered the
+// transaction, which is found in the transport using the name and the parameters of the
+// current action. An error is returned when the transaction or the entity is not available.
+func (a *Action) GetResolvedParams() ([]*Param, error) {
+	params := a.GetParams()
+	if !hasParamTemplates(params) {
+		return params, nil
+	}
+
+	values := make(map[string]interface{}, len(params))
+	for _, p := range params {
+		values[p.GetName()] = p.GetValue()
+	}
+
+	transport := Transport{a.transport}
+	for _, command := range []string{Commit, Rollback, Complete} {
+		transactions, _ := transport.GetTransactions(command)
+		for _, trx := range transactions {
+			if trx.GetName() != a.GetName() || trx.GetVersion() != a.GetVersion() || trx.GetCalleeAction() != a.GetActionName() {
+				continue
+			}
+
+			if !trx.matchParamTemplates(values) {
+				continue
+			}
+
+			entity, ok := transport.getActionEntity(trx.GetName(), trx.GetVersion(), trx.GetCallerAction())
+			if !ok {
+				return nil, fmt.Errorf(`The entity of action "%s" is not available to resolve the params`, trx.GetCallerAction())
+			}
+			return ResolveParamTemplates(params, entity)
+		}
+	}
+	return nil, fmt.Errorf(`The transaction for action "%s" is not available to resolve the params`, a.GetActionName())
+}
@@ -0,0 +1,202 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Entity used to resolve the parameter templates in the tests.
+func newTemplateTestEntity() map[string]interface{} {
+	return map[string]interface{}{
+		"id":   42,
+		"name": "jane",
+		"tags": []interface{}{"admin", "staff"},
+		"address": map[string]interface{}{
+			"city": "Paris",
+		},
+	}
+}
+
+// Get the parameter type of a value.
+func valueTypeOf(value interface{}) string {
+	if _, ok := value.(string); ok {
+		return payload.TypeString
+	}
+	return payload.TypeInteger
+}
+
+func TestEntityField(t *testing.T) {
+	if ref := EntityField("address.city"); ref != "${entity.address.city}" {
+		t.Errorf("unexpected reference: %s", ref)
+	}
+
+	if ref := EntityField(""); ref != "${entity}" {
+		t.Errorf("unexpected reference to the entity: %s", ref)
+	}
+}
+
+func TestResolveParamTemplates(t *testing.T) {
+	entity := newTemplateTestEntity()
+
+	cases := []struct {
+		name      string
+		value     interface{}
+		expected  interface{}
+		valueType string
+	}{
+		{"id", EntityField("id"), int64(42), payload.TypeInteger},
+		{"city", EntityField("address.city"), "Paris", payload.TypeString},
+		{"tag", EntityField("tags.1"), "staff", payload.TypeString},
+		{"label", "user ${entity.name} (${entity.id})", "user jane (42)", payload.TypeString},
+		{"plain", "no references", "no references", payload.TypeString},
+		{"number", 7, 7, payload.TypeInteger},
+	}
+
+	for _, c := range cases {
+		p, err := newParam(c.name, c.value, valueTypeOf(c.value), true)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		resolved, err := ResolveParamTemplates([]*Param{p}, entity)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+
+		if value := resolved[0].GetValue(); value != c.expected {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", c.name, c.expected, c.expected, value, value)
+		}
+		if valueType := resolved[0].GetType(); valueType != c.valueType {
+			t.Errorf("%s: expected type %s, got %s", c.name, c.valueType, valueType)
+		}
+	}
+
+	// Objects and collections keep their type
+	p, err := newParam("address", EntityField("address"), payload.TypeString, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resolved, err := ResolveParamTemplates([]*Param{p}, entity); err != nil {
+		t.Error(err)
+	} else if resolved[0].GetType() != payload.TypeObject {
+		t.Errorf("expected an object, got %s", resolved[0].GetType())
+	}
+}
+
+func TestResolveParamTemplatesErrors(t *testing.T) {
+	entity := newTemplateTestEntity()
+
+	for _, value := range []string{
+		EntityField("missing"),
+		EntityField("tags.5"),
+		EntityField("tags.first"),
+		EntityField("name.first"),
+		"tags: ${entity.tags}",
+	} {
+		p, err := newParam("value", value, payload.TypeString, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ResolveParamTemplates([]*Param{p}, entity); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+// Create a transport with an entity and a rollback transaction that references it.
+func newTemplateTestTransport() *payload.Transport {
+	params := []payload.Param{
+		{Name: "id", Value: EntityField("id"), Type: payload.TypeString},
+		{Name: "label", Value: "user ${entity.name}", Type: payload.TypeString},
+	}
+
+	t := &payload.Transport{}
+	t.Meta.Gateway = []string{"ktp://internal", "http://public"}
+	t.SetData("users", "1.0.0", "create", map[string]interface{}{"id": "1"})
+	t.SetData("users", "1.0.0", "create", newTemplateTestEntity())
+	t.SetTransaction(Rollback, "users", "1.0.0", "create", "undo", params)
+	return t
+}
+
+func TestTransactionResolveParams(t *testing.T) {
+	transport := Transport{newTemplateTestTransport()}
+	transactions, err := transport.GetTransactions(Rollback)
+	if err != nil || len(transactions) != 1 {
+		t.Fatalf("expected the rollback transaction, got %v %v", transactions, err)
+	}
+
+	trx := transactions[0]
+	if !trx.HasParamTemplates() {
+		t.Fatal("expected the transaction to have templates")
+	}
+
+	// The last entity saved by the action is used
+	entity, ok := transport.getActionEntity(trx.GetName(), trx.GetVersion(), trx.GetCallerAction())
+	if !ok {
+		t.Fatal("expected the action entity")
+	}
+
+	params, err := trx.ResolveParams(entity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]interface{}{}
+	for _, p := range params {
+		values[p.GetName()] = p.GetValue()
+	}
+	if values["id"] != int64(42) || values["label"] != "user jane" {
+		t.Errorf("unexpected params: %v", values)
+	}
+}
+
+func TestActionGetResolvedParams(t *testing.T) {
+	// The framework calls the transaction action with the unresolved params
+	s := newTestState("users", "1.0.0", "undo", newTemplateTestTransport())
+	s.command.Command.Arguments.Params = payload.ActionParams{
+		{Name: "id", Value: EntityField("id"), Type: payload.TypeString},
+		{Name: "label", Value: "user ${entity.name}", Type: payload.TypeString},
+	}
+	action := newAction(NewService(), s)
+
+	params, err := action.GetResolvedParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]interface{}{}
+	for _, p := range params {
+		values[p.GetName()] = p.GetValue()
+	}
+	if values["id"] != int64(42) || values["label"] != "user jane" {
+		t.Errorf("unexpected params: %v", values)
+	}
+
+	// Params that don't match a transaction can't be resolved
+	s = newTestState("users", "1.0.0", "undo", newTemplateTestTransport())
+	s.command.Command.Arguments.Params = payload.ActionParams{
+		{Name: "id", Value: EntityField("uuid"), Type: payload.TypeString},
+	}
+	if _, err := newAction(NewService(), s).GetResolvedParams(); err == nil {
+		t.Error("expected an error without a matching transaction")
+	}
+
+	// Params without references are returned as they are
+	s = newTestState("users", "1.0.0", "undo", nil)
+	s.command.Command.Arguments.Params = payload.ActionParams{{Name: "id", Value: "1", Type: payload.TypeString}}
+	if params, err := newAction(NewService(), s).GetResolvedParams(); err != nil || len(params) != 1 || params[0].GetValue() != "1" {
+		t.Errorf("expected the params without changes, got %v %v", params, err)
+	}
+}
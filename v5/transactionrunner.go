@@ -0,0 +1,151 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// NewTransactionRunner creates a runner for the transactions registered in a transport.
+//
+// The transactions are executed as run-time calls made by the given action,
+// so each transaction must be configured as a call in the action's config.
+//
+// action: The action used to make the run-time calls.
+// transport: The transport with the registered transactions.
+func NewTransactionRunner(action *Action, transport *Transport) *TransactionRunner {
+	return &TransactionRunner{action, transport, 0}
+}
+
+// TransactionRunner executes the transactions registered in a transport.
+type TransactionRunner struct {
+	action    *Action
+	transport *Transport
+	timeout   uint
+}
+
+// SetTimeout sets the timeout in milliseconds for each transaction call.
+//
+// timeout: The timeout in milliseconds.
+func (r *TransactionRunner) SetTimeout(timeout uint) *TransactionRunner {
+	r.timeout = timeout
+	return r
+}
+
+// Run executes the transactions for the given commands.
+//
+// The commands are processed in the given order, and for each command the transactions
+// are called in the order they were registered. At least one command is required, so
+// commit and rollback transactions are never executed together by default. Use RunOutcome
+// to select the commands from the outcome of the request.
+// The entity references in the parameters, like "${entity.id}", are resolved using
+// the last entity saved in the transport data by the action that registered the transaction.
+//
+// An error is returned when a command is invalid or when any of the transactions fail.
+// The results contain the return value or the error for each executed transaction.
+//
+// commands: The transaction commands.
+func (r *TransactionRunner) Run(commands ...string) (results []TransactionResult, err error) {
+	if len(commands) == 0 {
+		return nil, errors.New("At least one transaction command is required")
+	}
+
+	failed := 0
+	for _, command := range commands {
+		transactions, err := r.transport.GetTransactions(command)
+		if err != nil {
+			return results, err
+		}
+
+		for _, trx := range transactions {
+			result := TransactionResult{transaction: trx}
+
+			// Entity references are resolved using the data saved by the action that registered the transaction
+			params := trx.GetParams()
+			if trx.HasParamTemplates() {
+				entity, _ := r.transport.getActionEntity(trx.GetName(), trx.GetVersion(), trx.GetCallerAction())
+				params, result.err = trx.ResolveParams(entity)
+			}
+
+			if result.err == nil {
+				result.returnValue, result.err = r.action.Call(
+					trx.GetName(),
+					trx.GetVersion(),
+					trx.GetCalleeAction(),
+					params,
+					nil,
+					r.timeout,
+				)
+			}
+
+			if result.err != nil {
+				failed++
+				r.action.logger.Errorf(
+					`Transaction "%s" failed for "%s" (%s) action "%s": %v`,
+					command,
+					trx.GetName(),
+					trx.GetVersion(),
+					trx.GetCalleeAction(),
+					result.err,
+				)
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d transactions failed", failed, len(results))
+	}
+
+	return results, nil
+}
+
+// RunOutcome executes the transactions for the outcome of a request.
+//
+// The "commit" transactions are executed when the request succeeded, otherwise
+// the "rollback" transactions are executed. The "complete" transactions are
+// always executed at the end.
+//
+// success: True when the request finished successfully.
+func (r *TransactionRunner) RunOutcome(success bool) ([]TransactionResult, error) {
+	if success {
+		return r.Run(Commit, Complete)
+	}
+
+	return r.Run(Rollback, Complete)
+}
+
+// TransactionResult contains the result of a transaction call.
+type TransactionResult struct {
+	transaction Transaction
+	returnValue interface{}
+	err         error
+}
+
+// GetTransaction returns the transaction that was executed.
+func (r TransactionResult) GetTransaction() Transaction {
+	return r.transaction
+}
+
+// GetReturnValue returns the value returned by the transaction call.
+func (r TransactionResult) GetReturnValue() interface{} {
+	return r.returnValue
+}
+
+// GetError returns the error when the transaction call failed.
+func (r TransactionResult) GetError() error {
+	return r.err
+}
+
+// Failed checks if the transaction call failed.
+func (r TransactionResult) Failed() bool {
+	return r.err != nil
+}
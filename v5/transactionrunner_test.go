@@ -0,0 +1,56 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestTransactionRunnerRunsSelectedCommands(t *testing.T) {
+	// The parameters reference a missing entity so the transactions fail without making calls
+	params := []payload.Param{{Name: "id", Value: EntityField("id"), Type: payload.TypeString}}
+
+	tr := &payload.Transport{}
+	tr.Meta.Gateway = []string{"ktp://internal", "http://public"}
+	tr.SetTransaction(Commit, "users", "1.0.0", "create", "save", params)
+	tr.SetTransaction(Rollback, "users", "1.0.0", "create", "undo", params)
+	tr.SetTransaction(Complete, "users", "1.0.0", "create", "cleanup", params)
+
+	runner := NewTransactionRunner(newTestAction("users", "1.0.0", "create", nil), &Transport{tr})
+
+	if _, err := runner.Run(); err == nil {
+		t.Error("expected an error when no commands are given")
+	}
+
+	cases := []struct {
+		run      func() ([]TransactionResult, error)
+		expected []string
+	}{
+		{func() ([]TransactionResult, error) { return runner.Run(Rollback) }, []string{"undo"}},
+		{func() ([]TransactionResult, error) { return runner.Run(Commit, Complete) }, []string{"save", "cleanup"}},
+		{func() ([]TransactionResult, error) { return runner.RunOutcome(true) }, []string{"save", "cleanup"}},
+		{func() ([]TransactionResult, error) { return runner.RunOutcome(false) }, []string{"undo", "cleanup"}},
+	}
+
+	for i, c := range cases {
+		results, _ := c.run()
+		if len(results) != len(c.expected) {
+			t.Errorf("case %d: expected %d results, got %d", i, len(c.expected), len(results))
+			continue
+		}
+
+		for j, result := range results {
+			if name := result.GetTransaction().GetCalleeAction(); name != c.expected[j] {
+				t.Errorf("case %d: expected transaction %q, got %q", i, c.expected[j], name)
+			}
+		}
+	}
+}
@@ -10,7 +10,9 @@ package kusanagi
 
 import (
 	"fmt"
+	"strconv"
 
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/json"
 	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
 )
 
@@ -24,6 +26,13 @@ func (t Transport) GetRequestID() string {
 	return t.payload.Meta.ID
 }
 
+// Hash returns the SHA-256 hash of the transport as an hexadecimal string.
+//
+// The hash is the same for transports with equal values.
+func (t Transport) Hash() (string, error) {
+	return t.payload.Hash()
+}
+
 // GetRequestTimestamp returns the request creation timestamp.
 func (t Transport) GetRequestTimestamp() string {
 	return t.payload.Meta.Datetime
@@ -63,6 +72,91 @@ func (t Transport) GetProperty(name, preset string) string {
 	return preset
 }
 
+// HasProperty checks if a userland property exists.
+//
+// name: The name of the property.
+func (t Transport) HasProperty(name string) bool {
+	_, exists := t.payload.Meta.Properties[name]
+	return exists
+}
+
+// GetPropertyInt returns a userland property value as an integer.
+//
+// name: The name of the property.
+// preset: The default value to use when the property doesn't exist.
+func (t Transport) GetPropertyInt(name string, preset int) (int, error) {
+	value, exists := t.payload.Meta.Properties[name]
+	if !exists {
+		return preset, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return preset, fmt.Errorf(`The value of the property "%s" is not an integer: "%s"`, name, value)
+	}
+	return number, nil
+}
+
+// GetPropertyBool returns a userland property value as a boolean.
+//
+// name: The name of the property.
+// preset: The default value to use when the property doesn't exist.
+func (t Transport) GetPropertyBool(name string, preset bool) (bool, error) {
+	value, exists := t.payload.Meta.Properties[name]
+	if !exists {
+		return preset, nil
+	}
+
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		return preset, fmt.Errorf(`The value of the property "%s" is not a boolean: "%s"`, name, value)
+	}
+	return flag, nil
+}
+
+// GetPropertyJSON reads a userland property value serialized as JSON.
+//
+// The value is not changed when the property doesn't exist.
+//
+// name: The name of the property.
+// value: A pointer where to decode the property value.
+func (t Transport) GetPropertyJSON(name string, value interface{}) error {
+	data, exists := t.payload.Meta.Properties[name]
+	if !exists {
+		return nil
+	}
+
+	if err := json.Decode([]byte(data), value); err != nil {
+		return fmt.Errorf(`Failed to read the JSON value of the property "%s": %v`, name, err)
+	}
+	return nil
+}
+
+// GetNamespaceProperties returns the userland properties in a namespace.
+//
+// The names of the properties in the result don't include the namespace.
+//
+// namespace: The property namespace.
+func (t Transport) GetNamespaceProperties(namespace string) map[string]string {
+	return getNamespaceProperties(t.payload.Meta.Properties, namespace)
+}
+
+// GetPagination returns the pagination metadata of a collection returned by an action.
+//
+// The result is nil when the action didn't return a paginated collection.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+func (t Transport) GetPagination(service, version, action string) (*Pagination, error) {
+	value := t.GetProperty(getPaginationProperty(service, version, action), "")
+	if value == "" {
+		return nil, nil
+	}
+
+	return decodePagination(value)
+}
+
 // GetProperties returns all the userland properties.
 func (t Transport) GetProperties() map[string]string {
 	if t.payload.Meta.Properties == nil {
@@ -96,6 +190,7 @@ func (t Transport) GetDownload() *File {
 
 // GetData returns the transport data.
 func (t Transport) GetData() (data []ServiceData) {
+	t.payload.Load()
 	if t.payload.Data == nil {
 		return nil
 	}
@@ -145,8 +240,34 @@ func (t Transport) GetLinks() (links []Link) {
 	return links
 }
 
+// HasFallbacks checks if fallbacks were triggered during the request.
+//
+// Responses are degraded when fallbacks were triggered, because the
+// fallback values were used instead of the results of the actions.
+func (t Transport) HasFallbacks() bool {
+	return len(t.payload.Meta.Fallbacks) > 0
+}
+
+// GetFallbacks returns the fallbacks triggered during the request.
+func (t Transport) GetFallbacks() (fallbacks []Fallback) {
+	for _, f := range t.payload.Meta.Fallbacks {
+		fallbacks = append(fallbacks, Fallback{f.GetName(), f.GetVersion(), f.GetActionNames()})
+	}
+
+	return fallbacks
+}
+
 // GetCalls returns the service calls.
 func (t Transport) GetCalls() (callers []Caller) {
+	return t.FilterCalls(nil)
+}
+
+// FilterCalls returns the service calls that match a filter.
+//
+// All the calls are returned when the filter is nil.
+//
+// filter: A function that returns true for the calls to include in the result.
+func (t Transport) FilterCalls(filter func(Caller) bool) (callers []Caller) {
 	if t.payload.Calls == nil {
 		return nil
 	}
@@ -160,11 +281,18 @@ func (t Transport) GetCalls() (callers []Caller) {
 					version:  call.Version,
 					action:   call.Action,
 					duration: call.Duration,
+					attempts: call.Attempts,
 					timeout:  call.Timeout,
 					params:   payloadToParams(call.Params),
+					retry:    payloadToRetryPolicy(call.Retry),
+					group:    call.Group,
+					priority: call.Priority,
 				}
 				action := call.Caller
-				callers = append(callers, Caller{service, version, action, callee})
+				caller := Caller{service, version, action, callee}
+				if filter == nil || filter(caller) {
+					callers = append(callers, caller)
+				}
 			}
 		}
 	}
@@ -172,6 +300,42 @@ func (t Transport) GetCalls() (callers []Caller) {
 	return callers
 }
 
+// GetPendingCalls returns the service calls that are not executed yet.
+//
+// Pending calls are the deferred calls and the calls to other realms.
+func (t Transport) GetPendingCalls() []Caller {
+	return t.FilterCalls(func(c Caller) bool {
+		return c.callee.IsPending()
+	})
+}
+
+// GetCompletedCalls returns the run-time calls that were already executed.
+func (t Transport) GetCompletedCalls() []Caller {
+	return t.FilterCalls(func(c Caller) bool {
+		return !c.callee.IsPending()
+	})
+}
+
+// GetCallsTo returns the service calls made to a service.
+//
+// name: The name of the service being called.
+// version: The version of the service being called, or empty to match any version.
+func (t Transport) GetCallsTo(name, version string) []Caller {
+	return t.FilterCalls(func(c Caller) bool {
+		return c.callee.name == name && (version == "" || c.callee.version == version)
+	})
+}
+
+// GetCallsFrom returns the service calls made by a service.
+//
+// name: The name of the service making the calls.
+// version: The version of the service making the calls, or empty to match any version.
+func (t Transport) GetCallsFrom(name, version string) []Caller {
+	return t.FilterCalls(func(c Caller) bool {
+		return c.service == name && (version == "" || c.version == version)
+	})
+}
+
 // GetTransactions returns the transactions for a specific type.
 //
 // The transaction type is case sensitive, and supports "commit", "rollback" or "complete" as value.
@@ -199,22 +363,35 @@ func (t Transport) GetTransactions(command string) ([]Transaction, error) {
 }
 
 // GetErrors returns the transport errors.
-func (t Transport) GetErrors() (result []Error) {
-	if t.payload.Errors == nil {
-		return nil
-	}
+func (t Transport) GetErrors() []Error {
+	return getTransportErrors(t.payload.Errors)
+}
+
+// GetWarnings returns the transport warnings.
+//
+// Warnings are the errors with warning severity that were added by
+// components configured to not fail the requests on warnings.
+func (t Transport) GetWarnings() []Error {
+	return getTransportErrors(t.payload.Warnings)
+}
 
-	for address, services := range t.payload.Errors {
+// Get the errors from a transport errors payload.
+func getTransportErrors(errors payload.Errors) (result []Error) {
+	for address, services := range errors {
 		for service, versions := range services {
 			for version, errors := range versions {
 				for _, err := range errors {
 					result = append(result, Error{
-						address: address,
-						service: service,
-						version: version,
-						message: err.GetMessage(),
-						code:    err.GetCode(),
-						status:  err.GetStatus(),
+						address:  address,
+						service:  service,
+						version:  version,
+						message:  err.GetMessage(),
+						code:     err.GetCode(),
+						status:   err.GetStatus(),
+						metadata: err.GetMetadata(),
+						severity: err.GetSeverity(),
+						count:    err.GetCount(),
+						omitted:  err.GetOmitted(),
 					})
 				}
 			}
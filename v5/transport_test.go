@@ -0,0 +1,60 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+// Get the names of the callees of a list of calls, sorted by name.
+func getCalleeNames(callers []Caller) string {
+	var names []string
+	for _, c := range callers {
+		names = append(names, c.GetCallee().GetName())
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestTransportFilterCalls(t *testing.T) {
+	transport := Transport{&payload.Transport{Calls: payload.Calls{
+		"users": {"1.0.0": []payload.Call{
+			{Name: "posts", Version: "1.0.0", Action: "list", Caller: "read", Duration: 12},
+			{Name: "mails", Version: "1.0.0", Action: "send", Caller: "read"},
+		}},
+		"posts": {"1.0.0": []payload.Call{
+			{Name: "comments", Version: "2.0.0", Action: "list", Caller: "list", Duration: 3},
+		}},
+	}}}
+
+	cases := []struct {
+		name     string
+		callers  []Caller
+		expected string
+	}{
+		{"all", transport.GetCalls(), "comments,mails,posts"},
+		{"pending", transport.GetPendingCalls(), "mails"},
+		{"completed", transport.GetCompletedCalls(), "comments,posts"},
+		{"to service", transport.GetCallsTo("comments", ""), "comments"},
+		{"to version", transport.GetCallsTo("comments", "1.0.0"), ""},
+		{"from service", transport.GetCallsFrom("users", "1.0.0"), "mails,posts"},
+		{"from version", transport.GetCallsFrom("users", "2.0.0"), ""},
+		{"custom", transport.FilterCalls(func(c Caller) bool { return c.GetAction() == "list" }), "comments"},
+	}
+
+	for _, c := range cases {
+		if names := getCalleeNames(c.callers); names != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, names)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/log"
+)
+
+// TransportAuditLogVariable is the name of the component variable that enables
+// the logging of the transport audit entries at DEBUG level.
+//
+// The transport audit is only recorded when the component runs in debug mode or
+// when the request is sampled, and it is always logged for sampled requests.
+const TransportAuditLogVariable = "transport-audit-log"
+
+// Create a new transport audit trail.
+func newTransportAudit(logger log.RequestLogger, logged bool) *transportAudit {
+	return &transportAudit{logger: logger, logged: logged}
+}
+
+// Audit trail with the changes made by an action to the transport.
+type transportAudit struct {
+	mutex   sync.Mutex
+	logger  log.RequestLogger
+	logged  bool
+	entries []TransportAuditEntry
+}
+
+// Record a change made to the transport.
+//
+// The location of the change is the caller of the action method that changed the transport.
+func (t *transportAudit) record(operation, format string, args ...interface{}) {
+	entry := TransportAuditEntry{
+		operation: operation,
+		details:   fmt.Sprintf(format, args...),
+		timestamp: time.Now(),
+	}
+
+	// Skip the audit and the action method frames
+	if _, file, line, ok := runtime.Caller(3); ok {
+		entry.location = filepath.Base(file) + ":" + strconv.Itoa(line)
+	}
+
+	t.mutex.Lock()
+	t.entries = append(t.entries, entry)
+	t.mutex.Unlock()
+
+	if t.logged {
+		t.logger.Debugf("Transport audit: %s", entry)
+	}
+}
+
+// Get a copy of the audit entries.
+func (t *transportAudit) getEntries() []TransportAuditEntry {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return append([]TransportAuditEntry{}, t.entries...)
+}
+
+// TransportAuditEntry contains the information of a change made to the transport.
+type TransportAuditEntry struct {
+	operation string
+	details   string
+	timestamp time.Time
+	location  string
+}
+
+// GetOperation returns the name of the operation that changed the transport.
+func (e TransportAuditEntry) GetOperation() string {
+	return e.operation
+}
+
+// GetDetails returns a description of the change.
+func (e TransportAuditEntry) GetDetails() string {
+	return e.details
+}
+
+// GetTimestamp returns the time when the change was made.
+func (e TransportAuditEntry) GetTimestamp() time.Time {
+	return e.timestamp
+}
+
+// GetLocation returns the source file and line where the change was made.
+func (e TransportAuditEntry) GetLocation() string {
+	return e.location
+}
+
+func (e TransportAuditEntry) String() string {
+	return fmt.Sprintf(
+		"[%s] %s: %s (%s)",
+		e.timestamp.UTC().Format("2006-01-02T15:04:05.000"),
+		e.operation,
+		e.details,
+		e.location,
+	)
+}
@@ -0,0 +1,67 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestActionGetTransportAudit(t *testing.T) {
+	s := newTestState("users", "1.0.0", "read", nil)
+	s.sampled = true
+	a := newAction(NewService(), s)
+
+	a.SetProperty("user", "jane")
+	if _, err := a.SetLink("self", "/users/1"); err != nil {
+		t.Fatal(err)
+	}
+	a.Error("Invalid user", 1, "400 Bad Request")
+
+	expected := []struct {
+		operation string
+		details   string
+	}{
+		{"SetProperty", `"user" = "jane"`},
+		{"SetLink", `"self" = "/users/1"`},
+		{"Error", `"Invalid user" (1 400 Bad Request)`},
+	}
+
+	entries := a.GetTransportAudit()
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d audit entries, got %d", len(expected), len(entries))
+	}
+	for i, e := range expected {
+		entry := entries[i]
+		if entry.GetOperation() != e.operation || entry.GetDetails() != e.details {
+			t.Errorf("%s: unexpected audit entry: %s", e.operation, entry)
+		}
+		// The location is where the action method was called
+		if !strings.HasPrefix(entry.GetLocation(), "transportaudit_test.go:") {
+			t.Errorf("%s: unexpected location: %s", e.operation, entry.GetLocation())
+		}
+		if entry.GetTimestamp().IsZero() {
+			t.Errorf("%s: expected a timestamp", e.operation)
+		}
+	}
+
+	// Changes to the result don't change the audit trail
+	entries[0].operation = "changed"
+	if a.GetTransportAudit()[0].GetOperation() != "SetProperty" {
+		t.Error("expected the audit entries not to change")
+	}
+}
+
+func TestActionGetTransportAuditDisabled(t *testing.T) {
+	a := newTestAction("users", "1.0.0", "read", nil)
+	a.SetProperty("user", "jane")
+	if entries := a.GetTransportAudit(); entries != nil {
+		t.Errorf("expected no audit entries, got %v", entries)
+	}
+}
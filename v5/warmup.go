@@ -0,0 +1,108 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/protocol"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/runtime"
+)
+
+// WarmupCallback is called when the component receives the first mapping.
+type WarmupCallback func(*Warmup) error
+
+// Creates the warmup API.
+func newWarmup(c Component, input cli.Input, schemas payload.Mapping, pool *runtime.Pool) *Warmup {
+	return &Warmup{c, input, schemas, pool}
+}
+
+// Warmup API used to prepare the component before it handles the requests.
+//
+// It gives access to the first mapping received from the framework, so it can
+// be used to create clients, prime caches or check that the callee services exist.
+type Warmup struct {
+	component Component
+	input     cli.Input
+	schemas   payload.Mapping
+	pool      *runtime.Pool
+}
+
+// GetComponent returns the component being warmed up.
+func (w *Warmup) GetComponent() Component {
+	return w.component
+}
+
+// GetServices return service names and versions from the mapping schemas.
+func (w *Warmup) GetServices() []payload.ServiceVersion {
+	return w.schemas.GetServices()
+}
+
+// GetServiceSchema returns a schema for a service.
+//
+// The version can be either a fixed version or a pattern that uses "*"
+// and resolves to the higher version available that matches.
+//
+// name: The name of the service.
+// version: The version of the service.
+func (w *Warmup) GetServiceSchema(name, version string) (*ServiceSchema, error) {
+	schema, err := w.schemas.GetSchema(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return &ServiceSchema{name, version, *schema}, nil
+}
+
+// RequireAction checks that an action exists in the mapping.
+//
+// The error explains what is missing, so it can be returned by the warmup
+// callback when the component depends on the action.
+//
+// service: The service name.
+// version: The service version.
+// action: The action name.
+func (w *Warmup) RequireAction(service, version, action string) error {
+	schema, err := w.GetServiceSchema(service, version)
+	if err != nil {
+		return fmt.Errorf(`The required service "%s" (%s) is not available in the mapping, check that it is running in the realm: %v`, service, version, err)
+	}
+
+	if !schema.HasAction(action) {
+		return fmt.Errorf(`The required action "%s" is not defined by the service "%s" (%s), check the service configuration`, action, service, version)
+	}
+	return nil
+}
+
+// PreconnectRuntimeCalls opens sockets for the run-time calls before the first call is made.
+//
+// The sockets are kept open for the calls, so it only works when the run-time
+// call socket pool is enabled with the "runtime-call-pool-size" component variable.
+//
+// sockets: The number of sockets to open.
+func (w *Warmup) PreconnectRuntimeCalls(sockets int) error {
+	if w.pool == nil {
+		return errors.New("The run-time call socket pool is disabled")
+	}
+
+	address := protocol.SocketAddress(w.input.GetComponentAddress(), w.input.IsTCPEnabled())
+	return w.pool.Preconnect(address, sockets)
+}
+
+// Run the warmup callback of the component.
+//
+// The callback runs in its own goroutine, so the component keeps processing
+// requests while it is warmed up.
+func (s *server) warmup(schemas payload.Mapping) {
+	c := s.component.(*component)
+	w := newWarmup(c, s.input, schemas, s.pool)
+	go c.events.warmup(w)
+}
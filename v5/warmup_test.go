@@ -0,0 +1,58 @@
+// Go SDK for the KUSANAGI(tm) framework (http://kusanagi.io)
+// Copyright (c) 2016-2023 KUSANAGI S.L. All rights reserved.
+//
+// Distributed under the MIT license.
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package kusanagi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/cli"
+	"github.com/kusanagi/kusanagi-sdk-go/v5/lib/payload"
+)
+
+func TestWarmupCallbackPanicIsRecovered(t *testing.T) {
+	events := eventsHandler{onWarmup: func(*Warmup) error {
+		panic("warmup failed")
+	}}
+
+	if events.warmup(&Warmup{}) {
+		t.Error("expected the warmup to fail")
+	}
+
+	events.onWarmup = func(*Warmup) error { return errors.New("warmup failed") }
+	if events.warmup(&Warmup{}) {
+		t.Error("expected the warmup to fail")
+	}
+}
+
+func TestServerWarmupRunsInBackground(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	c := NewService()
+	c.Warmup(func(w *Warmup) error {
+		defer close(done)
+
+		<-release
+		return w.RequireAction("users", "1.0.0", "read")
+	})
+
+	s := &server{component: c.base(), input: cli.Input{}}
+
+	// The warmup doesn't block the caller while the callback runs
+	s.warmup(payload.Mapping{})
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the warmup callback to run")
+	}
+}